@@ -86,15 +86,10 @@ func build(ctx context.Context) error {
 		WithEnvVariable("PGDATABASE", "db").
 		WithExposedPort(5432)
 
-	migrations := client.Host().Directory("./migrations")
-	out, err = client.Container().
+	out, err = runner.
 		WithServiceBinding("db", postgres.AsService()).
-		From("migrate/migrate:4").
-		WithDirectory("/migrations", migrations).
 		WithEnvVariable("CACHEBUSTER", time.Now().String()).
-		WithExec([]string{
-			"-database", testDSN + "?sslmode=disable", "-path=/migrations", "up",
-		}).
+		WithExec([]string{"run", "./cmd/indexer", "migrate", "-dsn", testDSN, "up"}).
 		Stdout(ctx)
 	if err != nil {
 		return fmt.Errorf("running migrations, %w", err)
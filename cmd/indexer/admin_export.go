@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/backup"
+)
+
+// exportIndexHandler streams every indexed image as jsonl, or as csv with ?format=csv.
+// The export can be imported with the import command.
+func (app *webApp) exportIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Format string `validate:"oneof=jsonl csv"`
+	}
+	req.Format = r.URL.Query().Get("format")
+	if req.Format == "" {
+		req.Format = backup.FormatJSONL
+	}
+
+	err := app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	bw, err := backup.NewWriter(w, req.Format)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	// exporting a large index takes longer than the write timeout of the server
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", backup.ContentTypes[req.Format])
+	w.Header().Set("Content-Disposition", `attachment; filename="index.`+req.Format+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = backup.Export(r.Context(), app.imageDescriptions, bw)
+	if err != nil {
+		// the response has started, the client gets a truncated export
+		app.error(r, err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/backup"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestExportIndexHandler(t *testing.T) {
+	tt := is.New(t)
+
+	repo := &imageRepoMock{
+		ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+			if after != "" {
+				return nil, nil
+			}
+			return []domain.Image{
+				{FileID: "a.jpg", LastModified: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Description: "OOM"},
+			}, nil
+		},
+		TagsOfImagesFunc: func(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error) {
+			return map[string][]domain.ImageTag{"a.jpg": {{Name: "incident", Source: domain.TagSourceManual}}}, nil
+		},
+	}
+
+	export := func(app *webApp, target string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+
+		return w.Result()
+	}
+
+	t.Run("jsonl", func(t *testing.T) {
+		resp := export(newTestApp(repo, nil), "/api/admin/export")
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(resp.Header.Get("Content-Type"), "application/x-ndjson")
+
+		var rec backup.Record
+		err := json.NewDecoder(resp.Body).Decode(&rec)
+		tt.NoErr(err)
+		tt.Equal(rec.FileID, "a.jpg")
+		tt.Equal(rec.Tags, []backup.Tag{{Name: "incident", Source: domain.TagSourceManual}})
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		resp := export(newTestApp(repo, nil), "/api/admin/export?format=csv")
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.True(strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv"))
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		tt.Equal(len(lines), 2) // header and one image
+		tt.True(strings.HasPrefix(lines[1], "a.jpg,2024-05-01T10:00:00Z,OOM,"))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		resp := export(newTestApp(repo, nil), "/api/admin/export?format=xml")
+
+		tt.Equal(resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("repository error truncates the export", func(t *testing.T) {
+		repo := &imageRepoMock{ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+			return nil, errors.New("expected error")
+		}}
+
+		resp := export(newTestApp(repo, nil), "/api/admin/export")
+		body, _ := io.ReadAll(resp.Body)
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(len(body), 0)
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/elnoro/foxyshot-indexer/internal/app"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
+)
+
+type indexStatus struct {
+	Runner   app.RunnerState
+	Progress indexer.Progress
+	Queue    struct {
+		Pending int
+		Failed  int
+	}
+}
+
+// indexStatusHandler reports the runs of the indexer, the file being indexed, the queue depth and the throughput.
+func (app *webApp) indexStatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := indexStatus{Runner: app.indexRunner.State(), Progress: app.indexRunner.Progress()}
+
+	var err error
+	status.Queue.Pending, status.Queue.Failed, err = app.imageDescriptions.IndexQueueDepth(context.Background())
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, status)
+}
+
+// runIndexHandler starts a scan without waiting for the schedule.
+func (app *webApp) runIndexHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.indexRunner.Trigger()
+	if err != nil {
+		app.errorResponse(r, w, http.StatusConflict, err.Error())
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusAccepted, app.indexRunner.State())
+}
+
+// pauseIndexHandler stops new indexing work, the process and the API keep running.
+func (app *webApp) pauseIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.indexRunner.Pause()
+
+	app.respondJSON(r, w, http.StatusOK, app.indexRunner.State())
+}
+
+func (app *webApp) resumeIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.indexRunner.Resume()
+
+	app.respondJSON(r, w, http.StatusOK, app.indexRunner.State())
+}
+
+// indexFileHandler indexes one file from the storage now, even if it was indexed before.
+func (app *webApp) indexFileHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Key string `json:"key" validate:"required,max=1024"`
+	}
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	img, err := app.indexRunner.IndexKey(r.Context(), req.Key)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			app.notFound(w, r)
+			return
+		}
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, img)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/app"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
+	"github.com/matryer/is"
+)
+
+func newTestRunner() *indexRunnerMock {
+	var paused bool
+
+	return &indexRunnerMock{
+		StateFunc:    func() app.RunnerState { return app.RunnerState{Paused: paused} },
+		ProgressFunc: func() indexer.Progress { return indexer.Progress{} },
+		TriggerFunc: func() error {
+			if paused {
+				return app.ErrPaused
+			}
+			return nil
+		},
+		PauseFunc:  func() { paused = true },
+		ResumeFunc: func() { paused = false },
+	}
+}
+
+func TestIndexStatusHandler(t *testing.T) {
+	tt := is.New(t)
+
+	lastRun := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	runner := newTestRunner()
+	runner.StateFunc = func() app.RunnerState {
+		return app.RunnerState{
+			LastRun:   &lastRun,
+			NextRun:   lastRun.Add(30 * time.Second),
+			LastError: "listing files, connection refused",
+			Failures:  2,
+		}
+	}
+	runner.ProgressFunc = func() indexer.Progress {
+		return indexer.Progress{CurrentFile: "current.jpg", Indexed: 10, Failed: 2, FilesPerMinute: 6}
+	}
+	imageDescriptions := &imageRepoMock{
+		IndexQueueDepthFunc: func(ctx context.Context) (int, int, error) { return 5, 1, nil },
+	}
+	webApp := newTestApp(imageDescriptions, nil)
+	webApp.indexRunner = runner
+
+	w := httptest.NewRecorder()
+	webApp.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/index/status", nil))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusOK)
+	body, err := io.ReadAll(resp.Body)
+	tt.NoErr(err)
+	tt.Equal(string(body), `{"Runner":{"Running":false,"Paused":false,"LastRun":"2024-01-10T10:00:00Z","LastSuccess":null,`+
+		`"NextRun":"2024-01-10T10:00:30Z","LastError":"listing files, connection refused","Failures":2},`+
+		`"Progress":{"CurrentFile":"current.jpg","Indexed":10,"Failed":2,"FilesPerMinute":6},`+
+		`"Queue":{"Pending":5,"Failed":1}}`)
+
+	t.Run("not available without the worker role", func(t *testing.T) {
+		tt := is.New(t)
+
+		w := httptest.NewRecorder()
+		newTestApp(nil, nil).routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/index/status", nil))
+
+		tt.Equal(w.Result().StatusCode, http.StatusNotFound)
+	})
+}
+
+func TestIndexControlHandlers(t *testing.T) {
+	tt := is.New(t)
+
+	runner := newTestRunner()
+	webApp := newTestApp(nil, nil)
+	webApp.indexRunner = runner
+	routes := webApp.routes()
+
+	post := func(endpoint string) int {
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, httptest.NewRequest(http.MethodPost, endpoint, nil))
+		return w.Result().StatusCode
+	}
+
+	tt.Equal(post("/api/admin/index/run"), http.StatusAccepted)
+	tt.Equal(post("/api/admin/index/pause"), http.StatusOK)
+	tt.Equal(post("/api/admin/index/run"), http.StatusConflict) // a paused indexer is not triggered
+	tt.Equal(post("/api/admin/index/resume"), http.StatusOK)
+	tt.Equal(post("/api/admin/index/run"), http.StatusAccepted)
+
+	tt.Equal(len(runner.TriggerCalls()), 3)
+	tt.Equal(len(runner.PauseCalls()), 1)
+	tt.Equal(len(runner.ResumeCalls()), 1)
+}
+
+func TestIndexFileHandler(t *testing.T) {
+	testCases := []struct {
+		name         string
+		body         string
+		indexErr     error
+		expectedCode int
+	}{
+		{"indexes the file", `{"key":"expected-key.jpg"}`, nil, http.StatusOK},
+		{"missing file", `{"key":"missing.jpg"}`, domain.ErrFileNotFound, http.StatusNotFound},
+		{"indexing error", `{"key":"broken.jpg"}`, errors.New("running ocr"), http.StatusInternalServerError},
+		{"empty key", `{"key":""}`, nil, http.StatusBadRequest},
+		{"malformed json", `{`, nil, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			runner := newTestRunner()
+			runner.IndexKeyFunc = func(ctx context.Context, key string) (domain.Image, error) {
+				return domain.Image{FileID: key}, tc.indexErr
+			}
+			webApp := newTestApp(nil, nil)
+			webApp.indexRunner = runner
+
+			w := httptest.NewRecorder()
+			webApp.indexFileHandler(w, httptest.NewRequest(http.MethodPost, "/api/admin/index/file", bytes.NewBufferString(tc.body)))
+
+			tt.Equal(w.Result().StatusCode, tc.expectedCode)
+			if tc.expectedCode == http.StatusOK {
+				tt.Equal(runner.IndexKeyCalls()[0].Key, "expected-key.jpg")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elnoro/foxyshot-indexer/internal/backup"
+	"github.com/elnoro/foxyshot-indexer/internal/entities"
+	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/secrets"
+	"github.com/jmoiron/sqlx"
+)
+
+func backupFlags(fs *flag.FlagSet, cfg *Config) {
+	dbFlags(fs, cfg)
+	fs.StringVar(&cfg.Backup.Format, "format", backup.FormatJSONL, "format of the export: jsonl or csv")
+}
+
+func exportIndexFlags(fs *flag.FlagSet, cfg *Config) {
+	backupFlags(fs, cfg)
+	fs.StringVar(&cfg.Backup.Output, "o", "-", "file to write the export to, - for stdout")
+}
+
+// runExportIndex writes every indexed image with its edits, tags and metadata.
+func runExportIndex(cfg Config, _ []string) error {
+	db, err := openBackupDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if cfg.Backup.Output != "-" {
+		f, err := os.Create(cfg.Backup.Output)
+		if err != nil {
+			return fmt.Errorf("creating %s, %w", cfg.Backup.Output, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	bw, err := backup.NewWriter(out, cfg.Backup.Format)
+	if err != nil {
+		return err
+	}
+	n, err := backup.Export(context.Background(), newImageRepo(db), bw)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "exported", n, "images")
+
+	if f, ok := out.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+
+	return nil
+}
+
+// runImportIndex upserts the images of an export from the file or stdin. Entities and findings are extracted
+// from the text again, OCR is not run and no events are published.
+func runImportIndex(cfg Config, args []string) error {
+	var in io.Reader = os.Stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening %s, %w", args[0], err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	db, err := openBackupDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	br, err := backup.NewReader(in, cfg.Backup.Format)
+	if err != nil {
+		return err
+	}
+	repo := newImageRepo(db)
+	stages := []backup.Stage{entities.NewExtractor(), secrets.NewDetector(false, repo, monitoring.NewTracker())}
+	n, err := backup.Import(context.Background(), repo, br, stages...)
+	fmt.Fprintln(os.Stderr, "imported", n, "images")
+
+	return err
+}
+
+func openBackupDatabase(cfg Config) (*sqlx.DB, error) {
+	err := validateConfig(cfg, cfg.Backup)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDatabase(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkSchema(context.Background(), db, false)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
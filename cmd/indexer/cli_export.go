@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+func exportFlags(fs *flag.FlagSet, cfg *Config) {
+	dbFlags(fs, cfg)
+	s3Flags(fs, cfg)
+	storageFlags(fs, cfg)
+	fs.StringVar(&cfg.Export.Search, "search", "", "export the results of the search instead of the given file ids")
+	fs.IntVar(&cfg.Export.Limit, "limit", defaultExportLimit, "maximum number of search results to export")
+	fs.StringVar(&cfg.Export.Output, "o", "-", "file to write the pdf to, - for stdout")
+}
+
+// runExportPDF writes a searchable PDF of the images with the ids or of the results of -search.
+// Redacted copies are exported when -redact is set, like the API serves them.
+func runExportPDF(cfg Config, args []string) error {
+	if (len(args) == 0) == (cfg.Export.Search == "") {
+		return errors.New("either file ids or -search is required")
+	}
+	if len(args) > maxExportPages {
+		return fmt.Errorf("at most %d file ids can be exported", maxExportPages)
+	}
+
+	dir, err := storageDir(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	parts := []any{cfg.Export, cfg.Redact}
+	if dir == "" {
+		parts = append(parts, cfg.S3)
+	}
+	err = validateConfig(cfg, parts...)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, err := openDatabase(cfg.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = checkSchema(ctx, db, false)
+	if err != nil {
+		return err
+	}
+
+	store, err := newStorage(cfg, slog.Default())
+	if err != nil {
+		return err
+	}
+
+	images, err := exportImages(ctx, newImageRepo(db), args, cfg.Export.Search, cfg.Export.Limit)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if cfg.Export.Output != "-" {
+		f, err := os.Create(cfg.Export.Output)
+		if err != nil {
+			return fmt.Errorf("creating %s, %w", cfg.Export.Output, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	open := func(ctx context.Context, fileID string) (io.ReadCloser, error) {
+		return store.Read(ctx, fileKey(cfg.Redact, fileID, false))
+	}
+	skipped, err := writePDF(ctx, out, images, open)
+	for _, s := range skipped {
+		fmt.Fprintln(os.Stderr, "skipped", s)
+	}
+	if err != nil {
+		return err
+	}
+
+	if f, ok := out.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/elnoro/foxyshot-indexer/internal/migrate"
+	"github.com/elnoro/foxyshot-indexer/internal/sqlite"
+	"github.com/elnoro/foxyshot-indexer/migrations"
+	sqlitemigrations "github.com/elnoro/foxyshot-indexer/migrations/sqlite"
+	"github.com/jmoiron/sqlx"
+)
+
+func migrateFlags(fs *flag.FlagSet, cfg *Config) {
+	dbFlags(fs, cfg)
+}
+
+// migrateOnStartFlags are the flags of the commands that check the schema version on startup.
+func migrateOnStartFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.BoolVar(&cfg.Migrate.OnStart, "migrate-on-start", false, "apply pending migrations before starting")
+}
+
+// runMigrate applies the embedded migrations: up [n], down [n], status or force <version>.
+func runMigrate(cfg Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate command is required: up [n], down [n], status or force <version>")
+	}
+
+	err := validateConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		n, err := stepsArg(args, len(m.Migrations()))
+		if err != nil {
+			return err
+		}
+		done, err := m.Steps(ctx, n)
+		fmt.Printf("applied %d migrations\n", done)
+		return err
+	case "down":
+		n, err := stepsArg(args, 1)
+		if err != nil {
+			return err
+		}
+		done, err := m.Down(ctx, n)
+		fmt.Printf("reverted %d migrations\n", done)
+		return err
+	case "status":
+		s, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(os.Stdout, s, m.Migrations())
+	case "force":
+		if len(args) != 2 {
+			return errors.New("version is required: force <version>")
+		}
+		v, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing version %s, %w", args[1], err)
+		}
+		return m.Force(ctx, uint(v))
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down, status or force", args[0])
+	}
+}
+
+// newMigrator returns the migrator with the migrations of the database backend.
+func newMigrator(db *sqlx.DB) (*migrate.Migrator, error) {
+	fsys := migrations.FS
+	if db.DriverName() == sqlite.DriverName {
+		fsys = sqlitemigrations.FS
+	}
+
+	loaded, err := migrate.Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(db, loaded), nil
+}
+
+// checkSchema refuses to start against a database with an unexpected schema version,
+// with migrateOnStart pending migrations are applied first.
+func checkSchema(ctx context.Context, db *sqlx.DB, migrateOnStart bool) error {
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if migrateOnStart {
+		done, err := m.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("migrating on start, %w", err)
+		}
+		if done > 0 {
+			log.Printf("applied %d migrations", done)
+		}
+	}
+
+	return m.Check(ctx)
+}
+
+func stepsArg(args []string, defaultSteps int) (int, error) {
+	if len(args) < 2 {
+		return defaultSteps, nil
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("number of migrations must be a positive number, got %s", args[1])
+	}
+
+	return n, nil
+}
+
+func printMigrationStatus(w io.Writer, s migrate.Status, all []migrate.Migration) error {
+	state := "clean"
+	if s.Dirty {
+		state = "dirty"
+	}
+	_, _ = fmt.Fprintf(w, "version %d of %d, %s\n\n", s.Version, s.Latest, state)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, m := range all {
+		status := "pending"
+		if m.Version <= s.Version {
+			status = "applied"
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\n", m.Version, m.Name, status)
+	}
+
+	return tw.Flush()
+}
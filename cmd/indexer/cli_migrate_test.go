@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/migrate"
+	"github.com/matryer/is"
+)
+
+func TestPrintMigrationStatus(t *testing.T) {
+	tt := is.New(t)
+
+	all := []migrate.Migration{
+		{Version: 1, Name: "create_table"},
+		{Version: 2, Name: "add_column"},
+	}
+	w := &bytes.Buffer{}
+
+	err := printMigrationStatus(w, migrate.Status{Version: 1, Latest: 2}, all)
+	tt.NoErr(err)
+	tt.Equal(w.String(), "version 1 of 2, clean\n\n"+
+		"VERSION  NAME          STATUS\n"+
+		"1        create_table  applied\n"+
+		"2        add_column    pending\n")
+}
+
+func TestStepsArg(t *testing.T) {
+	tt := is.New(t)
+
+	n, err := stepsArg([]string{"down"}, 1)
+	tt.NoErr(err)
+	tt.Equal(n, 1)
+
+	n, err = stepsArg([]string{"up", "3"}, 12)
+	tt.NoErr(err)
+	tt.Equal(n, 3)
+
+	_, err = stepsArg([]string{"down", "0"}, 1)
+	tt.True(err != nil)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/feed"
+)
+
+const snippetLen = 80
+
+func searchFlags(fs *flag.FlagSet, cfg *Config) {
+	dbFlags(fs, cfg)
+	fs.IntVar(&cfg.Search.Page, "page", 1, "page of results")
+	fs.IntVar(&cfg.Search.PerPage, "per-page", 20, "results per page")
+	fs.BoolVar(&cfg.Search.JSON, "json", false, "print results as json")
+}
+
+// runSearch searches the database directly, it needs neither s3 nor tesseract.
+func runSearch(cfg Config, args []string) error {
+	query := strings.Join(args, " ")
+	if query == "" {
+		return errors.New("search query is required")
+	}
+
+	err := validateConfig(cfg, cfg.Search)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = checkSchema(context.Background(), db, false)
+	if err != nil {
+		return err
+	}
+
+	images, err := newImageRepo(db).FindByDescription(context.Background(), query, cfg.Search.Page, cfg.Search.PerPage)
+	if err != nil {
+		return err
+	}
+
+	return printSearchResults(os.Stdout, images, cfg.Search.JSON)
+}
+
+func printSearchResults(w io.Writer, images []domain.Image, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(images)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "FILE\tMODIFIED\tTEXT")
+	for _, img := range images {
+		text := img.Description
+		if img.CorrectedDescription != "" {
+			text = img.CorrectedDescription
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			img.FileID,
+			img.LastModified.UTC().Format(time.DateTime),
+			feed.Snippet(text, snippetLen),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestPrintSearchResults(t *testing.T) {
+	tt := is.New(t)
+
+	images := []domain.Image{
+		{FileID: "a.jpg", LastModified: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Description: "connection\nrefused"},
+		{FileID: "long-name.jpg", LastModified: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), Description: "0OM", CorrectedDescription: "OOM"},
+	}
+
+	var buf bytes.Buffer
+	tt.NoErr(printSearchResults(&buf, images, false))
+	tt.Equal(buf.String(), ""+
+		"FILE           MODIFIED             TEXT\n"+
+		"a.jpg          2024-05-01 10:00:00  connection refused\n"+
+		"long-name.jpg  2024-05-02 10:00:00  OOM\n",
+	)
+
+	buf.Reset()
+	tt.NoErr(printSearchResults(&buf, images[:1], true))
+	tt.Equal(buf.String(), `[{"FileID":"a.jpg","Description":"connection\nrefused","LastModified":"2024-05-01T10:00:00Z"}]`+"\n")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/sqlite"
+	"github.com/jmoiron/sqlx"
+)
+
+// sqliteDSNPrefix selects the SQLite backend, the rest of the dsn is the path of the database file.
+const sqliteDSNPrefix = "sqlite:"
+
+// sqlitePath returns the path of the SQLite database, it is empty for Postgres.
+// Both sqlite:///var/lib/index.db and sqlite:index.db are accepted.
+func sqlitePath(dsn string) string {
+	if !strings.HasPrefix(dsn, sqliteDSNPrefix) {
+		return ""
+	}
+
+	// an url with an empty host, the path follows the slashes
+	return strings.TrimPrefix(strings.TrimPrefix(dsn, sqliteDSNPrefix), "//")
+}
+
+// openDatabase connects to Postgres or opens the SQLite file, depending on the scheme of the dsn.
+func openDatabase(dsn string) (*sqlx.DB, error) {
+	if strings.HasPrefix(dsn, sqliteDSNPrefix) {
+		path := sqlitePath(dsn)
+		if path == "" {
+			return nil, fmt.Errorf("sqlite dsn %q has no path, use sqlite:/path/to/index.db", dsn)
+		}
+
+		return sqlite.Open(path)
+	}
+
+	db, err := sqlx.Connect("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the database, %w", err)
+	}
+
+	return db, nil
+}
+
+// newImageRepo returns the repository of the database backend.
+func newImageRepo(db *sqlx.DB) repository {
+	if db.DriverName() == sqlite.DriverName {
+		return sqlite.NewImageRepo(db)
+	}
+
+	return dbadapter.NewImageRepo(db)
+}
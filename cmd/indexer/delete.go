@@ -3,6 +3,10 @@ package main
 import (
 	"context"
 	"net/http"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/redact"
 )
 
 func (app *webApp) deleteHandler(w http.ResponseWriter, r *http.Request) {
@@ -29,11 +33,21 @@ func (app *webApp) deleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if app.config.Redact.Enabled {
+		err = app.fileStorage.DeleteFile(ctx, redact.Key(app.config.Redact.Prefix, req.FileID))
+		if err != nil {
+			app.serverError(r, w, err)
+			return
+		}
+	}
+
 	err = app.imageDescriptions.Delete(ctx, req.FileID)
 	if err != nil {
 		app.serverError(r, w, err)
 		return
 	}
 
+	app.events.Publish(ctx, domain.Event{Type: domain.EventImageDeleted, FileID: req.FileID, Time: time.Now()})
+
 	app.respondNoContent(r, w)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
 	"github.com/matryer/is"
 	"io"
 	"net/http"
@@ -23,7 +24,10 @@ func TestDeleteHandler(t *testing.T) {
 			DeleteFileFunc: func(ctx context.Context, key string) error { return nil },
 		}
 
+		publisher := &eventPublisherMock{PublishFunc: func(ctx context.Context, e domain.Event) {}}
+
 		app := newTestApp(imageDescriptions, storage)
+		app.events = publisher
 
 		req := httptest.NewRequest(http.MethodPost, "/delete", bytes.NewBufferString(
 			`{ "file_id": "expected-file-id" }`,
@@ -37,7 +41,35 @@ func TestDeleteHandler(t *testing.T) {
 
 		tt.Equal(imageDescriptions.calls.Delete[0].FileID, "expected-file-id")
 		tt.Equal(storage.calls.DeleteFile[0].Key, "expected-file-id")
+		tt.Equal(publisher.calls.Publish[0].E.Type, domain.EventImageDeleted)
+		tt.Equal(publisher.calls.Publish[0].E.FileID, "expected-file-id")
+
+		tt.Equal(resp.StatusCode, http.StatusNoContent)
+	})
+
+	t.Run("deletes redacted copy", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			DeleteFunc: func(ctx context.Context, fileID string) error { return nil },
+		}
+		storage := &fileStorageMock{
+			DeleteFileFunc: func(ctx context.Context, key string) error { return nil },
+		}
+
+		app := newTestApp(imageDescriptions, storage)
+		app.config.Redact = RedactConfig{Enabled: true, Prefix: "redacted/"}
+
+		req := httptest.NewRequest(http.MethodPost, "/delete", bytes.NewBufferString(
+			`{ "file_id": "expected-file-id" }`,
+		))
+		w := httptest.NewRecorder()
+
+		app.deleteHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
 
+		tt.Equal(len(storage.calls.DeleteFile), 2)
+		tt.Equal(storage.calls.DeleteFile[1].Key, "redacted/expected-file-id")
 		tt.Equal(resp.StatusCode, http.StatusNoContent)
 	})
 
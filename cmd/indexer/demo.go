@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+
+	"github.com/elnoro/foxyshot-indexer/fakes"
+)
+
+func demoFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Demo, "demo", "",
+		"run without s3, the database and tesseract, screenshots are loaded into memory from the directory "+
+			"and their text is read from .txt sidecar files")
+}
+
+// openDemo returns in-memory backends seeded from the demo directory, nothing is persisted.
+func openDemo(cfg Config, logger *slog.Logger) (backends, error) {
+	if cfg.Storage != s3Storage {
+		return backends{}, errors.New("-storage cannot be used with -demo, demo files are kept in memory")
+	}
+	if cfg.Redact.Enabled {
+		return backends{}, errors.New("-redact cannot be used with -demo, redaction needs tesseract")
+	}
+
+	store := fakes.NewStorage()
+	ocrEngine := fakes.NewOCR()
+
+	n, err := fakes.Seed(cfg.Demo, store, ocrEngine)
+	if err != nil {
+		return backends{}, err
+	}
+	logger.Info("running in demo mode, changes are lost on exit",
+		slog.String("dir", cfg.Demo), slog.Int("files", n), slog.String("ext", cfg.Index.Ext))
+
+	repo := fakes.NewImageRepo()
+
+	return backends{
+		storage: store,
+		ocr:     ocrEngine,
+		repo:    repo,
+		events:  repo,
+		close:   func() {},
+	}, nil
+}
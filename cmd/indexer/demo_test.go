@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestOpenDemo(t *testing.T) {
+	tt := is.New(t)
+
+	dir := t.TempDir()
+	tt.NoErr(os.WriteFile(filepath.Join(dir, "shot.jpg"), []byte("image"), 0o600))
+	tt.NoErr(os.WriteFile(filepath.Join(dir, "shot.jpg.txt"), []byte("text"), 0o600))
+
+	b, err := openDemo(Config{
+		Demo:    dir,
+		Storage: s3Storage,
+		Index:   IndexConfig{Ext: ".jpg"},
+		Redact:  RedactConfig{Prefix: "redacted/"},
+	}, slog.Default())
+	tt.NoErr(err)
+	defer b.close()
+
+	files, err := b.storage.ListFiles(time.Time{}, ".jpg")
+	tt.NoErr(err)
+	tt.Equal(len(files), 1)
+	tt.Equal(files[0].Key, "shot.jpg")
+
+	last, err := b.repo.GetLastModified(context.Background())
+	tt.NoErr(err)
+	tt.Equal(last, time.Unix(0, 0).UTC()) // nothing is indexed until the worker runs
+}
+
+func TestOpenDemo_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing directory", Config{Demo: filepath.Join(t.TempDir(), "missing"), Storage: s3Storage}},
+		{"filesystem storage", Config{Demo: t.TempDir(), Storage: "fs:" + t.TempDir()}},
+		{"redaction", Config{Demo: t.TempDir(), Storage: s3Storage, Redact: RedactConfig{Enabled: true}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			_, err := openDemo(tc.cfg, slog.Default())
+			tt.True(err != nil)
+		})
+	}
+}
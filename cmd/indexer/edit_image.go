@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+func (app *webApp) editImageHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Annotations          *string `json:"annotations" validate:"omitempty,max=10000"`
+		CorrectedDescription *string `json:"corrected_description" validate:"omitempty,max=10000"`
+	}
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+	if req.Annotations == nil && req.CorrectedDescription == nil {
+		app.validationError(r, w, errors.New("annotations or corrected_description is required"))
+		return
+	}
+
+	img, err := app.imageDescriptions.Patch(context.Background(), chi.URLParam(r, "file_id"), domain.ImagePatch{
+		Annotations:          req.Annotations,
+		CorrectedDescription: req.CorrectedDescription,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, img)
+}
+
+func (app *webApp) imageEditsHandler(w http.ResponseWriter, r *http.Request) {
+	fileID := chi.URLParam(r, "file_id")
+	ctx := context.Background()
+
+	_, err := app.imageDescriptions.Get(ctx, fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	edits, err := app.imageDescriptions.ImageEdits(ctx, fileID)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, edits)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestEditImageHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("patches only passed fields", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			PatchFunc: func(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error) {
+				return domain.Image{FileID: fileID, Annotations: *patch.Annotations}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/images/expected-id", bytes.NewBufferString(
+			`{"annotations": "prod incident 2024-05"}`,
+		))
+		w := httptest.NewRecorder()
+
+		app.editImageHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(imageDescriptions.PatchCalls()[0].FileID, "expected-id")
+		tt.Equal(*imageDescriptions.PatchCalls()[0].Patch.Annotations, "prod incident 2024-05")
+		tt.True(imageDescriptions.PatchCalls()[0].Patch.CorrectedDescription == nil) // missing field must be kept
+
+		body, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(
+			string(body),
+			`{"FileID":"expected-id","Description":"","LastModified":"0001-01-01T00:00:00Z",`+
+				`"Annotations":"prod incident 2024-05"}`,
+		)
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			PatchFunc: func(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error) {
+				return domain.Image{}, fmt.Errorf("not found, %w", dbadapter.ErrRecordNotFound)
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/images/unknown-id", bytes.NewBufferString(
+			`{"corrected_description": "fixed"}`,
+		))
+		w := httptest.NewRecorder()
+
+		app.editImageHandler(w, withURLParam(req, "file_id", "unknown-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		for _, body := range []string{`{}`, `not json`, `{"annotations": "` + strings.Repeat("a", 10001) + `"}`} {
+			req := httptest.NewRequest(http.MethodPatch, "/images/expected-id", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			app.editImageHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+			resp := w.Result()
+			resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestImageEditsHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+			return domain.Image{FileID: fileID}, nil
+		},
+		ImageEditsFunc: func(ctx context.Context, fileID string) ([]domain.ImageEdit, error) {
+			return []domain.ImageEdit{{
+				Field:    domain.FieldAnnotations,
+				OldValue: "",
+				NewValue: "prod incident",
+				EditedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			}}, nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/images/expected-id/edits", nil)
+	w := httptest.NewRecorder()
+
+	app.imageEditsHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusOK)
+	body, err := io.ReadAll(resp.Body)
+	tt.NoErr(err)
+	tt.Equal(
+		string(body),
+		`[{"Field":"annotations","OldValue":"","NewValue":"prod incident","EditedAt":"2024-05-01T00:00:00Z"}]`,
+	)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+func (app *webApp) entitiesHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind  string `validate:"omitempty,oneof=url ip email hostname uuid error_code date"`
+		Limit int    `validate:"min=1,max=1000"`
+	}
+
+	var err error
+	qs := r.URL.Query()
+	req.Kind = qs.Get("kind")
+	req.Limit, err = app.readInt(qs, "limit", 100)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	ctx := context.Background()
+	entities, err := app.imageDescriptions.ListEntities(ctx, req.Kind, req.Limit)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, entities)
+}
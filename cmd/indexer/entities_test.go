@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestEntitiesHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("lists entities of the kind", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			ListEntitiesFunc: func(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+				return []domain.EntityCount{
+					{Entity: domain.Entity{Kind: domain.EntityIP, Value: "10.0.0.1"}, Count: 3},
+				}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/entities?kind=ip", nil)
+		w := httptest.NewRecorder()
+
+		app.entitiesHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(imageDescriptions.ListEntitiesCalls()[0].Kind, domain.EntityIP)
+		tt.Equal(imageDescriptions.ListEntitiesCalls()[0].Limit, 100)
+
+		body, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(string(body), `[{"Kind":"ip","Value":"10.0.0.1","Count":3}]`)
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/entities?kind=phone", nil)
+		w := httptest.NewRecorder()
+
+		app.entitiesHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			ListEntitiesFunc: func(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+				return nil, errors.New("expected-err")
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/entities", nil)
+		w := httptest.NewRecorder()
+
+		app.entitiesHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+const (
+	replayLimit       = 100
+	keepAliveInterval = 15 * time.Second
+)
+
+// eventsHandler streams indexed and deleted images as server-sent events.
+// With a search query only matching indexed images are sent, deletions are always sent,
+// because the image can no longer be matched. Clients resume with the Last-Event-ID header.
+func (app *webApp) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query       string `validate:"max=1000"`
+		LastEventID int64  `validate:"min=0"`
+	}
+
+	var err error
+	req.Query = r.URL.Query().Get("q")
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		req.LastEventID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			app.validationError(r, w, errors.New("Last-Event-ID must be an integer"))
+			return
+		}
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	// the stream outlives the write timeout of the server
+	rc := http.NewResponseController(w)
+	err = rc.SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.serverError(r, w, err)
+		return
+	}
+
+	// subscribe before replaying, so that events stored in between are not lost
+	live, unsubscribe := app.stream.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ctx := r.Context()
+	lastSeq := req.LastEventID
+	send := func(e domain.StreamEvent) error {
+		if e.Seq <= lastSeq {
+			return nil
+		}
+		lastSeq = e.Seq
+
+		if req.Query != "" && e.Type == domain.EventImageIndexed {
+			matches, err := app.imageDescriptions.Matches(ctx, e.FileID, req.Query)
+			if err != nil || !matches {
+				return err
+			}
+		}
+
+		data, err := json.Marshal(e.Event)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+		if err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	if req.LastEventID > 0 {
+		for {
+			events, err := app.stream.Replay(ctx, lastSeq, replayLimit)
+			if err != nil {
+				app.error(r, err)
+				return
+			}
+
+			for _, e := range events {
+				err = send(e)
+				if err != nil {
+					app.error(r, err)
+					return
+				}
+			}
+
+			if len(events) < replayLimit {
+				break
+			}
+		}
+	}
+
+	err = rc.Flush()
+	if err != nil {
+		app.error(r, err)
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case e, ok := <-live:
+			if !ok {
+				// the client fell behind, it reconnects and catches up from the last event it received
+				return
+			}
+
+			err = send(e)
+			if err != nil {
+				app.error(r, err)
+				return
+			}
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
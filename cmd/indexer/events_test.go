@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestEventsHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("replays missed events and streams new ones", func(t *testing.T) {
+		live := make(chan domain.StreamEvent, 2)
+		live <- domain.StreamEvent{Seq: 4, Event: domain.Event{Type: domain.EventImageIndexed, FileID: "other.jpg"}}
+		live <- domain.StreamEvent{Seq: 5, Event: domain.Event{Type: domain.EventImageDeleted, FileID: "deleted.jpg"}}
+		close(live)
+
+		stream := &eventStreamMock{
+			SubscribeFunc: func() (<-chan domain.StreamEvent, func()) { return live, func() {} },
+			ReplayFunc: func(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+				return []domain.StreamEvent{
+					{Seq: 3, Event: domain.Event{Type: domain.EventImageIndexed, FileID: "matching.jpg"}},
+					{Seq: 4, Event: domain.Event{Type: domain.EventImageIndexed, FileID: "other.jpg"}},
+				}, nil
+			},
+		}
+		imageDescriptions := &imageRepoMock{
+			MatchesFunc: func(ctx context.Context, fileID, searchString string) (bool, error) {
+				return fileID == "matching.jpg", nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+		app.stream = stream
+
+		req := httptest.NewRequest(http.MethodGet, "/api/events?q=grafana", nil)
+		req.Header.Set("Last-Event-ID", "2")
+		w := httptest.NewRecorder()
+
+		app.eventsHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(resp.Header.Get("Content-Type"), "text/event-stream")
+		tt.Equal(stream.calls.Replay[0].After, int64(2))
+
+		body, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(string(body), "id: 3\nevent: image.indexed\n"+
+			`data: {"Type":"image.indexed","FileID":"matching.jpg","Time":"0001-01-01T00:00:00Z"}`+"\n\n"+
+			"id: 5\nevent: image.deleted\n"+
+			`data: {"Type":"image.deleted","FileID":"deleted.jpg","Time":"0001-01-01T00:00:00Z"}`+"\n\n",
+		)
+		tt.Equal(len(imageDescriptions.calls.Matches), 2) // the replayed event is not sent or matched twice
+	})
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		w := httptest.NewRecorder()
+
+		app.eventsHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("stream is not limited by the request timeout", func(t *testing.T) {
+		live := make(chan domain.StreamEvent)
+		app := newTestApp(nil, nil)
+		app.stream = &eventStreamMock{
+			SubscribeFunc: func() (<-chan domain.StreamEvent, func()) { return live, func() {} },
+		}
+
+		srv := httptest.NewServer(app.routes())
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events", nil)
+		tt.NoErr(err)
+
+		resp, err := http.DefaultClient.Do(req)
+		tt.NoErr(err)
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(resp.Header.Get("Content-Type"), "text/event-stream")
+
+		live <- domain.StreamEvent{Seq: 1, Event: domain.Event{Type: domain.EventImageDeleted, FileID: "deleted.jpg"}}
+		close(live)
+
+		body, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(string(body), "id: 1\nevent: image.deleted\n"+
+			`data: {"Type":"image.deleted","FileID":"deleted.jpg","Time":"0001-01-01T00:00:00Z"}`+"\n\n",
+		)
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/elnoro/foxyshot-indexer/internal/pdf"
+)
+
+const (
+	defaultExportLimit = 50
+	// maxExportPages limits how many images a single export downloads and converts
+	maxExportPages = 100
+	exportPrefix   = "foxyshot_export_"
+)
+
+// exportRepo finds the images to export.
+type exportRepo interface {
+	Get(ctx context.Context, fileID string) (domain.Image, error)
+	FindByDescription(ctx context.Context, searchString string, page, perPage int) ([]domain.Image, error)
+}
+
+// exportPDFHandler streams a searchable PDF of the images with the ids or of the first results of the search.
+// Files are served like /file, the redacted copies are exported when redaction is enabled.
+func (app *webApp) exportPDFHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileIDs []string `json:"file_ids" validate:"required_without=Search,export_pages,dive,required"`
+		Search  string   `json:"search" validate:"excluded_with=FileIDs"`
+		Limit   int      `json:"limit" validate:"min=1,export_pages"`
+	}
+	req.Limit = defaultExportLimit
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	ctx := r.Context()
+	images, err := exportImages(ctx, app.imageDescriptions, req.FileIDs, req.Search, req.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	// converting and compressing many images takes longer than the write timeout of the server
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="screenshots.pdf"`)
+	w.WriteHeader(http.StatusOK)
+
+	open := func(ctx context.Context, fileID string) (io.ReadCloser, error) {
+		return app.fileStorage.Read(ctx, fileKey(app.config.Redact, fileID, false))
+	}
+	skipped, err := writePDF(ctx, w, images, open)
+	if err != nil {
+		// the response has started, the client gets a truncated document
+		app.error(r, err)
+		return
+	}
+	for _, s := range skipped {
+		app.log.Println("skipped in pdf export:", s)
+	}
+}
+
+// exportImages returns the images with the ids in the given order, or up to limit results of the search.
+func exportImages(ctx context.Context, repo exportRepo, fileIDs []string, search string, limit int) ([]domain.Image, error) {
+	if len(fileIDs) == 0 {
+		images, err := repo.FindByDescription(ctx, search, 1, limit)
+		if err != nil {
+			return nil, fmt.Errorf("searching images to export, %w", err)
+		}
+
+		return images, nil
+	}
+
+	images := make([]domain.Image, 0, len(fileIDs))
+	for _, id := range fileIDs {
+		img, err := repo.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("getting image %s, %w", id, err)
+		}
+		images = append(images, img)
+	}
+
+	return images, nil
+}
+
+// writePDF writes a page for every image. Images whose files are gone or have unsupported formats are skipped,
+// the reasons are returned.
+func writePDF(
+	ctx context.Context,
+	w io.Writer,
+	images []domain.Image,
+	open func(ctx context.Context, fileID string) (io.ReadCloser, error),
+) ([]string, error) {
+	pw := pdf.NewWriter(w)
+
+	var skipped []string
+	for _, img := range images {
+		err := addPDFPage(ctx, pw, img, open)
+		if errors.Is(err, domain.ErrFileNotFound) || errors.Is(err, imageformat.ErrUnsupported) {
+			skipped = append(skipped, fmt.Sprintf("%s: %s", img.FileID, err))
+			continue
+		}
+		if err != nil {
+			return skipped, err
+		}
+	}
+
+	return skipped, pw.Close()
+}
+
+func addPDFPage(
+	ctx context.Context,
+	pw *pdf.Writer,
+	img domain.Image,
+	open func(ctx context.Context, fileID string) (io.ReadCloser, error),
+) error {
+	body, err := open(ctx, img.FileID)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.CreateTemp("", exportPrefix)
+	if err != nil {
+		return fmt.Errorf("creating temp file, %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, body)
+	_ = f.Close()
+	if err != nil {
+		return fmt.Errorf("reading %s, %w", img.FileID, err)
+	}
+
+	// the first page of a document or frame of an animation is shown
+	frames, cleanup, err := imageformat.Frames(f.Name())
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	if len(frames) == 0 {
+		return fmt.Errorf("%w, %s has no frames", imageformat.ErrUnsupported, img.FileID)
+	}
+
+	text := img.Description
+	if img.CorrectedDescription != "" {
+		text = img.CorrectedDescription
+	}
+
+	return pw.AddPage(pdf.Page{
+		Image:   frames[0],
+		Caption: img.FileID + ", " + img.LastModified.UTC().Format(time.DateTime) + " UTC",
+		Text:    text,
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestExportPDFHandler(t *testing.T) {
+	tt := is.New(t)
+
+	images := map[string]domain.Image{
+		"a.png": {FileID: "a.png", Description: "kubectl get pods", LastModified: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		"b.png": {FileID: "b.png", Description: "0OM", CorrectedDescription: "OOM killed"},
+		"gone":  {FileID: "gone"},
+		"empty": {FileID: "empty"},
+	}
+	repo := &imageRepoMock{
+		GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+			img, ok := images[fileID]
+			if !ok {
+				return domain.Image{}, dbadapter.ErrRecordNotFound
+			}
+			return img, nil
+		},
+		FindByDescriptionFunc: func(ctx context.Context, searchString string, page, perPage int) ([]domain.Image, error) {
+			return []domain.Image{images["b.png"]}, nil
+		},
+	}
+	storage := &fileStorageMock{ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+		if key == "gone" || key == "redacted/gone" {
+			return nil, fmt.Errorf("reading %s, %w", key, domain.ErrFileNotFound)
+		}
+		if key == "empty" {
+			return io.NopCloser(strings.NewReader("II*\x00\x00\x00\x00\x00")), nil // a tiff without pages
+		}
+		return io.NopCloser(bytes.NewReader(testPNG(t))), nil
+	}}
+
+	export := func(app *webApp, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/export/pdf", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		app.exportPDFHandler(w, req)
+
+		return w.Result()
+	}
+
+	t.Run("images with the ids in the given order", func(t *testing.T) {
+		app := newTestApp(repo, storage)
+
+		resp := export(app, `{"file_ids": ["b.png", "a.png", "gone", "empty"]}`)
+		defer resp.Body.Close()
+		doc, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(resp.Header.Get("Content-Type"), "application/pdf")
+		tt.True(bytes.HasPrefix(doc, []byte("%PDF-")))
+		tt.True(bytes.Contains(doc, []byte("/Count 2"))) // missing files and images without frames are skipped
+
+		b := bytes.Index(doc, []byte("(OOM killed) Tj")) // corrected text is exported
+		a := bytes.Index(doc, []byte("(a.png, 2024-05-01 10:00:00 UTC) Tj"))
+		tt.True(b > 0 && a > b)
+	})
+
+	t.Run("search results", func(t *testing.T) {
+		app := newTestApp(repo, storage)
+
+		resp := export(app, `{"search": "oom", "limit": 10}`)
+		doc, _ := io.ReadAll(resp.Body)
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.True(bytes.Contains(doc, []byte("/Count 1")))
+		call := repo.FindByDescriptionCalls()[0]
+		tt.Equal(call.SearchString, "oom")
+		tt.Equal(call.PerPage, 10)
+	})
+
+	t.Run("redacted copies are exported", func(t *testing.T) {
+		storage := &fileStorageMock{ReadFunc: storage.ReadFunc}
+		app := newTestApp(repo, storage)
+		app.config.Redact = RedactConfig{Enabled: true, Prefix: "redacted/"}
+
+		resp := export(app, `{"file_ids": ["a.png"]}`)
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(storage.ReadCalls()[0].Key, "redacted/a.png")
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		resp := export(newTestApp(repo, storage), `{"file_ids": ["a.png", "unknown"]}`)
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		for _, body := range []string{
+			`{}`,
+			`{"file_ids": ["a.png"], "search": "oom"}`,
+			`{"file_ids": [""]}`,
+			`{"search": "oom", "limit": 101}`,
+			`{"file_ids": [` + strings.Repeat(`"a.png", `, maxExportPages) + `"a.png"]}`,
+		} {
+			resp := export(newTestApp(repo, storage), body)
+
+			tt.Equal(resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("exports are not limited by the request timeout", func(t *testing.T) {
+		timeout := requestTimeout
+		requestTimeout = 50 * time.Millisecond
+		defer func() { requestTimeout = timeout }()
+
+		slow := &fileStorageMock{ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(30 * time.Millisecond):
+				return storage.ReadFunc(ctx, key)
+			}
+		}}
+		app := newTestApp(repo, slow)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/export/pdf", strings.NewReader(`{"file_ids": ["a.png", "b.png", "a.png"]}`))
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, req)
+		doc, _ := io.ReadAll(w.Result().Body)
+
+		tt.Equal(w.Result().StatusCode, http.StatusOK)
+		tt.True(bytes.Contains(doc, []byte("/Count 3")))
+	})
+}
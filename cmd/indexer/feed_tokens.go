@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/feed"
+)
+
+func (app *webApp) listFeedTokensHandler(w http.ResponseWriter, r *http.Request) {
+	tokens, err := app.imageDescriptions.ListFeedTokens(context.Background())
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, tokens)
+}
+
+// createFeedTokenHandler issues a feed token to a user, the token is only returned once.
+func (app *webApp) createFeedTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name" validate:"required,max=200"`
+	}
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	token, hash, err := feed.GenerateToken()
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	created, err := app.imageDescriptions.CreateFeedToken(context.Background(), req.Name, hash)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	created.Token = token
+
+	app.respondJSON(r, w, http.StatusCreated, created)
+}
+
+func (app *webApp) deleteFeedTokenHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.imageDescriptions.DeleteFeedToken(context.Background(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondNoContent(r, w)
+}
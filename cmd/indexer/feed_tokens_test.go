@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/feed"
+	"github.com/matryer/is"
+)
+
+func TestCreateFeedTokenHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("returns the token once and stores its hash", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			CreateFeedTokenFunc: func(ctx context.Context, name, hash string) (domain.FeedToken, error) {
+				return domain.FeedToken{ID: 1, Name: name}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/feed-tokens", bytes.NewBufferString(`{"name": "alex"}`))
+		w := httptest.NewRecorder()
+
+		app.createFeedTokenHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusCreated)
+
+		var created domain.FeedToken
+		tt.NoErr(json.NewDecoder(resp.Body).Decode(&created))
+		tt.Equal(created.Name, "alex")
+		tt.True(created.Token != "")
+		tt.Equal(imageDescriptions.calls.CreateFeedToken[0].Hash, feed.HashToken(created.Token))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/feed-tokens", bytes.NewBufferString(`{}`))
+		w := httptest.NewRecorder()
+
+		app.createFeedTokenHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+	})
+}
+
+func TestDeleteFeedTokenHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		DeleteFeedTokenFunc: func(ctx context.Context, id int) error {
+			if id != 3 {
+				return dbadapter.ErrRecordNotFound
+			}
+			return nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	for id, expectedStatus := range map[string]int{"3": http.StatusNoContent, "4": http.StatusNotFound, "x": http.StatusBadRequest} {
+		req := withURLParam(httptest.NewRequest(http.MethodDelete, "/feed-tokens/"+id, nil), "id", id)
+		w := httptest.NewRecorder()
+
+		app.deleteFeedTokenHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, expectedStatus)
+	}
+}
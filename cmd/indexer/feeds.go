@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/feed"
+)
+
+// requireFeedToken checks the token query parameter, feed readers cannot send authorization headers.
+func (app *webApp) requireFeedToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			app.unauthorized(w, r)
+			return
+		}
+
+		err := app.imageDescriptions.UseFeedToken(r.Context(), feed.HashToken(token))
+		if err != nil {
+			switch {
+			case errors.Is(err, dbadapter.ErrRecordNotFound):
+				app.unauthorized(w, r)
+			default:
+				app.serverError(r, w, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *webApp) recentFeedHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := app.readFeedLimit(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	images, err := app.imageDescriptions.RecentImages(context.Background(), limit)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondFeed(w, r, "Recent screenshots", "/feeds/recent.atom", images)
+}
+
+func (app *webApp) searchFeedHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query string `validate:"required,max=1000"`
+	}
+	req.Query = r.URL.Query().Get("q")
+
+	err := app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	limit, err := app.readFeedLimit(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	images, err := app.imageDescriptions.FindByDescription(context.Background(), req.Query, 1, limit)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	self := "/feeds/search.atom?" + url.Values{"q": {req.Query}}.Encode()
+	app.respondFeed(w, r, "Screenshots matching "+req.Query, self, images)
+}
+
+func (app *webApp) readFeedLimit(r *http.Request) (int, error) {
+	var req struct {
+		Limit int `validate:"min=1,max=200"`
+	}
+
+	var err error
+	req.Limit, err = app.readInt(r.URL.Query(), "limit", 50)
+	if err != nil {
+		return 0, err
+	}
+
+	return req.Limit, app.validate(req)
+}
+
+func (app *webApp) respondFeed(w http.ResponseWriter, r *http.Request, title, self string, images []domain.Image) {
+	f := feed.NewBuilder(app.baseURL()).Build(title, self, images)
+
+	w.Header().Set("Content-Type", feed.ContentType)
+	w.Header().Set("Cache-Control", "private")
+	w.WriteHeader(http.StatusOK)
+
+	err := feed.Write(w, f)
+	if err != nil {
+		app.error(r, err)
+	}
+}
+
+// baseURL returns the configured public url of the app. It is never guessed from the Host header,
+// which is set by the client, without it links are relative.
+func (app *webApp) baseURL() string {
+	return strings.TrimSuffix(app.config.Web.BaseURL, "/")
+}
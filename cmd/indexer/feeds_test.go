@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/feed"
+	"github.com/matryer/is"
+)
+
+func TestFeeds(t *testing.T) {
+	tt := is.New(t)
+
+	validHash := feed.HashToken("valid-token")
+	images := []domain.Image{{FileID: "a.jpg", Description: "grafana dashboard", LastModified: time.Unix(100, 0)}}
+	imageDescriptions := &imageRepoMock{
+		UseFeedTokenFunc: func(ctx context.Context, hash string) error {
+			if hash != validHash {
+				return dbadapter.ErrRecordNotFound
+			}
+			return nil
+		},
+		RecentImagesFunc: func(ctx context.Context, limit int) ([]domain.Image, error) {
+			return images, nil
+		},
+		FindByDescriptionFunc: func(ctx context.Context, searchString string, page, perPage int) ([]domain.Image, error) {
+			return images, nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+	app.config.Web.BaseURL = "https://shots.example.com"
+	routes := app.routes()
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedSelf   string
+	}{
+		{name: "no token", url: "/feeds/recent.atom", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid token", url: "/feeds/recent.atom?token=wrong", expectedStatus: http.StatusUnauthorized},
+		{
+			name: "recent", url: "/feeds/recent.atom?token=valid-token&limit=10",
+			expectedStatus: http.StatusOK, expectedSelf: "https://shots.example.com/feeds/recent.atom",
+		},
+		{
+			name: "search", url: "/feeds/search.atom?token=valid-token&q=grafana+dashboard",
+			expectedStatus: http.StatusOK, expectedSelf: "https://shots.example.com/feeds/search.atom?q=grafana+dashboard",
+		},
+		{name: "search without query", url: "/feeds/search.atom?token=valid-token", expectedStatus: http.StatusBadRequest},
+		{name: "invalid limit", url: "/feeds/recent.atom?token=valid-token&limit=1000", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, tc.expectedStatus)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			tt.Equal(resp.Header.Get("Content-Type"), feed.ContentType)
+
+			body, err := io.ReadAll(resp.Body)
+			tt.NoErr(err)
+
+			var f feed.Feed
+			tt.NoErr(xml.Unmarshal(body, &f))
+			tt.Equal(f.Links[0].Href, tc.expectedSelf) // the token is not leaked into the feed
+			tt.Equal(f.Entries[0].Links[0].Href, "https://shots.example.com/api/images/a.jpg/file")
+		})
+	}
+
+	tt.Equal(imageDescriptions.calls.RecentImages[0].Limit, 10)
+	tt.Equal(imageDescriptions.calls.FindByDescription[0].SearchString, "grafana dashboard")
+
+	t.Run("not served without a public url", func(t *testing.T) {
+		app := newTestApp(imageDescriptions, nil)
+
+		w := httptest.NewRecorder()
+		app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/feeds/recent.atom?token=valid-token", nil))
+
+		tt.Equal(w.Result().StatusCode, http.StatusNotFound)
+	})
+}
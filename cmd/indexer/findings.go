@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+func (app *webApp) findingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Rule    string
+		Page    int `validate:"min=1"`
+		PerPage int `validate:"min=1,max=100"`
+	}
+
+	var err error
+	qs := r.URL.Query()
+	req.Rule = qs.Get("rule")
+	req.Page, err = app.readInt(qs, "page", 1)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+	req.PerPage, err = app.readInt(qs, "per_page", 20)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	ctx := context.Background()
+	images, err := app.imageDescriptions.ListFindings(ctx, req.Rule, req.Page, req.PerPage)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, images)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestFindingsHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("lists flagged images", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			ListFindingsFunc: func(ctx context.Context, rule string, page int, perPage int) ([]domain.FlaggedImage, error) {
+				return []domain.FlaggedImage{{
+					FileID:       "flagged-id",
+					LastModified: time.Time{},
+					Findings:     []domain.Finding{{Rule: "aws_access_key_id", Preview: "AKIA****"}},
+				}}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/findings?rule=aws_access_key_id&page=2", nil)
+		w := httptest.NewRecorder()
+
+		app.findingsHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(imageDescriptions.ListFindingsCalls()[0].Rule, "aws_access_key_id")
+		tt.Equal(imageDescriptions.ListFindingsCalls()[0].Page, 2)
+		tt.Equal(imageDescriptions.ListFindingsCalls()[0].PerPage, 20)
+
+		body, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(
+			string(body),
+			`[{"FileID":"flagged-id","LastModified":"0001-01-01T00:00:00Z",`+
+				`"Findings":[{"Rule":"aws_access_key_id","Preview":"AKIA****"}]}]`,
+		)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/findings?page=0", nil)
+		w := httptest.NewRecorder()
+
+		app.findingsHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			ListFindingsFunc: func(ctx context.Context, rule string, page int, perPage int) ([]domain.FlaggedImage, error) {
+				return nil, errors.New("expected-err")
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/findings", nil)
+		w := httptest.NewRecorder()
+
+		app.findingsHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+	})
+}
@@ -2,9 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -35,6 +39,30 @@ func (app *webApp) parseJSON(r *http.Request, o any) error {
 	return nil
 }
 
+func (app *webApp) readInt(qs url.Values, key string, defaultValue int) (int, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer value", key)
+	}
+
+	return i, nil
+}
+
+// readIDParam reads a positive integer id from the url.
+func (app *webApp) readIDParam(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		return 0, errors.New("id must be a positive integer")
+	}
+
+	return id, nil
+}
+
 func (app *webApp) malformedJSON(r *http.Request, w http.ResponseWriter) {
 	app.errorResponse(r, w, http.StatusBadRequest, "malformed json")
 }
@@ -63,6 +91,7 @@ func (app *webApp) error(r *http.Request, err error) {
 
 func (app *webApp) validate(o any) error {
 	validate := validator.New()
+	validate.RegisterAlias("export_pages", fmt.Sprintf("max=%d", maxExportPages))
 
 	return validate.Struct(o)
 }
@@ -74,3 +103,7 @@ func (app *webApp) notFound(w http.ResponseWriter, r *http.Request) {
 func (app *webApp) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
 	app.errorResponse(r, w, http.StatusMethodNotAllowed, "Method Not Allowed")
 }
+
+func (app *webApp) unauthorized(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(r, w, http.StatusUnauthorized, "Unauthorized")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/feed"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/elnoro/foxyshot-indexer/internal/redact"
+	"github.com/elnoro/foxyshot-indexer/internal/thumbnail"
+	"github.com/go-chi/chi/v5"
+)
+
+// sniffLen is the number of bytes the content type is detected from.
+const sniffLen = 512
+
+var (
+	errOriginalsNotServed = errors.New("originals are not served, start the API with -redact.serve-originals")
+	errNoRedactedCopy     = errors.New("the image has no redacted copy, reindex it to create one")
+)
+
+// imageFileHandler serves the image file, the redacted copy is served unless the original is requested
+// and originals are served.
+func (app *webApp) imageFileHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileID   string `validate:"required"`
+		Original bool
+	}
+	req.FileID = chi.URLParam(r, "file_id")
+	req.Original = r.URL.Query().Get("original") == "true"
+
+	err := app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	body, err := app.openImage(context.Background(), req.FileID, req.Original)
+	if err != nil {
+		app.openImageError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	br := bufio.NewReaderSize(body, sniffLen)
+	head, _ := br.Peek(sniffLen)
+
+	w.Header().Set("Content-Type", imageformat.Detect(head))
+	w.Header().Set("Cache-Control", "private")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(w, br)
+	if err != nil {
+		app.error(r, err)
+	}
+}
+
+// openImage reads the file of an indexed image, the redacted copy is read unless the original is requested.
+// Images indexed before redaction was enabled have no redacted copy, their originals are never served instead.
+func (app *webApp) openImage(ctx context.Context, fileID string, original bool) (io.ReadCloser, error) {
+	if original && app.config.Redact.Enabled && !app.config.Redact.ServeOriginals {
+		return nil, errOriginalsNotServed
+	}
+
+	_, err := app.imageDescriptions.Get(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fileKey(app.config.Redact, fileID, original)
+	body, err := app.fileStorage.Read(ctx, key)
+	if key == fileID || !errors.Is(err, domain.ErrFileNotFound) {
+		return body, err
+	}
+
+	orig, origErr := app.fileStorage.Read(ctx, fileID)
+	if origErr != nil {
+		return nil, err
+	}
+	_ = orig.Close()
+
+	return nil, errNoRedactedCopy
+}
+
+func (app *webApp) openImageError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, dbadapter.ErrRecordNotFound), errors.Is(err, domain.ErrFileNotFound):
+		app.notFound(w, r)
+	case errors.Is(err, errOriginalsNotServed):
+		app.errorResponse(r, w, http.StatusForbidden, err.Error())
+	case errors.Is(err, errNoRedactedCopy):
+		app.errorResponse(r, w, http.StatusConflict, err.Error())
+	default:
+		app.serverError(r, w, err)
+	}
+}
+
+// fileKey returns the key of the file of an image, the key of the redacted copy unless the original is requested.
+func fileKey(cfg RedactConfig, fileID string, original bool) string {
+	if cfg.Enabled && !original {
+		return redact.Key(cfg.Prefix, fileID)
+	}
+
+	return fileID
+}
+
+// imageThumbnailHandler serves a scaled down jpeg of the image, it follows the same redaction rules as the file.
+func (app *webApp) imageThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileID   string `validate:"required"`
+		Original bool
+		Width    int `validate:"min=16,max=1280"`
+	}
+
+	var err error
+	qs := r.URL.Query()
+	req.FileID = chi.URLParam(r, "file_id")
+	req.Original = qs.Get("original") == "true"
+	req.Width, err = app.readInt(qs, "width", feed.ThumbnailWidth)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	body, err := app.openImage(context.Background(), req.FileID, req.Original)
+	if err != nil {
+		app.openImageError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	err = thumbnail.Write(&buf, body, req.Width)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		app.error(r, err)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestImageFileHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+			return domain.Image{FileID: fileID}, nil
+		},
+	}
+	newStorage := func() *fileStorageMock {
+		return &fileStorageMock{
+			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("content of " + key)), nil
+			},
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		redact         bool
+		serveOriginals bool
+		query          string
+		expectedKey    string
+	}{
+		{name: "redacted copy by default", redact: true, expectedKey: "redacted/expected-id.jpg"},
+		{name: "original on request", redact: true, serveOriginals: true, query: "?original=true", expectedKey: "expected-id.jpg"},
+		{name: "original without redaction", redact: false, expectedKey: "expected-id.jpg"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := newStorage()
+			app := newTestApp(imageDescriptions, storage)
+			app.config.Redact = RedactConfig{Enabled: tc.redact, Prefix: "redacted/", ServeOriginals: tc.serveOriginals}
+
+			req := httptest.NewRequest(http.MethodGet, "/images/expected-id.jpg/file"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			app.imageFileHandler(w, withURLParam(req, "file_id", "expected-id.jpg"))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, http.StatusOK)
+			tt.Equal(storage.ReadCalls()[0].Key, tc.expectedKey)
+
+			body, err := io.ReadAll(resp.Body)
+			tt.NoErr(err)
+			tt.Equal(string(body), "content of "+tc.expectedKey)
+		})
+	}
+
+	t.Run("originals are not served by default", func(t *testing.T) {
+		storage := newStorage()
+		app := newTestApp(imageDescriptions, storage)
+		app.config.Redact = RedactConfig{Enabled: true, Prefix: "redacted/"}
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id.jpg/file?original=true", nil)
+		w := httptest.NewRecorder()
+
+		app.imageFileHandler(w, withURLParam(req, "file_id", "expected-id.jpg"))
+
+		tt.Equal(w.Result().StatusCode, http.StatusForbidden)
+		tt.Equal(len(storage.ReadCalls()), 0)
+	})
+
+	t.Run("image indexed before redaction was enabled", func(t *testing.T) {
+		storage := &fileStorageMock{
+			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				if strings.HasPrefix(key, "redacted/") {
+					return nil, fmt.Errorf("reading, %w", domain.ErrFileNotFound)
+				}
+				return io.NopCloser(strings.NewReader("content of " + key)), nil
+			},
+		}
+		app := newTestApp(imageDescriptions, storage)
+		app.config.Redact = RedactConfig{Enabled: true, Prefix: "redacted/"}
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id.jpg/file", nil)
+		w := httptest.NewRecorder()
+
+		app.imageFileHandler(w, withURLParam(req, "file_id", "expected-id.jpg"))
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		tt.Equal(resp.StatusCode, http.StatusConflict)
+		tt.True(strings.Contains(string(body), "no redacted copy")) // the original is not served instead
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+				return domain.Image{}, fmt.Errorf("not found, %w", dbadapter.ErrRecordNotFound)
+			},
+		}
+		storage := newStorage()
+		app := newTestApp(imageDescriptions, storage)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/unknown-id/file", nil)
+		w := httptest.NewRecorder()
+
+		app.imageFileHandler(w, withURLParam(req, "file_id", "unknown-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+		tt.Equal(len(storage.ReadCalls()), 0) // files of unknown images must not be served
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		storage := &fileStorageMock{
+			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return nil, fmt.Errorf("reading, %w", domain.ErrFileNotFound)
+			},
+		}
+		app := newTestApp(imageDescriptions, storage)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id/file", nil)
+		w := httptest.NewRecorder()
+
+		app.imageFileHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		storage := &fileStorageMock{
+			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return nil, errors.New("expected-err")
+			},
+		}
+		app := newTestApp(imageDescriptions, storage)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id/file", nil)
+		w := httptest.NewRecorder()
+
+		app.imageFileHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+	})
+}
+
+func TestImageThumbnailHandler(t *testing.T) {
+	tt := is.New(t)
+
+	var src bytes.Buffer
+	tt.NoErr(png.Encode(&src, image.NewRGBA(image.Rect(0, 0, 800, 400))))
+
+	imageDescriptions := &imageRepoMock{
+		GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+			return domain.Image{FileID: fileID}, nil
+		},
+	}
+	storage := &fileStorageMock{
+		ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(src.Bytes())), nil
+		},
+	}
+
+	t.Run("scales down the redacted copy", func(t *testing.T) {
+		app := newTestApp(imageDescriptions, storage)
+		app.config.Redact = RedactConfig{Enabled: true, Prefix: "redacted/"}
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id.png/thumbnail?width=200", nil)
+		w := httptest.NewRecorder()
+
+		app.imageThumbnailHandler(w, withURLParam(req, "file_id", "expected-id.png"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(resp.Header.Get("Content-Type"), "image/jpeg")
+		tt.Equal(storage.ReadCalls()[0].Key, "redacted/expected-id.png")
+
+		cfg, err := jpeg.DecodeConfig(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(cfg.Width, 200)
+		tt.Equal(cfg.Height, 100)
+	})
+
+	t.Run("invalid width", func(t *testing.T) {
+		app := newTestApp(imageDescriptions, storage)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id.png/thumbnail?width=5000", nil)
+		w := httptest.NewRecorder()
+
+		app.imageThumbnailHandler(w, withURLParam(req, "file_id", "expected-id.png"))
+
+		tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/elnoro/foxyshot-indexer/internal/fetch"
+)
+
+// ingestHandler fetches an image from a url, stores and indexes it like an upload.
+// The url is kept as the source of the image and is searchable.
+func (app *webApp) ingestHandler(w http.ResponseWriter, r *http.Request) {
+	wait, err := readWait(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	var req struct {
+		URL string `json:"url" validate:"required,url,max=2048"`
+	}
+	err = app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	f, err := os.CreateTemp("", tempUploadPrefix)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	defer app.removeTemp(r, f.Name())
+
+	err = app.fetcher.Fetch(r.Context(), req.URL, f)
+	_ = f.Close()
+	if err != nil {
+		switch {
+		case errors.Is(err, fetch.ErrUnsupportedURL), errors.Is(err, fetch.ErrForbiddenAddr):
+			app.errorResponse(r, w, http.StatusUnprocessableEntity, "url must point to a public http or https address")
+		case errors.Is(err, fetch.ErrTooLarge):
+			app.imageTooLarge(r, w)
+		default:
+			app.error(r, err)
+			app.errorResponse(r, w, http.StatusBadGateway, "image could not be fetched")
+		}
+		return
+	}
+
+	app.storeImage(w, r, f.Name(), wait, req.URL)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/fetch"
+	"github.com/matryer/is"
+)
+
+func TestIngestHandler(t *testing.T) {
+	tt := is.New(t)
+
+	ingestRequest := func(body string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/ingest?wait=true", strings.NewReader(body))
+	}
+
+	t.Run("fetched image is stored and indexed with its source url", func(t *testing.T) {
+		repo := &imageRepoMock{SetSourceURLFunc: func(ctx context.Context, fileID, sourceURL string) error {
+			return nil
+		}}
+		storage := &fileStorageMock{
+			UploadFunc: func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+				return nil
+			},
+		}
+		app := newTestApp(repo, storage)
+		app.uploadIndexer = &keyIndexerMock{IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+			return domain.Image{FileID: key}, nil
+		}}
+		app.fetcher = &urlFetcherMock{FetchFunc: func(ctx context.Context, url string, w io.Writer) error {
+			_, err := w.Write(testPNG(t))
+			return err
+		}}
+
+		w := httptest.NewRecorder()
+		app.ingestHandler(w, ingestRequest(`{"url": "https://chat.example.com/screenshot.png"}`))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusCreated)
+		tt.Equal(app.fetcher.(*urlFetcherMock).FetchCalls()[0].Url, "https://chat.example.com/screenshot.png")
+		tt.Equal(storage.UploadCalls()[0].ContentType, "image/png")
+		tt.Equal(repo.SetSourceURLCalls()[0].SourceURL, "https://chat.example.com/screenshot.png")
+
+		var got uploadedImage
+		err := json.NewDecoder(resp.Body).Decode(&got)
+		tt.NoErr(err)
+		tt.Equal(got.FileID, storage.UploadCalls()[0].Key)
+		tt.Equal(got.SourceURL, "https://chat.example.com/screenshot.png")
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+		app.fetcher = &urlFetcherMock{}
+
+		w := httptest.NewRecorder()
+		app.ingestHandler(w, ingestRequest(`{"url": "not a url"}`))
+
+		tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+		tt.Equal(len(app.fetcher.(*urlFetcherMock).FetchCalls()), 0)
+	})
+
+	tests := []struct {
+		name     string
+		fetchErr error
+		want     int
+	}{
+		{"private address", fmt.Errorf("fetching, %w", fetch.ErrForbiddenAddr), http.StatusUnprocessableEntity},
+		{"too large", fmt.Errorf("reading, %w", fetch.ErrTooLarge), http.StatusRequestEntityTooLarge},
+		{"unreachable", errors.New("connection refused"), http.StatusBadGateway},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(nil, nil)
+			app.fetcher = &urlFetcherMock{FetchFunc: func(ctx context.Context, url string, w io.Writer) error {
+				return tc.fetchErr
+			}}
+
+			w := httptest.NewRecorder()
+			app.ingestHandler(w, ingestRequest(`{"url": "http://10.0.0.1/screenshot.png"}`))
+
+			tt.Equal(w.Result().StatusCode, tc.want)
+		})
+	}
+
+	t.Run("not an image", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+		app.fetcher = &urlFetcherMock{FetchFunc: func(ctx context.Context, url string, w io.Writer) error {
+			_, err := w.Write([]byte("<html></html>"))
+			return err
+		}}
+
+		w := httptest.NewRecorder()
+		app.ingestHandler(w, ingestRequest(`{"url": "https://example.com/page"}`))
+
+		tt.Equal(w.Result().StatusCode, http.StatusUnsupportedMediaType)
+	})
+}
@@ -1,35 +1,108 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"log/slog"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/elnoro/foxyshot-indexer/internal/app"
-	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
-	"github.com/elnoro/foxyshot-indexer/internal/indexer"
-	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/redact"
+	"github.com/elnoro/foxyshot-indexer/internal/schedule"
+	"github.com/elnoro/foxyshot-indexer/internal/secrets"
 	"github.com/go-playground/validator/v10"
 	_ "github.com/jackc/pgx/stdlib"
-	"github.com/jmoiron/sqlx"
-
-	"github.com/elnoro/foxyshot-indexer/internal/ocr"
-	"github.com/elnoro/foxyshot-indexer/internal/s3wrapper"
 )
 
 type Config struct {
-	Port           int           `validate:"required"`
+	Web      WebConfig
+	Ingest   IngestConfig
+	Index    IndexConfig
+	DSN      string
+	Storage  string
+	S3       S3Config
+	Redact   RedactConfig
+	TagRules string
+	Alerts   AlertsConfig
+	Webhooks WebhooksConfig
+	Search   SearchConfig
+	Export   ExportConfig
+	Backup   BackupConfig
+	Migrate  MigrateConfig
+	Demo     string
+}
+
+type WebConfig struct {
+	Port    int    `validate:"required"`
+	BaseURL string `validate:"omitempty,url"`
+	// ReadOnly disables the endpoints that change data or need tesseract
+	ReadOnly bool
+}
+
+type IngestConfig struct {
+	Timeout time.Duration `validate:"required"`
+	// AllowPrivate lets the API fetch images from private and loopback addresses, e.g. a chat server on the LAN
+	AllowPrivate bool
+}
+
+type IndexConfig struct {
+	Ext            string `validate:"required"`
+	MaskSecrets    bool
 	ScrapeInterval time.Duration `validate:"required"`
-	Ext            string        `validate:"required"`
-	DSN            string        `validate:"required"`
-	S3             S3Config      `validate:"required"`
+	// ScrapeCron replaces the interval with a cron expression
+	ScrapeCron string
+	// ScrapeWindows are the daily time ranges when indexing is allowed, e.g. 22:00-06:00
+	ScrapeWindows []string
+	// Watch indexes new files of a filesystem storage as soon as they appear
+	Watch bool
+}
+
+type SearchConfig struct {
+	Page    int `validate:"min=1"`
+	PerPage int `validate:"min=1,max=100"`
+	JSON    bool
+}
+
+type ExportConfig struct {
+	// Search exports the results of the search instead of the given file ids
+	Search string
+	Limit  int    `validate:"min=1,max=100"`
+	Output string `validate:"required"`
+}
+
+type BackupConfig struct {
+	Format string `validate:"oneof=jsonl csv"`
+	// Output is where the export command writes, - for stdout
+	Output string
+}
+
+type MigrateConfig struct {
+	// OnStart applies pending migrations before the schema version is checked
+	OnStart bool
+}
+
+type AlertsConfig struct {
+	WebhookURL    string `validate:"omitempty,url"`
+	WebhookSecret string `validate:"required_with=WebhookURL"`
+}
+
+type WebhooksConfig struct {
+	// AllowPrivate lets webhooks be sent to private and loopback addresses, e.g. a service on the LAN
+	AllowPrivate bool
+}
+
+type RedactConfig struct {
+	Enabled bool
+	// Prefix is where redacted copies are stored, files under it are never indexed
+	Prefix   string `validate:"required"`
+	Patterns []string
+	// ServeOriginals lets clients of the API request unredacted files with ?original=true
+	ServeOriginals bool
 }
 
 type S3Config struct {
@@ -45,121 +118,270 @@ type S3Config struct {
 
 var version = "development"
 
-func main() {
-	cfg := Config{}
+const webhookTimeout = 10 * time.Second
 
-	flag.IntVar(&cfg.Port, "web.port", 8080, "API server port")
+// defaultCommand keeps the invocation without a command working.
+const defaultCommand = "all"
 
-	flag.DurationVar(&cfg.ScrapeInterval, "scrape.interval", 15*time.Minute, "how often to scrape s3")
-	flag.StringVar(&cfg.Ext, "ext", ".jpg", "file extensions to use")
-	flag.StringVar(&cfg.DSN, "dsn", os.Getenv("DB_DSN"), "connection string for the database")
-
-	flag.StringVar(&cfg.S3.Key, "s3.key", os.Getenv("S3_KEY"), "s3 key")
-	flag.StringVar(&cfg.S3.Secret, "s3.secret", os.Getenv("S3_SECRET"), "s3 secret")
-	flag.StringVar(&cfg.S3.Endpoint, "s3.endpoint", os.Getenv("S3_ENDPOINT"), "s3 endpoint")
-	flag.StringVar(&cfg.S3.Region, "s3.region", "eu-west1", "s3 region")
-	flag.StringVar(&cfg.S3.Bucket, "s3.bucket", os.Getenv("S3_BUCKET"), "s3 bucket")
-	flag.BoolVar(&cfg.S3.Insecure, "s3.insecure", false, "disable ssl. For testing purposes only!")
+type command struct {
+	usage string
+	flags func(fs *flag.FlagSet, cfg *Config)
+	run   func(cfg Config, args []string) error
+}
 
-	flag.IntVar(&cfg.S3.RetryAttempts, "s3.attempts", 0, "how many times to check s3 connectivity during startup")
-	flag.DurationVar(&cfg.S3.RetryDuration, "s3.retry", 15*time.Second, "retry duration between attempts")
-	flag.Parse()
+var commands = map[string]command{
+	"serve": {
+		usage: "serve the API and the event stream",
+		flags: func(fs *flag.FlagSet, cfg *Config) {
+			dbFlags(fs, cfg)
+			webFlags(fs, cfg)
+			webhookFlags(fs, cfg)
+			s3Flags(fs, cfg)
+			storageFlags(fs, cfg)
+			stageFlags(fs, cfg)
+			migrateOnStartFlags(fs, cfg)
+		},
+		run: func(cfg Config, _ []string) error {
+			return runRoles(cfg, roles{web: true})
+		},
+	},
+	"worker": {
+		usage: "index new screenshots and deliver webhooks",
+		flags: func(fs *flag.FlagSet, cfg *Config) {
+			dbFlags(fs, cfg)
+			indexFlags(fs, cfg)
+			webhookFlags(fs, cfg)
+			s3Flags(fs, cfg)
+			storageFlags(fs, cfg)
+			stageFlags(fs, cfg)
+			migrateOnStartFlags(fs, cfg)
+		},
+		run: func(cfg Config, _ []string) error {
+			return runRoles(cfg, roles{worker: true})
+		},
+	},
+	"all": {
+		usage: "run serve and worker in one process",
+		flags: func(fs *flag.FlagSet, cfg *Config) {
+			dbFlags(fs, cfg)
+			webFlags(fs, cfg)
+			indexFlags(fs, cfg)
+			webhookFlags(fs, cfg)
+			s3Flags(fs, cfg)
+			storageFlags(fs, cfg)
+			stageFlags(fs, cfg)
+			migrateOnStartFlags(fs, cfg)
+			demoFlags(fs, cfg)
+		},
+		run: func(cfg Config, _ []string) error {
+			return runRoles(cfg, roles{web: true, worker: true})
+		},
+	},
+	"search": {
+		usage: "search screenshots from the command line: search [flags] <query>",
+		flags: searchFlags,
+		run:   runSearch,
+	},
+	"export": {
+		usage: "export the index with edits, tags and metadata as jsonl or csv: export [flags]",
+		flags: exportIndexFlags,
+		run:   runExportIndex,
+	},
+	"import": {
+		usage: "import an export without running OCR: import [flags] [file]",
+		flags: backupFlags,
+		run:   runImportIndex,
+	},
+	"export-pdf": {
+		usage: "write a searchable pdf of screenshots: export-pdf [flags] <file id>... or -search <query>",
+		flags: exportFlags,
+		run:   runExportPDF,
+	},
+	"migrate": {
+		usage: "apply the embedded database migrations: migrate [flags] up [n]|down [n]|status|force <version>",
+		flags: migrateFlags,
+		run:   runMigrate,
+	},
+	"version": {
+		usage: "print the version",
+		flags: func(*flag.FlagSet, *Config) {},
+		run: func(Config, []string) error {
+			fmt.Println(version)
+			return nil
+		},
+	},
+}
 
-	err := validateConfig(cfg)
-	if err != nil {
-		log.Fatal(err)
+func main() {
+	name, args := parseCommand(os.Args[1:])
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
 	}
 
-	logger := slog.Default()
-
-	storage, err := s3wrapper.NewFromSecrets(
-		cfg.S3.Key,
-		cfg.S3.Secret,
-		cfg.S3.Endpoint,
-		cfg.S3.Region,
-		cfg.S3.Bucket,
-		cfg.S3.Insecure,
-		logger,
-	)
+	cfg := Config{}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cmd.flags(fs, &cfg)
+	_ = fs.Parse(args)
+
+	err := cmd.run(cfg, fs.Args())
 	if err != nil {
 		log.Fatal(err)
 	}
-	if cfg.S3.RetryAttempts > 0 {
-		err := storage.CheckConnectivity(cfg.S3.RetryAttempts, cfg.S3.RetryDuration)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+}
 
-	ocrEngine, err := ocr.Default()
-	if err != nil {
-		log.Fatal(err)
+// parseCommand splits the arguments into the command and its flags, flags without a command run the default command.
+func parseCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return defaultCommand, args
 	}
 
-	tracker := monitoring.NewTracker()
-	err = tracker.Register()
-	if err != nil {
-		log.Fatal(err)
+	return args[0], args[1:]
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	db, err := sqlx.Connect("pgx", cfg.DSN)
-	if err != nil {
-		log.Fatal("sqlx error", err)
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-11s %s\n", name, commands[name].usage)
 	}
-	defer func(db *sqlx.DB) {
-		err := db.Close()
-		if err != nil {
-			log.Println("database closing error,", err)
-		}
-	}(db)
+	fmt.Fprintf(os.Stderr, "\nwithout a command %q is run, use <command> -h to list its flags\n", defaultCommand)
+}
 
-	imgRepo := dbadapter.NewImageRepo(db)
+func dbFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.DSN, "dsn", os.Getenv("DB_DSN"),
+		"connection string for the database, postgres://... or sqlite:///path/to/index.db")
+}
+
+func webFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.IntVar(&cfg.Web.Port, "web.port", 8080, "API server port")
+	fs.StringVar(&cfg.Web.BaseURL, "web.base-url", "",
+		"public url of the app for links in feeds and uploads, feeds are served only when it is set, e.g. https://shots.example.com")
+	fs.BoolVar(&cfg.Web.ReadOnly, "web.read-only", false,
+		"serve only endpoints that do not change data and do not need tesseract")
+	fs.DurationVar(&cfg.Ingest.Timeout, "ingest.timeout", 15*time.Second, "timeout of fetching an ingested image")
+	fs.BoolVar(&cfg.Ingest.AllowPrivate, "ingest.allow-private", false,
+		"allow ingesting images from private, loopback and link-local addresses")
+	fs.BoolVar(&cfg.Redact.ServeOriginals, "redact.serve-originals", false,
+		"serve unredacted files to requests with ?original=true")
+}
+
+func indexFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.DurationVar(&cfg.Index.ScrapeInterval, "scrape.interval", 15*time.Minute, "how often to scrape s3")
+	fs.StringVar(&cfg.Index.ScrapeCron, "scrape.cron", "",
+		"cron expression of scrapes in local time, replaces the interval, e.g. \"*/30 * * * *\"")
+	fs.Func("scrape.window", "daily time range when scraping is allowed, e.g. 22:00-06:00, can be repeated",
+		func(s string) error {
+			cfg.Index.ScrapeWindows = append(cfg.Index.ScrapeWindows, s)
+			return nil
+		})
+	fs.StringVar(&cfg.Index.Ext, "ext", ".jpg", "comma separated file extensions or content types to index, e.g. .jpg,.png or image/*")
+	fs.BoolVar(&cfg.Index.Watch, "storage.watch", false,
+		"index new files of a filesystem storage as soon as they appear, uses inotify")
+}
+
+// stageFlags configure the stages of the indexer, which is built by every replica that indexes images,
+// including uploads to the API.
+func stageFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.BoolVar(&cfg.Index.MaskSecrets, "secrets.mask", false, "mask detected secrets in stored descriptions")
+	fs.Func("redact.pattern", "additional regexp of sensitive text, can be repeated", func(s string) error {
+		cfg.Redact.Patterns = append(cfg.Redact.Patterns, s)
+		return nil
+	})
+}
+
+// webhookFlags configure outgoing webhooks and saved search alerts, which are sent by every replica
+// that indexes images.
+func webhookFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Alerts.WebhookURL, "alerts.webhook-url", "", "url that receives saved search matches")
+	fs.StringVar(&cfg.Alerts.WebhookSecret, "alerts.webhook-secret", os.Getenv("ALERTS_WEBHOOK_SECRET"),
+		"secret for signing alert webhooks")
+	fs.BoolVar(&cfg.Webhooks.AllowPrivate, "webhooks.allow-private", false,
+		"allow webhooks to private, loopback and link-local addresses")
+}
 
-	idxr := indexer.NewIndexer(imgRepo, storage, ocrEngine, logger, tracker)
-	runner := app.NewIndexRunner(idxr, cfg.Ext, cfg.ScrapeInterval, logger)
+// storageFlags describe how images are stored, the API and the worker must use the same values.
+func storageFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Storage, "storage", s3Storage, "where screenshots are stored: s3 or fs:/path/to/dir")
+	fs.StringVar(&cfg.TagRules, "tags.rules", "", "path to a json file with tagging rules")
 
-	ctx, cancel := context.WithCancel(context.Background())
+	fs.BoolVar(&cfg.Redact.Enabled, "redact", false, "store redacted copies of images and serve them by default")
+	fs.StringVar(&cfg.Redact.Prefix, "redact.prefix", "redacted/", "s3 prefix for redacted copies")
+}
 
-	wg := sync.WaitGroup{}
+func s3Flags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.S3.Key, "s3.key", os.Getenv("S3_KEY"), "s3 key")
+	fs.StringVar(&cfg.S3.Secret, "s3.secret", os.Getenv("S3_SECRET"), "s3 secret")
+	fs.StringVar(&cfg.S3.Endpoint, "s3.endpoint", os.Getenv("S3_ENDPOINT"), "s3 endpoint")
+	fs.StringVar(&cfg.S3.Region, "s3.region", "eu-west1", "s3 region")
+	fs.StringVar(&cfg.S3.Bucket, "s3.bucket", os.Getenv("S3_BUCKET"), "s3 bucket")
+	fs.BoolVar(&cfg.S3.Insecure, "s3.insecure", false, "disable ssl. For testing purposes only!")
 
-	web := &webApp{
-		config: cfg,
-		log:    log.Default(),
+	fs.IntVar(&cfg.S3.RetryAttempts, "s3.attempts", 0, "how many times to check s3 connectivity during startup")
+	fs.DurationVar(&cfg.S3.RetryDuration, "s3.retry", 15*time.Second, "retry duration between attempts")
+}
 
-		imageDescriptions: imgRepo,
-		fileStorage:       storage,
-		tracker:           tracker,
+// validateConfig checks the dsn and the given parts of the config, so that commands only validate what they use.
+func validateConfig(cfg Config, parts ...any) error {
+	if cfg.DSN == "" {
+		return errors.New("dsn is required, set -dsn or DB_DSN")
 	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		err := web.serve(ctx)
+	return validateParts(parts...)
+}
+
+func validateParts(parts ...any) error {
+	validate := validator.New()
+	for _, p := range parts {
+		err := validate.Struct(p)
 		if err != nil {
-			log.Println("web server error:", err)
+			return err
 		}
-	}()
+	}
+
+	return nil
+}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		err := runner.Start(ctx)
+// indexSchedule returns when the indexer runs, the cron expression takes precedence over the interval.
+func indexSchedule(cfg IndexConfig) (schedule.Schedule, schedule.Windows, error) {
+	var sched schedule.Schedule = schedule.Interval(cfg.ScrapeInterval)
+	if cfg.ScrapeCron != "" {
+		c, err := schedule.ParseCron(cfg.ScrapeCron)
 		if err != nil {
-			log.Println("index runner error:", err)
+			return nil, nil, err
 		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		sched = c
+	}
 
-	s := <-quit
+	windows := make(schedule.Windows, 0, len(cfg.ScrapeWindows))
+	for _, s := range cfg.ScrapeWindows {
+		w, err := schedule.ParseWindow(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		windows = append(windows, w)
+	}
 
-	fmt.Printf("Received %s signal\n", s.String())
-	cancel()
-	wg.Wait()
+	return sched, windows, nil
 }
 
-func validateConfig(cfg Config) error {
-	validate := validator.New()
-	return validate.Struct(cfg)
+// redactionRules extends a copy of the default rules with the patterns from the config.
+func redactionRules(patterns []string) ([]secrets.Rule, error) {
+	rules := slices.Clone(redact.DefaultRules)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compiling redaction pattern %s, %w", p, err)
+		}
+		rules = append(rules, secrets.Rule{Name: "custom", Pattern: re})
+	}
+
+	return rules, nil
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/redact"
+	"github.com/elnoro/foxyshot-indexer/internal/schedule"
+	"github.com/matryer/is"
+)
+
+func TestParseCommand(t *testing.T) {
+	tt := is.New(t)
+
+	testCases := []struct {
+		args         []string
+		expectedName string
+		expectedArgs []string
+	}{
+		{args: []string{}, expectedName: "all", expectedArgs: []string{}},
+		{args: []string{"-s3.insecure", "-scrape.interval=1m"}, expectedName: "all", expectedArgs: []string{"-s3.insecure", "-scrape.interval=1m"}},
+		{args: []string{"worker", "-ext=.png"}, expectedName: "worker", expectedArgs: []string{"-ext=.png"}},
+		{args: []string{"search", "-json", "connection refused"}, expectedName: "search", expectedArgs: []string{"-json", "connection refused"}},
+	}
+	for _, tc := range testCases {
+		name, args := parseCommand(tc.args)
+		tt.Equal(name, tc.expectedName)
+		tt.Equal(args, tc.expectedArgs)
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	tt := is.New(t)
+
+	// every role that indexes images, including uploads to the api, accepts the flags of the stages
+	for _, name := range []string{"serve", "worker", "all"} {
+		cfg := Config{}
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		commands[name].flags(fs, &cfg)
+
+		err := fs.Parse([]string{"-secrets.mask", "-redact", "-redact.pattern=customer-[0-9]+"})
+		tt.NoErr(err)
+		tt.True(cfg.Index.MaskSecrets)
+		tt.True(cfg.Redact.Enabled)
+		tt.Equal(cfg.Redact.Patterns, []string{"customer-[0-9]+"})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tt := is.New(t)
+
+	cfg := Config{DSN: "postgres://localhost/db", Search: SearchConfig{Page: 1, PerPage: 20}}
+
+	tt.NoErr(validateConfig(cfg, cfg.Search)) // s3 is not needed to search
+	tt.True(validateConfig(cfg, cfg.Search, cfg.S3) != nil)
+	tt.True(validateConfig(Config{}, cfg.Search) != nil) // the dsn is always required
+}
+
+func TestIndexSchedule(t *testing.T) {
+	tt := is.New(t)
+
+	sched, windows, err := indexSchedule(IndexConfig{ScrapeInterval: time.Minute})
+	tt.NoErr(err)
+	tt.Equal(sched, schedule.Interval(time.Minute))
+	tt.Equal(len(windows), 0)
+
+	sched, windows, err = indexSchedule(IndexConfig{
+		ScrapeInterval: time.Minute,
+		ScrapeCron:     "0 2 * * *",
+		ScrapeWindows:  []string{"22:00-06:00"},
+	})
+	tt.NoErr(err)
+	_, isCron := sched.(*schedule.Cron)
+	tt.True(isCron) // cron takes precedence over the interval
+	tt.Equal(windows, schedule.Windows{{From: 22 * 60, To: 6 * 60}})
+
+	_, _, err = indexSchedule(IndexConfig{ScrapeCron: "every night"})
+	tt.True(err != nil)
+	_, _, err = indexSchedule(IndexConfig{ScrapeWindows: []string{"night"}})
+	tt.True(err != nil)
+}
+
+func TestRedactionRules(t *testing.T) {
+	tt := is.New(t)
+
+	defaults := len(redact.DefaultRules)
+	first, err := redactionRules([]string{"first"})
+	tt.NoErr(err)
+	_, err = redactionRules([]string{"second"})
+	tt.NoErr(err)
+
+	tt.Equal(len(redact.DefaultRules), defaults)
+	tt.Equal(first[len(first)-1].Pattern.String(), "first") // rules of other calls do not share the slice
+
+	_, err = redactionRules([]string{"("})
+	tt.True(err != nil)
+}
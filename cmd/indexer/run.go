@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/alerts"
+	"github.com/elnoro/foxyshot-indexer/internal/app"
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/entities"
+	"github.com/elnoro/foxyshot-indexer/internal/events"
+	"github.com/elnoro/foxyshot-indexer/internal/fetch"
+	"github.com/elnoro/foxyshot-indexer/internal/fsstorage"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
+	"github.com/elnoro/foxyshot-indexer/internal/metadata"
+	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/ocr"
+	"github.com/elnoro/foxyshot-indexer/internal/redact"
+	"github.com/elnoro/foxyshot-indexer/internal/schedule"
+	"github.com/elnoro/foxyshot-indexer/internal/secrets"
+	"github.com/elnoro/foxyshot-indexer/internal/stream"
+	"github.com/elnoro/foxyshot-indexer/internal/tagging"
+	"github.com/elnoro/foxyshot-indexer/internal/webhook"
+)
+
+// roles are the parts of the app that run in the process.
+// The web role serves the API, the worker role indexes screenshots and delivers webhooks.
+type roles struct {
+	web    bool
+	worker bool
+}
+
+// repository stores images and everything around them: tags, saved searches, webhooks and the event log.
+type repository interface {
+	imageRepo
+	GetLastModified(ctx context.Context) (time.Time, error)
+	Upsert(ctx context.Context, image domain.Image) error
+	AppendEvent(ctx context.Context, e domain.Event) (int64, error)
+	EventsAfter(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error)
+	LastEventSeq(ctx context.Context) (int64, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) error
+	EnqueueDeliveries(ctx context.Context, event string, payload []byte) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.ClaimedDelivery, error)
+	UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error
+	SetSavedSearchMatch(ctx context.Context, searchID int, fileID string, matches bool) (bool, error)
+	ImageFindings(ctx context.Context, fileID string) ([]domain.Finding, error)
+}
+
+// eventNotifier wakes up the event stream when any replica appends an event.
+type eventNotifier interface {
+	Wait(ctx context.Context) error
+}
+
+// backends are what the roles are built on, real services or in-memory fakes in demo mode.
+type backends struct {
+	storage storage
+	// ocr is nil for a read-only API, tesseract is also nil in demo mode
+	ocr       ocrEngine
+	tesseract *ocr.Tesseract
+	repo      repository
+	events    eventNotifier
+	close     func()
+}
+
+func runRoles(cfg Config, r roles) error {
+	dir, err := storageDir(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	parts := []any{cfg.Redact, cfg.Alerts}
+	if dir == "" && cfg.Demo == "" {
+		parts = append(parts, cfg.S3)
+	}
+	if r.web {
+		parts = append(parts, cfg.Web, cfg.Ingest)
+	}
+	if r.worker {
+		parts = append(parts, cfg.Index)
+	}
+	if cfg.Demo != "" {
+		err = validateParts(parts...)
+	} else {
+		err = validateConfig(cfg, parts...)
+	}
+	if err != nil {
+		return err
+	}
+	if r.worker {
+		exts, err := imageformat.Extensions(cfg.Index.Ext)
+		if err != nil {
+			return fmt.Errorf("invalid ext %s, %w", cfg.Index.Ext, err)
+		}
+		cfg.Index.Ext = strings.Join(exts, ",")
+	}
+
+	logger := slog.Default()
+
+	tracker := monitoring.NewTracker()
+	err = tracker.Register()
+	if err != nil {
+		return err
+	}
+
+	var b backends
+	if cfg.Demo != "" {
+		b, err = openDemo(cfg, logger)
+	} else {
+		b, err = openBackends(cfg, r, logger)
+	}
+	if err != nil {
+		return err
+	}
+	defer b.close()
+
+	tagger, err := tagging.NewTagger(cfg.TagRules)
+	if err != nil {
+		return err
+	}
+
+	var (
+		sched   schedule.Schedule
+		windows schedule.Windows
+	)
+	if r.worker {
+		sched, windows, err = indexSchedule(cfg.Index)
+		if err != nil {
+			return err
+		}
+	}
+
+	var redactRules []secrets.Rule
+	if cfg.Redact.Enabled {
+		redactRules, err = redactionRules(cfg.Redact.Patterns)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+
+	// events of both roles are stored for the stream and the webhooks, so that any replica can serve them
+	bus := events.NewBus()
+	broker := stream.NewBroker(b.repo, logger)
+	dispatcher := webhook.NewDispatcher(b.repo, webhook.NewClient(webhookTimeout, cfg.Webhooks.AllowPrivate), logger)
+	bus.Subscribe(broker, dispatcher)
+
+	// saved searches are evaluated by every replica that indexes images, the worker or the one that accepts uploads.
+	// The indexer is built once, uploads reuse the indexer of the worker.
+	newIndexer := func() *indexer.Indexer {
+		if cfg.Alerts.WebhookURL != "" {
+			// the alert url is set by the operator, not by users of the API
+			sender := webhook.NewSender(
+				webhook.NewClient(webhookTimeout, true),
+				cfg.Alerts.WebhookURL,
+				cfg.Alerts.WebhookSecret,
+				logger,
+			)
+			bus.Subscribe(alerts.NewEvaluator(b.repo, tracker, logger, sender))
+
+			goRun(&wg, "alert webhook", func() error { return sender.Run(ctx) })
+		} else {
+			bus.Subscribe(alerts.NewEvaluator(b.repo, tracker, logger))
+		}
+
+		idxr := indexer.NewIndexer(b.repo, b.storage, b.ocr, logger, tracker)
+		idxr.Use(metadata.NewReader(b.storage, logger))
+		// secrets go first, so that masked values are not extracted as entities
+		idxr.Use(secrets.NewDetector(cfg.Index.MaskSecrets, b.repo, tracker), entities.NewExtractor(), tagger)
+		if cfg.Redact.Enabled {
+			idxr.Use(redact.NewRedactor(b.tesseract, b.storage, redactRules, cfg.Redact.Prefix))
+		}
+		idxr.SetPublisher(bus)
+
+		return idxr
+	}
+
+	var (
+		runner *app.IndexRunner
+		idxr   *indexer.Indexer
+	)
+	if r.worker {
+		idxr = newIndexer()
+		// replicas share new files through the queue, so that a file is indexed once
+		if q, ok := b.repo.(indexer.Queue); ok {
+			idxr.SetQueue(q)
+		}
+		runner = app.NewIndexRunner(idxr, cfg.Index.Ext, sched, logger, tracker)
+		runner.SetWindows(windows)
+
+		goRun(&wg, "webhook dispatcher", func() error { return dispatcher.Run(ctx) })
+		goRun(&wg, "index runner", func() error { return runner.Start(ctx) })
+
+		if fsStore, ok := b.storage.(*fsstorage.Storage); ok && cfg.Index.Watch {
+			goRun(&wg, "file watcher", func() error {
+				// paused indexing picks the files up on the next scheduled run
+				return fsStore.Watch(ctx, cfg.Index.Ext, func() { _ = runner.Trigger() })
+			})
+		}
+	}
+
+	if r.web {
+		web := &webApp{
+			config: cfg,
+			log:    log.Default(),
+
+			imageDescriptions: b.repo,
+			fileStorage:       b.storage,
+			ocrEngine:         b.ocr,
+			tagger:            tagger,
+			events:            bus,
+			stream:            broker,
+			tracker:           tracker,
+		}
+		if runner != nil {
+			web.indexRunner = runner
+		}
+		// uploads are indexed by the replica that received them, a read-only API does not accept them
+		if !cfg.Web.ReadOnly {
+			if idxr == nil {
+				idxr = newIndexer()
+			}
+			web.uploadIndexer = idxr
+			web.fetcher = fetch.NewClient(cfg.Ingest.Timeout, maxImageSize, cfg.Ingest.AllowPrivate)
+		}
+
+		goRun(&wg, "event stream", func() error { return broker.Run(ctx, b.events) })
+		goRun(&wg, "web server", func() error { return web.serve(ctx) })
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	s := <-quit
+
+	fmt.Printf("Received %s signal\n", s.String())
+	cancel()
+	wg.Wait()
+
+	return nil
+}
+
+// openBackends connects to the storage and the database, the schema version is checked before anything runs.
+func openBackends(cfg Config, r roles, logger *slog.Logger) (backends, error) {
+	store, err := newStorage(cfg, logger)
+	if err != nil {
+		return backends{}, err
+	}
+
+	b := backends{storage: store}
+
+	// a read-only API does not search by image, so it runs without tesseract
+	if r.worker || !cfg.Web.ReadOnly {
+		b.tesseract, err = ocr.Default()
+		if err != nil {
+			return backends{}, err
+		}
+		// nil pointers must not become non-nil interfaces
+		b.ocr = b.tesseract
+	}
+
+	db, err := openDatabase(cfg.DSN)
+	if err != nil {
+		return backends{}, err
+	}
+
+	// Postgres notifies about appended events on a dedicated connection,
+	// a SQLite database is used by a single process, so its repository wakes up the broker itself
+	repo := newImageRepo(db)
+	notifier, ok := repo.(eventNotifier)
+	var listener *dbadapter.Listener
+	if !ok {
+		listener = dbadapter.NewListener(cfg.DSN, dbadapter.EventsChannel)
+		notifier = listener
+	}
+	b.close = func() {
+		if listener != nil {
+			_ = listener.Close()
+		}
+		err := db.Close()
+		if err != nil {
+			log.Println("database closing error,", err)
+		}
+	}
+
+	err = checkSchema(context.Background(), db, cfg.Migrate.OnStart)
+	if err != nil {
+		b.close()
+		return backends{}, err
+	}
+
+	b.repo = repo
+	b.events = notifier
+
+	return b, nil
+}
+
+// goRun runs the function in the background until the context of the process is cancelled.
+func goRun(wg *sync.WaitGroup, name string, f func() error) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := f()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("%s error: %s", name, err)
+		}
+	}()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+)
+
+type savedSearchRequest struct {
+	Name  string `json:"name" validate:"required,max=200"`
+	Query string `json:"query" validate:"required,max=1000"`
+}
+
+func (app *webApp) listSavedSearchesHandler(w http.ResponseWriter, r *http.Request) {
+	searches, err := app.imageDescriptions.ListSavedSearches(context.Background())
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, searches)
+}
+
+func (app *webApp) getSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	s, err := app.imageDescriptions.GetSavedSearch(context.Background(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, s)
+}
+
+func (app *webApp) createSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var req savedSearchRequest
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	s, err := app.imageDescriptions.CreateSavedSearch(context.Background(), req.Name, req.Query)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusCreated, s)
+}
+
+func (app *webApp) updateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	var req savedSearchRequest
+	err = app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	s, err := app.imageDescriptions.UpdateSavedSearch(context.Background(), id, req.Name, req.Query)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, s)
+}
+
+func (app *webApp) deleteSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.imageDescriptions.DeleteSavedSearch(context.Background(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondNoContent(r, w)
+}
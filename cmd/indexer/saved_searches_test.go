@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestListSavedSearchesHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		ListSavedSearchesFunc: func(ctx context.Context) ([]domain.SavedSearch, error) {
+			return []domain.SavedSearch{{ID: 1, Name: "oom", Query: "OOMKilled", CreatedAt: time.Unix(0, 0).UTC()}}, nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/saved-searches", nil)
+	w := httptest.NewRecorder()
+
+	app.listSavedSearchesHandler(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusOK)
+	body, err := io.ReadAll(resp.Body)
+	tt.NoErr(err)
+	tt.Equal(string(body), `[{"ID":1,"Name":"oom","Query":"OOMKilled","CreatedAt":"1970-01-01T00:00:00Z"}]`)
+}
+
+func TestCreateSavedSearchHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("creates saved search", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			CreateSavedSearchFunc: func(ctx context.Context, name, query string) (domain.SavedSearch, error) {
+				return domain.SavedSearch{ID: 1, Name: name, Query: query}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/saved-searches", bytes.NewBufferString(
+			`{"name": "panics", "query": "panic:"}`,
+		))
+		w := httptest.NewRecorder()
+
+		app.createSavedSearchHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusCreated)
+		tt.Equal(imageDescriptions.CreateSavedSearchCalls()[0].Name, "panics")
+		tt.Equal(imageDescriptions.CreateSavedSearchCalls()[0].Query, "panic:")
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		for _, body := range []string{`{"name": "no query"}`, `not json`} {
+			req := httptest.NewRequest(http.MethodPost, "/saved-searches", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			app.createSavedSearchHandler(w, req)
+
+			resp := w.Result()
+			resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestUpdateSavedSearchHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("updates saved search", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			UpdateSavedSearchFunc: func(ctx context.Context, id int, name, query string) (domain.SavedSearch, error) {
+				return domain.SavedSearch{ID: id, Name: name, Query: query}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/saved-searches/7", bytes.NewBufferString(
+			`{"name": "oom", "query": "OOMKilled"}`,
+		))
+		w := httptest.NewRecorder()
+
+		app.updateSavedSearchHandler(w, withURLParam(req, "id", "7"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(imageDescriptions.UpdateSavedSearchCalls()[0].Id, 7)
+	})
+
+	t.Run("unknown saved search", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			UpdateSavedSearchFunc: func(ctx context.Context, id int, name, query string) (domain.SavedSearch, error) {
+				return domain.SavedSearch{}, fmt.Errorf("not found, %w", dbadapter.ErrRecordNotFound)
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/saved-searches/7", bytes.NewBufferString(
+			`{"name": "oom", "query": "OOMKilled"}`,
+		))
+		w := httptest.NewRecorder()
+
+		app.updateSavedSearchHandler(w, withURLParam(req, "id", "7"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+	})
+}
+
+func TestGetSavedSearchHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("invalid id", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		for _, id := range []string{"abc", "0", "-1"} {
+			req := httptest.NewRequest(http.MethodGet, "/saved-searches/"+id, nil)
+			w := httptest.NewRecorder()
+
+			app.getSavedSearchHandler(w, withURLParam(req, "id", id))
+
+			resp := w.Result()
+			resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetSavedSearchFunc: func(ctx context.Context, id int) (domain.SavedSearch, error) {
+				return domain.SavedSearch{}, errors.New("expected-err")
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/saved-searches/1", nil)
+		w := httptest.NewRecorder()
+
+		app.getSavedSearchHandler(w, withURLParam(req, "id", "1"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+	})
+}
+
+func TestDeleteSavedSearchHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		DeleteSavedSearchFunc: func(ctx context.Context, id int) error { return nil },
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/saved-searches/3", nil)
+	w := httptest.NewRecorder()
+
+	app.deleteSavedSearchHandler(w, withURLParam(req, "id", "3"))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusNoContent)
+	tt.Equal(imageDescriptions.DeleteSavedSearchCalls()[0].Id, 3)
+}
@@ -3,13 +3,21 @@ package main
 import (
 	"context"
 	"net/http"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/phash"
 )
 
+// duplicateDistance is the maximum distance between perceptual hashes of near-duplicate images.
+const duplicateDistance = 6
+
 func (app *webApp) searchHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Search  string `json:"search" validate:"required"`
 		Page    int    `json:"page" validate:"min=1"`
 		PerPage int    `json:"per_page" validate:"min=1,max=100"`
+		// Collapse hides near-duplicates of images that are already in the results
+		Collapse bool `json:"collapse"`
 	}
 	err := app.parseJSON(r, &req)
 	if err != nil {
@@ -31,5 +39,32 @@ func (app *webApp) searchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	app.tracker.OnSearch()
 
+	if req.Collapse {
+		images = collapseDuplicates(images, duplicateDistance)
+	}
+
 	app.respondJSON(r, w, http.StatusOK, images)
 }
+
+// collapseDuplicates keeps the first image of every group of visually similar images.
+func collapseDuplicates(images []domain.Image, maxDistance int) []domain.Image {
+	collapsed := make([]domain.Image, 0, len(images))
+	for _, img := range images {
+		if img.PHash != 0 && hasDuplicate(collapsed, img, maxDistance) {
+			continue
+		}
+		collapsed = append(collapsed, img)
+	}
+
+	return collapsed
+}
+
+func hasDuplicate(images []domain.Image, img domain.Image, maxDistance int) bool {
+	for _, other := range images {
+		if other.PHash != 0 && phash.Distance(uint64(other.PHash), uint64(img.PHash)) <= maxDistance {
+			return true
+		}
+	}
+
+	return false
+}
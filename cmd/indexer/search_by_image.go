@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/elnoro/foxyshot-indexer/internal/match"
+	"github.com/elnoro/foxyshot-indexer/internal/phash"
+)
+
+const (
+	maxImageSize = 10 << 20
+	// maxCandidates limits how many images are scored against the example
+	maxCandidates = 200
+	// maxTerms limits the number of OCR words used to look up candidates
+	maxTerms          = 32
+	candidateDistance = 16
+	tempUploadPrefix  = "foxyshot_upload_"
+)
+
+func (app *webApp) searchByImageHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageSize)
+
+	var req struct {
+		Limit int `validate:"min=1,max=100"`
+	}
+
+	err := r.ParseMultipartForm(maxImageSize)
+	if err != nil {
+		app.validationError(r, w, fmt.Errorf("reading multipart form, %w", err))
+		return
+	}
+	req.Limit, err = app.readInt(r.MultipartForm.Value, "limit", 20)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	upload, _, err := r.FormFile("image")
+	if err != nil {
+		app.validationError(r, w, errors.New("image file is required"))
+		return
+	}
+	defer upload.Close()
+
+	name, err := app.saveTemp(upload)
+	if name != "" {
+		defer app.removeTemp(r, name)
+	}
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	// the example is converted like indexed images, only its first frame is compared
+	frames, cleanup, err := imageformat.Frames(name)
+	defer cleanup()
+	if errors.Is(err, imageformat.ErrUnsupported) {
+		app.validationError(r, w, errors.New("unsupported image format"))
+		return
+	}
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	hash, err := phash.FromFile(frames[0])
+	if err != nil {
+		app.validationError(r, w, errors.New("unsupported image format"))
+		return
+	}
+
+	text, err := app.ocrEngine.Run(frames[0])
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	terms := match.Tokens(text)
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+
+	ctx := context.Background()
+	candidates, err := app.imageDescriptions.FindCandidates(ctx, int64(hash), terms, candidateDistance, maxCandidates)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	app.tracker.OnSearch()
+
+	matches := match.Rank(hash, text, candidates)
+	if len(matches) > req.Limit {
+		matches = matches[:req.Limit]
+	}
+
+	app.respondJSON(r, w, http.StatusOK, matches)
+}
+
+func (app *webApp) saveTemp(src io.Reader) (string, error) {
+	f, err := os.CreateTemp("", tempUploadPrefix)
+	if err != nil {
+		return "", fmt.Errorf("creating temp file, %w", err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, src)
+	if err != nil {
+		return f.Name(), fmt.Errorf("saving upload, %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+func (app *webApp) removeTemp(r *http.Request, name string) {
+	err := os.Remove(name)
+	if err != nil {
+		app.error(r, err)
+	}
+}
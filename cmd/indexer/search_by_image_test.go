@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/matryer/is"
+)
+
+func multipartImage(t *testing.T, field string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile(field, "example.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fw.Write(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body, mw.FormDataContentType()
+}
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 36, 16))
+	for x := 0; x < 36; x++ {
+		for y := 0; y < 16; y++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(255 - x*7)})
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestSearchByImageHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("ranks candidates by visual and text similarity", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			FindCandidatesFunc: func(
+				ctx context.Context, hash int64, terms []string, maxDistance int, limit int,
+			) ([]domain.Image, error) {
+				return []domain.Image{
+					{FileID: "text-match", Description: "grafana dashboard"},
+					{FileID: "exact-match", Description: "grafana dashboard", PHash: hash},
+				}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+		app.ocrEngine = &ocrEngineMock{RunFunc: func(file string) (string, error) {
+			return "Grafana dashboard", nil
+		}}
+
+		body, contentType := multipartImage(t, "image", testPNG(t))
+		req := httptest.NewRequest(http.MethodPost, "/search/by-image", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		app.searchByImageHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(imageDescriptions.FindCandidatesCalls()[0].Terms, []string{"grafana", "dashboard"})
+		tt.True(imageDescriptions.FindCandidatesCalls()[0].Hash != 0) // hash of the upload must be used
+
+		var matches []domain.ImageMatch
+		err := json.NewDecoder(resp.Body).Decode(&matches)
+		tt.NoErr(err)
+		tt.Equal(len(matches), 2)
+		tt.Equal(matches[0].FileID, "exact-match")
+		tt.Equal(matches[0].Score, 1.0)
+	})
+
+	t.Run("webp is converted before comparing", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			FindCandidatesFunc: func(
+				ctx context.Context, hash int64, terms []string, maxDistance int, limit int,
+			) ([]domain.Image, error) {
+				return []domain.Image{}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+		var ocrFormat string
+		app.ocrEngine = &ocrEngineMock{RunFunc: func(file string) (string, error) {
+			ocrFormat, _ = imageformat.DetectFile(file)
+			return "grafana", nil
+		}}
+
+		// a 1x1 lossless webp
+		webp, err := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+		tt.NoErr(err)
+		body, contentType := multipartImage(t, "image", webp)
+		req := httptest.NewRequest(http.MethodPost, "/search/by-image", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		app.searchByImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusOK)
+		tt.Equal(ocrFormat, imageformat.PNG) // tesseract reads the converted frame
+		tt.Equal(imageDescriptions.FindCandidatesCalls()[0].Terms, []string{"grafana"})
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		body, contentType := multipartImage(t, "not-an-image", testPNG(t))
+		req := httptest.NewRequest(http.MethodPost, "/search/by-image", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		app.searchByImageHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("unsupported image", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		body, contentType := multipartImage(t, "image", []byte("plain text"))
+		req := httptest.NewRequest(http.MethodPost, "/search/by-image", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		app.searchByImageHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("ocr error", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+		app.ocrEngine = &ocrEngineMock{RunFunc: func(file string) (string, error) {
+			return "", errors.New("expected-err")
+		}}
+
+		body, contentType := multipartImage(t, "image", testPNG(t))
+		req := httptest.NewRequest(http.MethodPost, "/search/by-image", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		app.searchByImageHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
 	"github.com/matryer/is"
@@ -61,6 +62,44 @@ func TestSearchHandler(t *testing.T) {
 		)
 	})
 
+	t.Run("collapses near-duplicates", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			FindByDescriptionFunc: func(
+				ctx context.Context, searchString string, page int, perPage int,
+			) ([]domain.Image, error) {
+				return []domain.Image{
+					{FileID: "latest", PHash: 0b1111},
+					{FileID: "duplicate", PHash: 0b0111},
+					{FileID: "different", PHash: -1},
+					{FileID: "no-hash"},
+				}, nil
+			},
+		}
+
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(
+			`{ "search": "grafana", "page": 1, "per_page": 10, "collapse": true }`,
+		))
+		w := httptest.NewRecorder()
+
+		app.searchHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+
+		var images []domain.Image
+		err := json.NewDecoder(resp.Body).Decode(&images)
+		tt.NoErr(err)
+
+		tt.Equal(len(images), 3) // the duplicate must be collapsed into the latest image
+		tt.Equal(images[0].FileID, "latest")
+		tt.Equal(images[1].FileID, "different")
+		tt.Equal(images[2].FileID, "no-hash")
+	})
+
 	t.Run("db error", func(t *testing.T) {
 		imageDescriptions := &imageRepoMock{
 			FindByDescriptionFunc: func(
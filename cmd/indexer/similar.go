@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultSimilarDistance = 10
+
+func (app *webApp) similarHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileID      string `validate:"required"`
+		MaxDistance int    `validate:"min=0,max=64"`
+		Limit       int    `validate:"min=1,max=100"`
+	}
+
+	var err error
+	qs := r.URL.Query()
+	req.FileID = chi.URLParam(r, "file_id")
+	req.MaxDistance, err = app.readInt(qs, "max_distance", defaultSimilarDistance)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+	req.Limit, err = app.readInt(qs, "limit", 20)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	ctx := context.Background()
+
+	_, err = app.imageDescriptions.Get(ctx, req.FileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	images, err := app.imageDescriptions.FindSimilar(ctx, req.FileID, req.MaxDistance, req.Limit)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, images)
+}
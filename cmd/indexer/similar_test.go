@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestSimilarHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("returns similar images", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+				return domain.Image{FileID: fileID}, nil
+			},
+			FindSimilarFunc: func(ctx context.Context, fileID string, maxDistance, limit int) ([]domain.SimilarImage, error) {
+				return []domain.SimilarImage{{Image: domain.Image{FileID: "similar-id"}, Distance: 3}}, nil
+			},
+		}
+
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id/similar?max_distance=5", nil)
+		w := httptest.NewRecorder()
+
+		app.similarHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+		tt.Equal(imageDescriptions.FindSimilarCalls()[0].FileID, "expected-id")
+		tt.Equal(imageDescriptions.FindSimilarCalls()[0].MaxDistance, 5)
+		tt.Equal(imageDescriptions.FindSimilarCalls()[0].Limit, 20) // default limit
+
+		body, err := io.ReadAll(resp.Body)
+		tt.NoErr(err)
+		tt.Equal(
+			string(body),
+			`[{"FileID":"similar-id","Description":"","LastModified":"0001-01-01T00:00:00Z","Distance":3}]`,
+		)
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+				return domain.Image{}, fmt.Errorf("not found, %w", dbadapter.ErrRecordNotFound)
+			},
+		}
+
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/unknown-id/similar", nil)
+		w := httptest.NewRecorder()
+
+		app.similarHandler(w, withURLParam(req, "file_id", "unknown-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+				return domain.Image{FileID: fileID}, nil
+			},
+			FindSimilarFunc: func(ctx context.Context, fileID string, maxDistance, limit int) ([]domain.SimilarImage, error) {
+				return nil, errors.New("expected-err")
+			},
+		}
+
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/images/expected-id/similar", nil)
+		w := httptest.NewRecorder()
+
+		app.similarHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+	})
+
+	t.Run("invalid query values", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		for _, qs := range []string{"max_distance=abc", "max_distance=65", "limit=0"} {
+			req := httptest.NewRequest(http.MethodGet, "/images/expected-id/similar?"+qs, nil)
+			w := httptest.NewRecorder()
+
+			app.similarHandler(w, withURLParam(req, "file_id", "expected-id"))
+
+			resp := w.Result()
+			resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/fsstorage"
+	"github.com/elnoro/foxyshot-indexer/internal/s3wrapper"
+)
+
+const (
+	s3Storage       = "s3"
+	fsStoragePrefix = "fs:"
+)
+
+// storage keeps the screenshots, it is used by the indexer, the redactor and the API.
+type storage interface {
+	ListFiles(start time.Time, ext string) ([]domain.File, error)
+	Stat(ctx context.Context, key string) (domain.File, error)
+	Download(key string) (*os.File, error)
+	Read(ctx context.Context, key string) (io.ReadCloser, error)
+	Metadata(ctx context.Context, key string) (map[string]string, error)
+	Upload(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+	DeleteFile(ctx context.Context, key string) error
+	Exclude(prefix string)
+}
+
+// storageDir returns the directory of the filesystem storage, it is empty for s3.
+func storageDir(storage string) (string, error) {
+	switch {
+	case storage == s3Storage:
+		return "", nil
+	case strings.HasPrefix(storage, fsStoragePrefix) && len(storage) > len(fsStoragePrefix):
+		return strings.TrimPrefix(storage, fsStoragePrefix), nil
+	default:
+		return "", fmt.Errorf("unknown storage %q, use s3 or fs:/path/to/dir", storage)
+	}
+}
+
+func newStorage(cfg Config, logger *slog.Logger) (storage, error) {
+	dir, err := storageDir(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir != "" {
+		store, err := fsstorage.New(dir, logger)
+		if err != nil {
+			return nil, err
+		}
+		// redacted copies are never indexed, also after redaction has been turned off
+		store.Exclude(cfg.Redact.Prefix)
+
+		return store, nil
+	}
+
+	store, err := s3wrapper.NewFromSecrets(
+		cfg.S3.Key,
+		cfg.S3.Secret,
+		cfg.S3.Endpoint,
+		cfg.S3.Region,
+		cfg.S3.Bucket,
+		cfg.S3.Insecure,
+		logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+	store.Exclude(cfg.Redact.Prefix)
+	if cfg.S3.RetryAttempts > 0 {
+		err := store.CheckConnectivity(cfg.S3.RetryAttempts, cfg.S3.RetryDuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
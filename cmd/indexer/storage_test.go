@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/fsstorage"
+	"github.com/matryer/is"
+)
+
+func TestStorageDir(t *testing.T) {
+	tt := is.New(t)
+
+	dir, err := storageDir("s3")
+	tt.NoErr(err)
+	tt.Equal(dir, "")
+
+	dir, err = storageDir("fs:/home/me/Screenshots")
+	tt.NoErr(err)
+	tt.Equal(dir, "/home/me/Screenshots")
+
+	for _, s := range []string{"", "fs:", "gcs:bucket", "/home/me/Screenshots"} {
+		_, err = storageDir(s)
+		tt.True(err != nil)
+	}
+}
+
+func TestNewStorage_Filesystem(t *testing.T) {
+	tt := is.New(t)
+
+	store, err := newStorage(Config{Storage: "fs:" + t.TempDir()}, slog.Default())
+	tt.NoErr(err)
+	_, ok := store.(*fsstorage.Storage)
+	tt.True(ok) // s3 settings are not needed
+}
+
+func TestNewStorage_RedactPrefix(t *testing.T) {
+	tt := is.New(t)
+
+	dir := t.TempDir()
+	tt.NoErr(os.MkdirAll(filepath.Join(dir, "redacted"), 0o755))
+	tt.NoErr(os.WriteFile(filepath.Join(dir, "redacted", "a.png"), []byte("png"), 0o644))
+
+	// copies left from an earlier run with redaction are skipped too
+	for _, enabled := range []bool{false, true} {
+		cfg := Config{Storage: "fs:" + dir, Redact: RedactConfig{Enabled: enabled, Prefix: "redacted/"}}
+		store, err := newStorage(cfg, slog.Default())
+		tt.NoErr(err)
+
+		files, err := store.ListFiles(time.Time{}, ".png")
+		tt.NoErr(err)
+		tt.Equal(len(files), 0)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/tagging"
+	"github.com/go-chi/chi/v5"
+)
+
+func (app *webApp) listTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := app.imageDescriptions.ListTags(context.Background())
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, tags)
+}
+
+func (app *webApp) createTagHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name" validate:"required"`
+	}
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+	req.Name, err = tagging.NormalizeTag(req.Name)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.imageDescriptions.CreateTag(context.Background(), req.Name)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusCreated, struct{ Name string }{req.Name})
+}
+
+func (app *webApp) deleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	name, err := tagging.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.imageDescriptions.DeleteTag(context.Background(), name)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondNoContent(r, w)
+}
+
+func (app *webApp) imageTagsHandler(w http.ResponseWriter, r *http.Request) {
+	fileID := chi.URLParam(r, "file_id")
+	ctx := context.Background()
+
+	_, err := app.imageDescriptions.Get(ctx, fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	tags, err := app.imageDescriptions.ImageTags(ctx, fileID)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, tags)
+}
+
+func (app *webApp) tagImageHandler(w http.ResponseWriter, r *http.Request) {
+	fileID := chi.URLParam(r, "file_id")
+	name, err := tagging.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	ctx := context.Background()
+
+	_, err = app.imageDescriptions.Get(ctx, fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	err = app.imageDescriptions.TagImage(ctx, fileID, name)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondNoContent(r, w)
+}
+
+func (app *webApp) untagImageHandler(w http.ResponseWriter, r *http.Request) {
+	fileID := chi.URLParam(r, "file_id")
+	name, err := tagging.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.imageDescriptions.UntagImage(context.Background(), fileID, name)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondNoContent(r, w)
+}
+
+// applyTagRulesHandler reloads the rules file and re-tags all stored images. It walks the whole index,
+// so it is not limited by the request timeout and stops when the client goes away.
+func (app *webApp) applyTagRulesHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.tagger.Reload()
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	n, err := app.tagger.Reapply(r.Context(), app.imageDescriptions)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, struct{ Images int }{n})
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/tagging"
+	"github.com/matryer/is"
+)
+
+func TestListTagsHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		ListTagsFunc: func(ctx context.Context) ([]domain.TagCount, error) {
+			return []domain.TagCount{{Name: "monitoring", Count: 2}}, nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	w := httptest.NewRecorder()
+
+	app.listTagsHandler(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusOK)
+	body, err := io.ReadAll(resp.Body)
+	tt.NoErr(err)
+	tt.Equal(string(body), `[{"Name":"monitoring","Count":2}]`)
+}
+
+func TestCreateTagHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("creates normalized tag", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			CreateTagFunc: func(ctx context.Context, name string) error { return nil },
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(`{"name": "Monitoring"}`))
+		w := httptest.NewRecorder()
+
+		app.createTagHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusCreated)
+		tt.Equal(imageDescriptions.CreateTagCalls()[0].Name, "monitoring")
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		for _, body := range []string{`{"name": "two words"}`, `{}`, `not json`} {
+			req := httptest.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			app.createTagHandler(w, req)
+
+			resp := w.Result()
+			resp.Body.Close()
+
+			tt.Equal(resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestDeleteTagHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("deletes tag", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			DeleteTagFunc: func(ctx context.Context, name string) error { return nil },
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/tags/monitoring", nil)
+		w := httptest.NewRecorder()
+
+		app.deleteTagHandler(w, withURLParam(req, "tag", "monitoring"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNoContent)
+		tt.Equal(imageDescriptions.DeleteTagCalls()[0].Name, "monitoring")
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			DeleteTagFunc: func(ctx context.Context, name string) error {
+				return fmt.Errorf("not found, %w", dbadapter.ErrRecordNotFound)
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/tags/unknown", nil)
+		w := httptest.NewRecorder()
+
+		app.deleteTagHandler(w, withURLParam(req, "tag", "unknown"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+	})
+}
+
+func TestTagImageHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("tags image", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+				return domain.Image{FileID: fileID}, nil
+			},
+			TagImageFunc: func(ctx context.Context, fileID, name string) error { return nil },
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/images/expected-id/tags/Prod", nil)
+		req = withURLParam(req, "file_id", "expected-id")
+		w := httptest.NewRecorder()
+
+		app.tagImageHandler(w, withURLParam(req, "tag", "Prod"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNoContent)
+		tt.Equal(imageDescriptions.TagImageCalls()[0].FileID, "expected-id")
+		tt.Equal(imageDescriptions.TagImageCalls()[0].Name, "prod")
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+				return domain.Image{}, fmt.Errorf("not found, %w", dbadapter.ErrRecordNotFound)
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/images/unknown-id/tags/prod", nil)
+		req = withURLParam(req, "file_id", "unknown-id")
+		w := httptest.NewRecorder()
+
+		app.tagImageHandler(w, withURLParam(req, "tag", "prod"))
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusNotFound)
+		tt.Equal(len(imageDescriptions.TagImageCalls()), 0)
+	})
+}
+
+func TestUntagImageHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		UntagImageFunc: func(ctx context.Context, fileID, name string) error { return errors.New("expected-err") },
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/images/expected-id/tags/prod", nil)
+	req = withURLParam(req, "file_id", "expected-id")
+	w := httptest.NewRecorder()
+
+	app.untagImageHandler(w, withURLParam(req, "tag", "prod"))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusInternalServerError)
+}
+
+func TestApplyTagRulesHandler(t *testing.T) {
+	tt := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	err := os.WriteFile(path, []byte(`[{"tag": "monitoring", "text": "grafana"}]`), 0o600)
+	tt.NoErr(err)
+	tagger, err := tagging.NewTagger(path)
+	tt.NoErr(err)
+
+	imageDescriptions := &imageRepoMock{
+		ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+			if after != "" {
+				return nil, nil
+			}
+			return []domain.Image{{FileID: "a", Description: "grafana"}, {FileID: "b"}}, nil
+		},
+		SetRuleTagsFunc: func(ctx context.Context, fileID string, tags []string) error { return nil },
+	}
+	app := newTestApp(imageDescriptions, nil)
+	app.tagger = tagger
+
+	// rules changed after startup must be picked up
+	err = os.WriteFile(path, []byte(`[{"tag": "dashboards", "text": "grafana"}]`), 0o600)
+	tt.NoErr(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/tags/apply", nil)
+	w := httptest.NewRecorder()
+
+	app.applyTagRulesHandler(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusOK)
+	tt.Equal(imageDescriptions.SetRuleTagsCalls()[0].Tags, []string{"dashboards"})
+	tt.Equal(imageDescriptions.SetRuleTagsCalls()[1].Tags, []string{})
+
+	body, err := io.ReadAll(resp.Body)
+	tt.NoErr(err)
+	tt.Equal(string(body), `{"Images":2}`)
+}
+
+func TestApplyTagRulesHandler_Timeout(t *testing.T) {
+	tt := is.New(t)
+
+	timeout := requestTimeout
+	requestTimeout = 50 * time.Millisecond
+	defer func() { requestTimeout = timeout }()
+
+	imageDescriptions := &imageRepoMock{
+		ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+			if after != "" {
+				return nil, nil
+			}
+			return []domain.Image{{FileID: "a"}, {FileID: "b"}, {FileID: "c"}}, nil
+		},
+		SetRuleTagsFunc: func(ctx context.Context, fileID string, tags []string) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(30 * time.Millisecond):
+				return nil
+			}
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/tags/apply", nil))
+
+	tt.Equal(w.Result().StatusCode, http.StatusOK) // re-tagging a large index outlives the request timeout
+	tt.Equal(w.Body.String(), `{"Images":3}`)
+}
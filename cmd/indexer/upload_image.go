@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
+)
+
+// uploadPrefix is the prefix of the keys of uploaded images, so that they are not mixed with foxyshot screenshots.
+const uploadPrefix = "uploads/"
+
+// uploadedImage is the created record, URL is the link to the stored file, it is relative without -web.base-url.
+type uploadedImage struct {
+	domain.Image
+	URL string
+}
+
+// uploadImageHandler stores an image sent as the "image" field of a multipart form or as the raw body and indexes it.
+// The image is indexed in the background, with ?wait=true the response is sent after indexing.
+func (app *webApp) uploadImageHandler(w http.ResponseWriter, r *http.Request) {
+	wait, err := readWait(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageSize)
+
+	name, err := app.saveUpload(r)
+	if name != "" {
+		defer app.removeTemp(r, name)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			app.imageTooLarge(r, w)
+			return
+		}
+		app.validationError(r, w, err)
+		return
+	}
+
+	app.storeImage(w, r, name, wait, "")
+}
+
+// storeImage uploads the image saved to the temp file to the storage and indexes it.
+// The source url of an ingested image is recorded after indexing.
+func (app *webApp) storeImage(w http.ResponseWriter, r *http.Request, name string, wait bool, sourceURL string) {
+	// the content type is sniffed, the client is not trusted
+	contentType, err := imageformat.DetectFile(name)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	ext := imageformat.Extension(contentType)
+	if ext == "" {
+		app.errorResponse(r, w, http.StatusUnsupportedMediaType, "unsupported image format")
+		return
+	}
+
+	key, err := uploadKey(time.Now(), ext)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	defer f.Close()
+
+	err = app.fileStorage.Upload(r.Context(), key, f, contentType)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	location := "/api/images/" + url.PathEscape(key) + "/file"
+	w.Header().Set("Location", location)
+
+	if !wait {
+		go func() {
+			// scans do not retry uploads, they may not match -ext, so a failed upload is queued for the workers.
+			// Without a queue, i.e. with sqlite, the failure is only logged and published by the indexer.
+			ctx := context.Background()
+			_, err := app.indexUpload(ctx, key, sourceURL)
+			if err == nil {
+				return
+			}
+			app.log.Println("Error:", err, "key:", key)
+
+			err = app.uploadIndexer.QueueRetry(ctx, key, err)
+			if err != nil && !errors.Is(err, indexer.ErrNoQueue) {
+				app.log.Println("Error: queueing the upload for a retry,", err, "key:", key)
+			}
+		}()
+
+		app.respondJSON(r, w, http.StatusAccepted, uploadedImage{
+			Image: domain.Image{FileID: key, SourceURL: sourceURL},
+			URL:   app.baseURL() + location,
+		})
+		return
+	}
+
+	img, err := app.indexUpload(r.Context(), key, sourceURL)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusCreated, uploadedImage{Image: img, URL: app.baseURL() + location})
+}
+
+func (app *webApp) indexUpload(ctx context.Context, key, sourceURL string) (domain.Image, error) {
+	img, err := app.uploadIndexer.IndexKey(ctx, key)
+	if err != nil || sourceURL == "" {
+		return img, err
+	}
+
+	err = app.imageDescriptions.SetSourceURL(ctx, key, sourceURL)
+	if err != nil {
+		return img, err
+	}
+	img.SourceURL = sourceURL
+
+	return img, nil
+}
+
+func (app *webApp) imageTooLarge(r *http.Request, w http.ResponseWriter) {
+	app.errorResponse(r, w, http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("image must not be larger than %d bytes", maxImageSize))
+}
+
+// readWait reads the wait query parameter, false if it is not set.
+func readWait(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get("wait")
+	if s == "" {
+		return false, nil
+	}
+
+	wait, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.New("wait must be true or false")
+	}
+
+	return wait, nil
+}
+
+// saveUpload saves the image of the request to a temp file and returns its name.
+func (app *webApp) saveUpload(r *http.Request) (string, error) {
+	var body io.Reader = r.Body
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		err := r.ParseMultipartForm(maxImageSize)
+		if err != nil {
+			return "", fmt.Errorf("reading multipart form, %w", err)
+		}
+
+		upload, _, err := r.FormFile("image")
+		if err != nil {
+			return "", errors.New("image file is required")
+		}
+		defer upload.Close()
+		body = upload
+	}
+
+	name, err := app.saveTemp(body)
+	if err != nil {
+		return name, err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return name, fmt.Errorf("checking upload, %w", err)
+	}
+	if info.Size() == 0 {
+		return name, errors.New("image is required")
+	}
+
+	return name, nil
+}
+
+// uploadKey generates a unique key of an uploaded image, grouped by the day of the upload.
+func uploadKey(now time.Time, ext string) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("generating key, %w", err)
+	}
+
+	return uploadPrefix + now.UTC().Format("2006/01/02/") + hex.EncodeToString(b) + ext, nil
+}
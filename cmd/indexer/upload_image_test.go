@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+	"golang.org/x/image/bmp"
+)
+
+func TestUploadImageHandler(t *testing.T) {
+	tt := is.New(t)
+
+	uploadingStorage := func(uploaded *[]byte) *fileStorageMock {
+		return &fileStorageMock{
+			UploadFunc: func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+				b, err := io.ReadAll(body)
+				*uploaded = b
+				return err
+			},
+		}
+	}
+
+	t.Run("multipart upload is indexed before the response with wait", func(t *testing.T) {
+		var uploaded []byte
+		storage := uploadingStorage(&uploaded)
+		app := newTestApp(nil, storage)
+		app.uploadIndexer = &keyIndexerMock{IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+			return domain.Image{FileID: key, Description: "expected-description"}, nil
+		}}
+
+		body, contentType := multipartImage(t, "image", testPNG(t))
+		req := httptest.NewRequest(http.MethodPost, "/images?wait=true", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusCreated)
+		tt.Equal(uploaded, testPNG(t))
+
+		call := storage.UploadCalls()[0]
+		tt.True(strings.HasPrefix(call.Key, uploadPrefix))
+		tt.True(strings.HasSuffix(call.Key, ".png"))
+		tt.Equal(call.ContentType, "image/png")
+
+		var got uploadedImage
+		err := json.NewDecoder(resp.Body).Decode(&got)
+		tt.NoErr(err)
+		tt.Equal(got.FileID, call.Key)
+		tt.Equal(got.Description, "expected-description")
+		tt.Equal(got.URL, resp.Header.Get("Location"))
+	})
+
+	t.Run("raw upload is indexed in the background", func(t *testing.T) {
+		var uploaded []byte
+		app := newTestApp(nil, uploadingStorage(&uploaded))
+		indexed := make(chan string, 1)
+		app.uploadIndexer = &keyIndexerMock{IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+			indexed <- key
+			return domain.Image{FileID: key}, nil
+		}}
+
+		req := httptest.NewRequest(http.MethodPost, "/images", bytes.NewReader(testPNG(t)))
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusAccepted)
+
+		var got uploadedImage
+		err := json.NewDecoder(resp.Body).Decode(&got)
+		tt.NoErr(err)
+
+		select {
+		case key := <-indexed:
+			tt.Equal(key, got.FileID)
+		case <-time.After(time.Second):
+			t.Fatal("upload was not indexed")
+		}
+	})
+
+	t.Run("failed background indexing is queued for a retry", func(t *testing.T) {
+		var uploaded []byte
+		app := newTestApp(nil, uploadingStorage(&uploaded))
+		queued := make(chan string, 1)
+		app.uploadIndexer = &keyIndexerMock{
+			IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+				return domain.Image{}, errors.New("expected error")
+			},
+			QueueRetryFunc: func(ctx context.Context, key string, cause error) error {
+				queued <- key
+				return nil
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/images", bytes.NewReader(testPNG(t)))
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusAccepted)
+		select {
+		case key := <-queued:
+			tt.True(strings.HasPrefix(key, uploadPrefix))
+		case <-time.After(time.Second):
+			t.Fatal("upload was not queued")
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+		app.uploadIndexer = &keyIndexerMock{}
+
+		req := httptest.NewRequest(http.MethodPost, "/images", strings.NewReader("plain text"))
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusUnsupportedMediaType)
+	})
+
+	t.Run("converted formats are stored as they are", func(t *testing.T) {
+		var uploaded []byte
+		storage := uploadingStorage(&uploaded)
+		app := newTestApp(nil, storage)
+		app.uploadIndexer = &keyIndexerMock{IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+			return domain.Image{FileID: key}, nil
+		}}
+
+		img := &bytes.Buffer{}
+		err := bmp.Encode(img, image.NewGray(image.Rect(0, 0, 4, 4)))
+		tt.NoErr(err)
+		req := httptest.NewRequest(http.MethodPost, "/images?wait=true", bytes.NewReader(img.Bytes()))
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusCreated)
+		tt.True(strings.HasSuffix(storage.UploadCalls()[0].Key, ".bmp"))
+		tt.Equal(storage.UploadCalls()[0].ContentType, "image/bmp")
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+		app.uploadIndexer = &keyIndexerMock{}
+
+		req := httptest.NewRequest(http.MethodPost, "/images", http.NoBody)
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+		app.uploadIndexer = &keyIndexerMock{}
+
+		req := httptest.NewRequest(http.MethodPost, "/images", bytes.NewReader(make([]byte, maxImageSize+1)))
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusRequestEntityTooLarge)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		app := newTestApp(nil, &fileStorageMock{
+			UploadFunc: func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+				return errors.New("upload failed")
+			},
+		})
+		app.uploadIndexer = &keyIndexerMock{}
+
+		req := httptest.NewRequest(http.MethodPost, "/images", bytes.NewReader(testPNG(t)))
+		w := httptest.NewRecorder()
+
+		app.uploadImageHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusInternalServerError)
+	})
+}
@@ -5,34 +5,118 @@ import (
 	"errors"
 	"expvar"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/elnoro/foxyshot-indexer/internal/app"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
 	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/tagging"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httprate"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-//go:generate moq -out web_moq_test.go . imageRepo fileStorage
+//go:generate moq -out web_moq_test.go . imageRepo fileStorage ocrEngine eventPublisher eventStream indexRunner keyIndexer urlFetcher
 type imageRepo interface {
+	Get(ctx context.Context, fileID string) (domain.Image, error)
 	FindByDescription(ctx context.Context, searchString string, page, perPage int) ([]domain.Image, error)
+	FindSimilar(ctx context.Context, fileID string, maxDistance, limit int) ([]domain.SimilarImage, error)
+	FindCandidates(ctx context.Context, hash int64, terms []string, maxDistance, limit int) ([]domain.Image, error)
+	ListEntities(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error)
+	ListFindings(ctx context.Context, rule string, page, perPage int) ([]domain.FlaggedImage, error)
+	ListTags(ctx context.Context) ([]domain.TagCount, error)
+	CreateTag(ctx context.Context, name string) error
+	DeleteTag(ctx context.Context, name string) error
+	ImageTags(ctx context.Context, fileID string) ([]domain.ImageTag, error)
+	TagsOfImages(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error)
+	TagImage(ctx context.Context, fileID, name string) error
+	UntagImage(ctx context.Context, fileID, name string) error
+	ListImages(ctx context.Context, after string, limit int) ([]domain.Image, error)
+	SetRuleTags(ctx context.Context, fileID string, tags []string) error
+	Patch(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error)
+	ImageEdits(ctx context.Context, fileID string) ([]domain.ImageEdit, error)
+	ListSavedSearches(ctx context.Context) ([]domain.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, id int) (domain.SavedSearch, error)
+	CreateSavedSearch(ctx context.Context, name, query string) (domain.SavedSearch, error)
+	UpdateSavedSearch(ctx context.Context, id int, name, query string) (domain.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id int) error
+	Matches(ctx context.Context, fileID, searchString string) (bool, error)
+	RecentImages(ctx context.Context, limit int) ([]domain.Image, error)
+	ListFeedTokens(ctx context.Context) ([]domain.FeedToken, error)
+	CreateFeedToken(ctx context.Context, name, hash string) (domain.FeedToken, error)
+	DeleteFeedToken(ctx context.Context, id int) error
+	UseFeedToken(ctx context.Context, hash string) error
+	ListWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	GetWebhook(ctx context.Context, id int) (domain.Webhook, error)
+	CreateWebhook(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, webhookID int, status string, page, perPage int) ([]domain.WebhookDelivery, error)
+	SetSourceURL(ctx context.Context, fileID, sourceURL string) error
 	Delete(ctx context.Context, fileID string) error
+	IndexQueueDepth(ctx context.Context) (pending, failed int, err error)
 }
 
 type fileStorage interface {
 	DeleteFile(ctx context.Context, key string) error
+	Read(ctx context.Context, key string) (io.ReadCloser, error)
+	Upload(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
 }
 
+type ocrEngine interface {
+	Run(file string) (string, error)
+}
+
+type eventPublisher interface {
+	Publish(ctx context.Context, e domain.Event)
+}
+
+type eventStream interface {
+	Subscribe() (<-chan domain.StreamEvent, func())
+	Replay(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error)
+}
+
+// indexRunner is the runner of the worker role, it is only available when the process runs both roles.
+type indexRunner interface {
+	State() app.RunnerState
+	Progress() indexer.Progress
+	Trigger() error
+	Pause()
+	Resume()
+	IndexKey(ctx context.Context, key string) (domain.Image, error)
+}
+
+// keyIndexer indexes uploaded images, every replica that accepts uploads has one.
+type keyIndexer interface {
+	IndexKey(ctx context.Context, key string) (domain.Image, error)
+	QueueRetry(ctx context.Context, key string, cause error) error
+}
+
+// urlFetcher downloads ingested images, it refuses private addresses.
+type urlFetcher interface {
+	Fetch(ctx context.Context, url string, w io.Writer) error
+}
+
+// requestTimeout limits every request except the long-lived ones, it is a variable so that tests can shorten it.
+var requestTimeout = 60 * time.Second
+
 type webApp struct {
 	config Config
 	log    *log.Logger
 
 	imageDescriptions imageRepo
 	fileStorage       fileStorage
+	ocrEngine         ocrEngine
+	tagger            *tagging.Tagger
+	events            eventPublisher
+	stream            eventStream
+	indexRunner       indexRunner
+	uploadIndexer     keyIndexer
+	fetcher           urlFetcher
 
 	tracker *monitoring.Tracker
 }
@@ -44,16 +128,87 @@ func (app *webApp) routes() http.Handler {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
 
-	r.Get("/healthcheck", app.healthcheckHandler)
+	// the event stream, exports and re-tagging are long-lived, so they are the only routes not limited by the timeout
+	timeout := middleware.Timeout(requestTimeout)
 
-	r.Method(http.MethodGet, "/debug/vars", expvar.Handler())
-	r.Method(http.MethodGet, "/metrics", promhttp.Handler())
+	r.Group(func(r chi.Router) {
+		r.Use(timeout)
+
+		r.Get("/healthcheck", app.healthcheckHandler)
+
+		r.Method(http.MethodGet, "/debug/vars", expvar.Handler())
+		r.Method(http.MethodGet, "/metrics", promhttp.Handler())
+
+		// feeds need absolute links, so they are served only with a public url
+		if app.config.Web.BaseURL != "" {
+			r.Route("/feeds", func(r chi.Router) {
+				r.Use(app.requireFeedToken)
+				r.Get("/recent.atom", app.recentFeedHandler)
+				r.Get("/search.atom", app.searchFeedHandler)
+			})
+		}
+	})
 
 	r.Route("/api", func(r chi.Router) {
-		r.Post("/search", app.searchHandler)
-		r.Delete("/delete", app.deleteHandler)
+		r.Get("/events", app.eventsHandler)
+		r.Post("/export/pdf", app.exportPDFHandler)
+		r.Group(func(r chi.Router) {
+			r.Use(timeout)
+
+			r.Post("/search", app.searchHandler)
+			r.Get("/images/{file_id}/edits", app.imageEditsHandler)
+			r.Get("/images/{file_id}/similar", app.similarHandler)
+			r.Get("/images/{file_id}/file", app.imageFileHandler)
+			r.Get("/images/{file_id}/thumbnail", app.imageThumbnailHandler)
+			r.Get("/images/{file_id}/tags", app.imageTagsHandler)
+			r.Get("/tags", app.listTagsHandler)
+			r.Get("/saved-searches", app.listSavedSearchesHandler)
+			r.Get("/saved-searches/{id}", app.getSavedSearchHandler)
+			r.Get("/webhooks", app.listWebhooksHandler)
+			r.Get("/webhooks/{id}/deliveries", app.webhookDeliveriesHandler)
+			r.Get("/feed-tokens", app.listFeedTokensHandler)
+			r.Get("/entities", app.entitiesHandler)
+			r.Get("/findings", app.findingsHandler)
+			if app.indexRunner != nil {
+				r.Get("/admin/index/status", app.indexStatusHandler)
+			}
+		})
+
+		if app.config.Web.ReadOnly {
+			return
+		}
+
+		r.Get("/admin/export", app.exportIndexHandler)
+		r.Post("/tags/apply", app.applyTagRulesHandler)
+		r.Group(func(r chi.Router) {
+			r.Use(timeout)
+
+			r.Post("/search/by-image", app.searchByImageHandler)
+			if app.uploadIndexer != nil {
+				r.Post("/images", app.uploadImageHandler)
+				r.Post("/ingest", app.ingestHandler)
+			}
+			r.Delete("/delete", app.deleteHandler)
+			r.Patch("/images/{file_id}", app.editImageHandler)
+			r.Put("/images/{file_id}/tags/{tag}", app.tagImageHandler)
+			r.Delete("/images/{file_id}/tags/{tag}", app.untagImageHandler)
+			r.Post("/tags", app.createTagHandler)
+			r.Delete("/tags/{tag}", app.deleteTagHandler)
+			r.Post("/saved-searches", app.createSavedSearchHandler)
+			r.Put("/saved-searches/{id}", app.updateSavedSearchHandler)
+			r.Delete("/saved-searches/{id}", app.deleteSavedSearchHandler)
+			r.Post("/webhooks", app.createWebhookHandler)
+			r.Delete("/webhooks/{id}", app.deleteWebhookHandler)
+			r.Post("/feed-tokens", app.createFeedTokenHandler)
+			r.Delete("/feed-tokens/{id}", app.deleteFeedTokenHandler)
+			if app.indexRunner != nil {
+				r.Post("/admin/index/run", app.runIndexHandler)
+				r.Post("/admin/index/pause", app.pauseIndexHandler)
+				r.Post("/admin/index/resume", app.resumeIndexHandler)
+				r.Post("/admin/index/file", app.indexFileHandler)
+			}
+		})
 	})
 
 	r.NotFound(app.notFound)
@@ -64,7 +219,7 @@ func (app *webApp) routes() http.Handler {
 
 func (app *webApp) serve(ctx context.Context) error {
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", app.config.Port),
+		Addr:         fmt.Sprintf(":%d", app.config.Web.Port),
 		Handler:      app.routes(),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
@@ -80,7 +235,7 @@ func (app *webApp) serve(ctx context.Context) error {
 		shutdownError <- srv.Shutdown(shutdownCtx)
 	}()
 
-	app.log.Println("starting server on port", app.config.Port)
+	app.log.Println("starting server on port", app.config.Web.Port)
 	err := srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("server listen&server err, %w", err)
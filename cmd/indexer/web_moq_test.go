@@ -5,7 +5,10 @@ package main
 
 import (
 	"context"
+	"github.com/elnoro/foxyshot-indexer/internal/app"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
+	"io"
 	"sync"
 )
 
@@ -19,170 +22,2694 @@ var _ imageRepo = &imageRepoMock{}
 //
 //		// make and configure a mocked imageRepo
 //		mockedimageRepo := &imageRepoMock{
+//			CreateFeedTokenFunc: func(ctx context.Context, name string, hash string) (domain.FeedToken, error) {
+//				panic("mock out the CreateFeedToken method")
+//			},
+//			CreateSavedSearchFunc: func(ctx context.Context, name string, query string) (domain.SavedSearch, error) {
+//				panic("mock out the CreateSavedSearch method")
+//			},
+//			CreateTagFunc: func(ctx context.Context, name string) error {
+//				panic("mock out the CreateTag method")
+//			},
+//			CreateWebhookFunc: func(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error) {
+//				panic("mock out the CreateWebhook method")
+//			},
 //			DeleteFunc: func(ctx context.Context, fileID string) error {
 //				panic("mock out the Delete method")
 //			},
-//			FindByDescriptionFunc: func(ctx context.Context, searchString string, page int, perPage int) ([]domain.Image, error) {
-//				panic("mock out the FindByDescription method")
+//			DeleteFeedTokenFunc: func(ctx context.Context, id int) error {
+//				panic("mock out the DeleteFeedToken method")
+//			},
+//			DeleteSavedSearchFunc: func(ctx context.Context, id int) error {
+//				panic("mock out the DeleteSavedSearch method")
+//			},
+//			DeleteTagFunc: func(ctx context.Context, name string) error {
+//				panic("mock out the DeleteTag method")
+//			},
+//			DeleteWebhookFunc: func(ctx context.Context, id int) error {
+//				panic("mock out the DeleteWebhook method")
+//			},
+//			FindByDescriptionFunc: func(ctx context.Context, searchString string, page int, perPage int) ([]domain.Image, error) {
+//				panic("mock out the FindByDescription method")
+//			},
+//			FindCandidatesFunc: func(ctx context.Context, hash int64, terms []string, maxDistance int, limit int) ([]domain.Image, error) {
+//				panic("mock out the FindCandidates method")
+//			},
+//			FindSimilarFunc: func(ctx context.Context, fileID string, maxDistance int, limit int) ([]domain.SimilarImage, error) {
+//				panic("mock out the FindSimilar method")
+//			},
+//			GetFunc: func(ctx context.Context, fileID string) (domain.Image, error) {
+//				panic("mock out the Get method")
+//			},
+//			GetSavedSearchFunc: func(ctx context.Context, id int) (domain.SavedSearch, error) {
+//				panic("mock out the GetSavedSearch method")
+//			},
+//			GetWebhookFunc: func(ctx context.Context, id int) (domain.Webhook, error) {
+//				panic("mock out the GetWebhook method")
+//			},
+//			ImageEditsFunc: func(ctx context.Context, fileID string) ([]domain.ImageEdit, error) {
+//				panic("mock out the ImageEdits method")
+//			},
+//			ImageTagsFunc: func(ctx context.Context, fileID string) ([]domain.ImageTag, error) {
+//				panic("mock out the ImageTags method")
+//			},
+//			IndexQueueDepthFunc: func(ctx context.Context) (int, int, error) {
+//				panic("mock out the IndexQueueDepth method")
+//			},
+//			ListDeliveriesFunc: func(ctx context.Context, webhookID int, status string, page int, perPage int) ([]domain.WebhookDelivery, error) {
+//				panic("mock out the ListDeliveries method")
+//			},
+//			ListEntitiesFunc: func(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+//				panic("mock out the ListEntities method")
+//			},
+//			ListFeedTokensFunc: func(ctx context.Context) ([]domain.FeedToken, error) {
+//				panic("mock out the ListFeedTokens method")
+//			},
+//			ListFindingsFunc: func(ctx context.Context, rule string, page int, perPage int) ([]domain.FlaggedImage, error) {
+//				panic("mock out the ListFindings method")
+//			},
+//			ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+//				panic("mock out the ListImages method")
+//			},
+//			ListSavedSearchesFunc: func(ctx context.Context) ([]domain.SavedSearch, error) {
+//				panic("mock out the ListSavedSearches method")
+//			},
+//			ListTagsFunc: func(ctx context.Context) ([]domain.TagCount, error) {
+//				panic("mock out the ListTags method")
+//			},
+//			ListWebhooksFunc: func(ctx context.Context) ([]domain.Webhook, error) {
+//				panic("mock out the ListWebhooks method")
+//			},
+//			MatchesFunc: func(ctx context.Context, fileID string, searchString string) (bool, error) {
+//				panic("mock out the Matches method")
+//			},
+//			PatchFunc: func(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error) {
+//				panic("mock out the Patch method")
+//			},
+//			RecentImagesFunc: func(ctx context.Context, limit int) ([]domain.Image, error) {
+//				panic("mock out the RecentImages method")
+//			},
+//			SetRuleTagsFunc: func(ctx context.Context, fileID string, tags []string) error {
+//				panic("mock out the SetRuleTags method")
+//			},
+//			SetSourceURLFunc: func(ctx context.Context, fileID string, sourceURL string) error {
+//				panic("mock out the SetSourceURL method")
+//			},
+//			TagImageFunc: func(ctx context.Context, fileID string, name string) error {
+//				panic("mock out the TagImage method")
+//			},
+//			TagsOfImagesFunc: func(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error) {
+//				panic("mock out the TagsOfImages method")
+//			},
+//			UntagImageFunc: func(ctx context.Context, fileID string, name string) error {
+//				panic("mock out the UntagImage method")
+//			},
+//			UpdateSavedSearchFunc: func(ctx context.Context, id int, name string, query string) (domain.SavedSearch, error) {
+//				panic("mock out the UpdateSavedSearch method")
+//			},
+//			UseFeedTokenFunc: func(ctx context.Context, hash string) error {
+//				panic("mock out the UseFeedToken method")
+//			},
+//		}
+//
+//		// use mockedimageRepo in code that requires imageRepo
+//		// and then make assertions.
+//
+//	}
+type imageRepoMock struct {
+	// CreateFeedTokenFunc mocks the CreateFeedToken method.
+	CreateFeedTokenFunc func(ctx context.Context, name string, hash string) (domain.FeedToken, error)
+
+	// CreateSavedSearchFunc mocks the CreateSavedSearch method.
+	CreateSavedSearchFunc func(ctx context.Context, name string, query string) (domain.SavedSearch, error)
+
+	// CreateTagFunc mocks the CreateTag method.
+	CreateTagFunc func(ctx context.Context, name string) error
+
+	// CreateWebhookFunc mocks the CreateWebhook method.
+	CreateWebhookFunc func(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error)
+
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, fileID string) error
+
+	// DeleteFeedTokenFunc mocks the DeleteFeedToken method.
+	DeleteFeedTokenFunc func(ctx context.Context, id int) error
+
+	// DeleteSavedSearchFunc mocks the DeleteSavedSearch method.
+	DeleteSavedSearchFunc func(ctx context.Context, id int) error
+
+	// DeleteTagFunc mocks the DeleteTag method.
+	DeleteTagFunc func(ctx context.Context, name string) error
+
+	// DeleteWebhookFunc mocks the DeleteWebhook method.
+	DeleteWebhookFunc func(ctx context.Context, id int) error
+
+	// FindByDescriptionFunc mocks the FindByDescription method.
+	FindByDescriptionFunc func(ctx context.Context, searchString string, page int, perPage int) ([]domain.Image, error)
+
+	// FindCandidatesFunc mocks the FindCandidates method.
+	FindCandidatesFunc func(ctx context.Context, hash int64, terms []string, maxDistance int, limit int) ([]domain.Image, error)
+
+	// FindSimilarFunc mocks the FindSimilar method.
+	FindSimilarFunc func(ctx context.Context, fileID string, maxDistance int, limit int) ([]domain.SimilarImage, error)
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, fileID string) (domain.Image, error)
+
+	// GetSavedSearchFunc mocks the GetSavedSearch method.
+	GetSavedSearchFunc func(ctx context.Context, id int) (domain.SavedSearch, error)
+
+	// GetWebhookFunc mocks the GetWebhook method.
+	GetWebhookFunc func(ctx context.Context, id int) (domain.Webhook, error)
+
+	// ImageEditsFunc mocks the ImageEdits method.
+	ImageEditsFunc func(ctx context.Context, fileID string) ([]domain.ImageEdit, error)
+
+	// ImageTagsFunc mocks the ImageTags method.
+	ImageTagsFunc func(ctx context.Context, fileID string) ([]domain.ImageTag, error)
+
+	// IndexQueueDepthFunc mocks the IndexQueueDepth method.
+	IndexQueueDepthFunc func(ctx context.Context) (int, int, error)
+
+	// ListDeliveriesFunc mocks the ListDeliveries method.
+	ListDeliveriesFunc func(ctx context.Context, webhookID int, status string, page int, perPage int) ([]domain.WebhookDelivery, error)
+
+	// ListEntitiesFunc mocks the ListEntities method.
+	ListEntitiesFunc func(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error)
+
+	// ListFeedTokensFunc mocks the ListFeedTokens method.
+	ListFeedTokensFunc func(ctx context.Context) ([]domain.FeedToken, error)
+
+	// ListFindingsFunc mocks the ListFindings method.
+	ListFindingsFunc func(ctx context.Context, rule string, page int, perPage int) ([]domain.FlaggedImage, error)
+
+	// ListImagesFunc mocks the ListImages method.
+	ListImagesFunc func(ctx context.Context, after string, limit int) ([]domain.Image, error)
+
+	// ListSavedSearchesFunc mocks the ListSavedSearches method.
+	ListSavedSearchesFunc func(ctx context.Context) ([]domain.SavedSearch, error)
+
+	// ListTagsFunc mocks the ListTags method.
+	ListTagsFunc func(ctx context.Context) ([]domain.TagCount, error)
+
+	// ListWebhooksFunc mocks the ListWebhooks method.
+	ListWebhooksFunc func(ctx context.Context) ([]domain.Webhook, error)
+
+	// MatchesFunc mocks the Matches method.
+	MatchesFunc func(ctx context.Context, fileID string, searchString string) (bool, error)
+
+	// PatchFunc mocks the Patch method.
+	PatchFunc func(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error)
+
+	// RecentImagesFunc mocks the RecentImages method.
+	RecentImagesFunc func(ctx context.Context, limit int) ([]domain.Image, error)
+
+	// SetRuleTagsFunc mocks the SetRuleTags method.
+	SetRuleTagsFunc func(ctx context.Context, fileID string, tags []string) error
+
+	// SetSourceURLFunc mocks the SetSourceURL method.
+	SetSourceURLFunc func(ctx context.Context, fileID string, sourceURL string) error
+
+	// TagImageFunc mocks the TagImage method.
+	TagImageFunc func(ctx context.Context, fileID string, name string) error
+
+	// TagsOfImagesFunc mocks the TagsOfImages method.
+	TagsOfImagesFunc func(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error)
+
+	// UntagImageFunc mocks the UntagImage method.
+	UntagImageFunc func(ctx context.Context, fileID string, name string) error
+
+	// UpdateSavedSearchFunc mocks the UpdateSavedSearch method.
+	UpdateSavedSearchFunc func(ctx context.Context, id int, name string, query string) (domain.SavedSearch, error)
+
+	// UseFeedTokenFunc mocks the UseFeedToken method.
+	UseFeedTokenFunc func(ctx context.Context, hash string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// CreateFeedToken holds details about calls to the CreateFeedToken method.
+		CreateFeedToken []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// Hash is the hash argument value.
+			Hash string
+		}
+		// CreateSavedSearch holds details about calls to the CreateSavedSearch method.
+		CreateSavedSearch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// Query is the query argument value.
+			Query string
+		}
+		// CreateTag holds details about calls to the CreateTag method.
+		CreateTag []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+		}
+		// CreateWebhook holds details about calls to the CreateWebhook method.
+		CreateWebhook []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Url is the url argument value.
+			Url string
+			// Events is the events argument value.
+			Events []string
+			// Secret is the secret argument value.
+			Secret string
+		}
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+		}
+		// DeleteFeedToken holds details about calls to the DeleteFeedToken method.
+		DeleteFeedToken []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Id is the id argument value.
+			Id int
+		}
+		// DeleteSavedSearch holds details about calls to the DeleteSavedSearch method.
+		DeleteSavedSearch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Id is the id argument value.
+			Id int
+		}
+		// DeleteTag holds details about calls to the DeleteTag method.
+		DeleteTag []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+		}
+		// DeleteWebhook holds details about calls to the DeleteWebhook method.
+		DeleteWebhook []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Id is the id argument value.
+			Id int
+		}
+		// FindByDescription holds details about calls to the FindByDescription method.
+		FindByDescription []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// SearchString is the searchString argument value.
+			SearchString string
+			// Page is the page argument value.
+			Page int
+			// PerPage is the perPage argument value.
+			PerPage int
+		}
+		// FindCandidates holds details about calls to the FindCandidates method.
+		FindCandidates []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Hash is the hash argument value.
+			Hash int64
+			// Terms is the terms argument value.
+			Terms []string
+			// MaxDistance is the maxDistance argument value.
+			MaxDistance int
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// FindSimilar holds details about calls to the FindSimilar method.
+		FindSimilar []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// MaxDistance is the maxDistance argument value.
+			MaxDistance int
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// Get holds details about calls to the Get method.
+		Get []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+		}
+		// GetSavedSearch holds details about calls to the GetSavedSearch method.
+		GetSavedSearch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Id is the id argument value.
+			Id int
+		}
+		// GetWebhook holds details about calls to the GetWebhook method.
+		GetWebhook []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Id is the id argument value.
+			Id int
+		}
+		// ImageEdits holds details about calls to the ImageEdits method.
+		ImageEdits []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+		}
+		// ImageTags holds details about calls to the ImageTags method.
+		ImageTags []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+		}
+		// IndexQueueDepth holds details about calls to the IndexQueueDepth method.
+		IndexQueueDepth []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ListDeliveries holds details about calls to the ListDeliveries method.
+		ListDeliveries []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// WebhookID is the webhookID argument value.
+			WebhookID int
+			// Status is the status argument value.
+			Status string
+			// Page is the page argument value.
+			Page int
+			// PerPage is the perPage argument value.
+			PerPage int
+		}
+		// ListEntities holds details about calls to the ListEntities method.
+		ListEntities []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Kind is the kind argument value.
+			Kind string
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListFeedTokens holds details about calls to the ListFeedTokens method.
+		ListFeedTokens []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ListFindings holds details about calls to the ListFindings method.
+		ListFindings []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Rule is the rule argument value.
+			Rule string
+			// Page is the page argument value.
+			Page int
+			// PerPage is the perPage argument value.
+			PerPage int
+		}
+		// ListImages holds details about calls to the ListImages method.
+		ListImages []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// After is the after argument value.
+			After string
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListSavedSearches holds details about calls to the ListSavedSearches method.
+		ListSavedSearches []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ListTags holds details about calls to the ListTags method.
+		ListTags []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ListWebhooks holds details about calls to the ListWebhooks method.
+		ListWebhooks []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Matches holds details about calls to the Matches method.
+		Matches []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// SearchString is the searchString argument value.
+			SearchString string
+		}
+		// Patch holds details about calls to the Patch method.
+		Patch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// Patch is the patch argument value.
+			Patch domain.ImagePatch
+		}
+		// RecentImages holds details about calls to the RecentImages method.
+		RecentImages []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// SetRuleTags holds details about calls to the SetRuleTags method.
+		SetRuleTags []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// Tags is the tags argument value.
+			Tags []string
+		}
+		// SetSourceURL holds details about calls to the SetSourceURL method.
+		SetSourceURL []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// SourceURL is the sourceURL argument value.
+			SourceURL string
+		}
+		// TagImage holds details about calls to the TagImage method.
+		TagImage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// Name is the name argument value.
+			Name string
+		}
+		// TagsOfImages holds details about calls to the TagsOfImages method.
+		TagsOfImages []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileIDs is the fileIDs argument value.
+			FileIDs []string
+		}
+		// UntagImage holds details about calls to the UntagImage method.
+		UntagImage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// Name is the name argument value.
+			Name string
+		}
+		// UpdateSavedSearch holds details about calls to the UpdateSavedSearch method.
+		UpdateSavedSearch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Id is the id argument value.
+			Id int
+			// Name is the name argument value.
+			Name string
+			// Query is the query argument value.
+			Query string
+		}
+		// UseFeedToken holds details about calls to the UseFeedToken method.
+		UseFeedToken []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Hash is the hash argument value.
+			Hash string
+		}
+	}
+	lockCreateFeedToken   sync.RWMutex
+	lockCreateSavedSearch sync.RWMutex
+	lockCreateTag         sync.RWMutex
+	lockCreateWebhook     sync.RWMutex
+	lockDelete            sync.RWMutex
+	lockDeleteFeedToken   sync.RWMutex
+	lockDeleteSavedSearch sync.RWMutex
+	lockDeleteTag         sync.RWMutex
+	lockDeleteWebhook     sync.RWMutex
+	lockFindByDescription sync.RWMutex
+	lockFindCandidates    sync.RWMutex
+	lockFindSimilar       sync.RWMutex
+	lockGet               sync.RWMutex
+	lockGetSavedSearch    sync.RWMutex
+	lockGetWebhook        sync.RWMutex
+	lockImageEdits        sync.RWMutex
+	lockImageTags         sync.RWMutex
+	lockIndexQueueDepth   sync.RWMutex
+	lockListDeliveries    sync.RWMutex
+	lockListEntities      sync.RWMutex
+	lockListFeedTokens    sync.RWMutex
+	lockListFindings      sync.RWMutex
+	lockListImages        sync.RWMutex
+	lockListSavedSearches sync.RWMutex
+	lockListTags          sync.RWMutex
+	lockListWebhooks      sync.RWMutex
+	lockMatches           sync.RWMutex
+	lockPatch             sync.RWMutex
+	lockRecentImages      sync.RWMutex
+	lockSetRuleTags       sync.RWMutex
+	lockSetSourceURL      sync.RWMutex
+	lockTagImage          sync.RWMutex
+	lockTagsOfImages      sync.RWMutex
+	lockUntagImage        sync.RWMutex
+	lockUpdateSavedSearch sync.RWMutex
+	lockUseFeedToken      sync.RWMutex
+}
+
+// CreateFeedToken calls CreateFeedTokenFunc.
+func (mock *imageRepoMock) CreateFeedToken(ctx context.Context, name string, hash string) (domain.FeedToken, error) {
+	if mock.CreateFeedTokenFunc == nil {
+		panic("imageRepoMock.CreateFeedTokenFunc: method is nil but imageRepo.CreateFeedToken was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+		Hash string
+	}{
+		Ctx:  ctx,
+		Name: name,
+		Hash: hash,
+	}
+	mock.lockCreateFeedToken.Lock()
+	mock.calls.CreateFeedToken = append(mock.calls.CreateFeedToken, callInfo)
+	mock.lockCreateFeedToken.Unlock()
+	return mock.CreateFeedTokenFunc(ctx, name, hash)
+}
+
+// CreateFeedTokenCalls gets all the calls that were made to CreateFeedToken.
+// Check the length with:
+//
+//	len(mockedimageRepo.CreateFeedTokenCalls())
+func (mock *imageRepoMock) CreateFeedTokenCalls() []struct {
+	Ctx  context.Context
+	Name string
+	Hash string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+		Hash string
+	}
+	mock.lockCreateFeedToken.RLock()
+	calls = mock.calls.CreateFeedToken
+	mock.lockCreateFeedToken.RUnlock()
+	return calls
+}
+
+// CreateSavedSearch calls CreateSavedSearchFunc.
+func (mock *imageRepoMock) CreateSavedSearch(ctx context.Context, name string, query string) (domain.SavedSearch, error) {
+	if mock.CreateSavedSearchFunc == nil {
+		panic("imageRepoMock.CreateSavedSearchFunc: method is nil but imageRepo.CreateSavedSearch was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Name  string
+		Query string
+	}{
+		Ctx:   ctx,
+		Name:  name,
+		Query: query,
+	}
+	mock.lockCreateSavedSearch.Lock()
+	mock.calls.CreateSavedSearch = append(mock.calls.CreateSavedSearch, callInfo)
+	mock.lockCreateSavedSearch.Unlock()
+	return mock.CreateSavedSearchFunc(ctx, name, query)
+}
+
+// CreateSavedSearchCalls gets all the calls that were made to CreateSavedSearch.
+// Check the length with:
+//
+//	len(mockedimageRepo.CreateSavedSearchCalls())
+func (mock *imageRepoMock) CreateSavedSearchCalls() []struct {
+	Ctx   context.Context
+	Name  string
+	Query string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Name  string
+		Query string
+	}
+	mock.lockCreateSavedSearch.RLock()
+	calls = mock.calls.CreateSavedSearch
+	mock.lockCreateSavedSearch.RUnlock()
+	return calls
+}
+
+// CreateTag calls CreateTagFunc.
+func (mock *imageRepoMock) CreateTag(ctx context.Context, name string) error {
+	if mock.CreateTagFunc == nil {
+		panic("imageRepoMock.CreateTagFunc: method is nil but imageRepo.CreateTag was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+	}{
+		Ctx:  ctx,
+		Name: name,
+	}
+	mock.lockCreateTag.Lock()
+	mock.calls.CreateTag = append(mock.calls.CreateTag, callInfo)
+	mock.lockCreateTag.Unlock()
+	return mock.CreateTagFunc(ctx, name)
+}
+
+// CreateTagCalls gets all the calls that were made to CreateTag.
+// Check the length with:
+//
+//	len(mockedimageRepo.CreateTagCalls())
+func (mock *imageRepoMock) CreateTagCalls() []struct {
+	Ctx  context.Context
+	Name string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+	}
+	mock.lockCreateTag.RLock()
+	calls = mock.calls.CreateTag
+	mock.lockCreateTag.RUnlock()
+	return calls
+}
+
+// CreateWebhook calls CreateWebhookFunc.
+func (mock *imageRepoMock) CreateWebhook(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error) {
+	if mock.CreateWebhookFunc == nil {
+		panic("imageRepoMock.CreateWebhookFunc: method is nil but imageRepo.CreateWebhook was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Url    string
+		Events []string
+		Secret string
+	}{
+		Ctx:    ctx,
+		Url:    url,
+		Events: events,
+		Secret: secret,
+	}
+	mock.lockCreateWebhook.Lock()
+	mock.calls.CreateWebhook = append(mock.calls.CreateWebhook, callInfo)
+	mock.lockCreateWebhook.Unlock()
+	return mock.CreateWebhookFunc(ctx, url, events, secret)
+}
+
+// CreateWebhookCalls gets all the calls that were made to CreateWebhook.
+// Check the length with:
+//
+//	len(mockedimageRepo.CreateWebhookCalls())
+func (mock *imageRepoMock) CreateWebhookCalls() []struct {
+	Ctx    context.Context
+	Url    string
+	Events []string
+	Secret string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Url    string
+		Events []string
+		Secret string
+	}
+	mock.lockCreateWebhook.RLock()
+	calls = mock.calls.CreateWebhook
+	mock.lockCreateWebhook.RUnlock()
+	return calls
+}
+
+// Delete calls DeleteFunc.
+func (mock *imageRepoMock) Delete(ctx context.Context, fileID string) error {
+	if mock.DeleteFunc == nil {
+		panic("imageRepoMock.DeleteFunc: method is nil but imageRepo.Delete was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(ctx, fileID)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedimageRepo.DeleteCalls())
+func (mock *imageRepoMock) DeleteCalls() []struct {
+	Ctx    context.Context
+	FileID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
+}
+
+// DeleteFeedToken calls DeleteFeedTokenFunc.
+func (mock *imageRepoMock) DeleteFeedToken(ctx context.Context, id int) error {
+	if mock.DeleteFeedTokenFunc == nil {
+		panic("imageRepoMock.DeleteFeedTokenFunc: method is nil but imageRepo.DeleteFeedToken was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Id  int
+	}{
+		Ctx: ctx,
+		Id:  id,
+	}
+	mock.lockDeleteFeedToken.Lock()
+	mock.calls.DeleteFeedToken = append(mock.calls.DeleteFeedToken, callInfo)
+	mock.lockDeleteFeedToken.Unlock()
+	return mock.DeleteFeedTokenFunc(ctx, id)
+}
+
+// DeleteFeedTokenCalls gets all the calls that were made to DeleteFeedToken.
+// Check the length with:
+//
+//	len(mockedimageRepo.DeleteFeedTokenCalls())
+func (mock *imageRepoMock) DeleteFeedTokenCalls() []struct {
+	Ctx context.Context
+	Id  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		Id  int
+	}
+	mock.lockDeleteFeedToken.RLock()
+	calls = mock.calls.DeleteFeedToken
+	mock.lockDeleteFeedToken.RUnlock()
+	return calls
+}
+
+// DeleteSavedSearch calls DeleteSavedSearchFunc.
+func (mock *imageRepoMock) DeleteSavedSearch(ctx context.Context, id int) error {
+	if mock.DeleteSavedSearchFunc == nil {
+		panic("imageRepoMock.DeleteSavedSearchFunc: method is nil but imageRepo.DeleteSavedSearch was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Id  int
+	}{
+		Ctx: ctx,
+		Id:  id,
+	}
+	mock.lockDeleteSavedSearch.Lock()
+	mock.calls.DeleteSavedSearch = append(mock.calls.DeleteSavedSearch, callInfo)
+	mock.lockDeleteSavedSearch.Unlock()
+	return mock.DeleteSavedSearchFunc(ctx, id)
+}
+
+// DeleteSavedSearchCalls gets all the calls that were made to DeleteSavedSearch.
+// Check the length with:
+//
+//	len(mockedimageRepo.DeleteSavedSearchCalls())
+func (mock *imageRepoMock) DeleteSavedSearchCalls() []struct {
+	Ctx context.Context
+	Id  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		Id  int
+	}
+	mock.lockDeleteSavedSearch.RLock()
+	calls = mock.calls.DeleteSavedSearch
+	mock.lockDeleteSavedSearch.RUnlock()
+	return calls
+}
+
+// DeleteTag calls DeleteTagFunc.
+func (mock *imageRepoMock) DeleteTag(ctx context.Context, name string) error {
+	if mock.DeleteTagFunc == nil {
+		panic("imageRepoMock.DeleteTagFunc: method is nil but imageRepo.DeleteTag was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+	}{
+		Ctx:  ctx,
+		Name: name,
+	}
+	mock.lockDeleteTag.Lock()
+	mock.calls.DeleteTag = append(mock.calls.DeleteTag, callInfo)
+	mock.lockDeleteTag.Unlock()
+	return mock.DeleteTagFunc(ctx, name)
+}
+
+// DeleteTagCalls gets all the calls that were made to DeleteTag.
+// Check the length with:
+//
+//	len(mockedimageRepo.DeleteTagCalls())
+func (mock *imageRepoMock) DeleteTagCalls() []struct {
+	Ctx  context.Context
+	Name string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+	}
+	mock.lockDeleteTag.RLock()
+	calls = mock.calls.DeleteTag
+	mock.lockDeleteTag.RUnlock()
+	return calls
+}
+
+// DeleteWebhook calls DeleteWebhookFunc.
+func (mock *imageRepoMock) DeleteWebhook(ctx context.Context, id int) error {
+	if mock.DeleteWebhookFunc == nil {
+		panic("imageRepoMock.DeleteWebhookFunc: method is nil but imageRepo.DeleteWebhook was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Id  int
+	}{
+		Ctx: ctx,
+		Id:  id,
+	}
+	mock.lockDeleteWebhook.Lock()
+	mock.calls.DeleteWebhook = append(mock.calls.DeleteWebhook, callInfo)
+	mock.lockDeleteWebhook.Unlock()
+	return mock.DeleteWebhookFunc(ctx, id)
+}
+
+// DeleteWebhookCalls gets all the calls that were made to DeleteWebhook.
+// Check the length with:
+//
+//	len(mockedimageRepo.DeleteWebhookCalls())
+func (mock *imageRepoMock) DeleteWebhookCalls() []struct {
+	Ctx context.Context
+	Id  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		Id  int
+	}
+	mock.lockDeleteWebhook.RLock()
+	calls = mock.calls.DeleteWebhook
+	mock.lockDeleteWebhook.RUnlock()
+	return calls
+}
+
+// FindByDescription calls FindByDescriptionFunc.
+func (mock *imageRepoMock) FindByDescription(ctx context.Context, searchString string, page int, perPage int) ([]domain.Image, error) {
+	if mock.FindByDescriptionFunc == nil {
+		panic("imageRepoMock.FindByDescriptionFunc: method is nil but imageRepo.FindByDescription was just called")
+	}
+	callInfo := struct {
+		Ctx          context.Context
+		SearchString string
+		Page         int
+		PerPage      int
+	}{
+		Ctx:          ctx,
+		SearchString: searchString,
+		Page:         page,
+		PerPage:      perPage,
+	}
+	mock.lockFindByDescription.Lock()
+	mock.calls.FindByDescription = append(mock.calls.FindByDescription, callInfo)
+	mock.lockFindByDescription.Unlock()
+	return mock.FindByDescriptionFunc(ctx, searchString, page, perPage)
+}
+
+// FindByDescriptionCalls gets all the calls that were made to FindByDescription.
+// Check the length with:
+//
+//	len(mockedimageRepo.FindByDescriptionCalls())
+func (mock *imageRepoMock) FindByDescriptionCalls() []struct {
+	Ctx          context.Context
+	SearchString string
+	Page         int
+	PerPage      int
+} {
+	var calls []struct {
+		Ctx          context.Context
+		SearchString string
+		Page         int
+		PerPage      int
+	}
+	mock.lockFindByDescription.RLock()
+	calls = mock.calls.FindByDescription
+	mock.lockFindByDescription.RUnlock()
+	return calls
+}
+
+// FindCandidates calls FindCandidatesFunc.
+func (mock *imageRepoMock) FindCandidates(ctx context.Context, hash int64, terms []string, maxDistance int, limit int) ([]domain.Image, error) {
+	if mock.FindCandidatesFunc == nil {
+		panic("imageRepoMock.FindCandidatesFunc: method is nil but imageRepo.FindCandidates was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		Hash        int64
+		Terms       []string
+		MaxDistance int
+		Limit       int
+	}{
+		Ctx:         ctx,
+		Hash:        hash,
+		Terms:       terms,
+		MaxDistance: maxDistance,
+		Limit:       limit,
+	}
+	mock.lockFindCandidates.Lock()
+	mock.calls.FindCandidates = append(mock.calls.FindCandidates, callInfo)
+	mock.lockFindCandidates.Unlock()
+	return mock.FindCandidatesFunc(ctx, hash, terms, maxDistance, limit)
+}
+
+// FindCandidatesCalls gets all the calls that were made to FindCandidates.
+// Check the length with:
+//
+//	len(mockedimageRepo.FindCandidatesCalls())
+func (mock *imageRepoMock) FindCandidatesCalls() []struct {
+	Ctx         context.Context
+	Hash        int64
+	Terms       []string
+	MaxDistance int
+	Limit       int
+} {
+	var calls []struct {
+		Ctx         context.Context
+		Hash        int64
+		Terms       []string
+		MaxDistance int
+		Limit       int
+	}
+	mock.lockFindCandidates.RLock()
+	calls = mock.calls.FindCandidates
+	mock.lockFindCandidates.RUnlock()
+	return calls
+}
+
+// FindSimilar calls FindSimilarFunc.
+func (mock *imageRepoMock) FindSimilar(ctx context.Context, fileID string, maxDistance int, limit int) ([]domain.SimilarImage, error) {
+	if mock.FindSimilarFunc == nil {
+		panic("imageRepoMock.FindSimilarFunc: method is nil but imageRepo.FindSimilar was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		FileID      string
+		MaxDistance int
+		Limit       int
+	}{
+		Ctx:         ctx,
+		FileID:      fileID,
+		MaxDistance: maxDistance,
+		Limit:       limit,
+	}
+	mock.lockFindSimilar.Lock()
+	mock.calls.FindSimilar = append(mock.calls.FindSimilar, callInfo)
+	mock.lockFindSimilar.Unlock()
+	return mock.FindSimilarFunc(ctx, fileID, maxDistance, limit)
+}
+
+// FindSimilarCalls gets all the calls that were made to FindSimilar.
+// Check the length with:
+//
+//	len(mockedimageRepo.FindSimilarCalls())
+func (mock *imageRepoMock) FindSimilarCalls() []struct {
+	Ctx         context.Context
+	FileID      string
+	MaxDistance int
+	Limit       int
+} {
+	var calls []struct {
+		Ctx         context.Context
+		FileID      string
+		MaxDistance int
+		Limit       int
+	}
+	mock.lockFindSimilar.RLock()
+	calls = mock.calls.FindSimilar
+	mock.lockFindSimilar.RUnlock()
+	return calls
+}
+
+// Get calls GetFunc.
+func (mock *imageRepoMock) Get(ctx context.Context, fileID string) (domain.Image, error) {
+	if mock.GetFunc == nil {
+		panic("imageRepoMock.GetFunc: method is nil but imageRepo.Get was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+	}
+	mock.lockGet.Lock()
+	mock.calls.Get = append(mock.calls.Get, callInfo)
+	mock.lockGet.Unlock()
+	return mock.GetFunc(ctx, fileID)
+}
+
+// GetCalls gets all the calls that were made to Get.
+// Check the length with:
+//
+//	len(mockedimageRepo.GetCalls())
+func (mock *imageRepoMock) GetCalls() []struct {
+	Ctx    context.Context
+	FileID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+	}
+	mock.lockGet.RLock()
+	calls = mock.calls.Get
+	mock.lockGet.RUnlock()
+	return calls
+}
+
+// GetSavedSearch calls GetSavedSearchFunc.
+func (mock *imageRepoMock) GetSavedSearch(ctx context.Context, id int) (domain.SavedSearch, error) {
+	if mock.GetSavedSearchFunc == nil {
+		panic("imageRepoMock.GetSavedSearchFunc: method is nil but imageRepo.GetSavedSearch was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Id  int
+	}{
+		Ctx: ctx,
+		Id:  id,
+	}
+	mock.lockGetSavedSearch.Lock()
+	mock.calls.GetSavedSearch = append(mock.calls.GetSavedSearch, callInfo)
+	mock.lockGetSavedSearch.Unlock()
+	return mock.GetSavedSearchFunc(ctx, id)
+}
+
+// GetSavedSearchCalls gets all the calls that were made to GetSavedSearch.
+// Check the length with:
+//
+//	len(mockedimageRepo.GetSavedSearchCalls())
+func (mock *imageRepoMock) GetSavedSearchCalls() []struct {
+	Ctx context.Context
+	Id  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		Id  int
+	}
+	mock.lockGetSavedSearch.RLock()
+	calls = mock.calls.GetSavedSearch
+	mock.lockGetSavedSearch.RUnlock()
+	return calls
+}
+
+// GetWebhook calls GetWebhookFunc.
+func (mock *imageRepoMock) GetWebhook(ctx context.Context, id int) (domain.Webhook, error) {
+	if mock.GetWebhookFunc == nil {
+		panic("imageRepoMock.GetWebhookFunc: method is nil but imageRepo.GetWebhook was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Id  int
+	}{
+		Ctx: ctx,
+		Id:  id,
+	}
+	mock.lockGetWebhook.Lock()
+	mock.calls.GetWebhook = append(mock.calls.GetWebhook, callInfo)
+	mock.lockGetWebhook.Unlock()
+	return mock.GetWebhookFunc(ctx, id)
+}
+
+// GetWebhookCalls gets all the calls that were made to GetWebhook.
+// Check the length with:
+//
+//	len(mockedimageRepo.GetWebhookCalls())
+func (mock *imageRepoMock) GetWebhookCalls() []struct {
+	Ctx context.Context
+	Id  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		Id  int
+	}
+	mock.lockGetWebhook.RLock()
+	calls = mock.calls.GetWebhook
+	mock.lockGetWebhook.RUnlock()
+	return calls
+}
+
+// ImageEdits calls ImageEditsFunc.
+func (mock *imageRepoMock) ImageEdits(ctx context.Context, fileID string) ([]domain.ImageEdit, error) {
+	if mock.ImageEditsFunc == nil {
+		panic("imageRepoMock.ImageEditsFunc: method is nil but imageRepo.ImageEdits was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+	}
+	mock.lockImageEdits.Lock()
+	mock.calls.ImageEdits = append(mock.calls.ImageEdits, callInfo)
+	mock.lockImageEdits.Unlock()
+	return mock.ImageEditsFunc(ctx, fileID)
+}
+
+// ImageEditsCalls gets all the calls that were made to ImageEdits.
+// Check the length with:
+//
+//	len(mockedimageRepo.ImageEditsCalls())
+func (mock *imageRepoMock) ImageEditsCalls() []struct {
+	Ctx    context.Context
+	FileID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+	}
+	mock.lockImageEdits.RLock()
+	calls = mock.calls.ImageEdits
+	mock.lockImageEdits.RUnlock()
+	return calls
+}
+
+// ImageTags calls ImageTagsFunc.
+func (mock *imageRepoMock) ImageTags(ctx context.Context, fileID string) ([]domain.ImageTag, error) {
+	if mock.ImageTagsFunc == nil {
+		panic("imageRepoMock.ImageTagsFunc: method is nil but imageRepo.ImageTags was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+	}
+	mock.lockImageTags.Lock()
+	mock.calls.ImageTags = append(mock.calls.ImageTags, callInfo)
+	mock.lockImageTags.Unlock()
+	return mock.ImageTagsFunc(ctx, fileID)
+}
+
+// ImageTagsCalls gets all the calls that were made to ImageTags.
+// Check the length with:
+//
+//	len(mockedimageRepo.ImageTagsCalls())
+func (mock *imageRepoMock) ImageTagsCalls() []struct {
+	Ctx    context.Context
+	FileID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+	}
+	mock.lockImageTags.RLock()
+	calls = mock.calls.ImageTags
+	mock.lockImageTags.RUnlock()
+	return calls
+}
+
+// IndexQueueDepth calls IndexQueueDepthFunc.
+func (mock *imageRepoMock) IndexQueueDepth(ctx context.Context) (int, int, error) {
+	if mock.IndexQueueDepthFunc == nil {
+		panic("imageRepoMock.IndexQueueDepthFunc: method is nil but imageRepo.IndexQueueDepth was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockIndexQueueDepth.Lock()
+	mock.calls.IndexQueueDepth = append(mock.calls.IndexQueueDepth, callInfo)
+	mock.lockIndexQueueDepth.Unlock()
+	return mock.IndexQueueDepthFunc(ctx)
+}
+
+// IndexQueueDepthCalls gets all the calls that were made to IndexQueueDepth.
+// Check the length with:
+//
+//	len(mockedimageRepo.IndexQueueDepthCalls())
+func (mock *imageRepoMock) IndexQueueDepthCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockIndexQueueDepth.RLock()
+	calls = mock.calls.IndexQueueDepth
+	mock.lockIndexQueueDepth.RUnlock()
+	return calls
+}
+
+// ListDeliveries calls ListDeliveriesFunc.
+func (mock *imageRepoMock) ListDeliveries(ctx context.Context, webhookID int, status string, page int, perPage int) ([]domain.WebhookDelivery, error) {
+	if mock.ListDeliveriesFunc == nil {
+		panic("imageRepoMock.ListDeliveriesFunc: method is nil but imageRepo.ListDeliveries was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		WebhookID int
+		Status    string
+		Page      int
+		PerPage   int
+	}{
+		Ctx:       ctx,
+		WebhookID: webhookID,
+		Status:    status,
+		Page:      page,
+		PerPage:   perPage,
+	}
+	mock.lockListDeliveries.Lock()
+	mock.calls.ListDeliveries = append(mock.calls.ListDeliveries, callInfo)
+	mock.lockListDeliveries.Unlock()
+	return mock.ListDeliveriesFunc(ctx, webhookID, status, page, perPage)
+}
+
+// ListDeliveriesCalls gets all the calls that were made to ListDeliveries.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListDeliveriesCalls())
+func (mock *imageRepoMock) ListDeliveriesCalls() []struct {
+	Ctx       context.Context
+	WebhookID int
+	Status    string
+	Page      int
+	PerPage   int
+} {
+	var calls []struct {
+		Ctx       context.Context
+		WebhookID int
+		Status    string
+		Page      int
+		PerPage   int
+	}
+	mock.lockListDeliveries.RLock()
+	calls = mock.calls.ListDeliveries
+	mock.lockListDeliveries.RUnlock()
+	return calls
+}
+
+// ListEntities calls ListEntitiesFunc.
+func (mock *imageRepoMock) ListEntities(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+	if mock.ListEntitiesFunc == nil {
+		panic("imageRepoMock.ListEntitiesFunc: method is nil but imageRepo.ListEntities was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Kind  string
+		Limit int
+	}{
+		Ctx:   ctx,
+		Kind:  kind,
+		Limit: limit,
+	}
+	mock.lockListEntities.Lock()
+	mock.calls.ListEntities = append(mock.calls.ListEntities, callInfo)
+	mock.lockListEntities.Unlock()
+	return mock.ListEntitiesFunc(ctx, kind, limit)
+}
+
+// ListEntitiesCalls gets all the calls that were made to ListEntities.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListEntitiesCalls())
+func (mock *imageRepoMock) ListEntitiesCalls() []struct {
+	Ctx   context.Context
+	Kind  string
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Kind  string
+		Limit int
+	}
+	mock.lockListEntities.RLock()
+	calls = mock.calls.ListEntities
+	mock.lockListEntities.RUnlock()
+	return calls
+}
+
+// ListFeedTokens calls ListFeedTokensFunc.
+func (mock *imageRepoMock) ListFeedTokens(ctx context.Context) ([]domain.FeedToken, error) {
+	if mock.ListFeedTokensFunc == nil {
+		panic("imageRepoMock.ListFeedTokensFunc: method is nil but imageRepo.ListFeedTokens was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListFeedTokens.Lock()
+	mock.calls.ListFeedTokens = append(mock.calls.ListFeedTokens, callInfo)
+	mock.lockListFeedTokens.Unlock()
+	return mock.ListFeedTokensFunc(ctx)
+}
+
+// ListFeedTokensCalls gets all the calls that were made to ListFeedTokens.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListFeedTokensCalls())
+func (mock *imageRepoMock) ListFeedTokensCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListFeedTokens.RLock()
+	calls = mock.calls.ListFeedTokens
+	mock.lockListFeedTokens.RUnlock()
+	return calls
+}
+
+// ListFindings calls ListFindingsFunc.
+func (mock *imageRepoMock) ListFindings(ctx context.Context, rule string, page int, perPage int) ([]domain.FlaggedImage, error) {
+	if mock.ListFindingsFunc == nil {
+		panic("imageRepoMock.ListFindingsFunc: method is nil but imageRepo.ListFindings was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Rule    string
+		Page    int
+		PerPage int
+	}{
+		Ctx:     ctx,
+		Rule:    rule,
+		Page:    page,
+		PerPage: perPage,
+	}
+	mock.lockListFindings.Lock()
+	mock.calls.ListFindings = append(mock.calls.ListFindings, callInfo)
+	mock.lockListFindings.Unlock()
+	return mock.ListFindingsFunc(ctx, rule, page, perPage)
+}
+
+// ListFindingsCalls gets all the calls that were made to ListFindings.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListFindingsCalls())
+func (mock *imageRepoMock) ListFindingsCalls() []struct {
+	Ctx     context.Context
+	Rule    string
+	Page    int
+	PerPage int
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Rule    string
+		Page    int
+		PerPage int
+	}
+	mock.lockListFindings.RLock()
+	calls = mock.calls.ListFindings
+	mock.lockListFindings.RUnlock()
+	return calls
+}
+
+// ListImages calls ListImagesFunc.
+func (mock *imageRepoMock) ListImages(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+	if mock.ListImagesFunc == nil {
+		panic("imageRepoMock.ListImagesFunc: method is nil but imageRepo.ListImages was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		After string
+		Limit int
+	}{
+		Ctx:   ctx,
+		After: after,
+		Limit: limit,
+	}
+	mock.lockListImages.Lock()
+	mock.calls.ListImages = append(mock.calls.ListImages, callInfo)
+	mock.lockListImages.Unlock()
+	return mock.ListImagesFunc(ctx, after, limit)
+}
+
+// ListImagesCalls gets all the calls that were made to ListImages.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListImagesCalls())
+func (mock *imageRepoMock) ListImagesCalls() []struct {
+	Ctx   context.Context
+	After string
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		After string
+		Limit int
+	}
+	mock.lockListImages.RLock()
+	calls = mock.calls.ListImages
+	mock.lockListImages.RUnlock()
+	return calls
+}
+
+// ListSavedSearches calls ListSavedSearchesFunc.
+func (mock *imageRepoMock) ListSavedSearches(ctx context.Context) ([]domain.SavedSearch, error) {
+	if mock.ListSavedSearchesFunc == nil {
+		panic("imageRepoMock.ListSavedSearchesFunc: method is nil but imageRepo.ListSavedSearches was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListSavedSearches.Lock()
+	mock.calls.ListSavedSearches = append(mock.calls.ListSavedSearches, callInfo)
+	mock.lockListSavedSearches.Unlock()
+	return mock.ListSavedSearchesFunc(ctx)
+}
+
+// ListSavedSearchesCalls gets all the calls that were made to ListSavedSearches.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListSavedSearchesCalls())
+func (mock *imageRepoMock) ListSavedSearchesCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListSavedSearches.RLock()
+	calls = mock.calls.ListSavedSearches
+	mock.lockListSavedSearches.RUnlock()
+	return calls
+}
+
+// ListTags calls ListTagsFunc.
+func (mock *imageRepoMock) ListTags(ctx context.Context) ([]domain.TagCount, error) {
+	if mock.ListTagsFunc == nil {
+		panic("imageRepoMock.ListTagsFunc: method is nil but imageRepo.ListTags was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListTags.Lock()
+	mock.calls.ListTags = append(mock.calls.ListTags, callInfo)
+	mock.lockListTags.Unlock()
+	return mock.ListTagsFunc(ctx)
+}
+
+// ListTagsCalls gets all the calls that were made to ListTags.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListTagsCalls())
+func (mock *imageRepoMock) ListTagsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListTags.RLock()
+	calls = mock.calls.ListTags
+	mock.lockListTags.RUnlock()
+	return calls
+}
+
+// ListWebhooks calls ListWebhooksFunc.
+func (mock *imageRepoMock) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	if mock.ListWebhooksFunc == nil {
+		panic("imageRepoMock.ListWebhooksFunc: method is nil but imageRepo.ListWebhooks was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListWebhooks.Lock()
+	mock.calls.ListWebhooks = append(mock.calls.ListWebhooks, callInfo)
+	mock.lockListWebhooks.Unlock()
+	return mock.ListWebhooksFunc(ctx)
+}
+
+// ListWebhooksCalls gets all the calls that were made to ListWebhooks.
+// Check the length with:
+//
+//	len(mockedimageRepo.ListWebhooksCalls())
+func (mock *imageRepoMock) ListWebhooksCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListWebhooks.RLock()
+	calls = mock.calls.ListWebhooks
+	mock.lockListWebhooks.RUnlock()
+	return calls
+}
+
+// Matches calls MatchesFunc.
+func (mock *imageRepoMock) Matches(ctx context.Context, fileID string, searchString string) (bool, error) {
+	if mock.MatchesFunc == nil {
+		panic("imageRepoMock.MatchesFunc: method is nil but imageRepo.Matches was just called")
+	}
+	callInfo := struct {
+		Ctx          context.Context
+		FileID       string
+		SearchString string
+	}{
+		Ctx:          ctx,
+		FileID:       fileID,
+		SearchString: searchString,
+	}
+	mock.lockMatches.Lock()
+	mock.calls.Matches = append(mock.calls.Matches, callInfo)
+	mock.lockMatches.Unlock()
+	return mock.MatchesFunc(ctx, fileID, searchString)
+}
+
+// MatchesCalls gets all the calls that were made to Matches.
+// Check the length with:
+//
+//	len(mockedimageRepo.MatchesCalls())
+func (mock *imageRepoMock) MatchesCalls() []struct {
+	Ctx          context.Context
+	FileID       string
+	SearchString string
+} {
+	var calls []struct {
+		Ctx          context.Context
+		FileID       string
+		SearchString string
+	}
+	mock.lockMatches.RLock()
+	calls = mock.calls.Matches
+	mock.lockMatches.RUnlock()
+	return calls
+}
+
+// Patch calls PatchFunc.
+func (mock *imageRepoMock) Patch(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error) {
+	if mock.PatchFunc == nil {
+		panic("imageRepoMock.PatchFunc: method is nil but imageRepo.Patch was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+		Patch  domain.ImagePatch
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+		Patch:  patch,
+	}
+	mock.lockPatch.Lock()
+	mock.calls.Patch = append(mock.calls.Patch, callInfo)
+	mock.lockPatch.Unlock()
+	return mock.PatchFunc(ctx, fileID, patch)
+}
+
+// PatchCalls gets all the calls that were made to Patch.
+// Check the length with:
+//
+//	len(mockedimageRepo.PatchCalls())
+func (mock *imageRepoMock) PatchCalls() []struct {
+	Ctx    context.Context
+	FileID string
+	Patch  domain.ImagePatch
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+		Patch  domain.ImagePatch
+	}
+	mock.lockPatch.RLock()
+	calls = mock.calls.Patch
+	mock.lockPatch.RUnlock()
+	return calls
+}
+
+// RecentImages calls RecentImagesFunc.
+func (mock *imageRepoMock) RecentImages(ctx context.Context, limit int) ([]domain.Image, error) {
+	if mock.RecentImagesFunc == nil {
+		panic("imageRepoMock.RecentImagesFunc: method is nil but imageRepo.RecentImages was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Limit int
+	}{
+		Ctx:   ctx,
+		Limit: limit,
+	}
+	mock.lockRecentImages.Lock()
+	mock.calls.RecentImages = append(mock.calls.RecentImages, callInfo)
+	mock.lockRecentImages.Unlock()
+	return mock.RecentImagesFunc(ctx, limit)
+}
+
+// RecentImagesCalls gets all the calls that were made to RecentImages.
+// Check the length with:
+//
+//	len(mockedimageRepo.RecentImagesCalls())
+func (mock *imageRepoMock) RecentImagesCalls() []struct {
+	Ctx   context.Context
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Limit int
+	}
+	mock.lockRecentImages.RLock()
+	calls = mock.calls.RecentImages
+	mock.lockRecentImages.RUnlock()
+	return calls
+}
+
+// SetRuleTags calls SetRuleTagsFunc.
+func (mock *imageRepoMock) SetRuleTags(ctx context.Context, fileID string, tags []string) error {
+	if mock.SetRuleTagsFunc == nil {
+		panic("imageRepoMock.SetRuleTagsFunc: method is nil but imageRepo.SetRuleTags was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+		Tags   []string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+		Tags:   tags,
+	}
+	mock.lockSetRuleTags.Lock()
+	mock.calls.SetRuleTags = append(mock.calls.SetRuleTags, callInfo)
+	mock.lockSetRuleTags.Unlock()
+	return mock.SetRuleTagsFunc(ctx, fileID, tags)
+}
+
+// SetRuleTagsCalls gets all the calls that were made to SetRuleTags.
+// Check the length with:
+//
+//	len(mockedimageRepo.SetRuleTagsCalls())
+func (mock *imageRepoMock) SetRuleTagsCalls() []struct {
+	Ctx    context.Context
+	FileID string
+	Tags   []string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+		Tags   []string
+	}
+	mock.lockSetRuleTags.RLock()
+	calls = mock.calls.SetRuleTags
+	mock.lockSetRuleTags.RUnlock()
+	return calls
+}
+
+// SetSourceURL calls SetSourceURLFunc.
+func (mock *imageRepoMock) SetSourceURL(ctx context.Context, fileID string, sourceURL string) error {
+	if mock.SetSourceURLFunc == nil {
+		panic("imageRepoMock.SetSourceURLFunc: method is nil but imageRepo.SetSourceURL was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		FileID    string
+		SourceURL string
+	}{
+		Ctx:       ctx,
+		FileID:    fileID,
+		SourceURL: sourceURL,
+	}
+	mock.lockSetSourceURL.Lock()
+	mock.calls.SetSourceURL = append(mock.calls.SetSourceURL, callInfo)
+	mock.lockSetSourceURL.Unlock()
+	return mock.SetSourceURLFunc(ctx, fileID, sourceURL)
+}
+
+// SetSourceURLCalls gets all the calls that were made to SetSourceURL.
+// Check the length with:
+//
+//	len(mockedimageRepo.SetSourceURLCalls())
+func (mock *imageRepoMock) SetSourceURLCalls() []struct {
+	Ctx       context.Context
+	FileID    string
+	SourceURL string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		FileID    string
+		SourceURL string
+	}
+	mock.lockSetSourceURL.RLock()
+	calls = mock.calls.SetSourceURL
+	mock.lockSetSourceURL.RUnlock()
+	return calls
+}
+
+// TagImage calls TagImageFunc.
+func (mock *imageRepoMock) TagImage(ctx context.Context, fileID string, name string) error {
+	if mock.TagImageFunc == nil {
+		panic("imageRepoMock.TagImageFunc: method is nil but imageRepo.TagImage was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+		Name   string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+		Name:   name,
+	}
+	mock.lockTagImage.Lock()
+	mock.calls.TagImage = append(mock.calls.TagImage, callInfo)
+	mock.lockTagImage.Unlock()
+	return mock.TagImageFunc(ctx, fileID, name)
+}
+
+// TagImageCalls gets all the calls that were made to TagImage.
+// Check the length with:
+//
+//	len(mockedimageRepo.TagImageCalls())
+func (mock *imageRepoMock) TagImageCalls() []struct {
+	Ctx    context.Context
+	FileID string
+	Name   string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+		Name   string
+	}
+	mock.lockTagImage.RLock()
+	calls = mock.calls.TagImage
+	mock.lockTagImage.RUnlock()
+	return calls
+}
+
+// TagsOfImages calls TagsOfImagesFunc.
+func (mock *imageRepoMock) TagsOfImages(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error) {
+	if mock.TagsOfImagesFunc == nil {
+		panic("imageRepoMock.TagsOfImagesFunc: method is nil but imageRepo.TagsOfImages was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		FileIDs []string
+	}{
+		Ctx:     ctx,
+		FileIDs: fileIDs,
+	}
+	mock.lockTagsOfImages.Lock()
+	mock.calls.TagsOfImages = append(mock.calls.TagsOfImages, callInfo)
+	mock.lockTagsOfImages.Unlock()
+	return mock.TagsOfImagesFunc(ctx, fileIDs)
+}
+
+// TagsOfImagesCalls gets all the calls that were made to TagsOfImages.
+// Check the length with:
+//
+//	len(mockedimageRepo.TagsOfImagesCalls())
+func (mock *imageRepoMock) TagsOfImagesCalls() []struct {
+	Ctx     context.Context
+	FileIDs []string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		FileIDs []string
+	}
+	mock.lockTagsOfImages.RLock()
+	calls = mock.calls.TagsOfImages
+	mock.lockTagsOfImages.RUnlock()
+	return calls
+}
+
+// UntagImage calls UntagImageFunc.
+func (mock *imageRepoMock) UntagImage(ctx context.Context, fileID string, name string) error {
+	if mock.UntagImageFunc == nil {
+		panic("imageRepoMock.UntagImageFunc: method is nil but imageRepo.UntagImage was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+		Name   string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+		Name:   name,
+	}
+	mock.lockUntagImage.Lock()
+	mock.calls.UntagImage = append(mock.calls.UntagImage, callInfo)
+	mock.lockUntagImage.Unlock()
+	return mock.UntagImageFunc(ctx, fileID, name)
+}
+
+// UntagImageCalls gets all the calls that were made to UntagImage.
+// Check the length with:
+//
+//	len(mockedimageRepo.UntagImageCalls())
+func (mock *imageRepoMock) UntagImageCalls() []struct {
+	Ctx    context.Context
+	FileID string
+	Name   string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+		Name   string
+	}
+	mock.lockUntagImage.RLock()
+	calls = mock.calls.UntagImage
+	mock.lockUntagImage.RUnlock()
+	return calls
+}
+
+// UpdateSavedSearch calls UpdateSavedSearchFunc.
+func (mock *imageRepoMock) UpdateSavedSearch(ctx context.Context, id int, name string, query string) (domain.SavedSearch, error) {
+	if mock.UpdateSavedSearchFunc == nil {
+		panic("imageRepoMock.UpdateSavedSearchFunc: method is nil but imageRepo.UpdateSavedSearch was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Id    int
+		Name  string
+		Query string
+	}{
+		Ctx:   ctx,
+		Id:    id,
+		Name:  name,
+		Query: query,
+	}
+	mock.lockUpdateSavedSearch.Lock()
+	mock.calls.UpdateSavedSearch = append(mock.calls.UpdateSavedSearch, callInfo)
+	mock.lockUpdateSavedSearch.Unlock()
+	return mock.UpdateSavedSearchFunc(ctx, id, name, query)
+}
+
+// UpdateSavedSearchCalls gets all the calls that were made to UpdateSavedSearch.
+// Check the length with:
+//
+//	len(mockedimageRepo.UpdateSavedSearchCalls())
+func (mock *imageRepoMock) UpdateSavedSearchCalls() []struct {
+	Ctx   context.Context
+	Id    int
+	Name  string
+	Query string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Id    int
+		Name  string
+		Query string
+	}
+	mock.lockUpdateSavedSearch.RLock()
+	calls = mock.calls.UpdateSavedSearch
+	mock.lockUpdateSavedSearch.RUnlock()
+	return calls
+}
+
+// UseFeedToken calls UseFeedTokenFunc.
+func (mock *imageRepoMock) UseFeedToken(ctx context.Context, hash string) error {
+	if mock.UseFeedTokenFunc == nil {
+		panic("imageRepoMock.UseFeedTokenFunc: method is nil but imageRepo.UseFeedToken was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Hash string
+	}{
+		Ctx:  ctx,
+		Hash: hash,
+	}
+	mock.lockUseFeedToken.Lock()
+	mock.calls.UseFeedToken = append(mock.calls.UseFeedToken, callInfo)
+	mock.lockUseFeedToken.Unlock()
+	return mock.UseFeedTokenFunc(ctx, hash)
+}
+
+// UseFeedTokenCalls gets all the calls that were made to UseFeedToken.
+// Check the length with:
+//
+//	len(mockedimageRepo.UseFeedTokenCalls())
+func (mock *imageRepoMock) UseFeedTokenCalls() []struct {
+	Ctx  context.Context
+	Hash string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Hash string
+	}
+	mock.lockUseFeedToken.RLock()
+	calls = mock.calls.UseFeedToken
+	mock.lockUseFeedToken.RUnlock()
+	return calls
+}
+
+// Ensure, that fileStorageMock does implement fileStorage.
+// If this is not the case, regenerate this file with moq.
+var _ fileStorage = &fileStorageMock{}
+
+// fileStorageMock is a mock implementation of fileStorage.
+//
+//	func TestSomethingThatUsesfileStorage(t *testing.T) {
+//
+//		// make and configure a mocked fileStorage
+//		mockedfileStorage := &fileStorageMock{
+//			DeleteFileFunc: func(ctx context.Context, key string) error {
+//				panic("mock out the DeleteFile method")
+//			},
+//			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+//				panic("mock out the Read method")
+//			},
+//			UploadFunc: func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+//				panic("mock out the Upload method")
+//			},
+//		}
+//
+//		// use mockedfileStorage in code that requires fileStorage
+//		// and then make assertions.
+//
+//	}
+type fileStorageMock struct {
+	// DeleteFileFunc mocks the DeleteFile method.
+	DeleteFileFunc func(ctx context.Context, key string) error
+
+	// ReadFunc mocks the Read method.
+	ReadFunc func(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// UploadFunc mocks the Upload method.
+	UploadFunc func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// DeleteFile holds details about calls to the DeleteFile method.
+		DeleteFile []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// Read holds details about calls to the Read method.
+		Read []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// Upload holds details about calls to the Upload method.
+		Upload []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+			// Body is the body argument value.
+			Body io.ReadSeeker
+			// ContentType is the contentType argument value.
+			ContentType string
+		}
+	}
+	lockDeleteFile sync.RWMutex
+	lockRead       sync.RWMutex
+	lockUpload     sync.RWMutex
+}
+
+// DeleteFile calls DeleteFileFunc.
+func (mock *fileStorageMock) DeleteFile(ctx context.Context, key string) error {
+	if mock.DeleteFileFunc == nil {
+		panic("fileStorageMock.DeleteFileFunc: method is nil but fileStorage.DeleteFile was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockDeleteFile.Lock()
+	mock.calls.DeleteFile = append(mock.calls.DeleteFile, callInfo)
+	mock.lockDeleteFile.Unlock()
+	return mock.DeleteFileFunc(ctx, key)
+}
+
+// DeleteFileCalls gets all the calls that were made to DeleteFile.
+// Check the length with:
+//
+//	len(mockedfileStorage.DeleteFileCalls())
+func (mock *fileStorageMock) DeleteFileCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockDeleteFile.RLock()
+	calls = mock.calls.DeleteFile
+	mock.lockDeleteFile.RUnlock()
+	return calls
+}
+
+// Read calls ReadFunc.
+func (mock *fileStorageMock) Read(ctx context.Context, key string) (io.ReadCloser, error) {
+	if mock.ReadFunc == nil {
+		panic("fileStorageMock.ReadFunc: method is nil but fileStorage.Read was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockRead.Lock()
+	mock.calls.Read = append(mock.calls.Read, callInfo)
+	mock.lockRead.Unlock()
+	return mock.ReadFunc(ctx, key)
+}
+
+// ReadCalls gets all the calls that were made to Read.
+// Check the length with:
+//
+//	len(mockedfileStorage.ReadCalls())
+func (mock *fileStorageMock) ReadCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockRead.RLock()
+	calls = mock.calls.Read
+	mock.lockRead.RUnlock()
+	return calls
+}
+
+// Upload calls UploadFunc.
+func (mock *fileStorageMock) Upload(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	if mock.UploadFunc == nil {
+		panic("fileStorageMock.UploadFunc: method is nil but fileStorage.Upload was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		Key         string
+		Body        io.ReadSeeker
+		ContentType string
+	}{
+		Ctx:         ctx,
+		Key:         key,
+		Body:        body,
+		ContentType: contentType,
+	}
+	mock.lockUpload.Lock()
+	mock.calls.Upload = append(mock.calls.Upload, callInfo)
+	mock.lockUpload.Unlock()
+	return mock.UploadFunc(ctx, key, body, contentType)
+}
+
+// UploadCalls gets all the calls that were made to Upload.
+// Check the length with:
+//
+//	len(mockedfileStorage.UploadCalls())
+func (mock *fileStorageMock) UploadCalls() []struct {
+	Ctx         context.Context
+	Key         string
+	Body        io.ReadSeeker
+	ContentType string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		Key         string
+		Body        io.ReadSeeker
+		ContentType string
+	}
+	mock.lockUpload.RLock()
+	calls = mock.calls.Upload
+	mock.lockUpload.RUnlock()
+	return calls
+}
+
+// Ensure, that ocrEngineMock does implement ocrEngine.
+// If this is not the case, regenerate this file with moq.
+var _ ocrEngine = &ocrEngineMock{}
+
+// ocrEngineMock is a mock implementation of ocrEngine.
+//
+//	func TestSomethingThatUsesocrEngine(t *testing.T) {
+//
+//		// make and configure a mocked ocrEngine
+//		mockedocrEngine := &ocrEngineMock{
+//			RunFunc: func(file string) (string, error) {
+//				panic("mock out the Run method")
+//			},
+//		}
+//
+//		// use mockedocrEngine in code that requires ocrEngine
+//		// and then make assertions.
+//
+//	}
+type ocrEngineMock struct {
+	// RunFunc mocks the Run method.
+	RunFunc func(file string) (string, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Run holds details about calls to the Run method.
+		Run []struct {
+			// File is the file argument value.
+			File string
+		}
+	}
+	lockRun sync.RWMutex
+}
+
+// Run calls RunFunc.
+func (mock *ocrEngineMock) Run(file string) (string, error) {
+	if mock.RunFunc == nil {
+		panic("ocrEngineMock.RunFunc: method is nil but ocrEngine.Run was just called")
+	}
+	callInfo := struct {
+		File string
+	}{
+		File: file,
+	}
+	mock.lockRun.Lock()
+	mock.calls.Run = append(mock.calls.Run, callInfo)
+	mock.lockRun.Unlock()
+	return mock.RunFunc(file)
+}
+
+// RunCalls gets all the calls that were made to Run.
+// Check the length with:
+//
+//	len(mockedocrEngine.RunCalls())
+func (mock *ocrEngineMock) RunCalls() []struct {
+	File string
+} {
+	var calls []struct {
+		File string
+	}
+	mock.lockRun.RLock()
+	calls = mock.calls.Run
+	mock.lockRun.RUnlock()
+	return calls
+}
+
+// Ensure, that eventPublisherMock does implement eventPublisher.
+// If this is not the case, regenerate this file with moq.
+var _ eventPublisher = &eventPublisherMock{}
+
+// eventPublisherMock is a mock implementation of eventPublisher.
+//
+//	func TestSomethingThatUseseventPublisher(t *testing.T) {
+//
+//		// make and configure a mocked eventPublisher
+//		mockedeventPublisher := &eventPublisherMock{
+//			PublishFunc: func(ctx context.Context, e domain.Event) {
+//				panic("mock out the Publish method")
+//			},
+//		}
+//
+//		// use mockedeventPublisher in code that requires eventPublisher
+//		// and then make assertions.
+//
+//	}
+type eventPublisherMock struct {
+	// PublishFunc mocks the Publish method.
+	PublishFunc func(ctx context.Context, e domain.Event)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Publish holds details about calls to the Publish method.
+		Publish []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E domain.Event
+		}
+	}
+	lockPublish sync.RWMutex
+}
+
+// Publish calls PublishFunc.
+func (mock *eventPublisherMock) Publish(ctx context.Context, e domain.Event) {
+	if mock.PublishFunc == nil {
+		panic("eventPublisherMock.PublishFunc: method is nil but eventPublisher.Publish was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   domain.Event
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockPublish.Lock()
+	mock.calls.Publish = append(mock.calls.Publish, callInfo)
+	mock.lockPublish.Unlock()
+	mock.PublishFunc(ctx, e)
+}
+
+// PublishCalls gets all the calls that were made to Publish.
+// Check the length with:
+//
+//	len(mockedeventPublisher.PublishCalls())
+func (mock *eventPublisherMock) PublishCalls() []struct {
+	Ctx context.Context
+	E   domain.Event
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   domain.Event
+	}
+	mock.lockPublish.RLock()
+	calls = mock.calls.Publish
+	mock.lockPublish.RUnlock()
+	return calls
+}
+
+// Ensure, that eventStreamMock does implement eventStream.
+// If this is not the case, regenerate this file with moq.
+var _ eventStream = &eventStreamMock{}
+
+// eventStreamMock is a mock implementation of eventStream.
+//
+//	func TestSomethingThatUseseventStream(t *testing.T) {
+//
+//		// make and configure a mocked eventStream
+//		mockedeventStream := &eventStreamMock{
+//			ReplayFunc: func(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+//				panic("mock out the Replay method")
+//			},
+//			SubscribeFunc: func() (<-chan domain.StreamEvent, func()) {
+//				panic("mock out the Subscribe method")
+//			},
+//		}
+//
+//		// use mockedeventStream in code that requires eventStream
+//		// and then make assertions.
+//
+//	}
+type eventStreamMock struct {
+	// ReplayFunc mocks the Replay method.
+	ReplayFunc func(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error)
+
+	// SubscribeFunc mocks the Subscribe method.
+	SubscribeFunc func() (<-chan domain.StreamEvent, func())
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Replay holds details about calls to the Replay method.
+		Replay []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// After is the after argument value.
+			After int64
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// Subscribe holds details about calls to the Subscribe method.
+		Subscribe []struct {
+		}
+	}
+	lockReplay    sync.RWMutex
+	lockSubscribe sync.RWMutex
+}
+
+// Replay calls ReplayFunc.
+func (mock *eventStreamMock) Replay(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+	if mock.ReplayFunc == nil {
+		panic("eventStreamMock.ReplayFunc: method is nil but eventStream.Replay was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		After int64
+		Limit int
+	}{
+		Ctx:   ctx,
+		After: after,
+		Limit: limit,
+	}
+	mock.lockReplay.Lock()
+	mock.calls.Replay = append(mock.calls.Replay, callInfo)
+	mock.lockReplay.Unlock()
+	return mock.ReplayFunc(ctx, after, limit)
+}
+
+// ReplayCalls gets all the calls that were made to Replay.
+// Check the length with:
+//
+//	len(mockedeventStream.ReplayCalls())
+func (mock *eventStreamMock) ReplayCalls() []struct {
+	Ctx   context.Context
+	After int64
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		After int64
+		Limit int
+	}
+	mock.lockReplay.RLock()
+	calls = mock.calls.Replay
+	mock.lockReplay.RUnlock()
+	return calls
+}
+
+// Subscribe calls SubscribeFunc.
+func (mock *eventStreamMock) Subscribe() (<-chan domain.StreamEvent, func()) {
+	if mock.SubscribeFunc == nil {
+		panic("eventStreamMock.SubscribeFunc: method is nil but eventStream.Subscribe was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockSubscribe.Lock()
+	mock.calls.Subscribe = append(mock.calls.Subscribe, callInfo)
+	mock.lockSubscribe.Unlock()
+	return mock.SubscribeFunc()
+}
+
+// SubscribeCalls gets all the calls that were made to Subscribe.
+// Check the length with:
+//
+//	len(mockedeventStream.SubscribeCalls())
+func (mock *eventStreamMock) SubscribeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockSubscribe.RLock()
+	calls = mock.calls.Subscribe
+	mock.lockSubscribe.RUnlock()
+	return calls
+}
+
+// Ensure, that indexRunnerMock does implement indexRunner.
+// If this is not the case, regenerate this file with moq.
+var _ indexRunner = &indexRunnerMock{}
+
+// indexRunnerMock is a mock implementation of indexRunner.
+//
+//	func TestSomethingThatUsesindexRunner(t *testing.T) {
+//
+//		// make and configure a mocked indexRunner
+//		mockedindexRunner := &indexRunnerMock{
+//			IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+//				panic("mock out the IndexKey method")
+//			},
+//			PauseFunc: func() {
+//				panic("mock out the Pause method")
+//			},
+//			ProgressFunc: func() indexer.Progress {
+//				panic("mock out the Progress method")
+//			},
+//			ResumeFunc: func() {
+//				panic("mock out the Resume method")
+//			},
+//			StateFunc: func() app.RunnerState {
+//				panic("mock out the State method")
+//			},
+//			TriggerFunc: func() error {
+//				panic("mock out the Trigger method")
 //			},
 //		}
 //
-//		// use mockedimageRepo in code that requires imageRepo
+//		// use mockedindexRunner in code that requires indexRunner
 //		// and then make assertions.
 //
 //	}
-type imageRepoMock struct {
-	// DeleteFunc mocks the Delete method.
-	DeleteFunc func(ctx context.Context, fileID string) error
+type indexRunnerMock struct {
+	// IndexKeyFunc mocks the IndexKey method.
+	IndexKeyFunc func(ctx context.Context, key string) (domain.Image, error)
 
-	// FindByDescriptionFunc mocks the FindByDescription method.
-	FindByDescriptionFunc func(ctx context.Context, searchString string, page int, perPage int) ([]domain.Image, error)
+	// PauseFunc mocks the Pause method.
+	PauseFunc func()
+
+	// ProgressFunc mocks the Progress method.
+	ProgressFunc func() indexer.Progress
+
+	// ResumeFunc mocks the Resume method.
+	ResumeFunc func()
+
+	// StateFunc mocks the State method.
+	StateFunc func() app.RunnerState
+
+	// TriggerFunc mocks the Trigger method.
+	TriggerFunc func() error
 
 	// calls tracks calls to the methods.
 	calls struct {
-		// Delete holds details about calls to the Delete method.
-		Delete []struct {
+		// IndexKey holds details about calls to the IndexKey method.
+		IndexKey []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// FileID is the fileID argument value.
-			FileID string
+			// Key is the key argument value.
+			Key string
 		}
-		// FindByDescription holds details about calls to the FindByDescription method.
-		FindByDescription []struct {
-			// Ctx is the ctx argument value.
-			Ctx context.Context
-			// SearchString is the searchString argument value.
-			SearchString string
-			// Page is the page argument value.
-			Page int
-			// PerPage is the perPage argument value.
-			PerPage int
+		// Pause holds details about calls to the Pause method.
+		Pause []struct {
+		}
+		// Progress holds details about calls to the Progress method.
+		Progress []struct {
+		}
+		// Resume holds details about calls to the Resume method.
+		Resume []struct {
+		}
+		// State holds details about calls to the State method.
+		State []struct {
+		}
+		// Trigger holds details about calls to the Trigger method.
+		Trigger []struct {
 		}
 	}
-	lockDelete            sync.RWMutex
-	lockFindByDescription sync.RWMutex
+	lockIndexKey sync.RWMutex
+	lockPause    sync.RWMutex
+	lockProgress sync.RWMutex
+	lockResume   sync.RWMutex
+	lockState    sync.RWMutex
+	lockTrigger  sync.RWMutex
 }
 
-// Delete calls DeleteFunc.
-func (mock *imageRepoMock) Delete(ctx context.Context, fileID string) error {
-	if mock.DeleteFunc == nil {
-		panic("imageRepoMock.DeleteFunc: method is nil but imageRepo.Delete was just called")
+// IndexKey calls IndexKeyFunc.
+func (mock *indexRunnerMock) IndexKey(ctx context.Context, key string) (domain.Image, error) {
+	if mock.IndexKeyFunc == nil {
+		panic("indexRunnerMock.IndexKeyFunc: method is nil but indexRunner.IndexKey was just called")
 	}
 	callInfo := struct {
-		Ctx    context.Context
-		FileID string
+		Ctx context.Context
+		Key string
 	}{
-		Ctx:    ctx,
-		FileID: fileID,
+		Ctx: ctx,
+		Key: key,
 	}
-	mock.lockDelete.Lock()
-	mock.calls.Delete = append(mock.calls.Delete, callInfo)
-	mock.lockDelete.Unlock()
-	return mock.DeleteFunc(ctx, fileID)
+	mock.lockIndexKey.Lock()
+	mock.calls.IndexKey = append(mock.calls.IndexKey, callInfo)
+	mock.lockIndexKey.Unlock()
+	return mock.IndexKeyFunc(ctx, key)
 }
 
-// DeleteCalls gets all the calls that were made to Delete.
+// IndexKeyCalls gets all the calls that were made to IndexKey.
 // Check the length with:
 //
-//	len(mockedimageRepo.DeleteCalls())
-func (mock *imageRepoMock) DeleteCalls() []struct {
-	Ctx    context.Context
-	FileID string
+//	len(mockedindexRunner.IndexKeyCalls())
+func (mock *indexRunnerMock) IndexKeyCalls() []struct {
+	Ctx context.Context
+	Key string
 } {
 	var calls []struct {
-		Ctx    context.Context
-		FileID string
+		Ctx context.Context
+		Key string
 	}
-	mock.lockDelete.RLock()
-	calls = mock.calls.Delete
-	mock.lockDelete.RUnlock()
+	mock.lockIndexKey.RLock()
+	calls = mock.calls.IndexKey
+	mock.lockIndexKey.RUnlock()
 	return calls
 }
 
-// FindByDescription calls FindByDescriptionFunc.
-func (mock *imageRepoMock) FindByDescription(ctx context.Context, searchString string, page int, perPage int) ([]domain.Image, error) {
-	if mock.FindByDescriptionFunc == nil {
-		panic("imageRepoMock.FindByDescriptionFunc: method is nil but imageRepo.FindByDescription was just called")
+// Pause calls PauseFunc.
+func (mock *indexRunnerMock) Pause() {
+	if mock.PauseFunc == nil {
+		panic("indexRunnerMock.PauseFunc: method is nil but indexRunner.Pause was just called")
 	}
 	callInfo := struct {
-		Ctx          context.Context
-		SearchString string
-		Page         int
-		PerPage      int
-	}{
-		Ctx:          ctx,
-		SearchString: searchString,
-		Page:         page,
-		PerPage:      perPage,
+	}{}
+	mock.lockPause.Lock()
+	mock.calls.Pause = append(mock.calls.Pause, callInfo)
+	mock.lockPause.Unlock()
+	mock.PauseFunc()
+}
+
+// PauseCalls gets all the calls that were made to Pause.
+// Check the length with:
+//
+//	len(mockedindexRunner.PauseCalls())
+func (mock *indexRunnerMock) PauseCalls() []struct {
+} {
+	var calls []struct {
 	}
-	mock.lockFindByDescription.Lock()
-	mock.calls.FindByDescription = append(mock.calls.FindByDescription, callInfo)
-	mock.lockFindByDescription.Unlock()
-	return mock.FindByDescriptionFunc(ctx, searchString, page, perPage)
+	mock.lockPause.RLock()
+	calls = mock.calls.Pause
+	mock.lockPause.RUnlock()
+	return calls
 }
 
-// FindByDescriptionCalls gets all the calls that were made to FindByDescription.
+// Progress calls ProgressFunc.
+func (mock *indexRunnerMock) Progress() indexer.Progress {
+	if mock.ProgressFunc == nil {
+		panic("indexRunnerMock.ProgressFunc: method is nil but indexRunner.Progress was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockProgress.Lock()
+	mock.calls.Progress = append(mock.calls.Progress, callInfo)
+	mock.lockProgress.Unlock()
+	return mock.ProgressFunc()
+}
+
+// ProgressCalls gets all the calls that were made to Progress.
 // Check the length with:
 //
-//	len(mockedimageRepo.FindByDescriptionCalls())
-func (mock *imageRepoMock) FindByDescriptionCalls() []struct {
-	Ctx          context.Context
-	SearchString string
-	Page         int
-	PerPage      int
+//	len(mockedindexRunner.ProgressCalls())
+func (mock *indexRunnerMock) ProgressCalls() []struct {
 } {
 	var calls []struct {
-		Ctx          context.Context
-		SearchString string
-		Page         int
-		PerPage      int
 	}
-	mock.lockFindByDescription.RLock()
-	calls = mock.calls.FindByDescription
-	mock.lockFindByDescription.RUnlock()
+	mock.lockProgress.RLock()
+	calls = mock.calls.Progress
+	mock.lockProgress.RUnlock()
 	return calls
 }
 
-// Ensure, that fileStorageMock does implement fileStorage.
+// Resume calls ResumeFunc.
+func (mock *indexRunnerMock) Resume() {
+	if mock.ResumeFunc == nil {
+		panic("indexRunnerMock.ResumeFunc: method is nil but indexRunner.Resume was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockResume.Lock()
+	mock.calls.Resume = append(mock.calls.Resume, callInfo)
+	mock.lockResume.Unlock()
+	mock.ResumeFunc()
+}
+
+// ResumeCalls gets all the calls that were made to Resume.
+// Check the length with:
+//
+//	len(mockedindexRunner.ResumeCalls())
+func (mock *indexRunnerMock) ResumeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockResume.RLock()
+	calls = mock.calls.Resume
+	mock.lockResume.RUnlock()
+	return calls
+}
+
+// State calls StateFunc.
+func (mock *indexRunnerMock) State() app.RunnerState {
+	if mock.StateFunc == nil {
+		panic("indexRunnerMock.StateFunc: method is nil but indexRunner.State was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockState.Lock()
+	mock.calls.State = append(mock.calls.State, callInfo)
+	mock.lockState.Unlock()
+	return mock.StateFunc()
+}
+
+// StateCalls gets all the calls that were made to State.
+// Check the length with:
+//
+//	len(mockedindexRunner.StateCalls())
+func (mock *indexRunnerMock) StateCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockState.RLock()
+	calls = mock.calls.State
+	mock.lockState.RUnlock()
+	return calls
+}
+
+// Trigger calls TriggerFunc.
+func (mock *indexRunnerMock) Trigger() error {
+	if mock.TriggerFunc == nil {
+		panic("indexRunnerMock.TriggerFunc: method is nil but indexRunner.Trigger was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockTrigger.Lock()
+	mock.calls.Trigger = append(mock.calls.Trigger, callInfo)
+	mock.lockTrigger.Unlock()
+	return mock.TriggerFunc()
+}
+
+// TriggerCalls gets all the calls that were made to Trigger.
+// Check the length with:
+//
+//	len(mockedindexRunner.TriggerCalls())
+func (mock *indexRunnerMock) TriggerCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockTrigger.RLock()
+	calls = mock.calls.Trigger
+	mock.lockTrigger.RUnlock()
+	return calls
+}
+
+// Ensure, that keyIndexerMock does implement keyIndexer.
 // If this is not the case, regenerate this file with moq.
-var _ fileStorage = &fileStorageMock{}
+var _ keyIndexer = &keyIndexerMock{}
 
-// fileStorageMock is a mock implementation of fileStorage.
+// keyIndexerMock is a mock implementation of keyIndexer.
 //
-//	func TestSomethingThatUsesfileStorage(t *testing.T) {
+//	func TestSomethingThatUseskeyIndexer(t *testing.T) {
 //
-//		// make and configure a mocked fileStorage
-//		mockedfileStorage := &fileStorageMock{
-//			DeleteFileFunc: func(ctx context.Context, key string) error {
-//				panic("mock out the DeleteFile method")
+//		// make and configure a mocked keyIndexer
+//		mockedkeyIndexer := &keyIndexerMock{
+//			IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+//				panic("mock out the IndexKey method")
+//			},
+//			QueueRetryFunc: func(ctx context.Context, key string, cause error) error {
+//				panic("mock out the QueueRetry method")
 //			},
 //		}
 //
-//		// use mockedfileStorage in code that requires fileStorage
+//		// use mockedkeyIndexer in code that requires keyIndexer
 //		// and then make assertions.
 //
 //	}
-type fileStorageMock struct {
-	// DeleteFileFunc mocks the DeleteFile method.
-	DeleteFileFunc func(ctx context.Context, key string) error
+type keyIndexerMock struct {
+	// IndexKeyFunc mocks the IndexKey method.
+	IndexKeyFunc func(ctx context.Context, key string) (domain.Image, error)
+
+	// QueueRetryFunc mocks the QueueRetry method.
+	QueueRetryFunc func(ctx context.Context, key string, cause error) error
 
 	// calls tracks calls to the methods.
 	calls struct {
-		// DeleteFile holds details about calls to the DeleteFile method.
-		DeleteFile []struct {
+		// IndexKey holds details about calls to the IndexKey method.
+		IndexKey []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// QueueRetry holds details about calls to the QueueRetry method.
+		QueueRetry []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// Key is the key argument value.
 			Key string
+			// Cause is the cause argument value.
+			Cause error
 		}
 	}
-	lockDeleteFile sync.RWMutex
+	lockIndexKey   sync.RWMutex
+	lockQueueRetry sync.RWMutex
 }
 
-// DeleteFile calls DeleteFileFunc.
-func (mock *fileStorageMock) DeleteFile(ctx context.Context, key string) error {
-	if mock.DeleteFileFunc == nil {
-		panic("fileStorageMock.DeleteFileFunc: method is nil but fileStorage.DeleteFile was just called")
+// IndexKey calls IndexKeyFunc.
+func (mock *keyIndexerMock) IndexKey(ctx context.Context, key string) (domain.Image, error) {
+	if mock.IndexKeyFunc == nil {
+		panic("keyIndexerMock.IndexKeyFunc: method is nil but keyIndexer.IndexKey was just called")
 	}
 	callInfo := struct {
 		Ctx context.Context
@@ -191,17 +2718,17 @@ func (mock *fileStorageMock) DeleteFile(ctx context.Context, key string) error {
 		Ctx: ctx,
 		Key: key,
 	}
-	mock.lockDeleteFile.Lock()
-	mock.calls.DeleteFile = append(mock.calls.DeleteFile, callInfo)
-	mock.lockDeleteFile.Unlock()
-	return mock.DeleteFileFunc(ctx, key)
+	mock.lockIndexKey.Lock()
+	mock.calls.IndexKey = append(mock.calls.IndexKey, callInfo)
+	mock.lockIndexKey.Unlock()
+	return mock.IndexKeyFunc(ctx, key)
 }
 
-// DeleteFileCalls gets all the calls that were made to DeleteFile.
+// IndexKeyCalls gets all the calls that were made to IndexKey.
 // Check the length with:
 //
-//	len(mockedfileStorage.DeleteFileCalls())
-func (mock *fileStorageMock) DeleteFileCalls() []struct {
+//	len(mockedkeyIndexer.IndexKeyCalls())
+func (mock *keyIndexerMock) IndexKeyCalls() []struct {
 	Ctx context.Context
 	Key string
 } {
@@ -209,8 +2736,126 @@ func (mock *fileStorageMock) DeleteFileCalls() []struct {
 		Ctx context.Context
 		Key string
 	}
-	mock.lockDeleteFile.RLock()
-	calls = mock.calls.DeleteFile
-	mock.lockDeleteFile.RUnlock()
+	mock.lockIndexKey.RLock()
+	calls = mock.calls.IndexKey
+	mock.lockIndexKey.RUnlock()
+	return calls
+}
+
+// QueueRetry calls QueueRetryFunc.
+func (mock *keyIndexerMock) QueueRetry(ctx context.Context, key string, cause error) error {
+	if mock.QueueRetryFunc == nil {
+		panic("keyIndexerMock.QueueRetryFunc: method is nil but keyIndexer.QueueRetry was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Key   string
+		Cause error
+	}{
+		Ctx:   ctx,
+		Key:   key,
+		Cause: cause,
+	}
+	mock.lockQueueRetry.Lock()
+	mock.calls.QueueRetry = append(mock.calls.QueueRetry, callInfo)
+	mock.lockQueueRetry.Unlock()
+	return mock.QueueRetryFunc(ctx, key, cause)
+}
+
+// QueueRetryCalls gets all the calls that were made to QueueRetry.
+// Check the length with:
+//
+//	len(mockedkeyIndexer.QueueRetryCalls())
+func (mock *keyIndexerMock) QueueRetryCalls() []struct {
+	Ctx   context.Context
+	Key   string
+	Cause error
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Key   string
+		Cause error
+	}
+	mock.lockQueueRetry.RLock()
+	calls = mock.calls.QueueRetry
+	mock.lockQueueRetry.RUnlock()
+	return calls
+}
+
+// Ensure, that urlFetcherMock does implement urlFetcher.
+// If this is not the case, regenerate this file with moq.
+var _ urlFetcher = &urlFetcherMock{}
+
+// urlFetcherMock is a mock implementation of urlFetcher.
+//
+//	func TestSomethingThatUsesurlFetcher(t *testing.T) {
+//
+//		// make and configure a mocked urlFetcher
+//		mockedurlFetcher := &urlFetcherMock{
+//			FetchFunc: func(ctx context.Context, url string, w io.Writer) error {
+//				panic("mock out the Fetch method")
+//			},
+//		}
+//
+//		// use mockedurlFetcher in code that requires urlFetcher
+//		// and then make assertions.
+//
+//	}
+type urlFetcherMock struct {
+	// FetchFunc mocks the Fetch method.
+	FetchFunc func(ctx context.Context, url string, w io.Writer) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Fetch holds details about calls to the Fetch method.
+		Fetch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Url is the url argument value.
+			Url string
+			// W is the w argument value.
+			W io.Writer
+		}
+	}
+	lockFetch sync.RWMutex
+}
+
+// Fetch calls FetchFunc.
+func (mock *urlFetcherMock) Fetch(ctx context.Context, url string, w io.Writer) error {
+	if mock.FetchFunc == nil {
+		panic("urlFetcherMock.FetchFunc: method is nil but urlFetcher.Fetch was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Url string
+		W   io.Writer
+	}{
+		Ctx: ctx,
+		Url: url,
+		W:   w,
+	}
+	mock.lockFetch.Lock()
+	mock.calls.Fetch = append(mock.calls.Fetch, callInfo)
+	mock.lockFetch.Unlock()
+	return mock.FetchFunc(ctx, url, w)
+}
+
+// FetchCalls gets all the calls that were made to Fetch.
+// Check the length with:
+//
+//	len(mockedurlFetcher.FetchCalls())
+func (mock *urlFetcherMock) FetchCalls() []struct {
+	Ctx context.Context
+	Url string
+	W   io.Writer
+} {
+	var calls []struct {
+		Ctx context.Context
+		Url string
+		W   io.Writer
+	}
+	mock.lockFetch.RLock()
+	calls = mock.calls.Fetch
+	mock.lockFetch.RUnlock()
 	return calls
 }
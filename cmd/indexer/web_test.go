@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
 	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/tagging"
+	"github.com/go-chi/chi/v5"
 	"github.com/matryer/is"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 )
@@ -26,11 +30,24 @@ func newTestApp(repo *imageRepoMock, fs fileStorage) *webApp {
 		log:               log.Default(),
 		imageDescriptions: repo,
 		fileStorage:       fs,
+		ocrEngine:         &ocrEngineMock{},
+		tagger:            &tagging.Tagger{},
+		events:            &eventPublisherMock{PublishFunc: func(ctx context.Context, e domain.Event) {}},
 		tracker:           monitoring.NewTracker(),
 	}
 	return app
 }
 
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		rctx = chi.NewRouteContext()
+	}
+	rctx.URLParams.Add(key, value)
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
 func Test_webApp_serve(t *testing.T) {
 	wg := sync.WaitGroup{}
 	defer wg.Wait()
@@ -46,7 +63,7 @@ func Test_webApp_serve(t *testing.T) {
 	defer cancel()
 
 	app := newTestApp(nil, nil)
-	app.config.Port = port
+	app.config.Web.Port = port
 
 	wg.Add(1)
 	go func() {
@@ -88,6 +105,36 @@ func Test_webApp_serve(t *testing.T) {
 
 }
 
+func Test_webApp_routes_readOnly(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		ListTagsFunc: func(ctx context.Context) ([]domain.TagCount, error) { return []domain.TagCount{}, nil },
+	}
+	app := newTestApp(imageDescriptions, nil)
+	app.config.Web.ReadOnly = true
+	routes := app.routes()
+
+	testCases := []struct {
+		method       string
+		endpoint     string
+		expectedCode int
+	}{
+		{http.MethodGet, "/api/tags", http.StatusOK},
+		{http.MethodPost, "/api/tags", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/api/delete", http.StatusNotFound},
+		{http.MethodGet, "/api/admin/export", http.StatusNotFound},
+		{http.MethodPost, "/api/search/by-image", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, httptest.NewRequest(tc.method, tc.endpoint, bytes.NewBufferString(`{"name":"expected"}`)))
+
+		tt.Equal(w.Result().StatusCode, tc.expectedCode)
+	}
+	tt.Equal(len(imageDescriptions.calls.CreateTag), 0)
+}
+
 func findUnusedPort() (int, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/webhook"
+)
+
+func (app *webApp) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := app.imageDescriptions.ListWebhooks(context.Background())
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, hooks)
+}
+
+// createWebhookHandler registers a webhook, the secret to verify its signatures is only returned once.
+// Deliveries to private addresses fail unless -webhooks.allow-private is set, the address is checked on every connection.
+func (app *webApp) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL    string   `json:"url" validate:"required,max=2000,url,startswith=http://|startswith=https://"`
+		Events []string `json:"events" validate:"dive,oneof=image.indexed image.failed image.deleted"`
+	}
+	err := app.parseJSON(r, &req)
+	if err != nil {
+		app.malformedJSON(r, w)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	hook, err := app.imageDescriptions.CreateWebhook(context.Background(), req.URL, req.Events, secret)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusCreated, hook)
+}
+
+func (app *webApp) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.imageDescriptions.DeleteWebhook(context.Background(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.respondNoContent(r, w)
+}
+
+func (app *webApp) webhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status  string `validate:"omitempty,oneof=pending delivered failed"`
+		Page    int    `validate:"min=1"`
+		PerPage int    `validate:"min=1,max=100"`
+	}
+
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	qs := r.URL.Query()
+	req.Status = qs.Get("status")
+	req.Page, err = app.readInt(qs, "page", 1)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+	req.PerPage, err = app.readInt(qs, "per_page", 20)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	err = app.validate(req)
+	if err != nil {
+		app.validationError(r, w, err)
+		return
+	}
+
+	ctx := context.Background()
+	_, err = app.imageDescriptions.GetWebhook(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, dbadapter.ErrRecordNotFound):
+			app.notFound(w, r)
+		default:
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	deliveries, err := app.imageDescriptions.ListDeliveries(ctx, id, req.Status, req.Page, req.PerPage)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.respondJSON(r, w, http.StatusOK, deliveries)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestCreateWebhookHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("creates webhook with a generated secret", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			CreateWebhookFunc: func(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error) {
+				return domain.Webhook{ID: 1, URL: url, Events: events, Secret: secret}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(
+			`{"url": "https://example.com/hook", "events": ["image.indexed", "image.deleted"]}`,
+		))
+		w := httptest.NewRecorder()
+
+		app.createWebhookHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusCreated)
+
+		call := imageDescriptions.calls.CreateWebhook[0]
+		tt.Equal(call.Url, "https://example.com/hook")
+		tt.Equal(call.Events, []string{"image.indexed", "image.deleted"})
+		tt.Equal(len(call.Secret), 64)
+
+		var hook domain.Webhook
+		tt.NoErr(json.NewDecoder(resp.Body).Decode(&hook))
+		tt.Equal(hook.Secret, call.Secret) // the secret is returned once, so that the receiver can verify signatures
+	})
+
+	for _, body := range []string{
+		`{}`,
+		`{"url": "not a url"}`,
+		`{"url": "ftp://example.com/hook"}`,
+		`{"url": "https://example.com/hook", "events": ["image.unknown"]}`,
+	} {
+		t.Run(fmt.Sprintf("invalid request %s", body), func(t *testing.T) {
+			app := newTestApp(nil, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			app.createWebhookHandler(w, req)
+
+			tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestListWebhooksHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		ListWebhooksFunc: func(ctx context.Context) ([]domain.Webhook, error) {
+			return []domain.Webhook{{ID: 1, URL: "https://example.com/hook", Events: []string{}, CreatedAt: time.Unix(0, 0).UTC()}}, nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+	w := httptest.NewRecorder()
+
+	app.listWebhooksHandler(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	tt.Equal(resp.StatusCode, http.StatusOK)
+	body, err := io.ReadAll(resp.Body)
+	tt.NoErr(err)
+	tt.Equal(string(body), `[{"ID":1,"URL":"https://example.com/hook","Events":[],"CreatedAt":"1970-01-01T00:00:00Z"}]`)
+}
+
+func TestDeleteWebhookHandler(t *testing.T) {
+	tt := is.New(t)
+
+	imageDescriptions := &imageRepoMock{
+		DeleteWebhookFunc: func(ctx context.Context, id int) error {
+			if id != 3 {
+				return dbadapter.ErrRecordNotFound
+			}
+			return nil
+		},
+	}
+	app := newTestApp(imageDescriptions, nil)
+
+	for id, expectedStatus := range map[string]int{"3": http.StatusNoContent, "4": http.StatusNotFound, "x": http.StatusBadRequest} {
+		req := withURLParam(httptest.NewRequest(http.MethodDelete, "/webhooks/"+id, nil), "id", id)
+		w := httptest.NewRecorder()
+
+		app.deleteWebhookHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, expectedStatus)
+	}
+}
+
+func TestWebhookDeliveriesHandler(t *testing.T) {
+	tt := is.New(t)
+
+	t.Run("lists deliveries", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetWebhookFunc: func(ctx context.Context, id int) (domain.Webhook, error) {
+				return domain.Webhook{ID: id}, nil
+			},
+			ListDeliveriesFunc: func(ctx context.Context, webhookID int, status string, page, perPage int) ([]domain.WebhookDelivery, error) {
+				return []domain.WebhookDelivery{{ID: 10, WebhookID: webhookID, Status: status}}, nil
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/webhooks/2/deliveries?status=failed&page=3&per_page=5", nil)
+		req = withURLParam(req, "id", "2")
+		w := httptest.NewRecorder()
+
+		app.webhookDeliveriesHandler(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		tt.Equal(resp.StatusCode, http.StatusOK)
+
+		call := imageDescriptions.calls.ListDeliveries[0]
+		tt.Equal(call.WebhookID, 2)
+		tt.Equal(call.Status, domain.DeliveryFailed)
+		tt.Equal(call.Page, 3)
+		tt.Equal(call.PerPage, 5)
+	})
+
+	t.Run("unknown webhook", func(t *testing.T) {
+		imageDescriptions := &imageRepoMock{
+			GetWebhookFunc: func(ctx context.Context, id int) (domain.Webhook, error) {
+				return domain.Webhook{}, dbadapter.ErrRecordNotFound
+			},
+		}
+		app := newTestApp(imageDescriptions, nil)
+
+		req := withURLParam(httptest.NewRequest(http.MethodGet, "/webhooks/2/deliveries", nil), "id", "2")
+		w := httptest.NewRecorder()
+
+		app.webhookDeliveriesHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		app := newTestApp(nil, nil)
+
+		req := withURLParam(httptest.NewRequest(http.MethodGet, "/webhooks/2/deliveries?status=unknown", nil), "id", "2")
+		w := httptest.NewRecorder()
+
+		app.webhookDeliveriesHandler(w, req)
+
+		tt.Equal(w.Result().StatusCode, http.StatusBadRequest)
+	})
+}
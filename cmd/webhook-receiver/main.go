@@ -0,0 +1,81 @@
+// Command webhook-receiver is a local receiver for testing webhooks of the indexer.
+// It verifies signatures and prints received payloads.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/webhook"
+)
+
+const maxBodySize = 1 << 20
+
+func main() {
+	port := flag.Int("port", 9090, "port to listen on")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "shared secret for verifying signatures")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "maximum age of a request")
+	fail := flag.Int64("fail", 0, "respond with 500 to the first n requests to test retries")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("secret is required")
+	}
+
+	var received atomic.Int64
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = webhook.Verify(
+			*secret,
+			r.Header.Get(webhook.SignatureHeader),
+			r.Header.Get(webhook.TimestampHeader),
+			body,
+			time.Now(),
+			*tolerance,
+		)
+		if err != nil {
+			log.Println("rejected request:", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		n := received.Add(1)
+		if n <= *fail {
+			log.Printf("failing request #%d on purpose", n)
+			http.Error(w, "failing on purpose", http.StatusInternalServerError)
+			return
+		}
+
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") != nil {
+			pretty.Reset()
+			pretty.Write(body)
+		}
+		fmt.Printf("#%d %s\n%s\n\n", n, r.Header.Get(webhook.EventHeader), pretty.String())
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      http.HandlerFunc(handler),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	log.Println("receiving webhooks on port", *port)
+	log.Fatal(srv.ListenAndServe())
+}
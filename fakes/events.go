@@ -0,0 +1,89 @@
+package fakes
+
+import (
+	"context"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type storedEvent struct {
+	domain.StreamEvent
+	createdAt time.Time
+}
+
+// AppendEvent stores the event in the event log and wakes up Wait.
+func (r *ImageRepo) AppendEvent(_ context.Context, e domain.Event) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.Image != nil {
+		img := *e.Image
+		e.Image = &img
+	}
+
+	r.lastSeq++
+	seq := r.lastSeq
+	r.events = append(r.events, storedEvent{StreamEvent: domain.StreamEvent{Seq: seq, Event: e}, createdAt: time.Now()})
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+
+	return seq, nil
+}
+
+// EventsAfter returns up to limit events with sequence numbers greater than after, in order.
+func (r *ImageRepo) EventsAfter(_ context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]domain.StreamEvent, 0)
+	for _, e := range r.events {
+		if len(events) == limit {
+			break
+		}
+		if e.Seq > after {
+			events = append(events, e.StreamEvent)
+		}
+	}
+
+	return events, nil
+}
+
+// LastEventSeq returns the sequence number of the latest event, 0 if there are none.
+func (r *ImageRepo) LastEventSeq(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastSeq, nil
+}
+
+// DeleteEventsBefore removes events that are too old to be resumed from.
+func (r *ImageRepo) DeleteEventsBefore(_ context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if !e.createdAt.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	r.events = kept
+
+	return nil
+}
+
+// Wait blocks until an event is appended, it replaces the database listener of the event stream.
+// Appends that happen while nobody waits are not lost, the next Wait returns immediately.
+// There must be a single waiter.
+func (r *ImageRepo) Wait(ctx context.Context) error {
+	select {
+	case <-r.notify:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
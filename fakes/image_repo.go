@@ -0,0 +1,534 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/search"
+)
+
+// ErrRecordNotFound is the error of the database repository, so that callers handle both repositories the same way.
+var ErrRecordNotFound = dbadapter.ErrRecordNotFound
+
+// ImageRepo keeps images, tags, saved searches, feed tokens, webhooks and events in memory.
+// It behaves like the database repository, except that search matches words instead of using full text search.
+// It is safe for concurrent use.
+type ImageRepo struct {
+	mu sync.Mutex
+
+	// images are stored without entities, findings and tags, like the database returns them
+	images    map[string]domain.Image
+	entities  map[string][]domain.Entity
+	findings  map[string][]domain.Finding
+	edits     map[string][]domain.ImageEdit
+	tags      map[string]struct{}
+	imageTags map[string]map[string]string
+
+	searches      []domain.SavedSearch
+	searchMatches map[searchMatch]struct{}
+	feedTokens    []feedToken
+	webhooks      []domain.Webhook
+	deliveries    []domain.WebhookDelivery
+	events        []storedEvent
+	lastSeq       int64
+	lastID        int
+	notify        chan struct{}
+}
+
+func NewImageRepo() *ImageRepo {
+	return &ImageRepo{
+		images:    make(map[string]domain.Image),
+		entities:  make(map[string][]domain.Entity),
+		findings:  make(map[string][]domain.Finding),
+		edits:     make(map[string][]domain.ImageEdit),
+		tags:      make(map[string]struct{}),
+		imageTags: make(map[string]map[string]string),
+
+		searchMatches: make(map[searchMatch]struct{}),
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// Upsert stores the image, user edits and the source url of a stored image are kept.
+// Entities, findings and tags are replaced only if they are set.
+func (r *ImageRepo) Upsert(_ context.Context, image domain.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := image
+	stored.Entities, stored.Findings, stored.Tags = nil, nil, nil
+	if old, ok := r.images[image.FileID]; ok {
+		stored.Annotations = old.Annotations
+		stored.CorrectedDescription = old.CorrectedDescription
+		stored.SourceURL = old.SourceURL
+	} else {
+		stored.Annotations, stored.CorrectedDescription, stored.SourceURL = "", "", ""
+	}
+	r.images[image.FileID] = stored
+
+	if image.Entities != nil {
+		r.entities[image.FileID] = append([]domain.Entity{}, image.Entities...)
+	}
+	if image.Findings != nil {
+		r.findings[image.FileID] = append([]domain.Finding{}, image.Findings...)
+	}
+	if image.Tags != nil {
+		r.setRuleTags(image.FileID, image.Tags)
+	}
+
+	return nil
+}
+
+func (r *ImageRepo) Get(_ context.Context, fileID string) (domain.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img, ok := r.images[fileID]
+	if !ok {
+		return domain.Image{}, fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+	}
+
+	return img, nil
+}
+
+// GetLastModified returns the latest modification time of stored images, the unix epoch if there are none.
+func (r *ImageRepo) GetLastModified(_ context.Context) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := time.Unix(0, 0).UTC()
+	for _, img := range r.images {
+		if img.LastModified.After(last) {
+			last = img.LastModified
+		}
+	}
+
+	return last, nil
+}
+
+// FindByDescription returns images that contain all words of the search string or the string itself,
+// the latest first. Filters of the search string are applied like in the database repository.
+func (r *ImageRepo) FindByDescription(
+	_ context.Context,
+	searchString string,
+	page,
+	perPage int,
+) ([]domain.Image, error) {
+	if perPage <= 0 {
+		return []domain.Image{}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q := search.Parse(searchString)
+	found := r.filter(func(img domain.Image) bool { return r.matches(img, q) })
+
+	return paginate(found, page, perPage), nil
+}
+
+// Matches checks if the image is found by the search string.
+func (r *ImageRepo) Matches(_ context.Context, fileID, searchString string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img, ok := r.images[fileID]
+	if !ok {
+		return false, nil
+	}
+
+	return r.matches(img, search.Parse(searchString)), nil
+}
+
+// SetSourceURL records the url the image was fetched from.
+func (r *ImageRepo) SetSourceURL(_ context.Context, fileID, sourceURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img, ok := r.images[fileID]
+	if !ok {
+		return fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+	}
+	img.SourceURL = sourceURL
+	r.images[fileID] = img
+
+	return nil
+}
+
+func (r *ImageRepo) Delete(_ context.Context, fileID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.images, fileID)
+	delete(r.entities, fileID)
+	delete(r.findings, fileID)
+	delete(r.edits, fileID)
+	delete(r.imageTags, fileID)
+	for m := range r.searchMatches {
+		if m.fileID == fileID {
+			delete(r.searchMatches, m)
+		}
+	}
+
+	return nil
+}
+
+// FindSimilar returns images whose perceptual hash is within maxDistance bits of the hash of the given image.
+func (r *ImageRepo) FindSimilar(
+	_ context.Context,
+	fileID string,
+	maxDistance,
+	limit int,
+) ([]domain.SimilarImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	similar := make([]domain.SimilarImage, 0)
+	src, ok := r.images[fileID]
+	if !ok || src.PHash == 0 {
+		return similar, nil
+	}
+
+	for _, img := range r.images {
+		if img.FileID == fileID || img.PHash == 0 {
+			continue
+		}
+		d := distance(src.PHash, img.PHash)
+		if d <= maxDistance {
+			similar = append(similar, domain.SimilarImage{Image: img, Distance: d})
+		}
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Distance != similar[j].Distance {
+			return similar[i].Distance < similar[j].Distance
+		}
+		return latestFirst(similar[i].Image, similar[j].Image)
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	return similar, nil
+}
+
+// FindCandidates returns images that are visually close to the hash or whose text contains any of the terms,
+// the closest first. The text is the corrected description if it is set.
+func (r *ImageRepo) FindCandidates(
+	_ context.Context,
+	hash int64,
+	terms []string,
+	maxDistance,
+	limit int,
+) ([]domain.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dist := func(img domain.Image) int {
+		if hash == 0 || img.PHash == 0 {
+			return 64
+		}
+		return distance(hash, img.PHash)
+	}
+
+	found := r.filter(func(img domain.Image) bool {
+		if hash != 0 && img.PHash != 0 && distance(hash, img.PHash) <= maxDistance {
+			return true
+		}
+		words := tokenize(img.Text())
+		for _, t := range terms {
+			if _, ok := words[strings.ToLower(t)]; ok {
+				return true
+			}
+		}
+		return false
+	})
+	sort.SliceStable(found, func(i, j int) bool { return dist(found[i]) < dist(found[j]) })
+	if len(found) > limit {
+		found = found[:limit]
+	}
+
+	return found, nil
+}
+
+// ListImages returns images ordered by file id, starting after the given id, for iterating over all images.
+func (r *ImageRepo) ListImages(_ context.Context, after string, limit int) ([]domain.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	images := make([]domain.Image, 0)
+	for _, img := range r.images {
+		if img.FileID > after {
+			images = append(images, img)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].FileID < images[j].FileID })
+	if len(images) > limit {
+		images = images[:limit]
+	}
+
+	return images, nil
+}
+
+// RecentImages returns the latest images by modification time.
+func (r *ImageRepo) RecentImages(_ context.Context, limit int) ([]domain.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	images := r.filter(func(domain.Image) bool { return true })
+	if len(images) > limit {
+		images = images[:limit]
+	}
+
+	return images, nil
+}
+
+// Patch updates user editable fields of the image and records changed values in its edit history.
+func (r *ImageRepo) Patch(_ context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img, ok := r.images[fileID]
+	if !ok {
+		return domain.Image{}, fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+	}
+
+	changes := []struct {
+		field    string
+		value    *string
+		oldValue *string
+	}{
+		{domain.FieldAnnotations, patch.Annotations, &img.Annotations},
+		{domain.FieldCorrectedDescription, patch.CorrectedDescription, &img.CorrectedDescription},
+	}
+	for _, c := range changes {
+		if c.value == nil || *c.value == *c.oldValue {
+			continue
+		}
+
+		edit := domain.ImageEdit{Field: c.field, OldValue: *c.oldValue, NewValue: *c.value, EditedAt: time.Now()}
+		r.edits[fileID] = append(r.edits[fileID], edit)
+		*c.oldValue = *c.value
+	}
+	r.images[fileID] = img
+
+	return img, nil
+}
+
+// ImageEdits returns the edit history of the image, the latest edit first.
+func (r *ImageRepo) ImageEdits(_ context.Context, fileID string) ([]domain.ImageEdit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.edits[fileID]
+	edits := make([]domain.ImageEdit, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		edits = append(edits, stored[i])
+	}
+
+	return edits, nil
+}
+
+// ListEntities returns entities with the number of images they were found in, the most frequent first.
+// Empty kind means any kind.
+func (r *ImageRepo) ListEntities(_ context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[domain.Entity]int)
+	for fileID, entities := range r.entities {
+		if _, ok := r.images[fileID]; !ok {
+			continue
+		}
+		for _, e := range entities {
+			if kind == "" || e.Kind == kind {
+				counts[e]++
+			}
+		}
+	}
+
+	entities := make([]domain.EntityCount, 0, len(counts))
+	for e, n := range counts {
+		entities = append(entities, domain.EntityCount{Entity: e, Count: n})
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		a, b := entities[i], entities[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Value < b.Value
+	})
+	if len(entities) > limit {
+		entities = entities[:limit]
+	}
+
+	return entities, nil
+}
+
+// ImageFindings returns findings of the image in the order they were found.
+func (r *ImageRepo) ImageFindings(_ context.Context, fileID string) ([]domain.Finding, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]domain.Finding{}, r.findings[fileID]...), nil
+}
+
+// ListFindings returns images with findings of the rule, the latest first. Empty rule means any rule.
+func (r *ImageRepo) ListFindings(_ context.Context, rule string, page, perPage int) ([]domain.FlaggedImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	flagged := r.filter(func(img domain.Image) bool {
+		for _, f := range r.findings[img.FileID] {
+			if rule == "" || f.Rule == rule {
+				return true
+			}
+		}
+		return false
+	})
+
+	images := make([]domain.FlaggedImage, 0)
+	for _, img := range paginate(flagged, page, perPage) {
+		images = append(images, domain.FlaggedImage{
+			FileID:       img.FileID,
+			LastModified: img.LastModified,
+			Findings:     append([]domain.Finding{}, r.findings[img.FileID]...),
+		})
+	}
+
+	return images, nil
+}
+
+// IndexQueueDepth is always zero, the repository is used by a single process that does not need the queue.
+func (r *ImageRepo) IndexQueueDepth(context.Context) (pending, failed int, err error) {
+	return 0, 0, nil
+}
+
+// filter returns the images accepted by the function, the latest first.
+func (r *ImageRepo) filter(accept func(domain.Image) bool) []domain.Image {
+	images := make([]domain.Image, 0)
+	for _, img := range r.images {
+		if accept(img) {
+			images = append(images, img)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return latestFirst(images[i], images[j]) })
+
+	return images
+}
+
+// matches checks the text and the filters of the query against the image.
+func (r *ImageRepo) matches(img domain.Image, q search.Query) bool {
+	for _, kind := range q.Has {
+		if !r.hasEntity(img.FileID, func(e domain.Entity) bool { return e.Kind == kind }) {
+			return false
+		}
+	}
+	for _, want := range q.Entities {
+		if !r.hasEntity(img.FileID, func(e domain.Entity) bool {
+			return e.Kind == want.Kind && strings.EqualFold(e.Value, want.Value)
+		}) {
+			return false
+		}
+	}
+	for _, tag := range q.Tags {
+		if _, ok := r.imageTags[img.FileID][tag]; !ok {
+			return false
+		}
+	}
+	for _, m := range q.Meta {
+		if !strings.EqualFold(img.Metadata[m.Key], m.Value) {
+			return false
+		}
+	}
+
+	if q.Text == "" {
+		return true
+	}
+
+	text := searchable(img)
+	if strings.Contains(strings.ToLower(text), strings.ToLower(q.Text)) {
+		return true
+	}
+
+	want := tokenize(q.Text)
+	if len(want) == 0 {
+		return false
+	}
+	words := tokenize(text)
+	for w := range want {
+		if _, ok := words[w]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *ImageRepo) hasEntity(fileID string, match func(domain.Entity) bool) bool {
+	for _, e := range r.entities[fileID] {
+		if match(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextID returns ids for saved searches, feed tokens, webhooks and deliveries.
+func (r *ImageRepo) nextID() int {
+	r.lastID++
+
+	return r.lastID
+}
+
+// searchable is the text that searches look at.
+func searchable(img domain.Image) string {
+	return strings.Join([]string{img.Description, img.CorrectedDescription, img.Annotations, img.SourceURL}, " ")
+}
+
+// tokenize returns the lower case words of the text.
+func tokenize(text string) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[w] = struct{}{}
+	}
+
+	return words
+}
+
+func distance(a, b int64) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+func latestFirst(a, b domain.Image) bool {
+	if !a.LastModified.Equal(b.LastModified) {
+		return a.LastModified.After(b.LastModified)
+	}
+
+	return a.FileID < b.FileID
+}
+
+func paginate[T any](items []T, page, perPage int) []T {
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * perPage
+	if start >= len(items) {
+		return items[:0]
+	}
+
+	return items[start:min(start+perPage, len(items))]
+}
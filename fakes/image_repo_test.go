@@ -0,0 +1,251 @@
+package fakes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestImageRepo_Search(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+	tt.NoErr(r.Upsert(ctx, domain.Image{
+		FileID:       "error.jpg",
+		Description:  "Connection timeout on 10.0.0.1",
+		LastModified: time.Unix(100, 0),
+		Entities:     []domain.Entity{{Kind: domain.EntityIP, Value: "10.0.0.1"}},
+		Tags:         []string{"errors"},
+	}))
+	tt.NoErr(r.Upsert(ctx, domain.Image{
+		FileID:       "dashboard.jpg",
+		Description:  "grafana dashboard, no timeout",
+		LastModified: time.Unix(200, 0),
+	}))
+
+	testCases := []struct {
+		search string
+		want   []string
+	}{
+		{"timeout", []string{"dashboard.jpg", "error.jpg"}},
+		{"TIMEOUT connection", []string{"error.jpg"}},
+		{"grafana dash", []string{"dashboard.jpg"}},
+		{"has:ip", []string{"error.jpg"}},
+		{"entity:ip=10.0.0.1 timeout", []string{"error.jpg"}},
+		{"tag:errors", []string{"error.jpg"}},
+		{"timeout tag:other", nil},
+		{"kibana", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.search, func(t *testing.T) {
+			tt := is.New(t)
+
+			images, err := r.FindByDescription(ctx, tc.search, 1, 10)
+			tt.NoErr(err)
+
+			var ids []string
+			for _, img := range images {
+				ids = append(ids, img.FileID)
+			}
+			tt.Equal(ids, tc.want)
+		})
+	}
+
+	images, err := r.FindByDescription(ctx, "timeout", 2, 1)
+	tt.NoErr(err)
+	tt.Equal(len(images), 1)
+	tt.Equal(images[0].FileID, "error.jpg")
+
+	ok, err := r.Matches(ctx, "error.jpg", "connection has:ip")
+	tt.NoErr(err)
+	tt.True(ok)
+}
+
+func TestImageRepo_Images(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+
+	last, err := r.GetLastModified(ctx)
+	tt.NoErr(err)
+	tt.Equal(last, time.Unix(0, 0).UTC())
+
+	_, err = r.Get(ctx, "a.jpg")
+	tt.True(errors.Is(err, ErrRecordNotFound))
+
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "a.jpg", Description: "first", LastModified: time.Unix(100, 0)}))
+	annotations := "my note"
+	_, err = r.Patch(ctx, "a.jpg", domain.ImagePatch{Annotations: &annotations})
+	tt.NoErr(err)
+
+	// indexing again keeps user edits and the tags that were set by hand
+	tt.NoErr(r.TagImage(ctx, "a.jpg", "keep"))
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "a.jpg", Description: "second", LastModified: time.Unix(200, 0), Tags: []string{}}))
+
+	img, err := r.Get(ctx, "a.jpg")
+	tt.NoErr(err)
+	tt.Equal(img.Description, "second")
+	tt.Equal(img.Annotations, "my note")
+
+	tags, err := r.ImageTags(ctx, "a.jpg")
+	tt.NoErr(err)
+	tt.Equal(tags, []domain.ImageTag{{Name: "keep", Source: domain.TagSourceManual}})
+
+	tagsOf, err := r.TagsOfImages(ctx, []string{"a.jpg", "missing"})
+	tt.NoErr(err)
+	tt.Equal(tagsOf, map[string][]domain.ImageTag{"a.jpg": tags})
+
+	findings := []domain.Finding{{Rule: "github_token", Preview: "ghp_****"}}
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "a.jpg", Description: "second", LastModified: time.Unix(200, 0), Findings: findings}))
+	stored, err := r.ImageFindings(ctx, "a.jpg")
+	tt.NoErr(err)
+	tt.Equal(stored, findings)
+
+	edits, err := r.ImageEdits(ctx, "a.jpg")
+	tt.NoErr(err)
+	tt.Equal(len(edits), 1)
+	tt.Equal(edits[0].NewValue, "my note")
+
+	last, err = r.GetLastModified(ctx)
+	tt.NoErr(err)
+	tt.Equal(last, time.Unix(200, 0))
+
+	tt.NoErr(r.Delete(ctx, "a.jpg"))
+	_, err = r.Get(ctx, "a.jpg")
+	tt.True(errors.Is(err, ErrRecordNotFound))
+}
+
+func TestImageRepo_FindSimilar(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "a.jpg", PHash: 0b1111}))
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "b.jpg", PHash: 0b0111}))
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "c.jpg", PHash: 0b0001}))
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "no-hash.jpg"}))
+
+	similar, err := r.FindSimilar(ctx, "a.jpg", 3, 10)
+	tt.NoErr(err)
+	tt.Equal(len(similar), 2)
+	tt.Equal(similar[0].FileID, "b.jpg")
+	tt.Equal(similar[0].Distance, 1)
+	tt.Equal(similar[1].FileID, "c.jpg")
+	tt.Equal(similar[1].Distance, 3)
+}
+
+func TestImageRepo_FindCandidates(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "a.jpg", PHash: 0b1111}))
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "b.jpg", Description: "kubernetes OOMKilled"}))
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "c.jpg", Description: "kubernetes OOMKiled"}))
+	corrected := "kubernetes OOMKilled"
+	_, err := r.Patch(ctx, "c.jpg", domain.ImagePatch{CorrectedDescription: &corrected})
+	tt.NoErr(err)
+	corrected = "kubernetes pending"
+	_, err = r.Patch(ctx, "b.jpg", domain.ImagePatch{CorrectedDescription: &corrected})
+	tt.NoErr(err)
+
+	candidates, err := r.FindCandidates(ctx, 0b1110, []string{"oomkilled"}, 1, 10)
+	tt.NoErr(err)
+	tt.Equal(len(candidates), 2)
+	tt.Equal(candidates[0].FileID, "a.jpg") // visually close images go first
+	tt.Equal(candidates[1].FileID, "c.jpg") // the corrected text replaces the ocr output
+}
+
+func TestImageRepo_SavedSearchMatches(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+	tt.NoErr(r.Upsert(ctx, domain.Image{FileID: "a.jpg", Description: "OOMKilled"}))
+	s, err := r.CreateSavedSearch(ctx, "oom", "OOMKilled")
+	tt.NoErr(err)
+
+	added, err := r.SetSavedSearchMatch(ctx, s.ID, "a.jpg", true)
+	tt.NoErr(err)
+	tt.True(added)
+	added, err = r.SetSavedSearchMatch(ctx, s.ID, "a.jpg", true)
+	tt.NoErr(err)
+	tt.True(!added) // reindexing does not add the match again
+
+	// a deleted image matches again when it is indexed again
+	tt.NoErr(r.Delete(ctx, "a.jpg"))
+	added, err = r.SetSavedSearchMatch(ctx, s.ID, "a.jpg", true)
+	tt.NoErr(err)
+	tt.True(added)
+}
+
+func TestImageRepo_Events(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+
+	// appends before the wait are not lost
+	seq, err := r.AppendEvent(ctx, domain.Event{Type: domain.EventImageIndexed, FileID: "a.jpg"})
+	tt.NoErr(err)
+	tt.Equal(seq, int64(1))
+	tt.NoErr(r.Wait(ctx))
+
+	_, err = r.AppendEvent(ctx, domain.Event{Type: domain.EventImageDeleted, FileID: "a.jpg"})
+	tt.NoErr(err)
+
+	events, err := r.EventsAfter(ctx, 1, 10)
+	tt.NoErr(err)
+	tt.Equal(len(events), 1)
+	tt.Equal(events[0].Type, domain.EventImageDeleted)
+
+	// sequence numbers keep growing after old events are removed
+	tt.NoErr(r.DeleteEventsBefore(ctx, time.Now().Add(time.Minute)))
+	seq, err = r.LastEventSeq(ctx)
+	tt.NoErr(err)
+	tt.Equal(seq, int64(2))
+}
+
+func TestImageRepo_Deliveries(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	r := NewImageRepo()
+	all, err := r.CreateWebhook(ctx, "https://all.example.com", nil, "secret")
+	tt.NoErr(err)
+	_, err = r.CreateWebhook(ctx, "https://deleted.example.com", []string{domain.EventImageDeleted}, "secret")
+	tt.NoErr(err)
+
+	tt.NoErr(r.EnqueueDeliveries(ctx, domain.EventImageIndexed, []byte(`{}`)))
+
+	claimed, err := r.ClaimDeliveries(ctx, 10, time.Minute)
+	tt.NoErr(err)
+	tt.Equal(len(claimed), 1)
+	tt.Equal(claimed[0].URL, "https://all.example.com")
+	tt.Equal(claimed[0].Secret, "secret")
+	tt.Equal(claimed[0].Attempts, 1)
+
+	// claimed deliveries are leased
+	again, err := r.ClaimDeliveries(ctx, 10, time.Minute)
+	tt.NoErr(err)
+	tt.Equal(len(again), 0)
+
+	d := claimed[0].WebhookDelivery
+	d.Status = domain.DeliveryDelivered
+	tt.NoErr(r.UpdateDelivery(ctx, d))
+
+	deliveries, err := r.ListDeliveries(ctx, all.ID, domain.DeliveryDelivered, 1, 10)
+	tt.NoErr(err)
+	tt.Equal(len(deliveries), 1)
+
+	tt.NoErr(r.DeleteWebhook(ctx, all.ID))
+	err = r.DeleteWebhook(ctx, all.ID)
+	tt.True(errors.Is(err, ErrRecordNotFound))
+}
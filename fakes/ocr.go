@@ -0,0 +1,75 @@
+package fakes
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SidecarExt is the extension of the files with the text of images, e.g. shot.jpg.txt or shot.txt for shot.jpg.
+const SidecarExt = ".txt"
+
+// OCR returns the text from sidecar files instead of recognizing it.
+// Downloaded copies of images have random names, so the text is also registered by the content of the image.
+type OCR struct {
+	mu    sync.Mutex
+	texts map[[sha256.Size]byte]string
+}
+
+func NewOCR() *OCR {
+	return &OCR{texts: make(map[[sha256.Size]byte]string)}
+}
+
+// SetText registers the text of the image with the content.
+func (o *OCR) SetText(image []byte, text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.texts[sha256.Sum256(image)] = text
+}
+
+// Run returns the text of the sidecar file next to the image or the text registered for its content.
+// Images without text have an empty text, like images without text for a real engine.
+func (o *OCR) Run(file string) (string, error) {
+	text, ok, err := ReadSidecar(file)
+	if err != nil || ok {
+		return text, err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading image %s, %w", file, err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.texts[sha256.Sum256(data)], nil
+}
+
+// ReadSidecar returns the text of the sidecar file of the image, ok is false if there is none.
+func ReadSidecar(file string) (text string, ok bool, err error) {
+	candidates := []string{file + SidecarExt, strings.TrimSuffix(file, filepath.Ext(file)) + SidecarExt}
+	for _, p := range candidates {
+		if p == file {
+			continue
+		}
+
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("reading sidecar %s, %w", p, err)
+		}
+
+		return strings.TrimSpace(string(data)), true, nil
+	}
+
+	return "", false, nil
+}
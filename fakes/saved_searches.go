@@ -0,0 +1,153 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type searchMatch struct {
+	searchID int
+	fileID   string
+}
+
+type feedToken struct {
+	domain.FeedToken
+	hash string
+}
+
+func (r *ImageRepo) ListSavedSearches(_ context.Context) ([]domain.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]domain.SavedSearch{}, r.searches...), nil
+}
+
+func (r *ImageRepo) GetSavedSearch(_ context.Context, id int) (domain.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.searches {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+
+	return domain.SavedSearch{}, fmt.Errorf("saved search %d not found, %w", id, ErrRecordNotFound)
+}
+
+func (r *ImageRepo) CreateSavedSearch(_ context.Context, name, query string) (domain.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := domain.SavedSearch{ID: r.nextID(), Name: name, Query: query, CreatedAt: time.Now()}
+	r.searches = append(r.searches, s)
+
+	return s, nil
+}
+
+func (r *ImageRepo) UpdateSavedSearch(_ context.Context, id int, name, query string) (domain.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.searches {
+		if s.ID == id {
+			r.searches[i].Name, r.searches[i].Query = name, query
+			return r.searches[i], nil
+		}
+	}
+
+	return domain.SavedSearch{}, fmt.Errorf("saved search %d not found, %w", id, ErrRecordNotFound)
+}
+
+func (r *ImageRepo) DeleteSavedSearch(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.searches {
+		if s.ID == id {
+			r.searches = append(r.searches[:i], r.searches[i+1:]...)
+			for m := range r.searchMatches {
+				if m.searchID == id {
+					delete(r.searchMatches, m)
+				}
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("saved search %d not found, %w", id, ErrRecordNotFound)
+}
+
+// SetSavedSearchMatch stores whether the image matches the saved search and reports if it has just started to match.
+func (r *ImageRepo) SetSavedSearchMatch(_ context.Context, searchID int, fileID string, matches bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := searchMatch{searchID: searchID, fileID: fileID}
+	if !matches {
+		delete(r.searchMatches, m)
+		return false, nil
+	}
+	if _, ok := r.searchMatches[m]; ok {
+		return false, nil
+	}
+	r.searchMatches[m] = struct{}{}
+
+	return true, nil
+}
+
+func (r *ImageRepo) ListFeedTokens(_ context.Context) ([]domain.FeedToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens := make([]domain.FeedToken, 0, len(r.feedTokens))
+	for _, t := range r.feedTokens {
+		tokens = append(tokens, t.FeedToken)
+	}
+
+	return tokens, nil
+}
+
+// CreateFeedToken stores the hash of a new token, the returned token has no value.
+func (r *ImageRepo) CreateFeedToken(_ context.Context, name, hash string) (domain.FeedToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := feedToken{FeedToken: domain.FeedToken{ID: r.nextID(), Name: name, CreatedAt: time.Now()}, hash: hash}
+	r.feedTokens = append(r.feedTokens, t)
+
+	return t.FeedToken, nil
+}
+
+func (r *ImageRepo) DeleteFeedToken(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.feedTokens {
+		if t.ID == id {
+			r.feedTokens = append(r.feedTokens[:i], r.feedTokens[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("feed token %d not found, %w", id, ErrRecordNotFound)
+}
+
+// UseFeedToken records that the token with the hash was used, ErrRecordNotFound means it is not valid.
+func (r *ImageRepo) UseFeedToken(_ context.Context, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.feedTokens {
+		if t.hash == hash {
+			now := time.Now()
+			r.feedTokens[i].LastUsedAt = &now
+			return nil
+		}
+	}
+
+	return fmt.Errorf("feed token not found, %w", ErrRecordNotFound)
+}
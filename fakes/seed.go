@@ -0,0 +1,62 @@
+package fakes
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Seed loads the files of the directory into the storage, keys are slash separated paths relative to the directory.
+// Sidecar files are not stored, their text is registered with the OCR for the image they belong to.
+// Hidden files and directories are skipped. Seed returns the number of stored files.
+func Seed(dir string, s *Storage, o *OCR) (int, error) {
+	n := 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.HasSuffix(p, SidecarExt) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return fmt.Errorf("resolving key of %s, %w", p, err)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("reading info of %s, %w", p, err)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("reading %s, %w", p, err)
+		}
+
+		s.Put(path.Clean(filepath.ToSlash(rel)), data, info.ModTime())
+		n++
+
+		text, ok, err := ReadSidecar(p)
+		if err != nil {
+			return err
+		}
+		if ok {
+			o.SetText(data, text)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return n, fmt.Errorf("seeding from %s, %w", dir, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,167 @@
+// Package fakes provides in-memory implementations of the storage, the image repository and the OCR engine.
+// They back the demo mode and can be used in tests of services that depend on the indexer interfaces.
+package fakes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+)
+
+const tempFilePrefix = "foxyshot_indexer_"
+
+type object struct {
+	data         []byte
+	lastModified time.Time
+}
+
+// Storage keeps files in memory, it is safe for concurrent use.
+type Storage struct {
+	mu               sync.Mutex
+	files            map[string]object
+	excludedPrefixes []string
+}
+
+func NewStorage() *Storage {
+	return &Storage{files: make(map[string]object)}
+}
+
+// Put stores the file with the given modification time.
+func (s *Storage) Put(key string, data []byte, lastModified time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[key] = object{data: bytes.Clone(data), lastModified: lastModified}
+}
+
+// Exclude hides files with the given key prefix from ListFiles.
+func (s *Storage) Exclude(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.excludedPrefixes = append(s.excludedPrefixes, prefix)
+}
+
+// ListFiles returns files with the extension that were modified at or after start, ordered by key.
+func (s *Storage) ListFiles(start time.Time, ext string) ([]domain.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var files []domain.File
+	for key, o := range s.files {
+		if !imageformat.Match(key, ext) || s.excluded(key) || o.lastModified.Before(start) {
+			continue
+		}
+		files = append(files, domain.File{Key: key, LastModified: o.lastModified})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Key < files[j].Key })
+
+	return files, nil
+}
+
+// Metadata is always empty, the demo has no user metadata.
+func (s *Storage) Metadata(ctx context.Context, key string) (map[string]string, error) {
+	_, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *Storage) Stat(_ context.Context, key string) (domain.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.files[key]
+	if !ok {
+		return domain.File{}, fmt.Errorf("getting file %s, %w", key, domain.ErrFileNotFound)
+	}
+
+	return domain.File{Key: key, LastModified: o.lastModified}, nil
+}
+
+// Download copies the file to a temporary file, the caller removes it after use.
+func (s *Storage) Download(key string) (*os.File, error) {
+	data, err := s.data(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", tempFilePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("creating local image file, %w", err)
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		return f, fmt.Errorf("copying file %s, %w", key, err)
+	}
+
+	return f, nil
+}
+
+func (s *Storage) Read(_ context.Context, key string) (io.ReadCloser, error) {
+	data, err := s.data(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Upload stores the file, the modification time is the current time.
+func (s *Storage) Upload(_ context.Context, key string, body io.ReadSeeker, _ string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("reading file %s, %w", key, err)
+	}
+
+	s.Put(key, data, time.Now())
+
+	return nil
+}
+
+func (s *Storage) DeleteFile(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[key]; !ok {
+		return fmt.Errorf("deleting file %s, %w", key, domain.ErrFileNotFound)
+	}
+	delete(s.files, key)
+
+	return nil
+}
+
+// data returns the content of the file, it must not be modified.
+func (s *Storage) data(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.files[key]
+	if !ok {
+		return nil, fmt.Errorf("reading file %s, %w", key, domain.ErrFileNotFound)
+	}
+
+	return o.data, nil
+}
+
+func (s *Storage) excluded(key string) bool {
+	for _, prefix := range s.excludedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package fakes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestStorage(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	s := NewStorage()
+	s.Put("new.jpg", []byte("new"), time.Unix(300, 0))
+	s.Put("old.jpg", []byte("old"), time.Unix(100, 0))
+	s.Put("other.png", []byte("png"), time.Unix(300, 0))
+	s.Put("redacted/new.jpg", []byte("redacted"), time.Unix(300, 0))
+	s.Exclude("redacted/")
+
+	files, err := s.ListFiles(time.Unix(200, 0), ".jpg")
+	tt.NoErr(err)
+	tt.Equal(files, []domain.File{{Key: "new.jpg", LastModified: time.Unix(300, 0)}})
+
+	err = s.Upload(ctx, "uploaded.jpg", bytes.NewReader([]byte("uploaded")), "image/jpeg")
+	tt.NoErr(err)
+
+	f, err := s.Download("uploaded.jpg")
+	tt.NoErr(err)
+	defer os.Remove(f.Name())
+	data, err := os.ReadFile(f.Name())
+	tt.NoErr(err)
+	tt.Equal(string(data), "uploaded")
+
+	err = s.DeleteFile(ctx, "new.jpg")
+	tt.NoErr(err)
+
+	_, err = s.Read(ctx, "new.jpg")
+	tt.True(errors.Is(err, domain.ErrFileNotFound))
+	_, err = s.Stat(ctx, "new.jpg")
+	tt.True(errors.Is(err, domain.ErrFileNotFound))
+	err = s.DeleteFile(ctx, "new.jpg")
+	tt.True(errors.Is(err, domain.ErrFileNotFound))
+
+	r, err := s.Read(ctx, "old.jpg")
+	tt.NoErr(err)
+	data, err = io.ReadAll(r)
+	tt.NoErr(err)
+	tt.Equal(string(data), "old")
+}
+
+func TestSeed(t *testing.T) {
+	tt := is.New(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"error.jpg":              "error image",
+		"error.jpg.txt":          "connection timeout",
+		"2024/dashboard.jpg":     "dashboard image",
+		"2024/dashboard.txt":     "grafana dashboard\n",
+		"no-text.jpg":            "plain image",
+		".thumbnails/hidden.jpg": "hidden image",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		tt.NoErr(os.MkdirAll(filepath.Dir(p), 0o755))
+		tt.NoErr(os.WriteFile(p, []byte(content), 0o600))
+	}
+
+	s, o := NewStorage(), NewOCR()
+	n, err := Seed(dir, s, o)
+	tt.NoErr(err)
+	tt.Equal(n, 3) // sidecars and hidden files are not stored
+
+	texts := map[string]string{
+		"error.jpg":          "connection timeout",
+		"2024/dashboard.jpg": "grafana dashboard",
+		"no-text.jpg":        "",
+	}
+	for key, want := range texts {
+		f, err := s.Download(key)
+		tt.NoErr(err)
+		tt.NoErr(f.Close())
+
+		// downloaded copies have random names, the text is found by the content
+		text, err := o.Run(f.Name())
+		tt.NoErr(err)
+		tt.Equal(text, want)
+		tt.NoErr(os.Remove(f.Name()))
+	}
+}
+
+func TestOCR_Sidecar(t *testing.T) {
+	tt := is.New(t)
+
+	dir := t.TempDir()
+	img := filepath.Join(dir, "shot.jpg")
+	tt.NoErr(os.WriteFile(img, []byte("image"), 0o600))
+	tt.NoErr(os.WriteFile(img+SidecarExt, []byte("text next to the image"), 0o600))
+
+	text, err := NewOCR().Run(img)
+	tt.NoErr(err)
+	tt.Equal(text, "text next to the image")
+}
@@ -0,0 +1,150 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// ListTags returns all tags with the number of tagged images, the most used first.
+func (r *ImageRepo) ListTags(_ context.Context) ([]domain.TagCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]int, len(r.tags))
+	for name := range r.tags {
+		counts[name] = 0
+	}
+	for _, tags := range r.imageTags {
+		for name := range tags {
+			counts[name]++
+		}
+	}
+
+	tags := make([]domain.TagCount, 0, len(counts))
+	for name, n := range counts {
+		tags = append(tags, domain.TagCount{Name: name, Count: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+// CreateTag creates the tag if it does not exist yet.
+func (r *ImageRepo) CreateTag(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tags[name] = struct{}{}
+
+	return nil
+}
+
+// DeleteTag deletes the tag and detaches it from all images.
+func (r *ImageRepo) DeleteTag(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[name]; !ok {
+		return fmt.Errorf("tag %s not found, %w", name, ErrRecordNotFound)
+	}
+	delete(r.tags, name)
+	for _, tags := range r.imageTags {
+		delete(tags, name)
+	}
+
+	return nil
+}
+
+// ImageTags returns tags of the image sorted by name.
+func (r *ImageRepo) ImageTags(_ context.Context, fileID string) ([]domain.ImageTag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tags := make([]domain.ImageTag, 0, len(r.imageTags[fileID]))
+	for name, source := range r.imageTags[fileID] {
+		tags = append(tags, domain.ImageTag{Name: name, Source: source})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	return tags, nil
+}
+
+// TagsOfImages returns tags of the images by file id sorted by name, images without tags are missing from the map.
+func (r *ImageRepo) TagsOfImages(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error) {
+	tags := make(map[string][]domain.ImageTag)
+	for _, fileID := range fileIDs {
+		imageTags, _ := r.ImageTags(ctx, fileID)
+		if len(imageTags) > 0 {
+			tags[fileID] = imageTags
+		}
+	}
+
+	return tags, nil
+}
+
+// TagImage attaches the tag to the image, the tag is created if needed.
+// A manual tag replaces a tag with the same name assigned by a rule, so that rules never remove it.
+func (r *ImageRepo) TagImage(_ context.Context, fileID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.images[fileID]; !ok {
+		return fmt.Errorf("tagging image id=%s, %w", fileID, ErrRecordNotFound)
+	}
+	r.attachTag(fileID, name, domain.TagSourceManual)
+
+	return nil
+}
+
+// UntagImage detaches the tag from the image.
+func (r *ImageRepo) UntagImage(_ context.Context, fileID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.imageTags[fileID], name)
+
+	return nil
+}
+
+// SetRuleTags replaces tags assigned by rules to the image. Manual tags are kept.
+func (r *ImageRepo) SetRuleTags(_ context.Context, fileID string, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.setRuleTags(fileID, tags)
+
+	return nil
+}
+
+func (r *ImageRepo) setRuleTags(fileID string, tags []string) {
+	for name, source := range r.imageTags[fileID] {
+		if source == domain.TagSourceRule {
+			delete(r.imageTags[fileID], name)
+		}
+	}
+
+	for _, name := range tags {
+		r.attachTag(fileID, name, domain.TagSourceRule)
+	}
+}
+
+// attachTag creates the tag and attaches it to the image, rule tags never overwrite manual ones.
+func (r *ImageRepo) attachTag(fileID, name, source string) {
+	r.tags[name] = struct{}{}
+
+	if r.imageTags[fileID] == nil {
+		r.imageTags[fileID] = make(map[string]string)
+	}
+	if r.imageTags[fileID][name] == domain.TagSourceManual {
+		return
+	}
+	r.imageTags[fileID][name] = source
+}
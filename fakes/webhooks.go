@@ -0,0 +1,179 @@
+package fakes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+func (r *ImageRepo) ListWebhooks(_ context.Context) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhooks := make([]domain.Webhook, 0, len(r.webhooks))
+	for _, w := range r.webhooks {
+		w.Secret = ""
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns the webhook without its secret.
+func (r *ImageRepo) GetWebhook(_ context.Context, id int) (domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.webhook(id)
+	if !ok {
+		return domain.Webhook{}, fmt.Errorf("webhook %d not found, %w", id, ErrRecordNotFound)
+	}
+	w.Secret = ""
+
+	return w, nil
+}
+
+func (r *ImageRepo) CreateWebhook(_ context.Context, url string, events []string, secret string) (domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := domain.Webhook{
+		ID:        r.nextID(),
+		URL:       url,
+		Events:    append([]string{}, events...),
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+	r.webhooks = append(r.webhooks, w)
+
+	return w, nil
+}
+
+// DeleteWebhook deletes the webhook together with its deliveries.
+func (r *ImageRepo) DeleteWebhook(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.webhooks)
+	r.webhooks = slices.DeleteFunc(r.webhooks, func(w domain.Webhook) bool { return w.ID == id })
+	if len(r.webhooks) == n {
+		return fmt.Errorf("webhook %d not found, %w", id, ErrRecordNotFound)
+	}
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d domain.WebhookDelivery) bool { return d.WebhookID == id })
+
+	return nil
+}
+
+// EnqueueDeliveries adds a pending delivery of the event for every webhook subscribed to it.
+func (r *ImageRepo) EnqueueDeliveries(_ context.Context, event string, payload []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, w := range r.webhooks {
+		if len(w.Events) > 0 && !slices.Contains(w.Events, event) {
+			continue
+		}
+		r.deliveries = append(r.deliveries, domain.WebhookDelivery{
+			ID:            int64(r.nextID()),
+			WebhookID:     w.ID,
+			Event:         event,
+			Payload:       bytes.Clone(payload),
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return nil
+}
+
+// ClaimDeliveries returns due pending deliveries and postpones them for the lease,
+// if they are not updated in time they become due again.
+func (r *ImageRepo) ClaimDeliveries(_ context.Context, limit int, lease time.Duration) ([]domain.ClaimedDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	due := make([]int, 0)
+	for i, d := range r.deliveries {
+		if d.Status == domain.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return r.deliveries[due[i]].NextAttemptAt.Before(r.deliveries[due[j]].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]domain.ClaimedDelivery, 0, len(due))
+	for _, i := range due {
+		d := &r.deliveries[i]
+		d.Attempts++
+		d.NextAttemptAt = now.Add(lease)
+
+		w, _ := r.webhook(d.WebhookID)
+		claimed = append(claimed, domain.ClaimedDelivery{WebhookDelivery: *d, URL: w.URL, Secret: w.Secret})
+	}
+
+	return claimed, nil
+}
+
+// UpdateDelivery stores the result of a delivery attempt.
+func (r *ImageRepo) UpdateDelivery(_ context.Context, d domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		stored := &r.deliveries[i]
+		if stored.ID != d.ID {
+			continue
+		}
+		stored.Status = d.Status
+		stored.ResponseCode = d.ResponseCode
+		stored.LastError = d.LastError
+		stored.NextAttemptAt = d.NextAttemptAt
+		stored.DeliveredAt = d.DeliveredAt
+	}
+
+	return nil
+}
+
+// ListDeliveries returns deliveries of the webhook, the latest first. Empty status means any status.
+func (r *ImageRepo) ListDeliveries(
+	_ context.Context,
+	webhookID int,
+	status string,
+	page,
+	perPage int,
+) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		d := r.deliveries[i]
+		if d.WebhookID == webhookID && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	return paginate(deliveries, page, perPage), nil
+}
+
+func (r *ImageRepo) webhook(id int) (domain.Webhook, bool) {
+	for _, w := range r.webhooks {
+		if w.ID == id {
+			return w, true
+		}
+	}
+
+	return domain.Webhook{}, false
+}
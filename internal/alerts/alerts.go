@@ -0,0 +1,94 @@
+// Package alerts evaluates saved searches against newly indexed images.
+package alerts
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// Event is the webhook event of alerts.
+const Event = "saved_search.matched"
+
+//go:generate moq -out alerts_moq_test.go . searchRepo notifier tracker
+type searchRepo interface {
+	ListSavedSearches(ctx context.Context) ([]domain.SavedSearch, error)
+	Matches(ctx context.Context, fileID, searchString string) (bool, error)
+	SetSavedSearchMatch(ctx context.Context, searchID int, fileID string, matches bool) (bool, error)
+}
+
+type notifier interface {
+	Enqueue(event string, payload any)
+}
+
+type tracker interface {
+	OnAlert(search string)
+}
+
+// Evaluator handles indexing events, images that start to match a saved search are logged, counted
+// and sent to the notifiers. Reindexing an image that already matches does not alert again.
+type Evaluator struct {
+	repo      searchRepo
+	notifiers []notifier
+	tracker   tracker
+	log       *slog.Logger
+}
+
+func NewEvaluator(repo searchRepo, tracker tracker, log *slog.Logger, notifiers ...notifier) *Evaluator {
+	return &Evaluator{
+		repo:      repo,
+		notifiers: notifiers,
+		tracker:   tracker,
+		log:       log.WithGroup("ALERTS"),
+	}
+}
+
+func (e *Evaluator) Handle(ctx context.Context, ev domain.Event) {
+	if ev.Type != domain.EventImageIndexed || ev.Image == nil {
+		return
+	}
+
+	searches, err := e.repo.ListSavedSearches(ctx)
+	if err != nil {
+		e.log.Error("listing saved searches", slog.String("err", err.Error()))
+		return
+	}
+
+	for _, s := range searches {
+		matches, err := e.repo.Matches(ctx, ev.FileID, s.Query)
+		if err != nil {
+			e.log.Error("matching saved search",
+				slog.Int("search", s.ID),
+				slog.String("file", ev.FileID),
+				slog.String("err", err.Error()),
+			)
+			continue
+		}
+
+		added, err := e.repo.SetSavedSearchMatch(ctx, s.ID, ev.FileID, matches)
+		if err != nil {
+			e.log.Error("storing saved search match",
+				slog.Int("search", s.ID),
+				slog.String("file", ev.FileID),
+				slog.String("err", err.Error()),
+			)
+			continue
+		}
+		if !added {
+			continue
+		}
+
+		e.log.Info("saved search matched",
+			slog.Int("search", s.ID),
+			slog.String("name", s.Name),
+			slog.String("file", ev.FileID),
+		)
+		e.tracker.OnAlert(s.Name)
+
+		alert := domain.Alert{Search: s, Image: *ev.Image, Time: ev.Time}
+		for _, n := range e.notifiers {
+			n.Enqueue(Event, alert)
+		}
+	}
+}
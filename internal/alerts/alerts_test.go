@@ -0,0 +1,97 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestEvaluator_Handle(t *testing.T) {
+	img := domain.Image{FileID: "expected-id", Description: "pod OOMKilled"}
+	event := domain.Event{Type: domain.EventImageIndexed, FileID: img.FileID, Time: time.Unix(100, 0), Image: &img}
+
+	newRepo := func() *searchRepoMock {
+		return &searchRepoMock{
+			ListSavedSearchesFunc: func(ctx context.Context) ([]domain.SavedSearch, error) {
+				return []domain.SavedSearch{{ID: 1, Name: "oom", Query: "OOMKilled"}, {ID: 2, Name: "panics", Query: "panic:"}}, nil
+			},
+			MatchesFunc: func(ctx context.Context, fileID, searchString string) (bool, error) {
+				return searchString == "OOMKilled", nil
+			},
+			SetSavedSearchMatchFunc: func(ctx context.Context, searchID int, fileID string, matches bool) (bool, error) {
+				return matches, nil
+			},
+		}
+	}
+
+	t.Run("notifies about matches", func(t *testing.T) {
+		tt := is.New(t)
+
+		repo := newRepo()
+		n := &notifierMock{EnqueueFunc: func(event string, payload any) {}}
+		tr := &trackerMock{OnAlertFunc: func(search string) {}}
+
+		NewEvaluator(repo, tr, slog.Default(), n).Handle(context.Background(), event)
+
+		tt.Equal(len(repo.MatchesCalls()), 2)
+		tt.Equal(len(n.EnqueueCalls()), 1)
+		tt.Equal(n.EnqueueCalls()[0].Event, Event)
+		tt.Equal(n.EnqueueCalls()[0].Payload, domain.Alert{
+			Search: domain.SavedSearch{ID: 1, Name: "oom", Query: "OOMKilled"},
+			Image:  img,
+			Time:   time.Unix(100, 0),
+		})
+		tt.Equal(tr.OnAlertCalls()[0].Search, "oom")
+	})
+
+	t.Run("match errors do not stop other searches", func(t *testing.T) {
+		tt := is.New(t)
+
+		repo := newRepo()
+		repo.MatchesFunc = func(ctx context.Context, fileID, searchString string) (bool, error) {
+			if searchString == "OOMKilled" {
+				return false, errors.New("expected-err")
+			}
+			return true, nil
+		}
+		n := &notifierMock{EnqueueFunc: func(event string, payload any) {}}
+		tr := &trackerMock{OnAlertFunc: func(search string) {}}
+
+		NewEvaluator(repo, tr, slog.Default(), n).Handle(context.Background(), event)
+
+		tt.Equal(len(n.EnqueueCalls()), 1)
+		tt.Equal(n.EnqueueCalls()[0].Payload.(domain.Alert).Search.Name, "panics")
+	})
+
+	t.Run("images that already matched are not alerted again", func(t *testing.T) {
+		tt := is.New(t)
+
+		repo := newRepo()
+		repo.SetSavedSearchMatchFunc = func(ctx context.Context, searchID int, fileID string, matches bool) (bool, error) {
+			return false, nil
+		}
+		n := &notifierMock{EnqueueFunc: func(event string, payload any) {}}
+
+		NewEvaluator(repo, &trackerMock{}, slog.Default(), n).Handle(context.Background(), event)
+
+		tt.Equal(len(n.EnqueueCalls()), 0)
+		calls := repo.SetSavedSearchMatchCalls()
+		tt.Equal(len(calls), 2)
+		tt.True(calls[0].Matches && !calls[1].Matches) // matches are stored, so that a later match alerts again
+	})
+
+	t.Run("ignores other events", func(t *testing.T) {
+		tt := is.New(t)
+
+		repo := newRepo()
+		NewEvaluator(repo, &trackerMock{}, slog.Default()).
+			Handle(context.Background(), domain.Event{Type: "image.other", FileID: "expected-id"})
+
+		tt.Equal(len(repo.ListSavedSearchesCalls()), 0)
+	})
+}
@@ -2,41 +2,212 @@ package app
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
+	"sync"
 	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
+	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/schedule"
+)
+
+// failed runs are retried after a delay that doubles from retryBase up to retryMax
+const (
+	retryBase = 10 * time.Second
+	retryMax  = 30 * time.Minute
 )
 
+var ErrPaused = errors.New("indexing is paused")
+
 //go:generate moq -out index_runner_moq_test.go . listIndexer
 type listIndexer interface {
 	IndexNewList(context.Context, string) error
+	IndexKey(ctx context.Context, key string) (domain.Image, error)
+	Progress() indexer.Progress
+	Pause()
+	Resume()
+	Paused() bool
+}
+
+// RunnerState describes the runs of the runner.
+type RunnerState struct {
+	Running     bool
+	Paused      bool
+	LastRun     *time.Time
+	LastSuccess *time.Time
+	NextRun     time.Time
+	LastError   string
+	// Failures is the number of runs that failed since the last successful one
+	Failures int
 }
 
 type IndexRunner struct {
 	indexer listIndexer
 	log     *slog.Logger
+	tracker *monitoring.Tracker
 
 	ext      string
-	interval time.Duration
+	schedule schedule.Schedule
+	windows  schedule.Windows
+
+	trigger chan struct{}
+	now     func() time.Time
+
+	mu    sync.Mutex
+	state RunnerState
+}
+
+func NewIndexRunner(
+	indexer listIndexer,
+	ext string,
+	sched schedule.Schedule,
+	log *slog.Logger,
+	tracker *monitoring.Tracker,
+) *IndexRunner {
+	return &IndexRunner{
+		indexer:  indexer,
+		ext:      ext,
+		schedule: sched,
+		log:      log,
+		tracker:  tracker,
+		trigger:  make(chan struct{}, 1),
+		now:      time.Now,
+	}
 }
 
-func NewIndexRunner(indexer listIndexer, ext string, interval time.Duration, log *slog.Logger) *IndexRunner {
-	return &IndexRunner{indexer: indexer, ext: ext, interval: interval, log: log}
+// SetWindows limits runs to the windows, runs that are due outside them wait for the next window
+// and runs that last until the end of a window are stopped.
+func (i *IndexRunner) SetWindows(windows schedule.Windows) {
+	i.windows = windows
 }
 
+// Start runs the indexer until the context is cancelled. The first run starts immediately,
+// failed runs are retried with backoff instead of stopping the runner.
 func (i *IndexRunner) Start(ctx context.Context) error {
 	i.log.Info("starting indexer")
-	timer := time.NewTimer(0) // starting immediately
+	next := i.windows.Open(time.Now())
 	for {
+		i.scheduled(next)
+
+		timer := time.NewTimer(time.Until(next))
 		select {
 		case <-timer.C:
-			err := i.indexer.IndexNewList(ctx, i.ext)
-			if err != nil {
-				return fmt.Errorf("indexing new, %w", err)
-			}
-			timer = time.NewTimer(i.interval)
+		case <-i.trigger:
+			timer.Stop()
 		case <-ctx.Done():
+			timer.Stop()
 			return ctx.Err()
 		}
+
+		if i.indexer.Paused() {
+			next = i.windows.Open(i.schedule.Next(time.Now()))
+			continue
+		}
+		next = i.run(ctx)
+	}
+}
+
+// Trigger starts a run now, outside of the schedule and the windows.
+// A trigger during a run starts another run after it.
+func (i *IndexRunner) Trigger() error {
+	if i.indexer.Paused() {
+		return ErrPaused
+	}
+
+	select {
+	case i.trigger <- struct{}{}:
+	default: // a run is already triggered
+	}
+
+	return nil
+}
+
+// Pause stops new work until Resume, the file being indexed is finished.
+func (i *IndexRunner) Pause() {
+	i.indexer.Pause()
+	i.log.Info("indexing paused")
+}
+
+func (i *IndexRunner) Resume() {
+	i.indexer.Resume()
+	i.log.Info("indexing resumed")
+}
+
+// IndexKey indexes one file on demand.
+func (i *IndexRunner) IndexKey(ctx context.Context, key string) (domain.Image, error) {
+	return i.indexer.IndexKey(ctx, key)
+}
+
+// Progress returns the files indexed since the process started.
+func (i *IndexRunner) Progress() indexer.Progress {
+	return i.indexer.Progress()
+}
+
+// State returns the state of the runs.
+func (i *IndexRunner) State() RunnerState {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	s := i.state
+	s.Paused = i.indexer.Paused()
+
+	return s
+}
+
+// run indexes new files and returns the time of the next run.
+func (i *IndexRunner) run(ctx context.Context) time.Time {
+	started := i.now()
+	i.mu.Lock()
+	i.state.Running = true
+	i.state.LastRun = &started
+	i.mu.Unlock()
+
+	runCtx := ctx
+	if end := i.windows.Close(started); !end.IsZero() {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, end.Sub(started))
+		defer cancel()
 	}
+
+	err := i.indexer.IndexNewList(runCtx, i.ext)
+
+	now := i.now()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.state.Running = false
+	var next time.Time
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		// the next run in a window indexes the files that are left
+		i.log.Info("indexing stopped at the end of the window")
+		next = i.schedule.Next(now)
+	} else if err != nil {
+		i.state.Failures++
+		i.state.LastError = err.Error()
+		delay := schedule.Backoff(i.state.Failures, retryBase, retryMax)
+		i.log.Error("indexing new files",
+			slog.String("err", err.Error()),
+			slog.Int("failures", i.state.Failures),
+			slog.Duration("retry_in", delay),
+		)
+		next = now.Add(delay)
+	} else {
+		i.state.Failures = 0
+		i.state.LastError = ""
+		i.state.LastSuccess = &now
+		next = i.schedule.Next(now)
+	}
+	i.tracker.OnIndexRun(err, i.state.Failures, now)
+
+	return i.windows.Open(next)
+}
+
+func (i *IndexRunner) scheduled(next time.Time) {
+	i.mu.Lock()
+	i.state.NextRun = next
+	i.mu.Unlock()
+
+	i.tracker.OnIndexScheduled(next)
 }
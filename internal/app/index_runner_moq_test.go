@@ -5,6 +5,8 @@ package app
 
 import (
 	"context"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/indexer"
 	"sync"
 )
 
@@ -18,9 +20,24 @@ var _ listIndexer = &listIndexerMock{}
 //
 //		// make and configure a mocked listIndexer
 //		mockedlistIndexer := &listIndexerMock{
+//			IndexKeyFunc: func(ctx context.Context, key string) (domain.Image, error) {
+//				panic("mock out the IndexKey method")
+//			},
 //			IndexNewListFunc: func(contextMoqParam context.Context, s string) error {
 //				panic("mock out the IndexNewList method")
 //			},
+//			PauseFunc: func() {
+//				panic("mock out the Pause method")
+//			},
+//			PausedFunc: func() bool {
+//				panic("mock out the Paused method")
+//			},
+//			ProgressFunc: func() indexer.Progress {
+//				panic("mock out the Progress method")
+//			},
+//			ResumeFunc: func() {
+//				panic("mock out the Resume method")
+//			},
 //		}
 //
 //		// use mockedlistIndexer in code that requires listIndexer
@@ -28,11 +45,33 @@ var _ listIndexer = &listIndexerMock{}
 //
 //	}
 type listIndexerMock struct {
+	// IndexKeyFunc mocks the IndexKey method.
+	IndexKeyFunc func(ctx context.Context, key string) (domain.Image, error)
+
 	// IndexNewListFunc mocks the IndexNewList method.
 	IndexNewListFunc func(contextMoqParam context.Context, s string) error
 
+	// PauseFunc mocks the Pause method.
+	PauseFunc func()
+
+	// PausedFunc mocks the Paused method.
+	PausedFunc func() bool
+
+	// ProgressFunc mocks the Progress method.
+	ProgressFunc func() indexer.Progress
+
+	// ResumeFunc mocks the Resume method.
+	ResumeFunc func()
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// IndexKey holds details about calls to the IndexKey method.
+		IndexKey []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
 		// IndexNewList holds details about calls to the IndexNewList method.
 		IndexNewList []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
@@ -40,8 +79,61 @@ type listIndexerMock struct {
 			// S is the s argument value.
 			S string
 		}
+		// Pause holds details about calls to the Pause method.
+		Pause []struct {
+		}
+		// Paused holds details about calls to the Paused method.
+		Paused []struct {
+		}
+		// Progress holds details about calls to the Progress method.
+		Progress []struct {
+		}
+		// Resume holds details about calls to the Resume method.
+		Resume []struct {
+		}
 	}
+	lockIndexKey     sync.RWMutex
 	lockIndexNewList sync.RWMutex
+	lockPause        sync.RWMutex
+	lockPaused       sync.RWMutex
+	lockProgress     sync.RWMutex
+	lockResume       sync.RWMutex
+}
+
+// IndexKey calls IndexKeyFunc.
+func (mock *listIndexerMock) IndexKey(ctx context.Context, key string) (domain.Image, error) {
+	if mock.IndexKeyFunc == nil {
+		panic("listIndexerMock.IndexKeyFunc: method is nil but listIndexer.IndexKey was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockIndexKey.Lock()
+	mock.calls.IndexKey = append(mock.calls.IndexKey, callInfo)
+	mock.lockIndexKey.Unlock()
+	return mock.IndexKeyFunc(ctx, key)
+}
+
+// IndexKeyCalls gets all the calls that were made to IndexKey.
+// Check the length with:
+//
+//	len(mockedlistIndexer.IndexKeyCalls())
+func (mock *listIndexerMock) IndexKeyCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockIndexKey.RLock()
+	calls = mock.calls.IndexKey
+	mock.lockIndexKey.RUnlock()
+	return calls
 }
 
 // IndexNewList calls IndexNewListFunc.
@@ -79,3 +171,111 @@ func (mock *listIndexerMock) IndexNewListCalls() []struct {
 	mock.lockIndexNewList.RUnlock()
 	return calls
 }
+
+// Pause calls PauseFunc.
+func (mock *listIndexerMock) Pause() {
+	if mock.PauseFunc == nil {
+		panic("listIndexerMock.PauseFunc: method is nil but listIndexer.Pause was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockPause.Lock()
+	mock.calls.Pause = append(mock.calls.Pause, callInfo)
+	mock.lockPause.Unlock()
+	mock.PauseFunc()
+}
+
+// PauseCalls gets all the calls that were made to Pause.
+// Check the length with:
+//
+//	len(mockedlistIndexer.PauseCalls())
+func (mock *listIndexerMock) PauseCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockPause.RLock()
+	calls = mock.calls.Pause
+	mock.lockPause.RUnlock()
+	return calls
+}
+
+// Paused calls PausedFunc.
+func (mock *listIndexerMock) Paused() bool {
+	if mock.PausedFunc == nil {
+		panic("listIndexerMock.PausedFunc: method is nil but listIndexer.Paused was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockPaused.Lock()
+	mock.calls.Paused = append(mock.calls.Paused, callInfo)
+	mock.lockPaused.Unlock()
+	return mock.PausedFunc()
+}
+
+// PausedCalls gets all the calls that were made to Paused.
+// Check the length with:
+//
+//	len(mockedlistIndexer.PausedCalls())
+func (mock *listIndexerMock) PausedCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockPaused.RLock()
+	calls = mock.calls.Paused
+	mock.lockPaused.RUnlock()
+	return calls
+}
+
+// Progress calls ProgressFunc.
+func (mock *listIndexerMock) Progress() indexer.Progress {
+	if mock.ProgressFunc == nil {
+		panic("listIndexerMock.ProgressFunc: method is nil but listIndexer.Progress was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockProgress.Lock()
+	mock.calls.Progress = append(mock.calls.Progress, callInfo)
+	mock.lockProgress.Unlock()
+	return mock.ProgressFunc()
+}
+
+// ProgressCalls gets all the calls that were made to Progress.
+// Check the length with:
+//
+//	len(mockedlistIndexer.ProgressCalls())
+func (mock *listIndexerMock) ProgressCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockProgress.RLock()
+	calls = mock.calls.Progress
+	mock.lockProgress.RUnlock()
+	return calls
+}
+
+// Resume calls ResumeFunc.
+func (mock *listIndexerMock) Resume() {
+	if mock.ResumeFunc == nil {
+		panic("listIndexerMock.ResumeFunc: method is nil but listIndexer.Resume was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockResume.Lock()
+	mock.calls.Resume = append(mock.calls.Resume, callInfo)
+	mock.lockResume.Unlock()
+	mock.ResumeFunc()
+}
+
+// ResumeCalls gets all the calls that were made to Resume.
+// Check the length with:
+//
+//	len(mockedlistIndexer.ResumeCalls())
+func (mock *listIndexerMock) ResumeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockResume.RLock()
+	calls = mock.calls.Resume
+	mock.lockResume.RUnlock()
+	return calls
+}
@@ -4,42 +4,191 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/schedule"
 	"github.com/matryer/is"
 )
 
 func TestIndexRunner_Start_NoError(t *testing.T) {
 	tt := is.New(t)
 
-	li := &listIndexerMock{IndexNewListFunc: func(_ context.Context, _ string) error { return nil }}
+	li := newListIndexer(func(_ context.Context, _ string) error { return nil })
 	l := slog.Default()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
 	defer cancel()
-	runner := NewIndexRunner(li, "expected-ext", 100*time.Millisecond, l)
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(100*time.Millisecond), l, monitoring.NewTracker())
 
 	err := runner.Start(ctx)
 
 	tt.True(errors.Is(err, context.DeadlineExceeded)) // must end by deadline
 	tt.Equal(len(li.IndexNewListCalls()), 2)          // must run 2 times (start immediately + 1 timer)
 	tt.Equal(li.IndexNewListCalls()[0].S, "expected-ext")
+
+	state := runner.State()
+	tt.True(state.LastSuccess != nil)
+	tt.Equal(state.Failures, 0)
+	tt.True(state.NextRun.After(*state.LastSuccess))
 }
 
 func TestIndexRunner_Start_Error(t *testing.T) {
 	tt := is.New(t)
 
 	expectedErr := errors.New("expected-err")
-	li := &listIndexerMock{IndexNewListFunc: func(_ context.Context, _ string) error { return expectedErr }}
+	li := newListIndexer(func(_ context.Context, _ string) error { return expectedErr })
 	l := slog.Default()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
 	defer cancel()
-	runner := NewIndexRunner(li, "expected-ext", 100*time.Millisecond, l)
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(100*time.Millisecond), l, monitoring.NewTracker())
+
+	err := runner.Start(ctx)
+
+	tt.True(errors.Is(err, context.DeadlineExceeded)) // errors do not stop the runner
+	tt.Equal(len(li.IndexNewListCalls()), 1)          // the retry waits for the backoff, not for the interval
+
+	state := runner.State()
+	tt.Equal(state.Failures, 1)
+	tt.Equal(state.LastError, "expected-err")
+	tt.True(state.LastSuccess == nil)
+	tt.True(state.NextRun.Sub(*state.LastRun) >= retryBase/2)
+}
+
+func TestIndexRunner_run_recovers(t *testing.T) {
+	tt := is.New(t)
+
+	fail := true
+	li := newListIndexer(func(_ context.Context, _ string) error {
+		if fail {
+			return errors.New("expected-err")
+		}
+		return nil
+	})
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(time.Hour), slog.Default(), monitoring.NewTracker())
+
+	runner.run(context.Background())
+	next := runner.run(context.Background())
+	tt.Equal(runner.State().Failures, 2)
+	tt.True(time.Until(next) <= 2*retryBase)
+
+	fail = false
+	next = runner.run(context.Background())
+	tt.Equal(runner.State().Failures, 0)
+	tt.Equal(runner.State().LastError, "")
+	tt.True(time.Until(next) > 59*time.Minute) // back to the schedule
+}
+
+func TestIndexRunner_Start_Windows(t *testing.T) {
+	tt := is.New(t)
+
+	li := newListIndexer(func(_ context.Context, _ string) error { return nil })
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(time.Millisecond), slog.Default(), monitoring.NewTracker())
+
+	// a window that is closed now
+	now := time.Now()
+	from := now.Add(time.Hour)
+	to := now.Add(2 * time.Hour)
+	runner.SetWindows(schedule.Windows{{From: from.Hour()*60 + from.Minute(), To: to.Hour()*60 + to.Minute()}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := runner.Start(ctx)
+
+	tt.True(errors.Is(err, context.DeadlineExceeded))
+	tt.Equal(len(li.IndexNewListCalls()), 0) // must wait for the window
+	tt.True(runner.State().NextRun.After(now.Add(58 * time.Minute)))
+}
+
+func TestIndexRunner_run_StopsAtWindowEnd(t *testing.T) {
+	tt := is.New(t)
+
+	var runErr error
+	li := newListIndexer(func(ctx context.Context, _ string) error {
+		<-ctx.Done()
+		runErr = ctx.Err()
+		return runErr
+	})
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(time.Hour), slog.Default(), monitoring.NewTracker())
+	night, err := schedule.ParseWindow("22:00-06:00")
+	tt.NoErr(err)
+	runner.SetWindows(schedule.Windows{night})
+	// the run starts shortly before the night window ends
+	runner.now = func() time.Time { return time.Date(2024, 1, 10, 5, 59, 59, int(900*time.Millisecond), time.Local) }
+
+	started := time.Now()
+	next := runner.run(context.Background())
+
+	tt.True(time.Since(started) < 5*time.Second)
+	tt.True(errors.Is(runErr, context.DeadlineExceeded))
+	tt.Equal(runner.State().Failures, 0) // the end of the window is not a failure
+	tt.Equal(runner.State().LastError, "")
+	tt.Equal(next, time.Date(2024, 1, 10, 22, 0, 0, 0, time.Local)) // the next window
+}
+
+func TestIndexRunner_Trigger(t *testing.T) {
+	tt := is.New(t)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	li := newListIndexer(func(_ context.Context, _ string) error {
+		// the first run waits for the triggers, so that they are not merged with it
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+		return nil
+	})
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(time.Hour), slog.Default(), monitoring.NewTracker())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	errs := make(chan error)
+	go func() { errs <- runner.Start(ctx) }()
+
+	<-started
+	tt.NoErr(runner.Trigger())
+	tt.NoErr(runner.Trigger()) // triggers are not queued up
+	close(release)
 
+	tt.True(errors.Is(<-errs, context.DeadlineExceeded))
+	tt.Equal(len(li.IndexNewListCalls()), 2) // start immediately + 1 trigger instead of waiting for an hour
+}
+
+func TestIndexRunner_Pause(t *testing.T) {
+	tt := is.New(t)
+
+	li := newListIndexer(func(_ context.Context, _ string) error { return nil })
+	runner := NewIndexRunner(li, "expected-ext", schedule.Interval(time.Millisecond), slog.Default(), monitoring.NewTracker())
+
+	runner.Pause()
+	tt.True(runner.State().Paused)
+	tt.True(errors.Is(runner.Trigger(), ErrPaused))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
 	err := runner.Start(ctx)
 
-	tt.True(errors.Is(err, expectedErr)) // must end after the first call
-	tt.Equal(len(li.IndexNewListCalls()), 1)
+	tt.True(errors.Is(err, context.DeadlineExceeded))
+	tt.Equal(len(li.IndexNewListCalls()), 0) // scheduled runs are skipped while paused
+
+	runner.Resume()
+	tt.True(!runner.State().Paused)
+	tt.NoErr(runner.Trigger())
+}
+
+func newListIndexer(indexNewList func(context.Context, string) error) *listIndexerMock {
+	var paused bool
+
+	return &listIndexerMock{
+		IndexNewListFunc: indexNewList,
+		PauseFunc:        func() { paused = true },
+		ResumeFunc:       func() { paused = false },
+		PausedFunc:       func() bool { return paused },
+	}
 }
@@ -0,0 +1,201 @@
+// Package backup exports the index as JSONL or CSV and imports it again, so that an environment
+// can be seeded without running OCR on every screenshot.
+//
+// A record holds what can not be derived from the text: the description, user edits, the source url,
+// metadata and tags. Entities and findings are extracted again by the stages of the import.
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// pageSize is the number of images read from the repository at a time.
+const pageSize = 500
+
+// Record is an exported image.
+type Record struct {
+	FileID               string            `json:"file_id"`
+	LastModified         time.Time         `json:"last_modified"`
+	Description          string            `json:"description"`
+	PHash                int64             `json:"phash,omitempty"`
+	Annotations          string            `json:"annotations,omitempty"`
+	CorrectedDescription string            `json:"corrected_description,omitempty"`
+	SourceURL            string            `json:"source_url,omitempty"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	Tags                 []Tag             `json:"tags,omitempty"`
+}
+
+// Tag is a tag of an image, its source tells manual tags from the ones assigned by rules.
+type Tag struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+type source interface {
+	ListImages(ctx context.Context, after string, limit int) ([]domain.Image, error)
+	TagsOfImages(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error)
+}
+
+type target interface {
+	Upsert(ctx context.Context, image domain.Image) error
+	Patch(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error)
+	TagImage(ctx context.Context, fileID, name string) error
+	SetSourceURL(ctx context.Context, fileID, sourceURL string) error
+}
+
+// Stage processes an imported image before it is stored, like the stages of the indexer.
+type Stage interface {
+	Process(ctx context.Context, file string, img *domain.Image) error
+}
+
+// Export writes every image ordered by file id and returns how many were written.
+func Export(ctx context.Context, src source, w Writer) (int, error) {
+	var count int
+	after := ""
+	for {
+		images, err := src.ListImages(ctx, after, pageSize)
+		if err != nil {
+			return count, err
+		}
+
+		fileIDs := make([]string, 0, len(images))
+		for _, img := range images {
+			fileIDs = append(fileIDs, img.FileID)
+		}
+		tags, err := src.TagsOfImages(ctx, fileIDs)
+		if err != nil {
+			return count, err
+		}
+
+		for _, img := range images {
+			err = w.Write(NewRecord(img, tags[img.FileID]))
+			if err != nil {
+				return count, fmt.Errorf("writing %s, %w", img.FileID, err)
+			}
+			count++
+		}
+
+		if len(images) < pageSize {
+			return count, w.Flush()
+		}
+		after = images[len(images)-1].FileID
+	}
+}
+
+// Import stores every record and returns how many were stored. Importing the same records again changes nothing,
+// values that are empty in a record are kept, e.g. annotations or manual tags added after the export.
+func Import(ctx context.Context, dst target, r Reader, stages ...Stage) (int, error) {
+	var count int
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		err = importRecord(ctx, dst, rec, stages)
+		if err != nil {
+			return count, fmt.Errorf("importing record %d, %w", count+1, err)
+		}
+		count++
+	}
+}
+
+func importRecord(ctx context.Context, dst target, rec Record, stages []Stage) error {
+	if rec.FileID == "" || rec.LastModified.IsZero() {
+		return errors.New("file_id and last_modified are required")
+	}
+
+	img := rec.Image()
+	for _, stage := range stages {
+		err := stage.Process(ctx, "", &img)
+		if err != nil {
+			return fmt.Errorf("processing %s, %w", rec.FileID, err)
+		}
+	}
+
+	err := dst.Upsert(ctx, img)
+	if err != nil {
+		return err
+	}
+
+	if rec.Annotations != "" || rec.CorrectedDescription != "" {
+		patch := domain.ImagePatch{}
+		if rec.Annotations != "" {
+			patch.Annotations = &rec.Annotations
+		}
+		if rec.CorrectedDescription != "" {
+			patch.CorrectedDescription = &rec.CorrectedDescription
+		}
+		_, err = dst.Patch(ctx, rec.FileID, patch)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range rec.Tags {
+		if tag.Source != domain.TagSourceManual {
+			continue
+		}
+		err = dst.TagImage(ctx, rec.FileID, tag.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rec.SourceURL != "" {
+		err = dst.SetSourceURL(ctx, rec.FileID, rec.SourceURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewRecord(img domain.Image, tags []domain.ImageTag) Record {
+	rec := Record{
+		FileID:               img.FileID,
+		LastModified:         img.LastModified.UTC(),
+		Description:          img.Description,
+		PHash:                img.PHash,
+		Annotations:          img.Annotations,
+		CorrectedDescription: img.CorrectedDescription,
+		SourceURL:            img.SourceURL,
+		Metadata:             img.Metadata,
+	}
+	for _, t := range tags {
+		rec.Tags = append(rec.Tags, Tag{Name: t.Name, Source: t.Source})
+	}
+
+	return rec
+}
+
+// Image returns the image to upsert, tags assigned by rules replace the stored ones.
+func (r Record) Image() domain.Image {
+	img := domain.Image{
+		FileID:       r.FileID,
+		LastModified: r.LastModified,
+		Description:  r.Description,
+		PHash:        r.PHash,
+		Tags:         make([]string, 0),
+	}
+	if len(r.Metadata) > 0 {
+		img.Metadata = r.Metadata
+	}
+	for _, t := range r.Tags {
+		if t.Source == domain.TagSourceRule {
+			img.Tags = append(img.Tags, t.Name)
+		}
+	}
+
+	return img
+}
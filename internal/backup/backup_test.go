@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/fakes"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/entities"
+	"github.com/matryer/is"
+)
+
+func TestFormats(t *testing.T) {
+	records := []Record{
+		{
+			FileID:               "shots/a.jpg",
+			LastModified:         time.Date(2024, 5, 1, 10, 0, 0, 123, time.UTC),
+			Description:          "kubectl get pods\n\"quoted\", with commas",
+			PHash:                -42,
+			Annotations:          "outage",
+			CorrectedDescription: "kubectl get pods",
+			SourceURL:            "https://chat.example.com/a.jpg",
+			Metadata:             map[string]string{"app": "Terminal"},
+			Tags:                 []Tag{{Name: "infra", Source: domain.TagSourceManual}},
+		},
+		{FileID: "shots/b.jpg", LastModified: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			tt := is.New(t)
+
+			buf := &bytes.Buffer{}
+			w, err := NewWriter(buf, format)
+			tt.NoErr(err)
+			for _, rec := range records {
+				tt.NoErr(w.Write(rec))
+			}
+			tt.NoErr(w.Flush())
+
+			r, err := NewReader(buf, format)
+			tt.NoErr(err)
+			var read []Record
+			for {
+				rec, err := r.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				tt.NoErr(err)
+				read = append(read, rec)
+			}
+			tt.Equal(read, records)
+		})
+	}
+
+	t.Run("empty csv has a header", func(t *testing.T) {
+		tt := is.New(t)
+
+		buf := &bytes.Buffer{}
+		w, err := NewWriter(buf, FormatCSV)
+		tt.NoErr(err)
+		tt.NoErr(w.Flush())
+		tt.True(strings.HasPrefix(buf.String(), "file_id,last_modified,"))
+
+		r, err := NewReader(buf, FormatCSV)
+		tt.NoErr(err)
+		_, err = r.Read()
+		tt.Equal(err, io.EOF)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		tt := is.New(t)
+
+		_, err := NewWriter(io.Discard, "xml")
+		tt.True(err != nil)
+		_, err = NewReader(strings.NewReader(""), "xml")
+		tt.True(err != nil)
+	})
+}
+
+func TestExportImport(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	src := fakes.NewImageRepo()
+	tt.NoErr(src.Upsert(ctx, domain.Image{
+		FileID:       "shots/a.jpg",
+		LastModified: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Description:  "ssh deploy@10.0.0.5",
+		PHash:        42,
+		Metadata:     domain.Metadata{"app": "Terminal"},
+		Tags:         []string{"ssh"},
+	}))
+	tt.NoErr(src.TagImage(ctx, "shots/a.jpg", "incident-42"))
+	tt.NoErr(src.SetSourceURL(ctx, "shots/a.jpg", "https://chat.example.com/a.jpg"))
+	annotations := "the deploy that failed"
+	_, err := src.Patch(ctx, "shots/a.jpg", domain.ImagePatch{Annotations: &annotations})
+	tt.NoErr(err)
+	tt.NoErr(src.Upsert(ctx, domain.Image{FileID: "shots/b.jpg", LastModified: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)}))
+
+	export := func(repo *fakes.ImageRepo) string {
+		buf := &bytes.Buffer{}
+		w, err := NewWriter(buf, FormatJSONL)
+		tt.NoErr(err)
+		n, err := Export(ctx, repo, w)
+		tt.NoErr(err)
+		tt.Equal(n, 2)
+
+		return buf.String()
+	}
+	exported := export(src)
+
+	dst := fakes.NewImageRepo()
+	for i := 0; i < 2; i++ { // importing again changes nothing
+		r, err := NewReader(strings.NewReader(exported), FormatJSONL)
+		tt.NoErr(err)
+		n, err := Import(ctx, dst, r, entities.NewExtractor())
+		tt.NoErr(err)
+		tt.Equal(n, 2)
+
+		tt.Equal(export(dst), exported)
+		edits, err := dst.ImageEdits(ctx, "shots/a.jpg")
+		tt.NoErr(err)
+		tt.Equal(len(edits), 1)
+	}
+
+	ips, err := dst.ListEntities(ctx, domain.EntityIP, 10)
+	tt.NoErr(err)
+	tt.Equal(len(ips), 1) // entities are extracted again
+}
+
+func TestImport_InvalidRecord(t *testing.T) {
+	tt := is.New(t)
+
+	r, err := NewReader(strings.NewReader(`{"file_id": "a.jpg", "last_modified": "2024-05-01T10:00:00Z"}`+"\n"+`{"file_id": ""}`), FormatJSONL)
+	tt.NoErr(err)
+
+	n, err := Import(context.Background(), fakes.NewImageRepo(), r)
+	tt.True(err != nil)
+	tt.True(strings.Contains(err.Error(), "record 2"))
+	tt.Equal(n, 1)
+}
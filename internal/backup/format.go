@@ -0,0 +1,210 @@
+package backup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formats of exported indexes.
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// ContentTypes of the formats, for serving exports.
+var ContentTypes = map[string]string{
+	FormatJSONL: "application/x-ndjson",
+	FormatCSV:   "text/csv; charset=utf-8",
+}
+
+// csvHeader are the columns of a csv export, metadata and tags are json encoded.
+var csvHeader = []string{
+	"file_id", "last_modified", "description", "phash", "annotations",
+	"corrected_description", "source_url", "metadata", "tags",
+}
+
+// Writer writes records in one of the formats, Flush must be called after the last record.
+type Writer interface {
+	Write(r Record) error
+	Flush() error
+}
+
+// Reader reads records, io.EOF is returned after the last one.
+type Reader interface {
+	Read() (Record, error)
+}
+
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q, use %s or %s", format, FormatJSONL, FormatCSV)
+	}
+}
+
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{dec: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(csvHeader)
+		return &csvReader{r: cr}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q, use %s or %s", format, FormatJSONL, FormatCSV)
+	}
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(r Record) error {
+	return w.enc.Encode(r)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
+
+type jsonlReader struct {
+	dec  *json.Decoder
+	line int
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	var rec Record
+	err := r.dec.Decode(&rec)
+	if errors.Is(err, io.EOF) {
+		return Record{}, io.EOF
+	}
+	r.line++
+	if err != nil {
+		return Record{}, fmt.Errorf("reading record %d, %w", r.line, err)
+	}
+
+	return rec, nil
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) Write(r Record) error {
+	if !w.headerWritten {
+		w.headerWritten = true
+		err := w.w.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+	}
+
+	metadata, err := json.Marshal(r.Metadata)
+	if err != nil {
+		return fmt.Errorf("encoding metadata of %s, %w", r.FileID, err)
+	}
+	tags, err := json.Marshal(r.Tags)
+	if err != nil {
+		return fmt.Errorf("encoding tags of %s, %w", r.FileID, err)
+	}
+
+	return w.w.Write([]string{
+		r.FileID,
+		r.LastModified.Format(time.RFC3339Nano),
+		r.Description,
+		strconv.FormatInt(r.PHash, 10),
+		r.Annotations,
+		r.CorrectedDescription,
+		r.SourceURL,
+		string(metadata),
+		string(tags),
+	})
+}
+
+// Flush writes the header of an empty export and everything buffered.
+func (w *csvWriter) Flush() error {
+	if !w.headerWritten {
+		w.headerWritten = true
+		err := w.w.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+
+	return w.w.Error()
+}
+
+type csvReader struct {
+	r             *csv.Reader
+	headerSkipped bool
+}
+
+func (r *csvReader) Read() (Record, error) {
+	if !r.headerSkipped {
+		r.headerSkipped = true
+		header, err := r.r.Read()
+		if err != nil {
+			return Record{}, readErr(err)
+		}
+		if header[0] != csvHeader[0] {
+			return Record{}, errors.New("csv header is missing")
+		}
+	}
+
+	row, err := r.r.Read()
+	if err != nil {
+		return Record{}, readErr(err)
+	}
+
+	rec := Record{
+		FileID:               row[0],
+		Description:          row[2],
+		Annotations:          row[4],
+		CorrectedDescription: row[5],
+		SourceURL:            row[6],
+	}
+	rec.LastModified, err = time.Parse(time.RFC3339Nano, row[1])
+	if err != nil {
+		return Record{}, r.wrap(fmt.Errorf("parsing last_modified, %w", err))
+	}
+	rec.PHash, err = strconv.ParseInt(row[3], 10, 64)
+	if err != nil {
+		return Record{}, r.wrap(fmt.Errorf("parsing phash, %w", err))
+	}
+	err = json.Unmarshal([]byte(row[7]), &rec.Metadata)
+	if err != nil {
+		return Record{}, r.wrap(fmt.Errorf("parsing metadata, %w", err))
+	}
+	err = json.Unmarshal([]byte(row[8]), &rec.Tags)
+	if err != nil {
+		return Record{}, r.wrap(fmt.Errorf("parsing tags, %w", err))
+	}
+
+	return rec, nil
+}
+
+// wrap adds the line of the last read row to the error.
+func (r *csvReader) wrap(err error) error {
+	line, _ := r.r.FieldPos(0)
+
+	return fmt.Errorf("reading csv line %d, %w", line, err)
+}
+
+// readErr keeps io.EOF as it is, errors of the csv reader contain the line.
+func readErr(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+
+	return fmt.Errorf("reading csv, %w", err)
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/search"
+	"github.com/jmoiron/sqlx"
+)
+
+// ListEntities returns distinct entities of the kind found across all images, the most frequent first.
+// Empty kind means all kinds.
+func (i *ImageRepo) ListEntities(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+	entities := make([]domain.EntityCount, 0)
+
+	query := `SELECT kind, value, count(*) AS count 
+		FROM image_entities 
+		WHERE (kind = $1 OR $1 = '')
+		GROUP BY kind, value
+		ORDER BY count desc, kind, value LIMIT $2`
+	err := i.db.SelectContext(ctx, &entities, query, kind, limit)
+	if err != nil {
+		return entities, fmt.Errorf("listing entities of kind %s, %w", kind, err)
+	}
+
+	return entities, nil
+}
+
+func replaceEntities(ctx context.Context, tx *sqlx.Tx, fileID string, entities []domain.Entity) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM image_entities WHERE file_id = $1`, fileID)
+	if err != nil {
+		return fmt.Errorf("deleting old entities, %w", err)
+	}
+
+	for _, e := range entities {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO image_entities (file_id, kind, value) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			fileID, e.Kind, e.Value,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting entity %s=%s, %w", e.Kind, e.Value, err)
+		}
+	}
+
+	return nil
+}
+
+// queryFilters returns conditions for the filters of the query to be appended to a WHERE clause
+// of a query on image_descriptions. Values of the filters are appended to args.
+func queryFilters(q search.Query, args []any) (string, []any) {
+	var sb strings.Builder
+
+	for _, kind := range q.Has {
+		args = append(args, kind)
+		fmt.Fprintf(&sb, ` AND EXISTS (SELECT 1 FROM image_entities e 
+			WHERE e.file_id = image_descriptions.file_id AND e.kind = $%d)`, len(args))
+	}
+
+	for _, e := range q.Entities {
+		args = append(args, e.Kind, e.Value)
+		fmt.Fprintf(&sb, ` AND EXISTS (SELECT 1 FROM image_entities e 
+			WHERE e.file_id = image_descriptions.file_id AND e.kind = $%d AND lower(e.value) = lower($%d))`,
+			len(args)-1, len(args),
+		)
+	}
+
+	for _, tag := range q.Tags {
+		args = append(args, tag)
+		fmt.Fprintf(&sb, ` AND EXISTS (SELECT 1 FROM image_tags it JOIN tags t ON t.id = it.tag_id 
+			WHERE it.file_id = image_descriptions.file_id AND t.name = $%d)`, len(args))
+	}
+
+	for _, m := range q.Meta {
+		args = append(args, m.Key, m.Value)
+		fmt.Fprintf(&sb, ` AND lower(image_descriptions.metadata ->> $%d) = lower($%d)`, len(args)-1, len(args))
+	}
+
+	return sb.String(), args
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/jackc/pgx"
+)
+
+// EventsChannel is notified with the sequence number of every appended event.
+const EventsChannel = "image_events"
+
+type eventRow struct {
+	Seq     int64  `db:"seq"`
+	Payload string `db:"payload"`
+}
+
+// AppendEvent stores the event in the event log and notifies listeners of EventsChannel.
+func (i *ImageRepo) AppendEvent(ctx context.Context, e domain.Event) (int64, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("encoding event, %w", err)
+	}
+
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction, %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var seq int64
+	err = tx.GetContext(ctx, &seq,
+		`INSERT INTO image_events (type, file_id, payload) VALUES ($1, $2, CAST(CAST($3 AS text) AS jsonb)) RETURNING seq`,
+		e.Type, e.FileID, string(payload),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("inserting event %s, %w", e.Type, err)
+	}
+
+	// notifications are sent on commit, so listeners never see a sequence number before the event is visible
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, CAST($2 AS text))`, EventsChannel, seq)
+	if err != nil {
+		return 0, fmt.Errorf("notifying about event %d, %w", seq, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("committing event, %w", err)
+	}
+
+	return seq, nil
+}
+
+// EventsAfter returns up to limit events with sequence numbers greater than after, in order.
+func (i *ImageRepo) EventsAfter(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+	var rows []eventRow
+	err := i.db.SelectContext(ctx, &rows,
+		`SELECT seq, payload FROM image_events WHERE seq > $1 ORDER BY seq LIMIT $2`,
+		after, limit,
+	)
+	if err != nil {
+		return []domain.StreamEvent{}, fmt.Errorf("listing events after %d, %w", after, err)
+	}
+
+	events := make([]domain.StreamEvent, 0, len(rows))
+	for _, r := range rows {
+		se := domain.StreamEvent{Seq: r.Seq}
+		err = json.Unmarshal([]byte(r.Payload), &se.Event)
+		if err != nil {
+			return []domain.StreamEvent{}, fmt.Errorf("decoding event %d, %w", r.Seq, err)
+		}
+		events = append(events, se)
+	}
+
+	return events, nil
+}
+
+// LastEventSeq returns the sequence number of the latest event, 0 if there are none.
+func (i *ImageRepo) LastEventSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := i.db.GetContext(ctx, &seq, `SELECT coalesce(max(seq), 0) FROM image_events`)
+	if err != nil {
+		return 0, fmt.Errorf("getting last event, %w", err)
+	}
+
+	return seq, nil
+}
+
+// DeleteEventsBefore removes events that are too old to be resumed from.
+func (i *ImageRepo) DeleteEventsBefore(ctx context.Context, before time.Time) error {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM image_events WHERE created_at < $1`, before)
+	if err != nil {
+		return fmt.Errorf("deleting events before %s, %w", before, err)
+	}
+
+	return nil
+}
+
+// Listener waits for notifications on a dedicated connection, because LISTEN does not work through a pool.
+// The connection is reopened on the next Wait if it fails.
+type Listener struct {
+	dsn     string
+	channel string
+	conn    *pgx.Conn
+}
+
+func NewListener(dsn, channel string) *Listener {
+	return &Listener{dsn: dsn, channel: channel}
+}
+
+// Wait blocks until a notification arrives on the channel.
+func (l *Listener) Wait(ctx context.Context) error {
+	if l.conn == nil {
+		err := l.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := l.conn.WaitForNotification(ctx)
+	if err != nil {
+		_ = l.Close()
+
+		return fmt.Errorf("waiting for notification on %s, %w", l.channel, err)
+	}
+
+	return nil
+}
+
+func (l *Listener) connect() error {
+	cfg, err := pgx.ParseConnectionString(l.dsn)
+	if err != nil {
+		return fmt.Errorf("parsing dsn, %w", err)
+	}
+
+	conn, err := pgx.Connect(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting listener, %w", err)
+	}
+
+	err = conn.Listen(l.channel)
+	if err != nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("listening on %s, %w", l.channel, err)
+	}
+	l.conn = conn
+
+	return nil
+}
+
+func (l *Listener) Close() error {
+	if l.conn == nil {
+		return nil
+	}
+
+	err := l.conn.Close()
+	l.conn = nil
+
+	return err
+}
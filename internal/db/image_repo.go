@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/search"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -19,20 +21,57 @@ var (
 	ErrRecordNotFound = errors.New("record not found")
 )
 
+// imageColumns are the columns of image_descriptions that are selected into domain.Image.
+const imageColumns = `file_id, description, last_modified, coalesce(phash, 0) AS phash, 
+	annotations, corrected_description, source_url, metadata`
+
 func NewImageRepo(db *sqlx.DB) *ImageRepo {
 	return &ImageRepo{db: db}
 }
 
 func (i *ImageRepo) Upsert(ctx context.Context, image domain.Image) error {
-	query := `INSERT INTO image_descriptions (file_id, description, last_modified) 
-			VALUES (:file_id, :description, :last_modified)
-			ON CONFLICT (file_id) DO UPDATE SET (description, last_modified) 
-			    = (excluded.description, excluded.last_modified)`
-	_, err := i.db.NamedExecContext(ctx, query, image)
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction for image id=%s, %w", image.FileID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// zero hash means that the hash could not be calculated
+	query := `INSERT INTO image_descriptions (file_id, description, last_modified, phash, metadata) 
+			VALUES (:file_id, :description, :last_modified, nullif(CAST(:phash AS bigint), 0), CAST(:metadata AS jsonb))
+			ON CONFLICT (file_id) DO UPDATE SET (description, last_modified, phash, metadata) 
+			    = (excluded.description, excluded.last_modified, excluded.phash, excluded.metadata)`
+	_, err = tx.NamedExecContext(ctx, query, image)
 	if err != nil {
 		return fmt.Errorf("inserting image id=%s, %w", image.FileID, err)
 	}
 
+	if image.Entities != nil {
+		err = replaceEntities(ctx, tx, image.FileID, image.Entities)
+		if err != nil {
+			return fmt.Errorf("storing entities of image id=%s, %w", image.FileID, err)
+		}
+	}
+
+	if image.Findings != nil {
+		err = replaceFindings(ctx, tx, image.FileID, image.Findings)
+		if err != nil {
+			return fmt.Errorf("storing findings of image id=%s, %w", image.FileID, err)
+		}
+	}
+
+	if image.Tags != nil {
+		err = replaceRuleTags(ctx, tx, image.FileID, image.Tags)
+		if err != nil {
+			return fmt.Errorf("storing tags of image id=%s, %w", image.FileID, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing image id=%s, %w", image.FileID, err)
+	}
+
 	return nil
 }
 
@@ -46,7 +85,9 @@ func (i *ImageRepo) FindByDescription(
 		return []domain.Image{}, nil
 	}
 
-	images, err := i.fullTextSearch(ctx, searchString, page, perPage)
+	q := search.Parse(searchString)
+
+	images, err := i.fullTextSearch(ctx, q, page, perPage)
 	if err != nil {
 		return []domain.Image{}, fmt.Errorf("full text search err, %w", err)
 	}
@@ -55,7 +96,7 @@ func (i *ImageRepo) FindByDescription(
 		return images, nil
 	}
 
-	images, err = i.patternMatching(ctx, searchString, page, perPage)
+	images, err = i.patternMatching(ctx, q, page, perPage)
 	if err != nil {
 		return []domain.Image{}, fmt.Errorf("pattern matching err, %w", err)
 	}
@@ -65,21 +106,21 @@ func (i *ImageRepo) FindByDescription(
 
 func (i *ImageRepo) fullTextSearch(
 	ctx context.Context,
-	searchString string,
+	q search.Query,
 	page,
 	perPage int,
 ) ([]domain.Image, error) {
-	pattern := "%" + searchString + "%"
 	limit := perPage
 	offset := (page - 1) * perPage
 
 	images := make([]domain.Image, 0)
 
-	query := `SELECT file_id, description, last_modified 
+	filters, args := queryFilters(q, []any{q.Text})
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`SELECT %s 
 		FROM image_descriptions 
-		WHERE (to_tsvector('simple', description) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		ORDER BY last_modified desc LIMIT $2 OFFSET $3`
-	args := []any{pattern, limit, offset}
+		WHERE (document @@ plainto_tsquery('simple', $1) OR $1 = '')%s
+		ORDER BY last_modified desc LIMIT $%d OFFSET $%d`, imageColumns, filters, len(args)-1, len(args))
 	err := i.db.SelectContext(ctx, &images, query, args...)
 	if err != nil {
 		return images, fmt.Errorf("searching for images with query %s, %w", query, err)
@@ -90,21 +131,23 @@ func (i *ImageRepo) fullTextSearch(
 
 func (i *ImageRepo) patternMatching(
 	ctx context.Context,
-	searchString string,
+	q search.Query,
 	page,
 	perPage int,
 ) ([]domain.Image, error) {
-	pattern := "%" + searchString + "%"
+	pattern := "%" + q.Text + "%"
 	limit := perPage
 	offset := (page - 1) * perPage
 
 	images := make([]domain.Image, 0)
 
-	query := `SELECT file_id, description, last_modified 
+	filters, args := queryFilters(q, []any{pattern})
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`SELECT %s 
 		FROM image_descriptions 
-		WHERE description ILIKE $1
-		ORDER BY last_modified desc LIMIT $2 OFFSET $3`
-	args := []any{pattern, limit, offset}
+		WHERE (description ILIKE $1 OR corrected_description ILIKE $1 OR annotations ILIKE $1
+			OR source_url ILIKE $1)%s
+		ORDER BY last_modified desc LIMIT $%d OFFSET $%d`, imageColumns, filters, len(args)-1, len(args))
 	err := i.db.SelectContext(ctx, &images, query, args...)
 	if err != nil {
 		return images, fmt.Errorf("searching for images with query %s, %w", query, err)
@@ -113,6 +156,24 @@ func (i *ImageRepo) patternMatching(
 	return images, nil
 }
 
+// SetSourceURL records the url the image was fetched from, indexing the image again keeps it.
+func (i *ImageRepo) SetSourceURL(ctx context.Context, fileID, sourceURL string) error {
+	res, err := i.db.ExecContext(ctx, `UPDATE image_descriptions SET source_url = $2 WHERE file_id = $1`, fileID, sourceURL)
+	if err != nil {
+		return fmt.Errorf("setting source url of image id=%s, %w", fileID, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking source url of image id=%s, %w", fileID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
 func (i *ImageRepo) Delete(ctx context.Context, fileID string) error {
 	query := `DELETE FROM image_descriptions where file_id = $1`
 	_, err := i.db.ExecContext(ctx, query, fileID)
@@ -123,8 +184,87 @@ func (i *ImageRepo) Delete(ctx context.Context, fileID string) error {
 	return nil
 }
 
+// FindSimilar returns images whose perceptual hash is within maxDistance bits of the hash of the given image.
+func (i *ImageRepo) FindSimilar(
+	ctx context.Context,
+	fileID string,
+	maxDistance,
+	limit int,
+) ([]domain.SimilarImage, error) {
+	images := make([]domain.SimilarImage, 0)
+
+	query := `SELECT d.file_id, d.description, d.last_modified, d.phash, d.annotations, d.corrected_description,
+       		d.source_url, d.metadata,
+       		bit_count((d.phash # s.phash)::bit(64)) AS distance
+		FROM image_descriptions d 
+		    JOIN image_descriptions s ON s.file_id = $1 AND s.file_id <> d.file_id
+		WHERE d.phash IS NOT NULL AND bit_count((d.phash # s.phash)::bit(64)) <= $2
+		ORDER BY distance, d.last_modified desc LIMIT $3`
+	err := i.db.SelectContext(ctx, &images, query, fileID, maxDistance, limit)
+	if err != nil {
+		return images, fmt.Errorf("searching for images similar to %s, %w", fileID, err)
+	}
+
+	return images, nil
+}
+
+// FindCandidates returns images that are visually close to the hash or whose text contains any of the terms,
+// the text is the corrected description if it is set.
+// Candidates are ordered by visual distance, so that the closest images are not cut off by the limit.
+func (i *ImageRepo) FindCandidates(
+	ctx context.Context,
+	hash int64,
+	terms []string,
+	maxDistance,
+	limit int,
+) ([]domain.Image, error) {
+	images := make([]domain.Image, 0)
+
+	query := `SELECT ` + imageColumns + ` 
+		FROM image_descriptions 
+		WHERE ($1 <> 0 AND phash IS NOT NULL AND bit_count((phash # $1)::bit(64)) <= $2)
+			OR ($3 <> '' AND to_tsvector('simple', coalesce(nullif(corrected_description, ''), description)) @@ to_tsquery('simple', $3))
+		ORDER BY coalesce(bit_count((phash # $1)::bit(64)), 64), last_modified desc LIMIT $4`
+	args := []any{hash, maxDistance, strings.Join(terms, " | "), limit}
+	err := i.db.SelectContext(ctx, &images, query, args...)
+	if err != nil {
+		return images, fmt.Errorf("searching for candidates with query %s, %w", query, err)
+	}
+
+	return images, nil
+}
+
+// ListImages returns images ordered by file id, starting after the given id, for iterating over all images.
+func (i *ImageRepo) ListImages(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+	images := make([]domain.Image, 0)
+
+	query := `SELECT ` + imageColumns + ` 
+		FROM image_descriptions 
+		WHERE file_id > $1
+		ORDER BY file_id LIMIT $2`
+	err := i.db.SelectContext(ctx, &images, query, after, limit)
+	if err != nil {
+		return images, fmt.Errorf("listing images after %s, %w", after, err)
+	}
+
+	return images, nil
+}
+
+// RecentImages returns the latest images by modification time.
+func (i *ImageRepo) RecentImages(ctx context.Context, limit int) ([]domain.Image, error) {
+	images := make([]domain.Image, 0)
+
+	query := `SELECT ` + imageColumns + ` FROM image_descriptions ORDER BY last_modified DESC, file_id LIMIT $1`
+	err := i.db.SelectContext(ctx, &images, query, limit)
+	if err != nil {
+		return images, fmt.Errorf("listing recent images, %w", err)
+	}
+
+	return images, nil
+}
+
 func (i *ImageRepo) Get(ctx context.Context, fileID string) (domain.Image, error) {
-	query := `SELECT file_id, description, last_modified FROM image_descriptions where file_id = $1`
+	query := `SELECT ` + imageColumns + ` FROM image_descriptions where file_id = $1`
 	img := &domain.Image{}
 	err := i.db.GetContext(ctx, img, query, fileID)
 
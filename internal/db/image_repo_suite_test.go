@@ -0,0 +1,35 @@
+package db_test
+
+import (
+	"os"
+	"testing"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/repotest"
+	_ "github.com/jackc/pgx/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestImageRepo_Suite(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	testDB, err := sqlx.Connect("pgx", os.Getenv("TEST_DSN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncate := func(t *testing.T) {
+		_, err := testDB.Exec(`TRUNCATE image_descriptions CASCADE`)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repo {
+		truncate(t)
+		t.Cleanup(func() { truncate(t) })
+
+		return dbadapter.NewImageRepo(testDB)
+	})
+}
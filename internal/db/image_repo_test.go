@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"os"
-	"strings"
 	"testing"
 	"time"
 
@@ -14,8 +13,6 @@ import (
 	"github.com/matryer/is"
 )
 
-const testFileID = "expected-file-id"
-
 func TestImageRepo(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -25,206 +22,444 @@ func TestImageRepo(t *testing.T) {
 
 	repo := NewImageRepo(testDB)
 
-	t.Run("Upsert replaces description on when the same file id is used", func(t *testing.T) {
+	t.Run("FindSimilar returns images with close perceptual hashes", func(t *testing.T) {
 		tt := is.New(t)
 
-		err := repo.Upsert(ctx, domain.Image{
-			FileID:      testFileID,
-			Description: "original-description",
-		})
+		images := []domain.Image{
+			{FileID: "similar-source", PHash: 0b1111},
+			{FileID: "similar-close", PHash: 0b0111},
+			{FileID: "similar-far", PHash: -1},
+			{FileID: "similar-no-hash"},
+		}
+		for _, img := range images {
+			err := repo.Upsert(ctx, img)
+			tt.NoErr(err)
+		}
+
+		similar, err := repo.FindSimilar(ctx, "similar-source", 5, 10)
 		tt.NoErr(err)
 
-		gotOrig, err := repo.Get(ctx, testFileID)
-		tt.NoErr(err)
-		tt.Equal(gotOrig.Description, "original-description")
+		tt.Equal(1, len(similar))
+		tt.Equal("similar-close", similar[0].FileID)
+		tt.Equal(1, similar[0].Distance)
+	})
 
-		err = repo.Upsert(context.Background(), domain.Image{
-			FileID:      testFileID,
-			Description: "updated-description",
-		})
-		tt.NoErr(err)
-		gotUpdated, err := repo.Get(ctx, testFileID)
+	t.Run("FindCandidates returns images matching either the hash or the terms", func(t *testing.T) {
+		tt := is.New(t)
+
+		images := []domain.Image{
+			{FileID: "candidate-visual", PHash: 0x0F0F0F0F0F0F0F0F},
+			{FileID: "candidate-text", Description: "kubernetes OOMKilled"},
+			{FileID: "candidate-none", Description: "nothing here", PHash: -1},
+		}
+		for _, img := range images {
+			err := repo.Upsert(ctx, img)
+			tt.NoErr(err)
+		}
+
+		candidates, err := repo.FindCandidates(ctx, 0x0F0F0F0F0F0F0F0E, []string{"oomkilled", "pods"}, 2, 10)
 		tt.NoErr(err)
-		tt.Equal(gotUpdated.Description, "updated-description")
+
+		tt.Equal(2, len(candidates))
+		tt.Equal("candidate-visual", candidates[0].FileID) // visually close images go first
+		tt.Equal("candidate-text", candidates[1].FileID)
 	})
 
-	t.Run("Upsert adds file id to an error in case of query failure", func(t *testing.T) {
+	t.Run("FindCandidates prefers the corrected description to the ocr output", func(t *testing.T) {
 		tt := is.New(t)
 
-		cancelledContext, cancel := context.WithCancel(ctx)
-		cancel() // cancelling immediately to induce error
+		err := repo.Upsert(ctx, domain.Image{FileID: "candidate-corrected", Description: "kubernetes CrashLoop"})
+		tt.NoErr(err)
+		corrected := "kubernetes ImagePullBackOff"
+		_, err = repo.Patch(ctx, "candidate-corrected", domain.ImagePatch{CorrectedDescription: &corrected})
+		tt.NoErr(err)
 
-		err := repo.Upsert(cancelledContext, domain.Image{
-			FileID: testFileID,
-		})
+		candidates, err := repo.FindCandidates(ctx, 0, []string{"crashloop"}, 2, 10)
+		tt.NoErr(err)
+		tt.Equal(0, len(candidates))
 
-		tt.True(strings.Contains(err.Error(), testFileID))
-		tt.True(errors.Is(err, context.Canceled))
+		candidates, err = repo.FindCandidates(ctx, 0, []string{"imagepullbackoff"}, 2, 10)
+		tt.NoErr(err)
+		tt.Equal(1, len(candidates))
+		tt.Equal("candidate-corrected", candidates[0].FileID)
 	})
 
-	t.Run("GetLastModified returns zero value if no images were added", func(t *testing.T) {
+	t.Run("Upsert keeps entities if they were not extracted", func(t *testing.T) {
 		tt := is.New(t)
 
-		want := time.Unix(32530952912, 0).UTC()
-		img := &domain.Image{FileID: "file-id", LastModified: want}
-		err := repo.Upsert(ctx, *img)
+		err := repo.Upsert(ctx, domain.Image{
+			FileID:      "entity-ip",
+			Description: "connection to 10.0.0.1 refused",
+			Entities:    []domain.Entity{{Kind: domain.EntityIP, Value: "10.0.0.1"}},
+		})
+		tt.NoErr(err)
+		err = repo.Upsert(ctx, domain.Image{FileID: "entity-ip", Description: "updated"})
 		tt.NoErr(err)
 
-		got, err := repo.GetLastModified(ctx)
+		entities, err := repo.ListEntities(ctx, domain.EntityIP, 10)
 		tt.NoErr(err)
-		tt.Equal(want, got)
+		tt.Equal([]domain.EntityCount{{Entity: domain.Entity{Kind: domain.EntityIP, Value: "10.0.0.1"}, Count: 1}}, entities)
 
-		want = want.Add(1 * time.Second)
-		img.LastModified = want
-		err = repo.Upsert(ctx, *img)
+		err = repo.Upsert(ctx, domain.Image{FileID: "entity-ip", Entities: []domain.Entity{}})
 		tt.NoErr(err)
 
-		got, err = repo.GetLastModified(ctx)
+		entities, err = repo.ListEntities(ctx, domain.EntityIP, 10)
 		tt.NoErr(err)
-		tt.Equal(want, got)
+		tt.Equal(0, len(entities)) // empty entities replace stored ones
 	})
 
-	t.Run("GetLastModified returns the latest last modified from all images", func(t *testing.T) {
+	t.Run("ListFindings returns images with findings", func(t *testing.T) {
 		tt := is.New(t)
 
-		want := time.Unix(32530952912, 0).UTC()
-		img := &domain.Image{FileID: "file-id", LastModified: want}
-		err := repo.Upsert(ctx, *img)
+		finding := domain.Finding{Rule: "aws_access_key_id", Preview: "AKIA****************"}
+		err := repo.Upsert(ctx, domain.Image{FileID: "flagged", Findings: []domain.Finding{finding}})
 		tt.NoErr(err)
-
-		got, err := repo.GetLastModified(ctx)
+		err = repo.Upsert(ctx, domain.Image{FileID: "clean", Findings: []domain.Finding{}})
 		tt.NoErr(err)
-		tt.Equal(want, got)
 
-		want = want.Add(1 * time.Second)
-		img.LastModified = want
-		err = repo.Upsert(ctx, *img)
+		images, err := repo.ListFindings(ctx, "aws_access_key_id", 1, 100)
 		tt.NoErr(err)
+		tt.Equal(1, len(images))
+		tt.Equal("flagged", images[0].FileID)
+		tt.Equal([]domain.Finding{finding}, images[0].Findings)
 
-		got, err = repo.GetLastModified(ctx)
+		images, err = repo.ListFindings(ctx, "github_token", 1, 100)
 		tt.NoErr(err)
-		tt.Equal(want, got)
+		tt.Equal(0, len(images))
 	})
 
-	t.Run("GetLastModified returns wrapped error if there is an error in the query", func(t *testing.T) {
+	t.Run("rule tags never remove manual tags", func(t *testing.T) {
 		tt := is.New(t)
 
-		ctx, cancel := context.WithCancel(ctx)
-		cancel() // inducing error
+		err := repo.Upsert(ctx, domain.Image{FileID: "tagged", Description: "grafana", Tags: []string{"monitoring"}})
+		tt.NoErr(err)
+		err = repo.TagImage(ctx, "tagged", "favourite")
+		tt.NoErr(err)
+		err = repo.TagImage(ctx, "tagged", "monitoring")
+		tt.NoErr(err)
+
+		err = repo.SetRuleTags(ctx, "tagged", []string{"dashboard"})
+		tt.NoErr(err)
+
+		tags, err := repo.ImageTags(ctx, "tagged")
+		tt.NoErr(err)
+		tt.Equal([]domain.ImageTag{
+			{Name: "dashboard", Source: domain.TagSourceRule},
+			{Name: "favourite", Source: domain.TagSourceManual},
+			{Name: "monitoring", Source: domain.TagSourceManual},
+		}, tags)
+
+		tagsOf, err := repo.TagsOfImages(ctx, []string{"tagged", "missing"})
+		tt.NoErr(err)
+		tt.Equal(map[string][]domain.ImageTag{"tagged": tags}, tagsOf)
+
+		images, err := repo.FindByDescription(ctx, "tag:favourite", 1, 100)
+		tt.NoErr(err)
+		tt.Equal(1, len(images))
+		tt.Equal("tagged", images[0].FileID)
+
+		err = repo.UntagImage(ctx, "tagged", "favourite")
+		tt.NoErr(err)
+		err = repo.DeleteTag(ctx, "dashboard")
+		tt.NoErr(err)
 
-		_, err := repo.GetLastModified(ctx)
+		tags, err = repo.ImageTags(ctx, "tagged")
+		tt.NoErr(err)
+		tt.Equal([]domain.ImageTag{{Name: "monitoring", Source: domain.TagSourceManual}}, tags)
 
-		tt.True(errors.Is(err, context.Canceled))
+		err = repo.DeleteTag(ctx, "dashboard")
+		tt.True(errors.Is(err, ErrRecordNotFound))
 	})
 
-	t.Run("GetLastModified returns start of Unix time if there is nothing to find", func(t *testing.T) {
+	t.Run("ListImages iterates over images by file id", func(t *testing.T) {
 		tt := is.New(t)
 
-		query := `truncate image_descriptions`
-		_, err := testDB.Exec(query)
+		images, err := repo.ListImages(ctx, "", 2)
 		tt.NoErr(err)
+		tt.Equal(2, len(images))
 
-		want := time.Unix(0, 0).UTC()
-		got, err := repo.GetLastModified(ctx)
-
+		next, err := repo.ListImages(ctx, images[1].FileID, 2)
 		tt.NoErr(err)
-		tt.Equal(want, got)
+		tt.True(len(next) > 0)
+		tt.True(next[0].FileID > images[1].FileID) // pages must not overlap
 	})
 
-	t.Run("FindByDescription returns images with descriptions matching search string", func(t *testing.T) {
+	t.Run("Patch keeps user edits on reindexing and records history", func(t *testing.T) {
 		tt := is.New(t)
 
-		err := repo.Upsert(ctx, domain.Image{
-			FileID:      "expected-found-id",
-			Description: "find me",
-		})
+		err := repo.Upsert(ctx, domain.Image{FileID: "edited", Description: "conection refused"})
 		tt.NoErr(err)
 
-		err = repo.Upsert(ctx, domain.Image{
-			FileID:      "expected-not-found-id",
-			Description: "skip me",
+		annotations, corrected := "prod incident 2024-05", "connection refused"
+		img, err := repo.Patch(ctx, "edited", domain.ImagePatch{Annotations: &annotations})
+		tt.NoErr(err)
+		tt.Equal(img.Annotations, annotations)
+		_, err = repo.Patch(ctx, "edited", domain.ImagePatch{
+			Annotations:          &annotations, // unchanged values are not recorded
+			CorrectedDescription: &corrected,
 		})
 		tt.NoErr(err)
 
-		images, err := repo.FindByDescription(ctx, "find me", 1, 100)
+		err = repo.Upsert(ctx, domain.Image{FileID: "edited", Description: "conection refused again"})
+		tt.NoErr(err)
+
+		img, err = repo.Get(ctx, "edited")
 		tt.NoErr(err)
+		tt.Equal(img.Description, "conection refused again")
+		tt.Equal(img.Annotations, annotations)
+		tt.Equal(img.CorrectedDescription, corrected)
 
+		images, err := repo.FindByDescription(ctx, "incident", 1, 100)
+		tt.NoErr(err)
 		tt.Equal(1, len(images))
-		tt.Equal("expected-found-id", images[0].FileID)
+		tt.Equal("edited", images[0].FileID)
+
+		edits, err := repo.ImageEdits(ctx, "edited")
+		tt.NoErr(err)
+		tt.Equal(2, len(edits))
+		tt.Equal(edits[0].Field, domain.FieldCorrectedDescription)
+		tt.Equal(edits[1].Field, domain.FieldAnnotations)
+		tt.Equal(edits[1].OldValue, "")
+
+		_, err = repo.Patch(ctx, "does-not-exist", domain.ImagePatch{Annotations: &annotations})
+		tt.True(errors.Is(err, ErrRecordNotFound))
 	})
 
-	t.Run("FindByDescription returns error if there is an error in the query", func(t *testing.T) {
+	t.Run("saved searches are matched against images", func(t *testing.T) {
 		tt := is.New(t)
 
-		ctx, cancel := context.WithCancel(ctx)
-		cancel()
+		err := repo.Upsert(ctx, domain.Image{FileID: "oom", Description: "pod was OOMKilled"})
+		tt.NoErr(err)
 
-		images, err := repo.FindByDescription(ctx, "find me", 1, 100)
-		tt.Equal(0, len(images))
-		tt.True(errors.Is(err, context.Canceled))
+		saved, err := repo.CreateSavedSearch(ctx, "oom", "oomkilled")
+		tt.NoErr(err)
+		saved, err = repo.UpdateSavedSearch(ctx, saved.ID, "oom kills", "OOMKilled")
+		tt.NoErr(err)
+		tt.Equal(saved.Name, "oom kills")
+
+		searches, err := repo.ListSavedSearches(ctx)
+		tt.NoErr(err)
+		tt.True(len(searches) > 0)
+
+		matches, err := repo.Matches(ctx, "oom", saved.Query)
+		tt.NoErr(err)
+		tt.True(matches)
+
+		matches, err = repo.Matches(ctx, "oom", "panic:")
+		tt.NoErr(err)
+		tt.True(!matches)
+
+		for _, tc := range []struct{ matches, added bool }{{true, true}, {true, false}, {false, false}, {true, true}} {
+			added, err := repo.SetSavedSearchMatch(ctx, saved.ID, "oom", tc.matches)
+			tt.NoErr(err)
+			tt.Equal(added, tc.added) // only an image that starts to match is added
+		}
+
+		err = repo.DeleteSavedSearch(ctx, saved.ID)
+		tt.NoErr(err)
+		_, err = repo.GetSavedSearch(ctx, saved.ID)
+		tt.True(errors.Is(err, ErrRecordNotFound))
 	})
 
-	t.Run("FindByDescription uses pattern matching if full text fails", func(t *testing.T) {
+	t.Run("index queue is shared between instances", func(t *testing.T) {
 		tt := is.New(t)
 
-		err := repo.Upsert(ctx, domain.Image{
-			FileID:      "expected-found-id",
-			Description: "complicatedstring",
-		})
+		unlock, ok, err := repo.TryLockScan(ctx)
+		tt.NoErr(err)
+		tt.True(ok)
+		_, ok, err = repo.TryLockScan(ctx)
 		tt.NoErr(err)
+		tt.True(!ok) // only one instance scans at a time
+		unlock()
 
-		images, err := repo.FindByDescription(ctx, "catedstring", 1, 100)
+		files := []domain.File{{Key: "queued-1", LastModified: time.Unix(1, 0)}, {Key: "queued-2", LastModified: time.Unix(2, 0)}}
+		added, err := repo.Enqueue(ctx, files)
 		tt.NoErr(err)
+		tt.Equal(added, 2)
+		added, err = repo.Enqueue(ctx, files)
+		tt.NoErr(err)
+		tt.Equal(added, 0) // queued files are skipped
 
-		tt.Equal(1, len(images))
-		tt.Equal("expected-found-id", images[0].FileID)
+		claimed, err := repo.ClaimFiles(ctx, 1, time.Minute)
+		tt.NoErr(err)
+		tt.Equal(len(claimed), 1)
+		tt.Equal(claimed[0].Key, "queued-1") // the oldest first
+		other, err := repo.ClaimFiles(ctx, 10, time.Minute)
+		tt.NoErr(err)
+		tt.Equal(len(other), 1)
+		tt.Equal(other[0].Key, "queued-2") // claimed files are leased
+
+		tt.NoErr(repo.CompleteFile(ctx, "queued-1"))
+		tt.NoErr(repo.FailFile(ctx, "queued-2", "expected error", 0, 1))
+
+		pending, failed, err := repo.IndexQueueDepth(ctx)
+		tt.NoErr(err)
+		tt.Equal(pending, 0)
+		tt.Equal(failed, 1)
+
+		left, err := repo.ClaimFiles(ctx, 10, time.Minute)
+		tt.NoErr(err)
+		tt.Equal(len(left), 0) // completed files are removed, failed ones are not retried after max attempts
+
+		_, err = testDB.ExecContext(ctx, `DELETE FROM index_queue`)
+		tt.NoErr(err)
 	})
 
-	t.Run("FindByDescription returns empty slice if there is nothing to be found", func(t *testing.T) {
+	t.Run("webhook deliveries go through the outbox", func(t *testing.T) {
 		tt := is.New(t)
 
-		images, err := repo.FindByDescription(ctx, "skipme", 1, 100)
-		tt.Equal([]domain.Image{}, images)
+		all, err := repo.CreateWebhook(ctx, "http://localhost/all", nil, "all-secret")
+		tt.NoErr(err)
+		tt.Equal(all.Secret, "all-secret")
+		deleted, err := repo.CreateWebhook(ctx, "http://localhost/deleted", []string{domain.EventImageDeleted}, "secret")
 		tt.NoErr(err)
+
+		err = repo.EnqueueDeliveries(ctx, domain.EventImageIndexed, []byte(`{"FileID":"expected-id"}`))
+		tt.NoErr(err)
+
+		claimed, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+		tt.NoErr(err)
+		tt.Equal(1, len(claimed)) // only the webhook subscribed to all events gets the delivery
+		tt.Equal(claimed[0].URL, "http://localhost/all")
+		tt.Equal(claimed[0].Secret, "all-secret")
+		tt.Equal(claimed[0].Attempts, 1)
+		tt.Equal(string(claimed[0].Payload), `{"FileID": "expected-id"}`)
+
+		again, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+		tt.NoErr(err)
+		tt.Equal(0, len(again)) // claimed deliveries are leased
+
+		now := time.Now()
+		d := claimed[0].WebhookDelivery
+		d.Status, d.ResponseCode, d.DeliveredAt = domain.DeliveryDelivered, 204, &now
+		err = repo.UpdateDelivery(ctx, d)
+		tt.NoErr(err)
+
+		deliveries, err := repo.ListDeliveries(ctx, all.ID, domain.DeliveryDelivered, 1, 10)
+		tt.NoErr(err)
+		tt.Equal(1, len(deliveries))
+		tt.Equal(deliveries[0].ResponseCode, 204)
+
+		err = repo.DeleteWebhook(ctx, all.ID)
+		tt.NoErr(err)
+		err = repo.DeleteWebhook(ctx, deleted.ID)
+		tt.NoErr(err)
+		_, err = repo.GetWebhook(ctx, all.ID)
+		tt.True(errors.Is(err, ErrRecordNotFound))
 	})
 
-	t.Run("Delete returns nil if removal is successful", func(t *testing.T) {
+	t.Run("appended events notify listeners and can be replayed", func(t *testing.T) {
 		tt := is.New(t)
 
-		err := repo.Upsert(ctx, domain.Image{FileID: "delete-me"})
+		listener := NewListener(os.Getenv("TEST_DSN"), EventsChannel)
+		defer listener.Close()
+
+		last, err := repo.LastEventSeq(ctx)
 		tt.NoErr(err)
-		err = repo.Delete(ctx, "delete-me")
+
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		notified := make(chan error)
+		go func() { notified <- listener.Wait(waitCtx) }()
+		// the listener must be subscribed before the event is appended
+		time.Sleep(100 * time.Millisecond)
+
+		seq, err := repo.AppendEvent(ctx, domain.Event{Type: domain.EventImageDeleted, FileID: "streamed-id"})
+		tt.NoErr(err)
+		tt.True(seq > last)
+		tt.NoErr(<-notified)
+
+		events, err := repo.EventsAfter(ctx, last, 10)
+		tt.NoErr(err)
+		tt.Equal(len(events), 1)
+		tt.Equal(events[0].Seq, seq)
+		tt.Equal(events[0].FileID, "streamed-id")
+
+		err = repo.DeleteEventsBefore(ctx, time.Now().Add(time.Hour))
+		tt.NoErr(err)
+		events, err = repo.EventsAfter(ctx, 0, 10)
+		tt.NoErr(err)
+		tt.Equal(len(events), 0)
+	})
+
+	t.Run("feed tokens are checked by hash", func(t *testing.T) {
+		tt := is.New(t)
+
+		created, err := repo.CreateFeedToken(ctx, "alex", "expected-hash")
+		tt.NoErr(err)
+		tt.True(created.LastUsedAt == nil)
+
+		err = repo.UseFeedToken(ctx, "expected-hash")
+		tt.NoErr(err)
+		err = repo.UseFeedToken(ctx, "unknown-hash")
+		tt.True(errors.Is(err, ErrRecordNotFound))
+
+		tokens, err := repo.ListFeedTokens(ctx)
 		tt.NoErr(err)
+		tt.Equal(tokens[len(tokens)-1].Name, "alex")
+		tt.True(tokens[len(tokens)-1].LastUsedAt != nil)
+
+		tt.NoErr(repo.DeleteFeedToken(ctx, created.ID))
+		err = repo.UseFeedToken(ctx, "expected-hash")
+		tt.True(errors.Is(err, ErrRecordNotFound))
 	})
 
-	t.Run("Delete returns error if removal has failed", func(t *testing.T) {
+	t.Run("RecentImages returns the latest images first", func(t *testing.T) {
 		tt := is.New(t)
 
-		ctx, cancel := context.WithCancel(ctx)
-		cancel() // inducing error
-		err := repo.Delete(ctx, "delete-me")
+		tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "recent-old", LastModified: time.Now().Add(-time.Hour)}))
+		tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "recent-new", LastModified: time.Now().Add(time.Hour)}))
 
-		tt.True(errors.Is(err, context.Canceled))
+		images, err := repo.RecentImages(ctx, 1)
+		tt.NoErr(err)
+		tt.Equal(len(images), 1)
+		tt.Equal(images[0].FileID, "recent-new")
+
+		tt.NoErr(repo.Delete(ctx, "recent-old"))
+		tt.NoErr(repo.Delete(ctx, "recent-new"))
 	})
 
-	t.Run("Get returns wrapped error if there is an error in the query", func(t *testing.T) {
+	t.Run("metadata is stored and filtered on", func(t *testing.T) {
 		tt := is.New(t)
 
-		ctx, cancel := context.WithCancel(ctx)
-		cancel() // inducing error
+		meta := domain.Metadata{"app": "Slack", "window_title": "#incidents"}
+		tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "with-meta", Description: "deploy failed", Metadata: meta}))
+		tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "without-meta", Description: "deploy failed"}))
 
-		_, err := repo.Get(ctx, testFileID)
+		img, err := repo.Get(ctx, "with-meta")
+		tt.NoErr(err)
+		tt.Equal(img.Metadata, meta)
 
-		tt.True(errors.Is(err, context.Canceled))
+		images, err := repo.FindByDescription(ctx, "deploy meta.app:slack", 1, 10)
+		tt.NoErr(err)
+		tt.Equal(len(images), 1)
+		tt.Equal(images[0].FileID, "with-meta")
+
+		tt.NoErr(repo.Delete(ctx, "with-meta"))
+		tt.NoErr(repo.Delete(ctx, "without-meta"))
 	})
 
-	t.Run("Get returns not found error if fileID does not exist", func(t *testing.T) {
+	t.Run("source url is kept on reindexing and is searchable", func(t *testing.T) {
 		tt := is.New(t)
 
-		_, err := repo.Get(ctx, "does-not-exist")
+		tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "ingested", Description: "stack trace"}))
+		tt.NoErr(repo.SetSourceURL(ctx, "ingested", "https://chat.example.com/files/trace.png"))
+		tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "ingested", Description: "stack trace again"}))
+
+		img, err := repo.Get(ctx, "ingested")
+		tt.NoErr(err)
+		tt.Equal(img.SourceURL, "https://chat.example.com/files/trace.png")
 
+		images, err := repo.FindByDescription(ctx, "chat.example.com", 1, 10)
+		tt.NoErr(err)
+		tt.Equal(len(images), 1)
+
+		err = repo.SetSourceURL(ctx, "does-not-exist", "https://example.com")
 		tt.True(errors.Is(err, ErrRecordNotFound))
+
+		tt.NoErr(repo.Delete(ctx, "ingested"))
 	})
+
 }
 
 func newTestDB(t *testing.T) *sqlx.DB {
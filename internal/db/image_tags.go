@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// ListTags returns all tags with the number of tagged images, the most used first.
+func (i *ImageRepo) ListTags(ctx context.Context) ([]domain.TagCount, error) {
+	tags := make([]domain.TagCount, 0)
+
+	query := `SELECT t.name, count(it.file_id) AS count 
+		FROM tags t LEFT JOIN image_tags it ON it.tag_id = t.id
+		GROUP BY t.name
+		ORDER BY count desc, t.name`
+	err := i.db.SelectContext(ctx, &tags, query)
+	if err != nil {
+		return tags, fmt.Errorf("listing tags, %w", err)
+	}
+
+	return tags, nil
+}
+
+// CreateTag creates the tag if it does not exist yet.
+func (i *ImageRepo) CreateTag(ctx context.Context, name string) error {
+	_, err := i.db.ExecContext(ctx, `INSERT INTO tags (name) VALUES ($1) ON CONFLICT DO NOTHING`, name)
+	if err != nil {
+		return fmt.Errorf("creating tag %s, %w", name, err)
+	}
+
+	return nil
+}
+
+// DeleteTag deletes the tag and detaches it from all images.
+func (i *ImageRepo) DeleteTag(ctx context.Context, name string) error {
+	res, err := i.db.ExecContext(ctx, `DELETE FROM tags WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("deleting tag %s, %w", name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting tag %s, %w", name, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("tag %s not found, %w", name, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// ImageTags returns tags of the image sorted by name.
+func (i *ImageRepo) ImageTags(ctx context.Context, fileID string) ([]domain.ImageTag, error) {
+	tags := make([]domain.ImageTag, 0)
+
+	query := `SELECT t.name, it.source 
+		FROM image_tags it JOIN tags t ON t.id = it.tag_id 
+		WHERE it.file_id = $1 
+		ORDER BY t.name`
+	err := i.db.SelectContext(ctx, &tags, query, fileID)
+	if err != nil {
+		return tags, fmt.Errorf("listing tags of image id=%s, %w", fileID, err)
+	}
+
+	return tags, nil
+}
+
+// TagsOfImages returns tags of the images by file id sorted by name, images without tags are missing from the map.
+func (i *ImageRepo) TagsOfImages(ctx context.Context, fileIDs []string) (map[string][]domain.ImageTag, error) {
+	tags := make(map[string][]domain.ImageTag)
+	if len(fileIDs) == 0 {
+		return tags, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT it.file_id, t.name, it.source 
+		FROM image_tags it JOIN tags t ON t.id = it.tag_id 
+		WHERE it.file_id IN (?) 
+		ORDER BY it.file_id, t.name`, fileIDs)
+	if err != nil {
+		return tags, fmt.Errorf("listing tags of %d images, %w", len(fileIDs), err)
+	}
+
+	var rows []struct {
+		FileID string `db:"file_id"`
+		domain.ImageTag
+	}
+	err = i.db.SelectContext(ctx, &rows, i.db.Rebind(query), args...)
+	if err != nil {
+		return tags, fmt.Errorf("listing tags of %d images, %w", len(fileIDs), err)
+	}
+	for _, row := range rows {
+		tags[row.FileID] = append(tags[row.FileID], row.ImageTag)
+	}
+
+	return tags, nil
+}
+
+// TagImage attaches the tag to the image, the tag is created if needed.
+// A manual tag replaces a tag with the same name assigned by a rule, so that rules never remove it.
+func (i *ImageRepo) TagImage(ctx context.Context, fileID, name string) error {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction for tagging image id=%s, %w", fileID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = insertImageTag(ctx, tx, fileID, name, domain.TagSourceManual)
+	if err != nil {
+		return fmt.Errorf("tagging image id=%s, %w", fileID, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing tag of image id=%s, %w", fileID, err)
+	}
+
+	return nil
+}
+
+// UntagImage detaches the tag from the image.
+func (i *ImageRepo) UntagImage(ctx context.Context, fileID, name string) error {
+	query := `DELETE FROM image_tags 
+		WHERE file_id = $1 AND tag_id = (SELECT id FROM tags WHERE name = $2)`
+	_, err := i.db.ExecContext(ctx, query, fileID, name)
+	if err != nil {
+		return fmt.Errorf("untagging image id=%s, %w", fileID, err)
+	}
+
+	return nil
+}
+
+// SetRuleTags replaces tags assigned by rules to the image. Manual tags are kept.
+func (i *ImageRepo) SetRuleTags(ctx context.Context, fileID string, tags []string) error {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction for rule tags of image id=%s, %w", fileID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = replaceRuleTags(ctx, tx, fileID, tags)
+	if err != nil {
+		return fmt.Errorf("storing rule tags of image id=%s, %w", fileID, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing rule tags of image id=%s, %w", fileID, err)
+	}
+
+	return nil
+}
+
+func replaceRuleTags(ctx context.Context, tx *sqlx.Tx, fileID string, tags []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM image_tags WHERE file_id = $1 AND source = $2`,
+		fileID, domain.TagSourceRule,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting old rule tags, %w", err)
+	}
+
+	for _, name := range tags {
+		err = insertImageTag(ctx, tx, fileID, name, domain.TagSourceRule)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertImageTag(ctx context.Context, tx *sqlx.Tx, fileID, name, source string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO tags (name) VALUES ($1) ON CONFLICT DO NOTHING`, name)
+	if err != nil {
+		return fmt.Errorf("creating tag %s, %w", name, err)
+	}
+
+	// rule tags never overwrite manual ones, manual tags always win
+	query := `INSERT INTO image_tags (file_id, tag_id, source) 
+		SELECT $1, id, $3 FROM tags WHERE name = $2
+		ON CONFLICT (file_id, tag_id) DO UPDATE SET source = excluded.source 
+		    WHERE excluded.source = 'manual'`
+	_, err = tx.ExecContext(ctx, query, fileID, name, source)
+	if err != nil {
+		return fmt.Errorf("attaching tag %s, %w", name, err)
+	}
+
+	return nil
+}
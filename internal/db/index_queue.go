@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// scanLockID is the key of the advisory lock held while an instance scans the storage.
+const scanLockID = 7_046_712_020
+
+// TryLockScan takes the scan lock without waiting, ok is false if another instance holds it.
+// The lock belongs to a dedicated connection that is released by unlock.
+func (i *ImageRepo) TryLockScan(ctx context.Context) (unlock func(), ok bool, err error) {
+	conn, err := i.db.Connx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("getting connection, %w", err)
+	}
+
+	err = conn.GetContext(ctx, &ok, `SELECT pg_try_advisory_lock($1)`, int64(scanLockID))
+	if err != nil || !ok {
+		_ = conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("locking scan, %w", err)
+		}
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, int64(scanLockID))
+		_ = conn.Close()
+	}
+
+	return unlock, true, nil
+}
+
+// Enqueue adds the files to the index queue, files that are already queued are skipped.
+func (i *ImageRepo) Enqueue(ctx context.Context, files []domain.File) (int, error) {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction, %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	added := 0
+	for _, f := range files {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO index_queue (file_id, last_modified) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			f.Key, f.LastModified,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("queueing file %s, %w", f.Key, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("queueing file %s, %w", f.Key, err)
+		}
+		added += int(n)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("committing queued files, %w", err)
+	}
+
+	return added, nil
+}
+
+// ClaimFiles leases up to limit pending files, the oldest first.
+// Files locked by other instances are skipped, so that several workers can share the queue.
+func (i *ImageRepo) ClaimFiles(ctx context.Context, limit int, lease time.Duration) ([]domain.File, error) {
+	var rows []struct {
+		FileID       string    `db:"file_id"`
+		LastModified time.Time `db:"last_modified"`
+	}
+	query := `UPDATE index_queue 
+		SET attempts = attempts + 1, next_attempt_at = now() + CAST($2 AS integer) * interval '1 second'
+		WHERE file_id IN (
+			SELECT file_id FROM index_queue 
+			WHERE status = 'pending' AND next_attempt_at <= now() 
+			ORDER BY last_modified, file_id LIMIT $1 
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING file_id, last_modified`
+	err := i.db.SelectContext(ctx, &rows, query, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("claiming files, %w", err)
+	}
+
+	files := make([]domain.File, 0, len(rows))
+	for _, r := range rows {
+		files = append(files, domain.File{Key: r.FileID, LastModified: r.LastModified})
+	}
+
+	return files, nil
+}
+
+// CompleteFile removes the indexed file from the queue.
+func (i *ImageRepo) CompleteFile(ctx context.Context, fileID string) error {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM index_queue WHERE file_id = $1`, fileID)
+	if err != nil {
+		return fmt.Errorf("completing file %s, %w", fileID, err)
+	}
+
+	return nil
+}
+
+// FailFile stores the error of the file, it is retried after retryAfter until it has failed maxAttempts times.
+func (i *ImageRepo) FailFile(ctx context.Context, fileID, reason string, retryAfter time.Duration, maxAttempts int) error {
+	query := `UPDATE index_queue 
+		SET last_error = $2, 
+		    next_attempt_at = now() + CAST($3 AS integer) * interval '1 second',
+		    status = CASE WHEN attempts >= $4 THEN 'failed' ELSE 'pending' END
+		WHERE file_id = $1`
+	_, err := i.db.ExecContext(ctx, query, fileID, reason, int(retryAfter.Seconds()), maxAttempts)
+	if err != nil {
+		return fmt.Errorf("failing file %s, %w", fileID, err)
+	}
+
+	return nil
+}
+
+// IndexQueueDepth counts the queued files that wait to be indexed and the ones that failed for good.
+func (i *ImageRepo) IndexQueueDepth(ctx context.Context) (pending, failed int, err error) {
+	row := struct {
+		Pending int `db:"pending"`
+		Failed  int `db:"failed"`
+	}{}
+	err = i.db.GetContext(ctx, &row, `SELECT 
+		count(*) FILTER (WHERE status = 'pending') AS pending, 
+		count(*) FILTER (WHERE status = 'failed') AS failed 
+		FROM index_queue`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("counting queued files, %w", err)
+	}
+
+	return row.Pending, row.Failed, nil
+}
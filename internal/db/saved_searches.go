@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/search"
+)
+
+func (i *ImageRepo) ListSavedSearches(ctx context.Context) ([]domain.SavedSearch, error) {
+	searches := make([]domain.SavedSearch, 0)
+
+	err := i.db.SelectContext(ctx, &searches, `SELECT id, name, query, created_at FROM saved_searches ORDER BY id`)
+	if err != nil {
+		return searches, fmt.Errorf("listing saved searches, %w", err)
+	}
+
+	return searches, nil
+}
+
+func (i *ImageRepo) GetSavedSearch(ctx context.Context, id int) (domain.SavedSearch, error) {
+	s := domain.SavedSearch{}
+
+	err := i.db.GetContext(ctx, &s, `SELECT id, name, query, created_at FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return s, fmt.Errorf("saved search %d not found, %w", id, ErrRecordNotFound)
+		default:
+			return s, fmt.Errorf("getting saved search %d, %w", id, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (i *ImageRepo) CreateSavedSearch(ctx context.Context, name, query string) (domain.SavedSearch, error) {
+	s := domain.SavedSearch{}
+
+	err := i.db.GetContext(ctx, &s,
+		`INSERT INTO saved_searches (name, query) VALUES ($1, $2) RETURNING id, name, query, created_at`,
+		name, query,
+	)
+	if err != nil {
+		return s, fmt.Errorf("creating saved search %s, %w", name, err)
+	}
+
+	return s, nil
+}
+
+func (i *ImageRepo) UpdateSavedSearch(ctx context.Context, id int, name, query string) (domain.SavedSearch, error) {
+	s := domain.SavedSearch{}
+
+	err := i.db.GetContext(ctx, &s,
+		`UPDATE saved_searches SET name = $2, query = $3 WHERE id = $1 RETURNING id, name, query, created_at`,
+		id, name, query,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return s, fmt.Errorf("saved search %d not found, %w", id, ErrRecordNotFound)
+		default:
+			return s, fmt.Errorf("updating saved search %d, %w", id, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (i *ImageRepo) DeleteSavedSearch(ctx context.Context, id int) error {
+	res, err := i.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("deleting saved search %d, %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting saved search %d, %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("saved search %d not found, %w", id, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// Matches checks if the image is found by the search string.
+// Both full text search and pattern matching are used, same as FindByDescription.
+func (i *ImageRepo) Matches(ctx context.Context, fileID, searchString string) (bool, error) {
+	q := search.Parse(searchString)
+
+	filters, args := queryFilters(q, []any{fileID, q.Text, "%" + q.Text + "%"})
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM image_descriptions 
+		WHERE file_id = $1 AND (
+		    $2 = '' OR document @@ plainto_tsquery('simple', $2) 
+		    OR description ILIKE $3 OR corrected_description ILIKE $3 OR annotations ILIKE $3
+		    OR source_url ILIKE $3
+		)%s)`, filters)
+
+	var matches bool
+	err := i.db.GetContext(ctx, &matches, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("matching image id=%s against %s, %w", fileID, searchString, err)
+	}
+
+	return matches, nil
+}
+
+// SetSavedSearchMatch stores whether the image matches the saved search and reports if it has just started to match.
+func (i *ImageRepo) SetSavedSearchMatch(ctx context.Context, searchID int, fileID string, matches bool) (bool, error) {
+	if !matches {
+		_, err := i.db.ExecContext(ctx, `DELETE FROM saved_search_matches WHERE saved_search_id = $1 AND file_id = $2`,
+			searchID, fileID)
+		if err != nil {
+			return false, fmt.Errorf("removing match of saved search %d and image id=%s, %w", searchID, fileID, err)
+		}
+
+		return false, nil
+	}
+
+	res, err := i.db.ExecContext(ctx,
+		`INSERT INTO saved_search_matches (saved_search_id, file_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		searchID, fileID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("storing match of saved search %d and image id=%s, %w", searchID, fileID, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("storing match of saved search %d and image id=%s, %w", searchID, fileID, err)
+	}
+
+	return n == 1, nil
+}
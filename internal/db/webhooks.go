@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type webhookRow struct {
+	ID        int       `db:"id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Events    string    `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (r webhookRow) toDomain() domain.Webhook {
+	events := make([]string, 0)
+	if r.Events != "" {
+		events = strings.Split(r.Events, ",")
+	}
+
+	return domain.Webhook{ID: r.ID, URL: r.URL, Events: events, Secret: r.Secret, CreatedAt: r.CreatedAt}
+}
+
+type deliveryRow struct {
+	ID            int64      `db:"id"`
+	WebhookID     int        `db:"webhook_id"`
+	Event         string     `db:"event"`
+	Payload       string     `db:"payload"`
+	Status        string     `db:"status"`
+	Attempts      int        `db:"attempts"`
+	ResponseCode  int        `db:"response_code"`
+	LastError     string     `db:"last_error"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+	URL           string     `db:"url"`
+	Secret        string     `db:"secret"`
+}
+
+func (r deliveryRow) toDomain() domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:            r.ID,
+		WebhookID:     r.WebhookID,
+		Event:         r.Event,
+		Payload:       json.RawMessage(r.Payload),
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		ResponseCode:  r.ResponseCode,
+		LastError:     r.LastError,
+		NextAttemptAt: r.NextAttemptAt,
+		CreatedAt:     r.CreatedAt,
+		DeliveredAt:   r.DeliveredAt,
+	}
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.event, d.payload::text AS payload, d.status, d.attempts, 
+	d.response_code, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+// ListWebhooks returns registered webhooks without their secrets.
+func (i *ImageRepo) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	var rows []webhookRow
+	err := i.db.SelectContext(ctx, &rows, `SELECT id, url, '' AS secret, events, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		return []domain.Webhook{}, fmt.Errorf("listing webhooks, %w", err)
+	}
+
+	webhooks := make([]domain.Webhook, 0, len(rows))
+	for _, r := range rows {
+		webhooks = append(webhooks, r.toDomain())
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns the webhook without its secret.
+func (i *ImageRepo) GetWebhook(ctx context.Context, id int) (domain.Webhook, error) {
+	var row webhookRow
+	err := i.db.GetContext(ctx, &row, `SELECT id, url, '' AS secret, events, created_at FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return domain.Webhook{}, fmt.Errorf("webhook %d not found, %w", id, ErrRecordNotFound)
+		default:
+			return domain.Webhook{}, fmt.Errorf("getting webhook %d, %w", id, err)
+		}
+	}
+
+	return row.toDomain(), nil
+}
+
+func (i *ImageRepo) CreateWebhook(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error) {
+	var row webhookRow
+	err := i.db.GetContext(ctx, &row,
+		`INSERT INTO webhooks (url, secret, events) VALUES ($1, $2, $3) RETURNING id, url, secret, events, created_at`,
+		url, secret, strings.Join(events, ","),
+	)
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("creating webhook for %s, %w", url, err)
+	}
+
+	return row.toDomain(), nil
+}
+
+func (i *ImageRepo) DeleteWebhook(ctx context.Context, id int) error {
+	res, err := i.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("deleting webhook %d, %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting webhook %d, %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("webhook %d not found, %w", id, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// EnqueueDeliveries adds a pending delivery of the event for every webhook subscribed to it.
+func (i *ImageRepo) EnqueueDeliveries(ctx context.Context, event string, payload []byte) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload) 
+		SELECT id, $1, CAST(CAST($2 AS text) AS jsonb) FROM webhooks 
+		WHERE events = '' OR $1 = ANY(string_to_array(events, ','))`
+	_, err := i.db.ExecContext(ctx, query, event, string(payload))
+	if err != nil {
+		return fmt.Errorf("enqueueing deliveries of %s, %w", event, err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries locks due pending deliveries for the lease, so that concurrent workers skip them.
+// If the worker dies, the deliveries become due again when the lease expires.
+func (i *ImageRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.ClaimedDelivery, error) {
+	var rows []deliveryRow
+	query := `UPDATE webhook_deliveries d 
+		SET attempts = d.attempts + 1, next_attempt_at = now() + CAST($2 AS integer) * interval '1 second'
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries 
+			WHERE status = 'pending' AND next_attempt_at <= now() 
+			ORDER BY next_attempt_at, id LIMIT $1 
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns + `, w.url, w.secret`
+	err := i.db.SelectContext(ctx, &rows, query, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("claiming deliveries, %w", err)
+	}
+
+	claimed := make([]domain.ClaimedDelivery, 0, len(rows))
+	for _, r := range rows {
+		claimed = append(claimed, domain.ClaimedDelivery{WebhookDelivery: r.toDomain(), URL: r.URL, Secret: r.Secret})
+	}
+
+	return claimed, nil
+}
+
+// UpdateDelivery stores the result of a delivery attempt.
+func (i *ImageRepo) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries 
+		SET status = $2, response_code = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6 
+		WHERE id = $1`
+	_, err := i.db.ExecContext(ctx, query, d.ID, d.Status, d.ResponseCode, d.LastError, d.NextAttemptAt, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("updating delivery %d, %w", d.ID, err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns deliveries of the webhook, the latest first. Empty status means any status.
+func (i *ImageRepo) ListDeliveries(
+	ctx context.Context,
+	webhookID int,
+	status string,
+	page,
+	perPage int,
+) ([]domain.WebhookDelivery, error) {
+	var rows []deliveryRow
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d 
+		WHERE d.webhook_id = $1 AND (d.status = $2 OR $2 = '')
+		ORDER BY d.id desc LIMIT $3 OFFSET $4`
+	err := i.db.SelectContext(ctx, &rows, query, webhookID, status, perPage, (page-1)*perPage)
+	if err != nil {
+		return []domain.WebhookDelivery{}, fmt.Errorf("listing deliveries of webhook %d, %w", webhookID, err)
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(rows))
+	for _, r := range rows {
+		deliveries = append(deliveries, r.toDomain())
+	}
+
+	return deliveries, nil
+}
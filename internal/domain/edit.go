@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Fields of an image that are edited by users. They are never changed by indexing.
+const (
+	FieldAnnotations          = "annotations"
+	FieldCorrectedDescription = "corrected_description"
+)
+
+// ImagePatch is a change of user editable fields, nil fields are left as is.
+type ImagePatch struct {
+	Annotations          *string
+	CorrectedDescription *string
+}
+
+// ImageEdit is a recorded change of a user editable field.
+type ImageEdit struct {
+	Field    string    `db:"field"`
+	OldValue string    `db:"old_value"`
+	NewValue string    `db:"new_value"`
+	EditedAt time.Time `db:"edited_at"`
+}
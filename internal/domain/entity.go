@@ -0,0 +1,24 @@
+package domain
+
+// Kinds of entities extracted from the text of images.
+const (
+	EntityURL       = "url"
+	EntityIP        = "ip"
+	EntityEmail     = "email"
+	EntityHostname  = "hostname"
+	EntityUUID      = "uuid"
+	EntityErrorCode = "error_code"
+	EntityDate      = "date"
+)
+
+// Entity is a typed value found in the text of an image.
+type Entity struct {
+	Kind  string `db:"kind"`
+	Value string `db:"value"`
+}
+
+// EntityCount is an entity together with the number of images it was found in.
+type EntityCount struct {
+	Entity
+	Count int `db:"count"`
+}
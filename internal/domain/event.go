@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Types of events published during the lifecycle of an image.
+const (
+	EventImageIndexed = "image.indexed"
+	EventImageFailed  = "image.failed"
+	EventImageDeleted = "image.deleted"
+)
+
+// Event is a change of an image that other parts of the app can react to.
+type Event struct {
+	Type   string
+	FileID string
+	Time   time.Time
+	// Image is set for events that carry the stored image.
+	Image *Image `json:",omitempty"`
+	// Error is the reason of a failure.
+	Error string `json:",omitempty"`
+}
+
+// StreamEvent is an event stored in the event log.
+// Seq orders events of all replicas and allows clients to resume a stream.
+type StreamEvent struct {
+	Seq int64
+	Event
+}
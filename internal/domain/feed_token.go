@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// FeedToken grants a feed reader access to the feeds, readers cannot send authorization headers.
+type FeedToken struct {
+	ID   int
+	Name string
+	// Token is only returned when the token is created.
+	Token      string `json:",omitempty"`
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
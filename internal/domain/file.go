@@ -1,8 +1,14 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type File struct {
 	Key          string
 	LastModified time.Time
 }
+
+// ErrFileNotFound is returned by storages when the requested file does not exist.
+var ErrFileNotFound = errors.New("file not found")
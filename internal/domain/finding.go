@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Finding is a secret detected in the text of an image. The secret itself is never stored,
+// only a preview that is safe to show.
+type Finding struct {
+	Rule    string `db:"rule"`
+	Preview string `db:"preview"`
+}
+
+// FlaggedImage is an image that has findings.
+type FlaggedImage struct {
+	FileID       string
+	LastModified time.Time
+	Findings     []Finding
+}
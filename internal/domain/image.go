@@ -6,4 +6,40 @@ type Image struct {
 	FileID       string    `db:"file_id"`
 	Description  string    `db:"description"`
 	LastModified time.Time `db:"last_modified"`
+	PHash        int64     `db:"phash" json:"-"`
+	// Annotations and CorrectedDescription are edited by users, OCR output is kept in Description.
+	Annotations          string `db:"annotations" json:",omitempty"`
+	CorrectedDescription string `db:"corrected_description" json:",omitempty"`
+	// SourceURL is the url an ingested image was fetched from, it is searchable like the description.
+	SourceURL string `db:"source_url" json:",omitempty"`
+	// Metadata comes from the object metadata of the file and its json sidecar.
+	Metadata Metadata `db:"metadata" json:",omitempty"`
+	// Entities and Findings are nil if they were not extracted, so that the stored ones are kept.
+	Entities []Entity  `db:"-" json:",omitempty"`
+	Findings []Finding `db:"-" json:",omitempty"`
+	// Tags are the tags assigned by rules, nil if the rules were not applied.
+	Tags []string `db:"-" json:",omitempty"`
+}
+
+// Text is the description corrected by a user if it is set, the OCR output otherwise.
+func (img Image) Text() string {
+	if img.CorrectedDescription != "" {
+		return img.CorrectedDescription
+	}
+
+	return img.Description
+}
+
+// SimilarImage is an image together with its perceptual hash distance to another image.
+type SimilarImage struct {
+	Image
+	Distance int `db:"distance"`
+}
+
+// ImageMatch is an image ranked by its similarity to an example image.
+type ImageMatch struct {
+	Image
+	Distance       int
+	TextSimilarity float64
+	Score          float64
 }
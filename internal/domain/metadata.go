@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Metadata is what uploaders know about an image besides its pixels, e.g. the app and the window title.
+// Keys are lower case. It is stored as a json object.
+type Metadata map[string]string
+
+// Value stores nil metadata as an empty object, so that the column is never null.
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	b, err := json.Marshal(map[string]string(m))
+	if err != nil {
+		return nil, fmt.Errorf("encoding metadata, %w", err)
+	}
+
+	return string(b), nil
+}
+
+func (m *Metadata) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("scanning metadata from %T", src)
+	}
+
+	var decoded map[string]string
+	err := json.Unmarshal(b, &decoded)
+	if err != nil {
+		return fmt.Errorf("decoding metadata, %w", err)
+	}
+	// empty metadata is nil, so that it is omitted from responses
+	if len(decoded) == 0 {
+		decoded = nil
+	}
+	*m = decoded
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// SavedSearch is a search that is evaluated against every newly indexed image.
+type SavedSearch struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	Query     string    `db:"query"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Alert is sent when a newly indexed image matches a saved search.
+type Alert struct {
+	Search SavedSearch
+	Image  Image
+	Time   time.Time
+}
@@ -0,0 +1,19 @@
+package domain
+
+// Sources of image tags. Manual tags are never removed by tagging rules.
+const (
+	TagSourceManual = "manual"
+	TagSourceRule   = "rule"
+)
+
+// ImageTag is a tag attached to an image.
+type ImageTag struct {
+	Name   string `db:"name"`
+	Source string `db:"source"`
+}
+
+// TagCount is a tag with the number of images it is attached to.
+type TagCount struct {
+	Name  string `db:"name"`
+	Count int    `db:"count"`
+}
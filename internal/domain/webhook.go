@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Statuses of webhook deliveries.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a registered url that receives image lifecycle events.
+type Webhook struct {
+	ID  int
+	URL string
+	// Events limits the event types that are sent, empty means all events.
+	Events []string
+	// Secret is only returned when the webhook is created.
+	Secret    string `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is an event queued for a webhook together with the state of its delivery.
+type WebhookDelivery struct {
+	ID            int64
+	WebhookID     int
+	Event         string
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int
+	ResponseCode  int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// ClaimedDelivery is a delivery locked by a worker for sending.
+type ClaimedDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
@@ -0,0 +1,223 @@
+// Package entities finds structured values like URLs, IP addresses and dates in OCR text.
+//
+// Every candidate found by a pattern is validated before it is accepted,
+// so that typical OCR noise does not end up in the index.
+package entities
+
+import (
+	"context"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type extractor struct {
+	kind    string
+	pattern *regexp.Regexp
+	// normalize validates the match and returns its canonical form, ok is false for invalid matches
+	normalize func(match string) (value string, ok bool)
+}
+
+var extractors = []extractor{
+	{
+		kind:      domain.EntityURL,
+		pattern:   regexp.MustCompile(`\bhttps?://[^\s<>"'` + "`" + `]+`),
+		normalize: normalizeURL,
+	},
+	{
+		kind:      domain.EntityEmail,
+		pattern:   regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`),
+		normalize: normalizeEmail,
+	},
+	{
+		kind:      domain.EntityIP,
+		pattern:   regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|\b(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`),
+		normalize: normalizeIP,
+	},
+	{
+		kind:      domain.EntityUUID,
+		pattern:   regexp.MustCompile(`\b[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\b`),
+		normalize: normalizeUUID,
+	},
+	{
+		kind:      domain.EntityHostname,
+		pattern:   regexp.MustCompile(`\b(?:[A-Za-z0-9](?:[A-Za-z0-9\-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,63}\b`),
+		normalize: normalizeHostname,
+	},
+	{
+		kind: domain.EntityErrorCode,
+		pattern: regexp.MustCompile(
+			`\b[A-Z]{2,5}-\d{3,5}\b|\bERR_[A-Z0-9_]+\b|\bE[A-Z]{4,14}\b|` +
+				`(?i:\bHTTP/?[\d.]*\s+[45]\d\d\b|\bexit (?:code|status):? \d{1,3}\b)`,
+		),
+		normalize: normalizeErrorCode,
+	},
+	{
+		kind: domain.EntityDate,
+		pattern: regexp.MustCompile(
+			`\b\d{4}-\d{2}-\d{2}\b|` +
+				`\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]* \d{1,2},? \d{4}\b|` +
+				`\b\d{1,2} (?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]* \d{4}\b`,
+		),
+		normalize: normalizeDate,
+	},
+}
+
+// errnoNames are the errno values that are commonly printed in error messages.
+var errnoNames = map[string]struct{}{
+	"EACCES": {}, "EADDRINUSE": {}, "ECONNABORTED": {}, "ECONNREFUSED": {}, "ECONNRESET": {},
+	"EEXIST": {}, "EHOSTUNREACH": {}, "EISDIR": {}, "EMFILE": {}, "ENETUNREACH": {}, "ENOENT": {},
+	"ENOMEM": {}, "ENOSPC": {}, "ENOTDIR": {}, "ENOTFOUND": {}, "EPERM": {}, "EPIPE": {}, "ETIMEDOUT": {},
+}
+
+// topLevelDomains limits hostnames to well-known domains, otherwise file names like main.go would match.
+var topLevelDomains = map[string]struct{}{
+	"ai": {}, "app": {}, "biz": {}, "ca": {}, "cloud": {}, "co": {}, "com": {}, "corp": {}, "de": {},
+	"dev": {}, "edu": {}, "eu": {}, "fr": {}, "gov": {}, "home": {}, "info": {}, "internal": {}, "io": {},
+	"lan": {}, "local": {}, "me": {}, "net": {}, "nl": {}, "org": {}, "ru": {}, "uk": {}, "us": {}, "xyz": {},
+}
+
+// Extractor is an indexing stage that extracts entities from the description of an image.
+type Extractor struct{}
+
+func NewExtractor() *Extractor {
+	return &Extractor{}
+}
+
+func (e *Extractor) Process(_ context.Context, _ string, img *domain.Image) error {
+	img.Entities = Extract(img.Description)
+
+	return nil
+}
+
+// Extract returns unique entities found in the text, sorted by kind and value.
+// The result is never nil.
+func Extract(text string) []domain.Entity {
+	seen := make(map[domain.Entity]struct{})
+	found := make([]domain.Entity, 0)
+
+	for _, ex := range extractors {
+		for _, loc := range ex.pattern.FindAllStringIndex(text, -1) {
+			if !isolated(text, loc[0], loc[1]) {
+				continue
+			}
+
+			value, ok := ex.normalize(text[loc[0]:loc[1]])
+			if !ok {
+				continue
+			}
+
+			e := domain.Entity{Kind: ex.kind, Value: value}
+			if _, ok := seen[e]; ok {
+				continue
+			}
+			seen[e] = struct{}{}
+			found = append(found, e)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Kind != found[j].Kind {
+			return found[i].Kind < found[j].Kind
+		}
+		return found[i].Value < found[j].Value
+	})
+
+	return found
+}
+
+// isolated checks that the match is not a part of a longer dotted sequence, e.g. a version number.
+func isolated(text string, start, end int) bool {
+	if start > 1 && text[start-1] == '.' && isAlnum(text[start-2]) {
+		return false
+	}
+	if end < len(text)-1 && text[end] == '.' && isAlnum(text[end+1]) {
+		return false
+	}
+
+	return true
+}
+
+func isAlnum(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func normalizeURL(match string) (string, bool) {
+	match = strings.TrimRight(match, ".,;:!?)]}")
+	u, err := url.Parse(match)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	return match, true
+}
+
+func normalizeEmail(match string) (string, bool) {
+	addr, err := mail.ParseAddress(match)
+	if err != nil {
+		return "", false
+	}
+
+	return strings.ToLower(addr.Address), true
+}
+
+func normalizeIP(match string) (string, bool) {
+	addr, err := netip.ParseAddr(match)
+	if err != nil || addr.IsUnspecified() {
+		return "", false
+	}
+
+	return addr.String(), true
+}
+
+func normalizeUUID(match string) (string, bool) {
+	return strings.ToLower(match), true
+}
+
+func normalizeHostname(match string) (string, bool) {
+	host := strings.ToLower(match)
+	tld := host[strings.LastIndex(host, ".")+1:]
+	if _, ok := topLevelDomains[tld]; !ok {
+		return "", false
+	}
+
+	return host, true
+}
+
+func normalizeErrorCode(match string) (string, bool) {
+	fields := strings.Fields(match)
+	switch {
+	case strings.HasPrefix(strings.ToUpper(match), "HTTP"):
+		return "HTTP " + fields[len(fields)-1], true
+	case strings.HasPrefix(strings.ToLower(match), "exit"):
+		return "exit " + fields[len(fields)-1], true
+	case strings.HasPrefix(match, "ERR_") || strings.Contains(match, "-"):
+		return match, true
+	}
+
+	if _, ok := errnoNames[match]; !ok {
+		return "", false
+	}
+
+	return match, true
+}
+
+var dateLayouts = []string{"2006-01-02", "Jan 2, 2006", "Jan 2 2006", "January 2, 2006", "January 2 2006",
+	"2 Jan 2006", "2 January 2006"}
+
+func normalizeDate(match string) (string, bool) {
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, match)
+		if err == nil {
+			return t.Format("2006-01-02"), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want []domain.Entity
+	}{
+		{
+			name: "urls without trailing punctuation",
+			text: "see https://grafana.example.com/d/abc?orgId=1, or (http://localhost:3000).",
+			want: []domain.Entity{
+				{Kind: domain.EntityHostname, Value: "grafana.example.com"},
+				{Kind: domain.EntityURL, Value: "http://localhost:3000"},
+				{Kind: domain.EntityURL, Value: "https://grafana.example.com/d/abc?orgId=1"},
+			},
+		},
+		{
+			name: "valid ip addresses only",
+			text: "connecting to 10.0.0.1:5432 failed, 999.1.1.1 and version 1.2.3.4.5 are not ips, fe80::1 is",
+			want: []domain.Entity{
+				{Kind: domain.EntityIP, Value: "10.0.0.1"},
+				{Kind: domain.EntityIP, Value: "fe80::1"},
+			},
+		},
+		{
+			name: "emails are lowercased",
+			text: "contact Ops.Team@Example.com now",
+			want: []domain.Entity{
+				{Kind: domain.EntityEmail, Value: "ops.team@example.com"},
+				{Kind: domain.EntityHostname, Value: "example.com"},
+			},
+		},
+		{
+			name: "file names are not hostnames",
+			text: "panic in main.go at db.internal",
+			want: []domain.Entity{
+				{Kind: domain.EntityHostname, Value: "db.internal"},
+			},
+		},
+		{
+			name: "uuids",
+			text: "request 64C988AF-A011-4C3F-AEF4-3EB070BA5EFB failed",
+			want: []domain.Entity{
+				{Kind: domain.EntityUUID, Value: "64c988af-a011-4c3f-aef4-3eb070ba5efb"},
+			},
+		},
+		{
+			name: "error codes",
+			text: "ORA-00942 ERR_CONNECTION_REFUSED ECONNREFUSED ERROR http 503 exit code 137",
+			want: []domain.Entity{
+				{Kind: domain.EntityErrorCode, Value: "ECONNREFUSED"},
+				{Kind: domain.EntityErrorCode, Value: "ERR_CONNECTION_REFUSED"},
+				{Kind: domain.EntityErrorCode, Value: "HTTP 503"},
+				{Kind: domain.EntityErrorCode, Value: "ORA-00942"},
+				{Kind: domain.EntityErrorCode, Value: "exit 137"},
+			},
+		},
+		{
+			name: "dates in different formats",
+			text: "2024-05-13 Jan 2, 2024 3 March 2024 2024-13-45",
+			want: []domain.Entity{
+				{Kind: domain.EntityDate, Value: "2024-01-02"},
+				{Kind: domain.EntityDate, Value: "2024-03-03"},
+				{Kind: domain.EntityDate, Value: "2024-05-13"},
+			},
+		},
+		{
+			name: "duplicates are removed",
+			text: "10.0.0.1 10.0.0.1",
+			want: []domain.Entity{{Kind: domain.EntityIP, Value: "10.0.0.1"}},
+		},
+		{
+			name: "nothing found",
+			text: "std::string and 12:30:45",
+			want: []domain.Entity{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			tt.Equal(Extract(tc.text), tc.want)
+		})
+	}
+}
+
+func TestExtractor_Process(t *testing.T) {
+	tt := is.New(t)
+
+	img := &domain.Image{Description: "10.0.0.1"}
+	err := NewExtractor().Process(context.Background(), "any-file", img)
+
+	tt.NoErr(err)
+	tt.Equal(img.Entities, []domain.Entity{{Kind: domain.EntityIP, Value: "10.0.0.1"}})
+}
@@ -0,0 +1,47 @@
+// Package events delivers image lifecycle events to subscribed handlers.
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type Handler interface {
+	Handle(ctx context.Context, e domain.Event)
+}
+
+// HandlerFunc adapts a function to Handler.
+type HandlerFunc func(ctx context.Context, e domain.Event)
+
+func (f HandlerFunc) Handle(ctx context.Context, e domain.Event) {
+	f(ctx, e)
+}
+
+// Bus calls subscribed handlers synchronously in the order of subscription.
+// Handlers that do slow work, e.g. network calls, must do it in the background.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handlers ...Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handlers...)
+}
+
+func (b *Bus) Publish(ctx context.Context, e domain.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, h := range b.handlers {
+		h.Handle(ctx, e)
+	}
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestBus_Publish(t *testing.T) {
+	tt := is.New(t)
+
+	var got []string
+	bus := NewBus()
+	bus.Subscribe(
+		HandlerFunc(func(_ context.Context, e domain.Event) { got = append(got, "first "+e.FileID) }),
+		HandlerFunc(func(_ context.Context, e domain.Event) { got = append(got, "second "+e.FileID) }),
+	)
+
+	bus.Publish(context.Background(), domain.Event{Type: domain.EventImageIndexed, FileID: "expected-id"})
+
+	tt.Equal(got, []string{"first expected-id", "second expected-id"})
+}
@@ -0,0 +1,182 @@
+// Package feed builds Atom feeds of screenshots and issues the tokens that protect them.
+package feed
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+const (
+	ContentType = "application/atom+xml; charset=utf-8"
+
+	atomNS  = "http://www.w3.org/2005/Atom"
+	mediaNS = "http://search.yahoo.com/mrss/"
+
+	snippetLen     = 300
+	titleLen       = 80
+	ThumbnailWidth = 320
+)
+
+type Feed struct {
+	XMLName xml.Name `xml:"feed"`
+	NS      string   `xml:"xmlns,attr"`
+	MediaNS string   `xml:"xmlns:media,attr"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Entries []Entry  `xml:"entry"`
+}
+
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type Entry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Updated   string    `xml:"updated"`
+	Links     []Link    `xml:"link"`
+	Summary   string    `xml:"summary"`
+	Content   Content   `xml:"content"`
+	Thumbnail Thumbnail `xml:"media:thumbnail"`
+}
+
+type Content struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type Thumbnail struct {
+	URL   string `xml:"url,attr"`
+	Width int    `xml:"width,attr"`
+}
+
+// Builder makes feeds with links relative to the base url of the app.
+type Builder struct {
+	baseURL string
+}
+
+func NewBuilder(baseURL string) Builder {
+	return Builder{baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Build returns a feed of the images, self is the path and query of the feed itself without the token.
+func (b Builder) Build(title, self string, images []domain.Image) Feed {
+	f := Feed{
+		NS:      atomNS,
+		MediaNS: mediaNS,
+		ID:      b.baseURL + self,
+		Title:   title,
+		Links:   []Link{{Href: b.baseURL + self, Rel: "self", Type: "application/atom+xml"}},
+		Entries: make([]Entry, 0, len(images)),
+	}
+
+	var updated time.Time
+	for _, img := range images {
+		if img.LastModified.After(updated) {
+			updated = img.LastModified
+		}
+		f.Entries = append(f.Entries, b.entry(img))
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	f.Updated = updated.UTC().Format(time.RFC3339)
+
+	return f
+}
+
+func (b Builder) entry(img domain.Image) Entry {
+	base := b.baseURL + "/api/images/" + url.PathEscape(img.FileID)
+	fileURL := base + "/file"
+	thumbURL := fmt.Sprintf("%s/thumbnail?width=%d", base, ThumbnailWidth)
+
+	text := img.Description
+	if img.CorrectedDescription != "" {
+		text = img.CorrectedDescription
+	}
+	snippet := Snippet(text, snippetLen)
+
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	title := Snippet(firstLine, titleLen)
+	if title == "" {
+		title = img.FileID
+	}
+
+	return Entry{
+		ID:      "urn:foxyshot-indexer:image:" + url.PathEscape(img.FileID),
+		Title:   title,
+		Updated: img.LastModified.UTC().Format(time.RFC3339),
+		Links:   []Link{{Href: fileURL, Rel: "alternate"}},
+		Summary: snippet,
+		Content: Content{
+			Type: "html",
+			Body: fmt.Sprintf(`<p><a href="%s"><img src="%s" alt="%s"></a></p><p>%s</p>`,
+				html.EscapeString(fileURL),
+				html.EscapeString(thumbURL),
+				html.EscapeString(img.FileID),
+				html.EscapeString(snippet),
+			),
+		},
+		Thumbnail: Thumbnail{URL: thumbURL, Width: ThumbnailWidth},
+	}
+}
+
+// Write writes the feed as an xml document.
+func Write(w io.Writer, f Feed) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("writing feed, %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(f)
+	if err != nil {
+		return fmt.Errorf("encoding feed, %w", err)
+	}
+
+	return nil
+}
+
+// Snippet collapses whitespace in the text and shortens it to at most n characters.
+func Snippet(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	return string([]rune(text)[:n-1]) + "…"
+}
+
+// GenerateToken returns a new feed token and its hash, only the hash should be stored.
+func GenerateToken() (string, string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", fmt.Errorf("generating feed token, %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	tt := is.New(t)
+
+	images := []domain.Image{
+		{
+			FileID:       "dir/first image.jpg",
+			LastModified: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			Description:  "connection   refused\nat <main>",
+		},
+		{
+			FileID:               "second.png",
+			LastModified:         time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+			Description:          "c0nnecti0n",
+			CorrectedDescription: "connection reset",
+		},
+		{FileID: "empty.jpg", LastModified: time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	f := NewBuilder("https://shots.example.com/").Build("Recent screenshots", "/feeds/recent.atom", images)
+
+	tt.Equal(f.ID, "https://shots.example.com/feeds/recent.atom")
+	tt.Equal(f.Updated, "2024-05-02T10:00:00Z") // the latest image
+	tt.Equal(len(f.Entries), 3)
+
+	first := f.Entries[0]
+	tt.Equal(first.Title, "connection refused")
+	tt.Equal(first.Summary, "connection refused at <main>")
+	tt.Equal(first.Links[0].Href, "https://shots.example.com/api/images/dir%2Ffirst%20image.jpg/file")
+	tt.Equal(first.Thumbnail.URL, "https://shots.example.com/api/images/dir%2Ffirst%20image.jpg/thumbnail?width=320")
+	tt.True(strings.Contains(first.Content.Body, "<p>connection refused at &lt;main&gt;</p>")) // text is escaped html
+
+	tt.Equal(f.Entries[1].Summary, "connection reset") // corrections are preferred over ocr text
+	tt.Equal(f.Entries[2].Title, "empty.jpg")
+}
+
+func TestWrite(t *testing.T) {
+	tt := is.New(t)
+
+	f := NewBuilder("http://localhost:8080").Build("Search", "/feeds/search.atom?q=grafana", []domain.Image{
+		{FileID: "a.jpg", Description: "grafana"},
+	})
+
+	var buf bytes.Buffer
+	tt.NoErr(Write(&buf, f))
+
+	out := buf.String()
+	tt.True(strings.HasPrefix(out, xml.Header))
+	tt.True(strings.Contains(out, `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">`))
+	tt.True(strings.Contains(out, `<media:thumbnail url="http://localhost:8080/api/images/a.jpg/thumbnail?width=320" width="320"></media:thumbnail>`))
+	tt.True(strings.Contains(out, `<link href="http://localhost:8080/feeds/search.atom?q=grafana" rel="self" type="application/atom+xml"></link>`))
+
+	var decoded Feed
+	tt.NoErr(xml.Unmarshal(buf.Bytes(), &decoded))
+	tt.Equal(decoded.Entries[0].Title, "grafana")
+}
+
+func TestSnippet(t *testing.T) {
+	tt := is.New(t)
+
+	tt.Equal(Snippet("  short\ttext\n", 10), "short text")
+	tt.Equal(Snippet("ошибка соединения", 7), "ошибка…")
+}
+
+func TestGenerateToken(t *testing.T) {
+	tt := is.New(t)
+
+	token, hash, err := GenerateToken()
+	tt.NoErr(err)
+	tt.Equal(len(token), 48)
+	tt.Equal(hash, HashToken(token))
+	tt.True(hash != token)
+}
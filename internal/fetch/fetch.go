@@ -0,0 +1,144 @@
+// Package fetch downloads images from urls given by users.
+// Connections to private, loopback and link-local addresses are refused unless allowed,
+// the check is done on the resolved address of every connection, so redirects and DNS rebinding do not bypass it.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// maxRedirects is how many redirects are followed before the fetch fails.
+const maxRedirects = 5
+
+var (
+	ErrUnsupportedURL = errors.New("only http and https urls are supported")
+	ErrForbiddenAddr  = errors.New("address is not allowed")
+	ErrTooLarge       = errors.New("response is too large")
+	ErrStatus         = errors.New("unexpected response status")
+)
+
+// sharedAddrs is the carrier-grade NAT range, it is not public although net.IP does not consider it private.
+var sharedAddrs = netip.MustParsePrefix("100.64.0.0/10")
+
+type Client struct {
+	http    *http.Client
+	maxSize int64
+}
+
+// NewClient returns a client that gives up after the timeout and on responses larger than maxSize bytes.
+// Private addresses are reachable only with allowPrivate.
+func NewClient(timeout time.Duration, maxSize int64, allowPrivate bool) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout:   timeout,
+			Transport: NewTransport(timeout, allowPrivate),
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return checkScheme(req.URL)
+			},
+		},
+		maxSize: maxSize,
+	}
+}
+
+// NewTransport returns a transport for requests to urls given by users, e.g. webhooks.
+// Private addresses are reachable only with allowPrivate.
+func NewTransport(timeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = checkAddr
+	}
+
+	return &http.Transport{
+		// a proxy would make the checked address the one of the proxy
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+	}
+}
+
+// Fetch writes the body of the url to w.
+func (c *Client) Fetch(ctx context.Context, rawURL string, w io.Writer) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parsing url, %w", err)
+	}
+	err = checkScheme(u)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request, %w", err)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching %s, %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s, %w %d", u.Redacted(), ErrStatus, resp.StatusCode)
+	}
+	if resp.ContentLength > c.maxSize {
+		return fmt.Errorf("fetching %s, %w", u.Redacted(), ErrTooLarge)
+	}
+
+	// one byte more than allowed tells a body of the maximum size from a larger one
+	n, err := io.Copy(w, io.LimitReader(resp.Body, c.maxSize+1))
+	if err != nil {
+		return fmt.Errorf("reading %s, %w", u.Redacted(), err)
+	}
+	if n > c.maxSize {
+		return fmt.Errorf("reading %s, %w", u.Redacted(), ErrTooLarge)
+	}
+
+	return nil
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w, got %q", ErrUnsupportedURL, u.Scheme)
+	}
+
+	return nil
+}
+
+// checkAddr is called with the resolved address before a connection is made.
+func checkAddr(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("parsing address %s, %w", address, err)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("parsing address %s, %w", address, err)
+	}
+
+	if !isPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddr, addr)
+	}
+
+	return nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	// global unicast excludes loopback, link-local, multicast and unspecified addresses
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddrs.Contains(addr)
+}
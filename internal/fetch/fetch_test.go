@@ -0,0 +1,84 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestClient_Fetch(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			_, _ = w.Write([]byte("expected-body"))
+		case "/large.png":
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 100))
+		case "/redirect":
+			http.Redirect(w, r, "/image.png", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("fetches the body", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := NewClient(time.Second, 20, true).Fetch(ctx, srv.URL+"/redirect", buf)
+		tt.NoErr(err)
+		tt.Equal(buf.String(), "expected-body")
+	})
+
+	t.Run("refuses private addresses", func(t *testing.T) {
+		err := NewClient(time.Second, 20, false).Fetch(ctx, srv.URL+"/image.png", &bytes.Buffer{})
+		tt.True(errors.Is(err, ErrForbiddenAddr))
+	})
+
+	t.Run("refuses large bodies", func(t *testing.T) {
+		err := NewClient(time.Second, 20, true).Fetch(ctx, srv.URL+"/large.png", &bytes.Buffer{})
+		tt.True(errors.Is(err, ErrTooLarge))
+	})
+
+	t.Run("fails on error statuses", func(t *testing.T) {
+		err := NewClient(time.Second, 20, true).Fetch(ctx, srv.URL+"/missing.png", &bytes.Buffer{})
+		tt.True(errors.Is(err, ErrStatus))
+	})
+
+	t.Run("refuses other schemes", func(t *testing.T) {
+		err := NewClient(time.Second, 20, true).Fetch(ctx, "file:///etc/passwd", &bytes.Buffer{})
+		tt.True(errors.Is(err, ErrUnsupportedURL))
+		tt.True(strings.Contains(err.Error(), "file"))
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	tt := is.New(t)
+
+	for addr, want := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false, // cloud metadata
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		tt.Equal(isPublic(netip.MustParseAddr(addr)), want) // unexpected result for an address
+	}
+}
@@ -0,0 +1,17 @@
+package fsstorage
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// changeTime returns the time the inode last changed, it is updated when a file is moved into the tree.
+func changeTime(info fs.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime()
+	}
+
+	return time.Unix(st.Ctimespec.Unix())
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package fsstorage
+
+import (
+	"io/fs"
+	"time"
+)
+
+// changeTime falls back to the modification time where the change time is not available.
+func changeTime(info fs.FileInfo) time.Time {
+	return info.ModTime()
+}
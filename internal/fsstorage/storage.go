@@ -0,0 +1,270 @@
+// Package fsstorage stores screenshots in a local directory tree, keys are slash separated paths relative to the root.
+package fsstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+)
+
+const tempFilePrefix = "foxyshot_indexer_"
+
+var ErrInvalidKey = errors.New("invalid key")
+
+type Storage struct {
+	root string
+	log  *slog.Logger
+	// excludedPrefixes hide files that are produced by the indexer itself
+	excludedPrefixes []string
+}
+
+// New returns the storage of the directory, the directory must exist.
+func New(root string, log *slog.Logger) (*Storage, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolving storage directory %s, %w", root, err)
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("opening storage directory, %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("storage path %s is not a directory", abs)
+	}
+
+	return &Storage{root: abs, log: log.WithGroup("FS")}, nil
+}
+
+// Root is the absolute path of the storage directory.
+func (s *Storage) Root() string {
+	return s.root
+}
+
+// Exclude hides files with the given key prefix from ListFiles.
+func (s *Storage) Exclude(prefix string) {
+	s.excludedPrefixes = append(s.excludedPrefixes, prefix)
+}
+
+// ListFiles returns files with the extension that were modified or moved into the tree at or after start,
+// mtime is used as LastModified. Hidden files and directories are skipped, they are usually partial downloads or app metadata.
+func (s *Storage) ListFiles(start time.Time, ext string) ([]domain.File, error) {
+	s.log.Info("listing files with ext", slog.String("ext", ext), slog.Time("from", start))
+
+	var files []domain.File
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != s.root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !imageformat.Match(d.Name(), ext) {
+			return nil
+		}
+
+		key, err := s.key(p)
+		if err != nil {
+			return err
+		}
+		if s.excluded(key) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("reading info of %s, %w", p, err)
+		}
+		if changedSince(info).Before(start) {
+			return nil
+		}
+
+		files = append(files, domain.File{Key: key, LastModified: info.ModTime()})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing files in %s, %w", s.root, err)
+	}
+
+	s.log.Info("prepared list of files for processing", slog.Int("numFiles", len(files)))
+
+	return files, nil
+}
+
+// Stat returns the file with the key without reading it.
+func (s *Storage) Stat(_ context.Context, key string) (domain.File, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return domain.File{}, fmt.Errorf("getting file %s, %w", key, notFound(err))
+	}
+	if info.IsDir() {
+		return domain.File{}, fmt.Errorf("getting file %s, %w", key, domain.ErrFileNotFound)
+	}
+
+	return domain.File{Key: key, LastModified: info.ModTime()}, nil
+}
+
+// Metadata is always empty, files have no user metadata, uploaders can put a json sidecar next to the file.
+func (s *Storage) Metadata(ctx context.Context, key string) (map[string]string, error) {
+	_, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Download copies the file to a temporary file, the caller removes it after use.
+func (s *Storage) Download(key string) (*os.File, error) {
+	src, err := s.Read(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	f, err := os.CreateTemp("", tempFilePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("creating local image file, %w", err)
+	}
+
+	_, err = io.Copy(f, src)
+	if err != nil {
+		return f, fmt.Errorf("copying file %s, %w", key, err)
+	}
+
+	return f, nil
+}
+
+// Read opens the file, the caller must close the reader.
+func (s *Storage) Read(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s, %w", key, notFound(err))
+	}
+
+	return f, nil
+}
+
+// Upload writes the file through a temporary file, so that readers never see a partial file.
+func (s *Storage) Upload(_ context.Context, key string, body io.ReadSeeker, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return fmt.Errorf("creating directory for %s, %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+tempFilePrefix)
+	if err != nil {
+		return fmt.Errorf("creating file %s, %w", key, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = io.Copy(tmp, body)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing file %s, %w", key, err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("writing file %s, %w", key, err)
+	}
+
+	err = os.Rename(tmp.Name(), p)
+	if err != nil {
+		return fmt.Errorf("storing file %s, %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteFile(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	// deleting a missing file succeeds like on s3, so that an image whose file is gone can be deleted
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting file %s, %w", key, err)
+	}
+
+	return nil
+}
+
+// changedSince is the later of the modification and the change time. Moving or copying a file with cp -p
+// keeps its modification time, which can be older than the last indexed image, the change time is new.
+func changedSince(info fs.FileInfo) time.Time {
+	modified, changed := info.ModTime(), changeTime(info)
+	if changed.After(modified) {
+		return changed
+	}
+
+	return modified
+}
+
+// path returns the path of the key, keys must not leave the root.
+func (s *Storage) path(key string) (string, error) {
+	local := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(local) {
+		return "", fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+
+	return filepath.Join(s.root, local), nil
+}
+
+func (s *Storage) key(p string) (string, error) {
+	rel, err := filepath.Rel(s.root, p)
+	if err != nil {
+		return "", fmt.Errorf("resolving key of %s, %w", p, err)
+	}
+
+	return path.Clean(filepath.ToSlash(rel)), nil
+}
+
+func (s *Storage) excluded(key string) bool {
+	for _, prefix := range s.excludedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return domain.ErrFileNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,210 @@
+package fsstorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func newTestStorage(t *testing.T, files map[string]time.Time) *Storage {
+	t.Helper()
+
+	root := t.TempDir()
+	for key, modified := range files {
+		p := filepath.Join(root, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("content of "+key), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := New(root, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestStorage_ListFiles(t *testing.T) {
+	tt := is.New(t)
+
+	// files are created now, start is after their change time, so that the modification time decides
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	s := newTestStorage(t, map[string]time.Time{
+		"old.jpg":                  start.Add(-time.Second),
+		"new.jpg":                  start.Add(time.Second),
+		"2024/01/nested.jpg":       start.Add(time.Second),
+		"other.png":                start.Add(time.Second),
+		".partial.jpg":             start.Add(time.Second),
+		".thumbnails/hidden.jpg":   start.Add(time.Second),
+		"redacted/new.jpg":         start.Add(time.Second),
+		"2024/01/exactly-from.jpg": start,
+	})
+	s.Exclude("redacted/")
+
+	files, err := s.ListFiles(start, ".jpg")
+
+	tt.NoErr(err)
+	tt.Equal(files, []domain.File{
+		{Key: "2024/01/exactly-from.jpg", LastModified: start},
+		{Key: "2024/01/nested.jpg", LastModified: start.Add(time.Second)},
+		{Key: "new.jpg", LastModified: start.Add(time.Second)},
+	})
+}
+
+func TestStorage_ListFiles_Moved(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("the change time is not available on", runtime.GOOS)
+	}
+	tt := is.New(t)
+
+	// mv and cp -p keep the modification time, the change time of the file is new
+	s := newTestStorage(t, map[string]time.Time{"moved.jpg": time.Unix(100, 0)})
+
+	files, err := s.ListFiles(time.Now().Add(-time.Hour), ".jpg")
+
+	tt.NoErr(err)
+	tt.Equal(files, []domain.File{{Key: "moved.jpg", LastModified: time.Unix(100, 0)}})
+}
+
+func TestStorage_Files(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stat, read and download", func(t *testing.T) {
+		tt := is.New(t)
+
+		s := newTestStorage(t, map[string]time.Time{"dir/shot.jpg": time.Unix(100, 0)})
+
+		file, err := s.Stat(ctx, "dir/shot.jpg")
+		tt.NoErr(err)
+		tt.Equal(file, domain.File{Key: "dir/shot.jpg", LastModified: time.Unix(100, 0)})
+
+		r, err := s.Read(ctx, "dir/shot.jpg")
+		tt.NoErr(err)
+		content, err := io.ReadAll(r)
+		tt.NoErr(err)
+		tt.NoErr(r.Close())
+		tt.Equal(string(content), "content of dir/shot.jpg")
+
+		f, err := s.Download("dir/shot.jpg")
+		tt.NoErr(err)
+		defer os.Remove(f.Name())
+		tt.True(filepath.Dir(f.Name()) != filepath.Join(s.Root(), "dir")) // removing the download keeps the original
+		downloaded, err := os.ReadFile(f.Name())
+		tt.NoErr(err)
+		tt.Equal(string(downloaded), "content of dir/shot.jpg")
+	})
+
+	t.Run("upload and delete", func(t *testing.T) {
+		tt := is.New(t)
+
+		s := newTestStorage(t, nil)
+
+		err := s.Upload(ctx, "redacted/dir/shot.jpg", bytes.NewReader([]byte("redacted")), "image/jpeg")
+		tt.NoErr(err)
+		content, err := os.ReadFile(filepath.Join(s.Root(), "redacted", "dir", "shot.jpg"))
+		tt.NoErr(err)
+		tt.Equal(string(content), "redacted")
+
+		tt.NoErr(s.DeleteFile(ctx, "redacted/dir/shot.jpg"))
+		_, err = s.Stat(ctx, "redacted/dir/shot.jpg")
+		tt.True(errors.Is(err, domain.ErrFileNotFound))
+	})
+
+	t.Run("missing files", func(t *testing.T) {
+		tt := is.New(t)
+
+		s := newTestStorage(t, map[string]time.Time{"dir/shot.jpg": time.Unix(100, 0)})
+
+		_, err := s.Stat(ctx, "missing.jpg")
+		tt.True(errors.Is(err, domain.ErrFileNotFound))
+		_, err = s.Stat(ctx, "dir")
+		tt.True(errors.Is(err, domain.ErrFileNotFound)) // directories are not files
+		_, err = s.Read(ctx, "missing.jpg")
+		tt.True(errors.Is(err, domain.ErrFileNotFound))
+		err = s.DeleteFile(ctx, "missing.jpg")
+		tt.NoErr(err) // deleting is idempotent like on s3
+	})
+
+	t.Run("keys must stay in the root", func(t *testing.T) {
+		tt := is.New(t)
+
+		s := newTestStorage(t, nil)
+
+		for _, key := range []string{"", "../outside.jpg", "dir/../../outside.jpg", "/etc/passwd"} {
+			_, err := s.Read(ctx, key)
+			tt.True(errors.Is(err, ErrInvalidKey))
+		}
+	})
+}
+
+func TestNew_NotADirectory(t *testing.T) {
+	tt := is.New(t)
+
+	p := filepath.Join(t.TempDir(), "file")
+	tt.NoErr(os.WriteFile(p, nil, 0o600))
+
+	_, err := New(p, slog.Default())
+	tt.True(err != nil)
+	_, err = New(filepath.Join(t.TempDir(), "missing"), slog.Default())
+	tt.True(err != nil)
+}
+
+func TestStorage_watch(t *testing.T) {
+	tt := is.New(t)
+
+	s := newTestStorage(t, nil)
+	s.Exclude("redacted/")
+	changes := make(chan struct{}, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.watch(ctx, ".jpg", 20*time.Millisecond, func() { changes <- struct{}{} })
+	}()
+	time.Sleep(50 * time.Millisecond) // the watcher is set up
+
+	tt.NoErr(os.WriteFile(filepath.Join(s.Root(), "ignored.txt"), nil, 0o600))
+	tt.NoErr(os.MkdirAll(filepath.Join(s.Root(), "new-dir"), 0o755))
+	select {
+	case <-changes: // the new directory is watched
+	case <-time.After(time.Second):
+		t.Fatal("no change after creating a directory")
+	}
+
+	tt.NoErr(os.WriteFile(filepath.Join(s.Root(), "new-dir", "a.jpg"), []byte("a"), 0o600))
+	tt.NoErr(os.WriteFile(filepath.Join(s.Root(), "new-dir", "b.jpg"), []byte("b"), 0o600))
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change after writing files")
+	}
+	time.Sleep(50 * time.Millisecond)
+	tt.Equal(len(changes), 0) // events of several files are reported once
+
+	// files produced by the indexer, e.g. redacted copies, do not trigger scans
+	tt.NoErr(os.MkdirAll(filepath.Join(s.Root(), "redacted", "new-dir"), 0o755))
+	tt.NoErr(os.WriteFile(filepath.Join(s.Root(), "redacted", "new-dir", "a.jpg"), []byte("a"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+	tt.Equal(len(changes), 0)
+
+	cancel()
+	tt.True(errors.Is(<-done, context.Canceled))
+}
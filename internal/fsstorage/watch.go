@@ -0,0 +1,112 @@
+package fsstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
+	"github.com/fsnotify/fsnotify"
+)
+
+// settleDelay waits for more events before notifying, screenshot apps often write a file in several steps.
+const settleDelay = 2 * time.Second
+
+// Watch calls onChange shortly after files with the extension are created or changed in the storage,
+// until the context is cancelled. New directories are watched as they appear, excluded files are ignored like in ListFiles.
+func (s *Storage) Watch(ctx context.Context, ext string, onChange func()) error {
+	return s.watch(ctx, ext, settleDelay, onChange)
+}
+
+func (s *Storage) watch(ctx context.Context, ext string, delay time.Duration, onChange func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher, %w", err)
+	}
+	defer w.Close()
+
+	err = s.addTree(w, s.root)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case e, ok := <-w.Events:
+			if !ok {
+				return errors.New("watcher closed")
+			}
+			if strings.HasPrefix(filepath.Base(e.Name), ".") || s.excludedPath(e.Name) {
+				continue
+			}
+
+			if e.Has(fsnotify.Create) {
+				info, err := os.Stat(e.Name)
+				if err == nil && info.IsDir() {
+					err = s.addTree(w, e.Name)
+					if err != nil {
+						s.log.Error("watching new directory", slog.String("dir", e.Name), slog.String("err", err.Error()))
+					}
+					// files that were moved in with the directory do not produce events of their own
+					timer.Reset(delay)
+					continue
+				}
+			}
+
+			if imageformat.Match(e.Name, ext) && (e.Has(fsnotify.Create) || e.Has(fsnotify.Write) || e.Has(fsnotify.Rename)) {
+				timer.Reset(delay)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return errors.New("watcher closed")
+			}
+			s.log.Error("watching files", slog.String("err", err.Error()))
+		case <-timer.C:
+			onChange()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// excludedPath tells if the file, or every file in the directory, is excluded.
+func (s *Storage) excludedPath(p string) bool {
+	key, err := s.key(p)
+	if err != nil {
+		return false
+	}
+
+	return s.excluded(key) || s.excluded(key+"/")
+}
+
+// addTree watches the directory and its subdirectories, hidden directories are skipped like in ListFiles.
+func (s *Storage) addTree(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != s.root && (strings.HasPrefix(d.Name(), ".") || s.excludedPath(p)) {
+			return filepath.SkipDir
+		}
+
+		err = w.Add(p)
+		if err != nil {
+			return fmt.Errorf("watching %s, %w", p, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,384 @@
+// Package imageformat detects the formats of screenshots by their content and prepares them for OCR.
+//
+// Tesseract reliably reads only JPEG and PNG, other formats are converted to PNG frames,
+// one for every page of a TIFF and every frame of an animated GIF.
+// HEIC is converted with heif-convert from libheif, it is unsupported if the command is not installed.
+package imageformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
+)
+
+// Content types of the supported formats.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	GIF  = "image/gif"
+	WebP = "image/webp"
+	BMP  = "image/bmp"
+	TIFF = "image/tiff"
+	HEIC = "image/heic"
+)
+
+// MaxFrames limits how many pages or frames of an image are OCR'd.
+const MaxFrames = 20
+
+// heicCommand converts HEIC images, it is part of libheif-examples.
+const heicCommand = "heif-convert"
+
+var ErrUnsupported = errors.New("unsupported image format")
+
+// extensions of the formats, the first one is used for stored files.
+var extensions = map[string][]string{
+	JPEG: {".jpg", ".jpeg"},
+	PNG:  {".png"},
+	GIF:  {".gif"},
+	WebP: {".webp"},
+	BMP:  {".bmp"},
+	TIFF: {".tiff", ".tif"},
+	HEIC: {".heic", ".heif"},
+}
+
+// Extension returns the extension of files of the content type, empty for unsupported types.
+func Extension(contentType string) string {
+	exts := extensions[contentType]
+	if len(exts) == 0 {
+		return ""
+	}
+
+	return exts[0]
+}
+
+// Extensions expands a comma separated list of extensions and content types into lower case extensions,
+// e.g. ".JPG,image/png" into .jpg and .png. "image/*" stands for all supported formats.
+func Extensions(list string) ([]string, error) {
+	seen := map[string]bool{}
+	var exts []string
+	add := func(ext string) {
+		if !seen[ext] {
+			seen[ext] = true
+			exts = append(exts, ext)
+		}
+	}
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		switch {
+		case item == "":
+			continue
+		case item == "image/*":
+			for _, e := range extensions {
+				for _, ext := range e {
+					add(ext)
+				}
+			}
+		case strings.Contains(item, "/"):
+			e, ok := extensions[item]
+			if !ok {
+				return nil, fmt.Errorf("%w %s", ErrUnsupported, item)
+			}
+			for _, ext := range e {
+				add(ext)
+			}
+		case strings.HasPrefix(item, "."):
+			add(item)
+		default:
+			add("." + item)
+		}
+	}
+	if len(exts) == 0 {
+		return nil, errors.New("no extensions given")
+	}
+	sort.Strings(exts)
+
+	return exts, nil
+}
+
+// Match reports whether the key ends with one of the comma separated extensions, ignoring case.
+func Match(key, exts string) bool {
+	key = strings.ToLower(key)
+	for _, ext := range strings.Split(exts, ",") {
+		if ext != "" && strings.HasSuffix(key, strings.ToLower(ext)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Detect returns the content type of the image from its first bytes, extensions are not trusted.
+func Detect(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return TIFF
+	case isHEIC(head):
+		return HEIC
+	}
+
+	return http.DetectContentType(head)
+}
+
+// DetectFile returns the content type of the file.
+func DetectFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("opening image %s, %w", name, err)
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("reading image %s, %w", name, err)
+	}
+
+	return Detect(head[:n]), nil
+}
+
+// isHEIC checks the ftyp box of the ISO base media file, HEIC and HEIF share the container with video formats.
+func isHEIC(head []byte) bool {
+	if len(head) < 12 || string(head[4:8]) != "ftyp" {
+		return false
+	}
+
+	switch string(head[8:12]) {
+	case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+		return true
+	}
+
+	return false
+}
+
+// Frames returns the files to OCR, JPEG and PNG files are returned as they are.
+// There is at least one frame unless an error is returned. Converted frames are written to temporary files that are removed by cleanup, which is never nil.
+func Frames(name string) (frames []string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	contentType, err := DetectFile(name)
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	var images []image.Image
+	switch contentType {
+	case JPEG, PNG:
+		return []string{name}, cleanup, nil
+	case GIF:
+		images, err = decodeGIF(name)
+	case WebP:
+		images, err = decodeOne(name, webp.Decode)
+	case BMP:
+		images, err = decodeOne(name, bmp.Decode)
+	case TIFF:
+		images, err = decodeTIFF(name)
+	case HEIC:
+		return convertHEIC(name)
+	default:
+		return nil, cleanup, fmt.Errorf("%w %s", ErrUnsupported, contentType)
+	}
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("decoding %s image %s, %w", contentType, name, err)
+	}
+	if len(images) == 0 {
+		return nil, cleanup, fmt.Errorf("%w, %s image %s has no frames", ErrUnsupported, contentType, name)
+	}
+
+	dir, err := os.MkdirTemp("", "foxyshot_frames_")
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("creating directory for frames, %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+
+	for i, img := range images {
+		frame := filepath.Join(dir, fmt.Sprintf("%03d.png", i))
+		err = writePNG(frame, img)
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames, cleanup, nil
+}
+
+func decodeOne(name string, decode func(io.Reader) (image.Image, error)) ([]image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return []image.Image{img}, nil
+}
+
+// decodeGIF returns the frames as they are shown, frames of animations usually update only a part of the canvas.
+func decodeGIF(name string) ([]image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	canvas := image.NewRGBA(bounds)
+	var frames []image.Image
+	for i, frame := range g.Image {
+		if i == MaxFrames {
+			break
+		}
+
+		var previous *image.RGBA
+		if g.Disposal != nil && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, image.Point{}, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		shown := image.NewRGBA(bounds)
+		draw.Draw(shown, bounds, canvas, image.Point{}, draw.Src)
+		frames = append(frames, shown)
+
+		switch {
+		case previous != nil:
+			canvas = previous
+		case g.Disposal != nil && g.Disposal[i] == gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+
+	return frames, nil
+}
+
+// decodeTIFF returns every page. The decoder reads only the first page,
+// so the other pages are read from copies of the file that point at them as the first page.
+func decodeTIFF(name string) ([]image.Image, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets, err := tiffPages(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("%w, tiff has no pages", ErrUnsupported)
+	}
+
+	var pages []image.Image
+	for _, offset := range offsets {
+		page := bytes.Clone(data)
+		order(page).PutUint32(page[4:8], offset)
+
+		img, err := tiff.Decode(bytes.NewReader(page))
+		if err != nil {
+			return nil, fmt.Errorf("decoding page %d, %w", len(pages)+1, err)
+		}
+		pages = append(pages, img)
+	}
+
+	return pages, nil
+}
+
+// tiffPages returns the offsets of the image file directories of the pages, up to MaxFrames.
+func tiffPages(data []byte) ([]uint32, error) {
+	if len(data) < 8 {
+		return nil, errors.New("tiff header is too short")
+	}
+	bo := order(data)
+
+	var offsets []uint32
+	seen := map[uint32]bool{}
+	for offset := bo.Uint32(data[4:8]); offset != 0 && len(offsets) < MaxFrames; {
+		if seen[offset] || int64(offset)+2 > int64(len(data)) {
+			return nil, fmt.Errorf("invalid tiff directory offset %d", offset)
+		}
+		seen[offset] = true
+		offsets = append(offsets, offset)
+
+		// a directory is the number of entries, 12 bytes per entry and the offset of the next directory
+		entries := int64(bo.Uint16(data[offset : offset+2]))
+		next := int64(offset) + 2 + entries*12
+		if next+4 > int64(len(data)) {
+			return nil, fmt.Errorf("invalid tiff directory at %d", offset)
+		}
+		offset = bo.Uint32(data[next : next+4])
+	}
+
+	return offsets, nil
+}
+
+func order(data []byte) binary.ByteOrder {
+	if data[0] == 'M' {
+		return binary.BigEndian
+	}
+
+	return binary.LittleEndian
+}
+
+// convertHEIC converts the primary image of the HEIC file to PNG.
+func convertHEIC(name string) ([]string, func(), error) {
+	_, err := exec.LookPath(heicCommand)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("%w %s, install %s to index it", ErrUnsupported, HEIC, heicCommand)
+	}
+
+	dir, err := os.MkdirTemp("", "foxyshot_frames_")
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("creating directory for frames, %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	frame := filepath.Join(dir, "000.png")
+	out, err := exec.Command(heicCommand, name, frame).CombinedOutput()
+	if err != nil {
+		cleanup()
+		return nil, func() {}, fmt.Errorf("running %s, %w: %s", heicCommand, err, bytes.TrimSpace(out))
+	}
+
+	return []string{frame}, cleanup, nil
+}
+
+func writePNG(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("creating frame, %w", err)
+	}
+	defer f.Close()
+
+	err = png.Encode(f, img)
+	if err != nil {
+		return fmt.Errorf("encoding frame, %w", err)
+	}
+
+	return f.Close()
+}
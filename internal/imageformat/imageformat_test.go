@@ -0,0 +1,223 @@
+package imageformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"golang.org/x/image/bmp"
+)
+
+func TestExtensions(t *testing.T) {
+	tt := is.New(t)
+
+	exts, err := Extensions(".JPG, png,image/tiff")
+	tt.NoErr(err)
+	tt.Equal(exts, []string{".jpg", ".png", ".tif", ".tiff"})
+
+	exts, err = Extensions("image/*")
+	tt.NoErr(err)
+	tt.Equal(len(exts), 10)
+
+	_, err = Extensions("video/mp4")
+	tt.True(errors.Is(err, ErrUnsupported))
+
+	_, err = Extensions(" , ")
+	tt.True(err != nil)
+}
+
+func TestMatch(t *testing.T) {
+	tt := is.New(t)
+
+	tt.True(Match("shots/Screenshot.JPG", ".jpg,.png"))
+	tt.True(Match("shots/screenshot.png", ".jpg,.png"))
+	tt.True(!Match("shots/screenshot.jpg.json", ".jpg,.png"))
+	tt.True(!Match("shots/screenshot.gif", ""))
+}
+
+func TestDetect(t *testing.T) {
+	tt := is.New(t)
+
+	tt.Equal(Detect([]byte("II*\x00\x08\x00\x00\x00")), TIFF)
+	tt.Equal(Detect([]byte("MM\x00*\x00\x00\x00\x08")), TIFF)
+	tt.Equal(Detect([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00")), HEIC)
+	tt.True(Detect([]byte("\x00\x00\x00\x18ftypisom\x00\x00\x00\x00")) != HEIC)
+	tt.Equal(Detect([]byte("RIFF\x00\x00\x00\x00WEBPVP8 ")), WebP)
+	tt.Equal(Detect([]byte("plain text")), "text/plain; charset=utf-8")
+}
+
+func TestFrames(t *testing.T) {
+	t.Run("png is used as it is", func(t *testing.T) {
+		tt := is.New(t)
+
+		name := writeFile(t, "shot.png", encoded(t, func(b *bytes.Buffer) error { return png.Encode(b, gray(10)) }))
+
+		frames, cleanup, err := Frames(name)
+		tt.NoErr(err)
+		defer cleanup()
+		tt.Equal(frames, []string{name})
+	})
+
+	t.Run("bmp is converted", func(t *testing.T) {
+		tt := is.New(t)
+
+		name := writeFile(t, "shot.bmp", encoded(t, func(b *bytes.Buffer) error { return bmp.Encode(b, gray(10)) }))
+
+		frames, cleanup, err := Frames(name)
+		tt.NoErr(err)
+		tt.Equal(grayOf(t, frames), []uint8{10})
+
+		cleanup()
+		_, err = os.Stat(frames[0])
+		tt.True(os.IsNotExist(err)) // frames must be removed by cleanup
+	})
+
+	t.Run("every frame of a gif", func(t *testing.T) {
+		tt := is.New(t)
+
+		palette := color.Palette{color.Gray{Y: 10}, color.Gray{Y: 20}}
+		first := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		second := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		for i := range second.Pix {
+			second.Pix[i] = 1
+		}
+		anim := &gif.GIF{Image: []*image.Paletted{first, second}, Delay: []int{0, 0}}
+		name := writeFile(t, "shot.gif", encoded(t, func(b *bytes.Buffer) error { return gif.EncodeAll(b, anim) }))
+
+		frames, cleanup, err := Frames(name)
+		tt.NoErr(err)
+		defer cleanup()
+		tt.Equal(grayOf(t, frames), []uint8{10, 20})
+	})
+
+	t.Run("every page of a tiff", func(t *testing.T) {
+		tt := is.New(t)
+
+		name := writeFile(t, "shot.tiff", multiPageTIFF(30, 40, 50))
+
+		frames, cleanup, err := Frames(name)
+		tt.NoErr(err)
+		defer cleanup()
+		tt.Equal(grayOf(t, frames), []uint8{30, 40, 50})
+	})
+
+	t.Run("tiff without pages", func(t *testing.T) {
+		tt := is.New(t)
+
+		name := writeFile(t, "shot.tiff", []byte("II*\x00\x00\x00\x00\x00"))
+
+		frames, cleanup, err := Frames(name)
+		defer cleanup()
+		tt.True(errors.Is(err, ErrUnsupported))
+		tt.Equal(len(frames), 0)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		tt := is.New(t)
+
+		name := writeFile(t, "shot.jpg", []byte("plain text"))
+
+		_, cleanup, err := Frames(name)
+		defer cleanup()
+		tt.True(errors.Is(err, ErrUnsupported))
+	})
+}
+
+func gray(y uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = y
+	}
+
+	return img
+}
+
+func encoded(t *testing.T, encode func(b *bytes.Buffer) error) []byte {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	err := encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(p, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+// grayOf returns the gray level of the top left pixel of every frame.
+func grayOf(t *testing.T, frames []string) []uint8 {
+	t.Helper()
+
+	var levels []uint8
+	for _, frame := range frames {
+		f, err := os.Open(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		levels = append(levels, color.GrayModel.Convert(img.At(0, 0)).(color.Gray).Y)
+	}
+
+	return levels
+}
+
+// multiPageTIFF returns an uncompressed grayscale TIFF with a 2x2 page of every gray level.
+func multiPageTIFF(levels ...uint8) []byte {
+	const entries = 8
+	le := binary.LittleEndian
+
+	buf := []byte("II*\x00\x00\x00\x00\x00")
+	prevNext := 4 // where the offset of the next directory is written
+	for _, level := range levels {
+		pixels := len(buf)
+		buf = append(buf, level, level, level, level)
+
+		ifd := len(buf)
+		le.PutUint32(buf[prevNext:], uint32(ifd))
+
+		buf = le.AppendUint16(buf, entries)
+		for _, e := range [entries][2]uint32{
+			{256, 2},              // width
+			{257, 2},              // height
+			{258, 8},              // bits per sample
+			{259, 1},              // no compression
+			{262, 1},              // black is zero
+			{273, uint32(pixels)}, // strip offset
+			{278, 2},              // rows per strip
+			{279, 4},              // strip byte count
+		} {
+			buf = le.AppendUint16(buf, uint16(e[0]))
+			buf = le.AppendUint16(buf, 4) // long
+			buf = le.AppendUint32(buf, 1)
+			buf = le.AppendUint32(buf, e[1])
+		}
+		prevNext = len(buf)
+		buf = le.AppendUint32(buf, 0)
+	}
+
+	return buf
+}
@@ -6,14 +6,19 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
 	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/phash"
 )
 
-//go:generate moq -out indexer_moq_test.go . ImageRepo FileStorage OCR
+//go:generate moq -out indexer_moq_test.go . ImageRepo FileStorage OCR Stage Publisher Queue
 type ImageRepo interface {
 	Get(ctx context.Context, fileID string) (domain.Image, error)
 	GetLastModified(ctx context.Context) (time.Time, error)
@@ -22,6 +27,7 @@ type ImageRepo interface {
 
 type FileStorage interface {
 	ListFiles(start time.Time, ext string) ([]domain.File, error)
+	Stat(ctx context.Context, key string) (domain.File, error)
 	Download(key string) (*os.File, error)
 }
 
@@ -29,10 +35,59 @@ type OCR interface {
 	Run(file string) (string, error)
 }
 
+// Stage processes an image after OCR, before it is stored.
+// File is the path to the downloaded image.
+type Stage interface {
+	Process(ctx context.Context, file string, img *domain.Image) error
+}
+
+// Publisher receives events about indexed images.
+type Publisher interface {
+	Publish(ctx context.Context, e domain.Event)
+}
+
+// Queue shares new files between instances, only one instance scans the storage at a time.
+type Queue interface {
+	TryLockScan(ctx context.Context) (unlock func(), ok bool, err error)
+	Enqueue(ctx context.Context, files []domain.File) (int, error)
+	ClaimFiles(ctx context.Context, limit int, lease time.Duration) ([]domain.File, error)
+	CompleteFile(ctx context.Context, fileID string) error
+	FailFile(ctx context.Context, fileID, reason string, retryAfter time.Duration, maxAttempts int) error
+}
+
+// ErrNoQueue means that failed files can not be queued for a retry, e.g. with sqlite or in demo mode.
+var ErrNoQueue = errors.New("indexer has no queue")
+
+const (
+	claimBatch = 5
+	// claimLease must be longer than indexing a batch takes, otherwise another instance claims the files again
+	claimLease  = 15 * time.Minute
+	retryAfter  = 5 * time.Minute
+	maxAttempts = 3
+)
+
+// Progress describes the files indexed since the process started.
+type Progress struct {
+	// CurrentFile is the file being indexed, empty when the indexer is idle
+	CurrentFile string
+	Indexed     int
+	Failed      int
+	// FilesPerMinute is the indexing rate while the indexer is busy
+	FilesPerMinute float64
+}
+
 type Indexer struct {
 	imageRepo ImageRepo
 	storage   FileStorage
 	ocrEngine OCR
+	stages    []Stage
+	publisher Publisher
+	queue     Queue
+	paused    atomic.Bool
+
+	mu       sync.Mutex
+	progress Progress
+	busy     time.Duration
 
 	log     *slog.Logger
 	tracker *monitoring.Tracker
@@ -54,16 +109,168 @@ func NewIndexer(
 	}
 }
 
+// Use adds stages that run for every indexed image in the given order.
+func (i *Indexer) Use(stages ...Stage) {
+	i.stages = append(i.stages, stages...)
+}
+
+// SetPublisher sets the publisher that is notified after an image is stored.
+func (i *Indexer) SetPublisher(p Publisher) {
+	i.publisher = p
+}
+
+// SetQueue makes the indexer share new files with other instances through the queue.
+func (i *Indexer) SetQueue(q Queue) {
+	i.queue = q
+}
+
+// IndexNewList indexes files that were added to the storage since the last indexed one.
+// With a queue the files are queued by the instance that holds the scan lock and indexed by every instance.
 func (i *Indexer) IndexNewList(ctx context.Context, pattern string) error {
+	if i.queue != nil {
+		return i.indexQueued(ctx, pattern)
+	}
+
+	files, err := i.newFiles(ctx, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if i.Paused() {
+			i.log.Info("paused, the remaining files are indexed after resuming")
+			return nil
+		}
+		// a cancelled run, e.g. at the end of a scrape window, finishes the file being indexed
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err = i.Index(file)
+		i.logResult(file, err)
+	}
+
+	return nil
+}
+
+func (i *Indexer) indexQueued(ctx context.Context, pattern string) error {
+	unlock, ok, err := i.queue.TryLockScan(ctx)
+	if err != nil {
+		return err
+	}
+	if ok {
+		files, err := i.newFiles(ctx, pattern)
+		if err == nil {
+			var queued int
+			queued, err = i.queue.Enqueue(ctx, files)
+			i.log.Info("files queued", slog.Int("count", queued))
+		}
+		unlock()
+		if err != nil {
+			return err
+		}
+	} else {
+		i.log.Info("skipping scan, another instance is scanning")
+	}
+
+	// a claimed batch is finished after pausing, so that its files do not wait for the lease to expire
+	for ctx.Err() == nil && !i.Paused() {
+		files, err := i.queue.ClaimFiles(ctx, claimBatch, claimLease)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return nil
+		}
+
+		for _, file := range files {
+			err = i.Index(file)
+			i.logResult(file, err)
+			if err != nil {
+				err = i.queue.FailFile(ctx, file.Key, err.Error(), retryAfter, maxAttempts)
+			} else {
+				err = i.queue.CompleteFile(ctx, file.Key)
+			}
+			if err != nil {
+				i.log.Error("updating queued file", slog.String("file", file.Key), slog.String("err", err.Error()))
+			}
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Pause stops the indexer from starting new work, the file being indexed is finished.
+func (i *Indexer) Pause() {
+	i.paused.Store(true)
+}
+
+func (i *Indexer) Resume() {
+	i.paused.Store(false)
+}
+
+func (i *Indexer) Paused() bool {
+	return i.paused.Load()
+}
+
+// Progress returns the files indexed since the process started.
+func (i *Indexer) Progress() Progress {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	p := i.progress
+	if i.busy > 0 {
+		p.FilesPerMinute = float64(p.Indexed+p.Failed) / i.busy.Minutes()
+	}
+
+	return p
+}
+
+// IndexKey indexes the file with the key on demand, even if it was indexed before or the indexer is paused.
+func (i *Indexer) IndexKey(ctx context.Context, key string) (domain.Image, error) {
+	file, err := i.storage.Stat(ctx, key)
+	if err != nil {
+		return domain.Image{}, err
+	}
+
+	img, err := i.indexFile(ctx, file)
+	i.logResult(file, err)
+
+	return img, err
+}
+
+// QueueRetry queues a file that failed to index outside of a scan, e.g. an upload, so that workers retry it
+// like the failed files of a scan. Scans can not be relied on, the file may not match their extensions
+// and they list only files newer than the last indexed one. It returns ErrNoQueue without a queue.
+func (i *Indexer) QueueRetry(ctx context.Context, key string, cause error) error {
+	if i.queue == nil {
+		return ErrNoQueue
+	}
+
+	file, err := i.storage.Stat(ctx, key)
+	if err != nil {
+		return err
+	}
+	_, err = i.queue.Enqueue(ctx, []domain.File{file})
+	if err != nil {
+		return err
+	}
+
+	return i.queue.FailFile(ctx, key, cause.Error(), retryAfter, maxAttempts)
+}
+
+// newFiles lists files that were modified since the last indexed image and are not indexed yet.
+func (i *Indexer) newFiles(ctx context.Context, pattern string) ([]domain.File, error) {
 	lastModified, err := i.imageRepo.GetLastModified(ctx)
 	if err != nil {
-		return fmt.Errorf("getting last modified, %w", err)
+		return nil, fmt.Errorf("getting last modified, %w", err)
 	}
 	files, err := i.storage.ListFiles(lastModified, pattern)
 	if err != nil {
-		return fmt.Errorf("listing files, %w", err)
+		return nil, fmt.Errorf("listing files, %w", err)
 	}
 
+	var newFiles []domain.File
 	for _, file := range files {
 		_, err := i.imageRepo.Get(ctx, file.Key)
 		if err != nil && !errors.Is(err, dbadapter.ErrRecordNotFound) {
@@ -74,20 +281,68 @@ func (i *Indexer) IndexNewList(ctx context.Context, pattern string) error {
 			i.log.Info("skipping, file already processed", slog.String("file", file.Key))
 			continue
 		}
+		newFiles = append(newFiles, file)
+	}
 
-		err = i.Index(file)
-		if err != nil {
-			i.log.Error("indexing file", slog.String("err", err.Error()))
-		} else {
-			i.log.Info("file processed", slog.String("file", file.Key))
-			i.tracker.OnIndex()
-		}
+	return newFiles, nil
+}
+
+func (i *Indexer) logResult(file domain.File, err error) {
+	if err != nil {
+		i.log.Error("indexing file", slog.String("err", err.Error()))
+		return
 	}
 
-	return nil
+	i.log.Info("file processed", slog.String("file", file.Key))
+	i.tracker.OnIndex()
 }
 
+// Index stores the image of the file and publishes the result, successful or not.
 func (i *Indexer) Index(file domain.File) error {
+	_, err := i.indexFile(context.TODO(), file)
+
+	return err
+}
+
+func (i *Indexer) indexFile(ctx context.Context, file domain.File) (domain.Image, error) {
+	started := time.Now()
+	i.mu.Lock()
+	i.progress.CurrentFile = file.Key
+	i.mu.Unlock()
+
+	img, err := i.index(ctx, file)
+
+	i.mu.Lock()
+	i.progress.CurrentFile = ""
+	i.busy += time.Since(started)
+	if err != nil {
+		i.progress.Failed++
+	} else {
+		i.progress.Indexed++
+	}
+	i.mu.Unlock()
+
+	if err != nil {
+		i.publish(ctx, domain.Event{Type: domain.EventImageFailed, FileID: file.Key, Error: err.Error()})
+
+		return domain.Image{}, err
+	}
+
+	i.publish(ctx, domain.Event{Type: domain.EventImageIndexed, FileID: img.FileID, Image: &img})
+
+	return img, nil
+}
+
+func (i *Indexer) publish(ctx context.Context, e domain.Event) {
+	if i.publisher == nil {
+		return
+	}
+
+	e.Time = time.Now()
+	i.publisher.Publish(ctx, e)
+}
+
+func (i *Indexer) index(ctx context.Context, file domain.File) (domain.Image, error) {
 	f, err := i.storage.Download(file.Key)
 	if f != nil {
 		defer func(name string) {
@@ -101,23 +356,66 @@ func (i *Indexer) Index(file domain.File) error {
 		}(f.Name())
 	}
 	if err != nil {
-		return fmt.Errorf("cannot download file, %w", err)
+		return domain.Image{}, fmt.Errorf("cannot download file, %w", err)
+	}
+	// other formats are converted, stages and the hash get the first page of a document or frame of an animation
+	frames, cleanup, err := imageformat.Frames(f.Name())
+	defer cleanup()
+	if err != nil {
+		return domain.Image{}, fmt.Errorf("preparing image, %w", err)
 	}
-	desc, err := i.ocrEngine.Run(f.Name())
+
+	desc, err := i.recognize(frames)
 	if err != nil {
-		return fmt.Errorf("running ocr, %w", err)
+		return domain.Image{}, err
+	}
+
+	// the hash is only needed for similarity search, so failing to calculate it does not fail indexing
+	hash, err := phash.FromFile(frames[0])
+	if err != nil {
+		i.log.Warn("calculating perceptual hash",
+			slog.String("file", file.Key),
+			slog.String("err", err.Error()),
+		)
 	}
 
 	img := domain.Image{
 		FileID:       file.Key,
 		LastModified: file.LastModified,
 		Description:  desc,
+		PHash:        int64(hash),
+	}
+
+	for _, stage := range i.stages {
+		err = stage.Process(ctx, frames[0], &img)
+		if err != nil {
+			return domain.Image{}, fmt.Errorf("processing image, %w", err)
+		}
 	}
 
-	err = i.imageRepo.Upsert(context.TODO(), img)
+	err = i.imageRepo.Upsert(ctx, img)
 	if err != nil {
-		return fmt.Errorf("inserting image, %w", err)
+		return domain.Image{}, fmt.Errorf("inserting image, %w", err)
 	}
 
-	return nil
+	return img, nil
+}
+
+// recognize runs OCR on every frame. Frames of animations often repeat the text of the previous one, it is kept once.
+func (i *Indexer) recognize(frames []string) (string, error) {
+	var texts []string
+	var last string
+	for n, frame := range frames {
+		text, err := i.ocrEngine.Run(frame)
+		if err != nil {
+			return "", fmt.Errorf("running ocr on frame %d, %w", n+1, err)
+		}
+		trimmed := strings.TrimSpace(text)
+		if trimmed != "" && trimmed != last {
+			texts = append(texts, text)
+			last = trimmed
+		}
+	}
+
+	return strings.Join(texts, "\n"), nil
 }
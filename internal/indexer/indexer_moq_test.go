@@ -193,6 +193,9 @@ var _ FileStorage = &FileStorageMock{}
 //			ListFilesFunc: func(start time.Time, ext string) ([]domain.File, error) {
 //				panic("mock out the ListFiles method")
 //			},
+//			StatFunc: func(ctx context.Context, key string) (domain.File, error) {
+//				panic("mock out the Stat method")
+//			},
 //		}
 //
 //		// use mockedFileStorage in code that requires FileStorage
@@ -206,6 +209,9 @@ type FileStorageMock struct {
 	// ListFilesFunc mocks the ListFiles method.
 	ListFilesFunc func(start time.Time, ext string) ([]domain.File, error)
 
+	// StatFunc mocks the Stat method.
+	StatFunc func(ctx context.Context, key string) (domain.File, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// Download holds details about calls to the Download method.
@@ -220,9 +226,17 @@ type FileStorageMock struct {
 			// Ext is the ext argument value.
 			Ext string
 		}
+		// Stat holds details about calls to the Stat method.
+		Stat []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
 	}
 	lockDownload  sync.RWMutex
 	lockListFiles sync.RWMutex
+	lockStat      sync.RWMutex
 }
 
 // Download calls DownloadFunc.
@@ -293,6 +307,42 @@ func (mock *FileStorageMock) ListFilesCalls() []struct {
 	return calls
 }
 
+// Stat calls StatFunc.
+func (mock *FileStorageMock) Stat(ctx context.Context, key string) (domain.File, error) {
+	if mock.StatFunc == nil {
+		panic("FileStorageMock.StatFunc: method is nil but FileStorage.Stat was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockStat.Lock()
+	mock.calls.Stat = append(mock.calls.Stat, callInfo)
+	mock.lockStat.Unlock()
+	return mock.StatFunc(ctx, key)
+}
+
+// StatCalls gets all the calls that were made to Stat.
+// Check the length with:
+//
+//	len(mockedFileStorage.StatCalls())
+func (mock *FileStorageMock) StatCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockStat.RLock()
+	calls = mock.calls.Stat
+	mock.lockStat.RUnlock()
+	return calls
+}
+
 // Ensure, that OCRMock does implement OCR.
 // If this is not the case, regenerate this file with moq.
 var _ OCR = &OCRMock{}
@@ -358,3 +408,443 @@ func (mock *OCRMock) RunCalls() []struct {
 	mock.lockRun.RUnlock()
 	return calls
 }
+
+// Ensure, that StageMock does implement Stage.
+// If this is not the case, regenerate this file with moq.
+var _ Stage = &StageMock{}
+
+// StageMock is a mock implementation of Stage.
+//
+//	func TestSomethingThatUsesStage(t *testing.T) {
+//
+//		// make and configure a mocked Stage
+//		mockedStage := &StageMock{
+//			ProcessFunc: func(ctx context.Context, file string, img *domain.Image) error {
+//				panic("mock out the Process method")
+//			},
+//		}
+//
+//		// use mockedStage in code that requires Stage
+//		// and then make assertions.
+//
+//	}
+type StageMock struct {
+	// ProcessFunc mocks the Process method.
+	ProcessFunc func(ctx context.Context, file string, img *domain.Image) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Process holds details about calls to the Process method.
+		Process []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// File is the file argument value.
+			File string
+			// Img is the img argument value.
+			Img *domain.Image
+		}
+	}
+	lockProcess sync.RWMutex
+}
+
+// Process calls ProcessFunc.
+func (mock *StageMock) Process(ctx context.Context, file string, img *domain.Image) error {
+	if mock.ProcessFunc == nil {
+		panic("StageMock.ProcessFunc: method is nil but Stage.Process was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		File string
+		Img  *domain.Image
+	}{
+		Ctx:  ctx,
+		File: file,
+		Img:  img,
+	}
+	mock.lockProcess.Lock()
+	mock.calls.Process = append(mock.calls.Process, callInfo)
+	mock.lockProcess.Unlock()
+	return mock.ProcessFunc(ctx, file, img)
+}
+
+// ProcessCalls gets all the calls that were made to Process.
+// Check the length with:
+//
+//	len(mockedStage.ProcessCalls())
+func (mock *StageMock) ProcessCalls() []struct {
+	Ctx  context.Context
+	File string
+	Img  *domain.Image
+} {
+	var calls []struct {
+		Ctx  context.Context
+		File string
+		Img  *domain.Image
+	}
+	mock.lockProcess.RLock()
+	calls = mock.calls.Process
+	mock.lockProcess.RUnlock()
+	return calls
+}
+
+// Ensure, that PublisherMock does implement Publisher.
+// If this is not the case, regenerate this file with moq.
+var _ Publisher = &PublisherMock{}
+
+// PublisherMock is a mock implementation of Publisher.
+//
+//	func TestSomethingThatUsesPublisher(t *testing.T) {
+//
+//		// make and configure a mocked Publisher
+//		mockedPublisher := &PublisherMock{
+//			PublishFunc: func(ctx context.Context, e domain.Event) {
+//				panic("mock out the Publish method")
+//			},
+//		}
+//
+//		// use mockedPublisher in code that requires Publisher
+//		// and then make assertions.
+//
+//	}
+type PublisherMock struct {
+	// PublishFunc mocks the Publish method.
+	PublishFunc func(ctx context.Context, e domain.Event)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Publish holds details about calls to the Publish method.
+		Publish []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E domain.Event
+		}
+	}
+	lockPublish sync.RWMutex
+}
+
+// Publish calls PublishFunc.
+func (mock *PublisherMock) Publish(ctx context.Context, e domain.Event) {
+	if mock.PublishFunc == nil {
+		panic("PublisherMock.PublishFunc: method is nil but Publisher.Publish was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   domain.Event
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockPublish.Lock()
+	mock.calls.Publish = append(mock.calls.Publish, callInfo)
+	mock.lockPublish.Unlock()
+	mock.PublishFunc(ctx, e)
+}
+
+// PublishCalls gets all the calls that were made to Publish.
+// Check the length with:
+//
+//	len(mockedPublisher.PublishCalls())
+func (mock *PublisherMock) PublishCalls() []struct {
+	Ctx context.Context
+	E   domain.Event
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   domain.Event
+	}
+	mock.lockPublish.RLock()
+	calls = mock.calls.Publish
+	mock.lockPublish.RUnlock()
+	return calls
+}
+
+// Ensure, that QueueMock does implement Queue.
+// If this is not the case, regenerate this file with moq.
+var _ Queue = &QueueMock{}
+
+// QueueMock is a mock implementation of Queue.
+//
+//	func TestSomethingThatUsesQueue(t *testing.T) {
+//
+//		// make and configure a mocked Queue
+//		mockedQueue := &QueueMock{
+//			ClaimFilesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]domain.File, error) {
+//				panic("mock out the ClaimFiles method")
+//			},
+//			CompleteFileFunc: func(ctx context.Context, fileID string) error {
+//				panic("mock out the CompleteFile method")
+//			},
+//			EnqueueFunc: func(ctx context.Context, files []domain.File) (int, error) {
+//				panic("mock out the Enqueue method")
+//			},
+//			FailFileFunc: func(ctx context.Context, fileID string, reason string, retryAfter time.Duration, maxAttempts int) error {
+//				panic("mock out the FailFile method")
+//			},
+//			TryLockScanFunc: func(ctx context.Context) (func(), bool, error) {
+//				panic("mock out the TryLockScan method")
+//			},
+//		}
+//
+//		// use mockedQueue in code that requires Queue
+//		// and then make assertions.
+//
+//	}
+type QueueMock struct {
+	// ClaimFilesFunc mocks the ClaimFiles method.
+	ClaimFilesFunc func(ctx context.Context, limit int, lease time.Duration) ([]domain.File, error)
+
+	// CompleteFileFunc mocks the CompleteFile method.
+	CompleteFileFunc func(ctx context.Context, fileID string) error
+
+	// EnqueueFunc mocks the Enqueue method.
+	EnqueueFunc func(ctx context.Context, files []domain.File) (int, error)
+
+	// FailFileFunc mocks the FailFile method.
+	FailFileFunc func(ctx context.Context, fileID string, reason string, retryAfter time.Duration, maxAttempts int) error
+
+	// TryLockScanFunc mocks the TryLockScan method.
+	TryLockScanFunc func(ctx context.Context) (func(), bool, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// ClaimFiles holds details about calls to the ClaimFiles method.
+		ClaimFiles []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Limit is the limit argument value.
+			Limit int
+			// Lease is the lease argument value.
+			Lease time.Duration
+		}
+		// CompleteFile holds details about calls to the CompleteFile method.
+		CompleteFile []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+		}
+		// Enqueue holds details about calls to the Enqueue method.
+		Enqueue []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Files is the files argument value.
+			Files []domain.File
+		}
+		// FailFile holds details about calls to the FailFile method.
+		FailFile []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// FileID is the fileID argument value.
+			FileID string
+			// Reason is the reason argument value.
+			Reason string
+			// RetryAfter is the retryAfter argument value.
+			RetryAfter time.Duration
+			// MaxAttempts is the maxAttempts argument value.
+			MaxAttempts int
+		}
+		// TryLockScan holds details about calls to the TryLockScan method.
+		TryLockScan []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+	}
+	lockClaimFiles   sync.RWMutex
+	lockCompleteFile sync.RWMutex
+	lockEnqueue      sync.RWMutex
+	lockFailFile     sync.RWMutex
+	lockTryLockScan  sync.RWMutex
+}
+
+// ClaimFiles calls ClaimFilesFunc.
+func (mock *QueueMock) ClaimFiles(ctx context.Context, limit int, lease time.Duration) ([]domain.File, error) {
+	if mock.ClaimFilesFunc == nil {
+		panic("QueueMock.ClaimFilesFunc: method is nil but Queue.ClaimFiles was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Limit int
+		Lease time.Duration
+	}{
+		Ctx:   ctx,
+		Limit: limit,
+		Lease: lease,
+	}
+	mock.lockClaimFiles.Lock()
+	mock.calls.ClaimFiles = append(mock.calls.ClaimFiles, callInfo)
+	mock.lockClaimFiles.Unlock()
+	return mock.ClaimFilesFunc(ctx, limit, lease)
+}
+
+// ClaimFilesCalls gets all the calls that were made to ClaimFiles.
+// Check the length with:
+//
+//	len(mockedQueue.ClaimFilesCalls())
+func (mock *QueueMock) ClaimFilesCalls() []struct {
+	Ctx   context.Context
+	Limit int
+	Lease time.Duration
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Limit int
+		Lease time.Duration
+	}
+	mock.lockClaimFiles.RLock()
+	calls = mock.calls.ClaimFiles
+	mock.lockClaimFiles.RUnlock()
+	return calls
+}
+
+// CompleteFile calls CompleteFileFunc.
+func (mock *QueueMock) CompleteFile(ctx context.Context, fileID string) error {
+	if mock.CompleteFileFunc == nil {
+		panic("QueueMock.CompleteFileFunc: method is nil but Queue.CompleteFile was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		FileID string
+	}{
+		Ctx:    ctx,
+		FileID: fileID,
+	}
+	mock.lockCompleteFile.Lock()
+	mock.calls.CompleteFile = append(mock.calls.CompleteFile, callInfo)
+	mock.lockCompleteFile.Unlock()
+	return mock.CompleteFileFunc(ctx, fileID)
+}
+
+// CompleteFileCalls gets all the calls that were made to CompleteFile.
+// Check the length with:
+//
+//	len(mockedQueue.CompleteFileCalls())
+func (mock *QueueMock) CompleteFileCalls() []struct {
+	Ctx    context.Context
+	FileID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		FileID string
+	}
+	mock.lockCompleteFile.RLock()
+	calls = mock.calls.CompleteFile
+	mock.lockCompleteFile.RUnlock()
+	return calls
+}
+
+// Enqueue calls EnqueueFunc.
+func (mock *QueueMock) Enqueue(ctx context.Context, files []domain.File) (int, error) {
+	if mock.EnqueueFunc == nil {
+		panic("QueueMock.EnqueueFunc: method is nil but Queue.Enqueue was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Files []domain.File
+	}{
+		Ctx:   ctx,
+		Files: files,
+	}
+	mock.lockEnqueue.Lock()
+	mock.calls.Enqueue = append(mock.calls.Enqueue, callInfo)
+	mock.lockEnqueue.Unlock()
+	return mock.EnqueueFunc(ctx, files)
+}
+
+// EnqueueCalls gets all the calls that were made to Enqueue.
+// Check the length with:
+//
+//	len(mockedQueue.EnqueueCalls())
+func (mock *QueueMock) EnqueueCalls() []struct {
+	Ctx   context.Context
+	Files []domain.File
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Files []domain.File
+	}
+	mock.lockEnqueue.RLock()
+	calls = mock.calls.Enqueue
+	mock.lockEnqueue.RUnlock()
+	return calls
+}
+
+// FailFile calls FailFileFunc.
+func (mock *QueueMock) FailFile(ctx context.Context, fileID string, reason string, retryAfter time.Duration, maxAttempts int) error {
+	if mock.FailFileFunc == nil {
+		panic("QueueMock.FailFileFunc: method is nil but Queue.FailFile was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		FileID      string
+		Reason      string
+		RetryAfter  time.Duration
+		MaxAttempts int
+	}{
+		Ctx:         ctx,
+		FileID:      fileID,
+		Reason:      reason,
+		RetryAfter:  retryAfter,
+		MaxAttempts: maxAttempts,
+	}
+	mock.lockFailFile.Lock()
+	mock.calls.FailFile = append(mock.calls.FailFile, callInfo)
+	mock.lockFailFile.Unlock()
+	return mock.FailFileFunc(ctx, fileID, reason, retryAfter, maxAttempts)
+}
+
+// FailFileCalls gets all the calls that were made to FailFile.
+// Check the length with:
+//
+//	len(mockedQueue.FailFileCalls())
+func (mock *QueueMock) FailFileCalls() []struct {
+	Ctx         context.Context
+	FileID      string
+	Reason      string
+	RetryAfter  time.Duration
+	MaxAttempts int
+} {
+	var calls []struct {
+		Ctx         context.Context
+		FileID      string
+		Reason      string
+		RetryAfter  time.Duration
+		MaxAttempts int
+	}
+	mock.lockFailFile.RLock()
+	calls = mock.calls.FailFile
+	mock.lockFailFile.RUnlock()
+	return calls
+}
+
+// TryLockScan calls TryLockScanFunc.
+func (mock *QueueMock) TryLockScan(ctx context.Context) (func(), bool, error) {
+	if mock.TryLockScanFunc == nil {
+		panic("QueueMock.TryLockScanFunc: method is nil but Queue.TryLockScan was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockTryLockScan.Lock()
+	mock.calls.TryLockScan = append(mock.calls.TryLockScan, callInfo)
+	mock.lockTryLockScan.Unlock()
+	return mock.TryLockScanFunc(ctx)
+}
+
+// TryLockScanCalls gets all the calls that were made to TryLockScan.
+// Check the length with:
+//
+//	len(mockedQueue.TryLockScanCalls())
+func (mock *QueueMock) TryLockScanCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockTryLockScan.RLock()
+	calls = mock.calls.TryLockScan
+	mock.lockTryLockScan.RUnlock()
+	return calls
+}
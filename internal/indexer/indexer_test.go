@@ -3,14 +3,21 @@ package indexer
 import (
 	"context"
 	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/elnoro/foxyshot-indexer/internal/db"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
 	"github.com/elnoro/foxyshot-indexer/internal/monitoring"
+	"github.com/elnoro/foxyshot-indexer/internal/phash"
 	"github.com/matryer/is"
 )
 
@@ -25,7 +32,7 @@ func TestIndexer_Index(t *testing.T) {
 	const testOCRResult = "expected-ocr-results"
 
 	repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return nil }}
-	storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) { return os.Create(testImg) }}
+	storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) { return downloadImage(testImg) }}
 	ocr := &OCRMock{RunFunc: func(file string) (string, error) { return testOCRResult, nil }}
 	logger := slog.Default()
 	tracker := monitoring.NewTracker()
@@ -41,6 +48,7 @@ func TestIndexer_Index(t *testing.T) {
 			FileID:       testFile.Key,
 			Description:  testOCRResult,
 			LastModified: testFile.LastModified,
+			PHash:        int64(phash.Difference(grayImage())),
 		})
 
 		_, err = os.Stat(testImg)
@@ -49,14 +57,94 @@ func TestIndexer_Index(t *testing.T) {
 		}
 	})
 
+	t.Run("stores perceptual hash of the image", func(t *testing.T) {
+		testImage := image.NewGray(image.Rect(0, 0, 36, 16))
+		for x := 0; x < 36; x++ {
+			for y := 0; y < 16; y++ {
+				testImage.SetGray(x, y, color.Gray{Y: uint8(255 - x*7)})
+			}
+		}
+
+		repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return nil }}
+		storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) {
+			f, err := os.Create(testImg)
+			if err != nil {
+				return nil, err
+			}
+
+			return f, png.Encode(f, testImage)
+		}}
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		err := indexer.Index(testFile)
+		tt.NoErr(err)
+
+		tt.True(repo.UpsertCalls()[0].Image.PHash != 0) // hash of a gradient must not be empty
+		tt.Equal(repo.UpsertCalls()[0].Image.PHash, int64(phash.Difference(testImage)))
+	})
+
+	t.Run("stages modify the image before it is stored", func(t *testing.T) {
+		repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return nil }}
+		stage := &StageMock{ProcessFunc: func(ctx context.Context, file string, img *domain.Image) error {
+			img.Entities = []domain.Entity{{Kind: domain.EntityIP, Value: img.Description}}
+			return nil
+		}}
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.Use(stage)
+		err := indexer.Index(testFile)
+		tt.NoErr(err)
+
+		tt.Equal(stage.ProcessCalls()[0].File, testImg)
+		tt.Equal(repo.UpsertCalls()[0].Image.Entities, []domain.Entity{{Kind: domain.EntityIP, Value: testOCRResult}})
+	})
+
+	t.Run("stage error", func(t *testing.T) {
+		expectedErr := errors.New("expected err")
+		repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return nil }}
+		stage := &StageMock{ProcessFunc: func(ctx context.Context, file string, img *domain.Image) error {
+			return expectedErr
+		}}
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.Use(stage)
+		err := indexer.Index(testFile)
+
+		tt.True(errors.Is(err, expectedErr))
+		tt.Equal(len(repo.UpsertCalls()), 0) // image must not be stored if a stage fails
+	})
+
+	t.Run("publishes indexed image", func(t *testing.T) {
+		publisher := &PublisherMock{PublishFunc: func(ctx context.Context, e domain.Event) {}}
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.SetPublisher(publisher)
+		err := indexer.Index(testFile)
+		tt.NoErr(err)
+
+		e := publisher.PublishCalls()[0].E
+		tt.Equal(e.Type, domain.EventImageIndexed)
+		tt.Equal(e.FileID, testFile.Key)
+		tt.Equal(e.Image.Description, testOCRResult)
+	})
+
 	t.Run("repo error", func(t *testing.T) {
 		expectedErr := errors.New("expected err")
 		repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return expectedErr }}
+		publisher := &PublisherMock{PublishFunc: func(ctx context.Context, e domain.Event) {}}
 
 		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.SetPublisher(publisher)
 		err := indexer.Index(testFile)
 
 		tt.True(errors.Is(err, expectedErr))
+		tt.Equal(len(publisher.PublishCalls()), 1)
+
+		e := publisher.PublishCalls()[0].E
+		tt.Equal(e.Type, domain.EventImageFailed) // images that were not stored must not be published as indexed
+		tt.Equal(e.FileID, testFile.Key)
+		tt.True(e.Image == nil)
+		tt.True(strings.Contains(e.Error, expectedErr.Error()))
 	})
 
 	t.Run("storage error", func(t *testing.T) {
@@ -79,12 +167,10 @@ func TestIndexer_Index(t *testing.T) {
 		tt.True(errors.Is(err, expectedErr))
 	})
 
-	t.Run("temp file was not created properly", func(t *testing.T) {
-		storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) {
-			f, _ := os.Create(testImg)
+	t.Run("temp file was removed during indexing", func(t *testing.T) {
+		ocr := &OCRMock{RunFunc: func(file string) (string, error) {
 			_ = os.Remove(testImg)
-
-			return f, nil
+			return testOCRResult, nil
 		}}
 
 		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
@@ -92,6 +178,91 @@ func TestIndexer_Index(t *testing.T) {
 
 		tt.NoErr(err)
 	})
+
+	t.Run("every frame of an animation is recognized", func(t *testing.T) {
+		repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return nil }}
+		storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) {
+			f, err := os.Create(testImg)
+			if err != nil {
+				return nil, err
+			}
+
+			palette := color.Palette{color.Gray{Y: 10}, color.Gray{Y: 20}}
+			frames := []*image.Paletted{
+				image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+				image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+				image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+			}
+			return f, gif.EncodeAll(f, &gif.GIF{Image: frames, Delay: []int{0, 0, 0}})
+		}}
+		texts := []string{"first slide", "first slide", "second slide"}
+		ocr := &OCRMock{}
+		ocr.RunFunc = func(file string) (string, error) { return texts[len(ocr.RunCalls())-1], nil }
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		err := indexer.Index(testFile)
+		tt.NoErr(err)
+
+		tt.Equal(len(ocr.RunCalls()), 3)
+		tt.True(ocr.RunCalls()[0].File != testImg)                                     // frames are converted for OCR
+		tt.Equal(repo.UpsertCalls()[0].Image.Description, "first slide\nsecond slide") // repeated text is kept once
+	})
+
+	t.Run("unsupported format is reported", func(t *testing.T) {
+		storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) {
+			f, err := os.Create(testImg)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = f.WriteString("not an image")
+			return f, err
+		}}
+		publisher := &PublisherMock{PublishFunc: func(ctx context.Context, e domain.Event) {}}
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.SetPublisher(publisher)
+		err := indexer.Index(testFile)
+
+		tt.True(errors.Is(err, imageformat.ErrUnsupported))
+		tt.Equal(publisher.PublishCalls()[0].E.Type, domain.EventImageFailed)
+	})
+
+	t.Run("tiff without pages is reported", func(t *testing.T) {
+		storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) {
+			f, err := os.Create(testImg)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = f.WriteString("II*\x00\x00\x00\x00\x00")
+			return f, err
+		}}
+
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		err := indexer.Index(testFile)
+
+		tt.True(errors.Is(err, imageformat.ErrUnsupported))
+	})
+}
+
+// downloadImage creates the file of a downloaded image, a PNG of grayImage.
+func downloadImage(name string) (*os.File, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, png.Encode(f, grayImage())
+}
+
+func grayImage() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+
+	return img
 }
 
 func TestIndexer_IndexNewList(t *testing.T) {
@@ -122,7 +293,7 @@ func TestIndexer_IndexNewList(t *testing.T) {
 		},
 	}
 	storage := &FileStorageMock{
-		DownloadFunc: func(key string) (*os.File, error) { return os.Create(testImg) },
+		DownloadFunc: func(key string) (*os.File, error) { return downloadImage(testImg) },
 		ListFilesFunc: func(_ time.Time, _ string) ([]domain.File, error) {
 			return []domain.File{{Key: expectedKey}, {Key: "invalid-key"}}, nil
 		},
@@ -181,7 +352,7 @@ func TestIndexer_IndexNewList(t *testing.T) {
 
 	t.Run("skips processing if image is already in the repo", func(t *testing.T) {
 		storage := &FileStorageMock{
-			DownloadFunc: func(key string) (*os.File, error) { return os.Create(testImg) },
+			DownloadFunc: func(key string) (*os.File, error) { return downloadImage(testImg) },
 			ListFilesFunc: func(_ time.Time, _ string) ([]domain.File, error) {
 				return []domain.File{{Key: expectedKey}, {Key: "invalid-key"}}, nil
 			},
@@ -200,4 +371,198 @@ func TestIndexer_IndexNewList(t *testing.T) {
 		tt.NoErr(err)                             // individual file indexing errors do not break stop the whole method
 		tt.Equal(len(storage.DownloadCalls()), 0) // must not get to the index stage
 	})
+
+	t.Run("cancelled run starts no new files", func(t *testing.T) {
+		storage := &FileStorageMock{ListFilesFunc: storage.ListFilesFunc}
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := indexer.IndexNewList(ctx, "expected-pattern")
+
+		tt.True(errors.Is(err, context.Canceled))
+		tt.Equal(len(storage.DownloadCalls()), 0)
+	})
+}
+
+func TestIndexer_IndexNewList_Queue(t *testing.T) {
+	const testImg = "./testdata/expected-downloaded-image"
+
+	ctx := context.Background()
+	tracker := monitoring.NewTracker()
+	logger := slog.Default()
+
+	repo := &ImageRepoMock{
+		UpsertFunc:          func(ctx context.Context, image domain.Image) error { return nil },
+		GetLastModifiedFunc: func(_ context.Context) (time.Time, error) { return time.Unix(99, 0), nil },
+		GetFunc: func(_ context.Context, fileID string) (domain.Image, error) {
+			return domain.Image{}, db.ErrRecordNotFound
+		},
+	}
+	ocr := &OCRMock{RunFunc: func(file string) (string, error) { return "expected-ocr-results", nil }}
+	newQueue := func(locked bool, claimed []domain.File) *QueueMock {
+		return &QueueMock{
+			TryLockScanFunc: func(ctx context.Context) (func(), bool, error) {
+				return func() {}, !locked, nil
+			},
+			EnqueueFunc: func(ctx context.Context, files []domain.File) (int, error) { return len(files), nil },
+			ClaimFilesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]domain.File, error) {
+				files := claimed
+				claimed = nil
+				return files, nil
+			},
+			CompleteFileFunc: func(ctx context.Context, fileID string) error { return nil },
+			FailFileFunc: func(ctx context.Context, fileID, reason string, retryAfter time.Duration, maxAttempts int) error {
+				return nil
+			},
+		}
+	}
+
+	t.Run("scans and indexes claimed files", func(t *testing.T) {
+		tt := is.New(t)
+
+		storage := &FileStorageMock{
+			DownloadFunc: func(key string) (*os.File, error) {
+				if key == "broken" {
+					return nil, errors.New("expected error")
+				}
+				return downloadImage(testImg)
+			},
+			ListFilesFunc: func(_ time.Time, _ string) ([]domain.File, error) {
+				return []domain.File{{Key: "new"}}, nil
+			},
+		}
+		queue := newQueue(false, []domain.File{{Key: "new"}, {Key: "broken"}})
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.SetQueue(queue)
+
+		err := indexer.IndexNewList(ctx, "expected-pattern")
+
+		tt.NoErr(err)
+		tt.Equal(queue.EnqueueCalls()[0].Files, []domain.File{{Key: "new"}})
+		tt.Equal(len(queue.ClaimFilesCalls()), 2) // claims until the queue is empty
+		tt.Equal(len(queue.CompleteFileCalls()), 1)
+		tt.Equal(queue.CompleteFileCalls()[0].FileID, "new")
+		tt.Equal(len(queue.FailFileCalls()), 1)
+		tt.Equal(queue.FailFileCalls()[0].FileID, "broken")
+	})
+
+	t.Run("skips the scan locked by another instance", func(t *testing.T) {
+		tt := is.New(t)
+
+		storage := &FileStorageMock{DownloadFunc: func(key string) (*os.File, error) { return downloadImage(testImg) }}
+		queue := newQueue(true, []domain.File{{Key: "queued-by-another-instance"}})
+		indexer := NewIndexer(repo, storage, ocr, logger, tracker)
+		indexer.SetQueue(queue)
+
+		err := indexer.IndexNewList(ctx, "expected-pattern")
+
+		tt.NoErr(err)
+		tt.Equal(len(storage.ListFilesCalls()), 0)
+		tt.Equal(len(queue.EnqueueCalls()), 0)
+		tt.Equal(queue.CompleteFileCalls()[0].FileID, "queued-by-another-instance")
+	})
+}
+
+func TestIndexer_IndexKey(t *testing.T) {
+	const testImg = "./testdata/expected-downloaded-image"
+
+	ctx := context.Background()
+	repo := &ImageRepoMock{UpsertFunc: func(ctx context.Context, image domain.Image) error { return nil }}
+	ocr := &OCRMock{RunFunc: func(file string) (string, error) { return "expected-ocr-results", nil }}
+
+	t.Run("indexes the file with the key", func(t *testing.T) {
+		tt := is.New(t)
+
+		storage := &FileStorageMock{
+			StatFunc: func(ctx context.Context, key string) (domain.File, error) {
+				return domain.File{Key: key, LastModified: time.Unix(99, 0)}, nil
+			},
+			DownloadFunc: func(key string) (*os.File, error) { return downloadImage(testImg) },
+		}
+		indexer := NewIndexer(repo, storage, ocr, slog.Default(), monitoring.NewTracker())
+		indexer.Pause() // on demand indexing ignores the pause
+
+		img, err := indexer.IndexKey(ctx, "expected-key")
+
+		tt.NoErr(err)
+		tt.Equal(img.FileID, "expected-key")
+		tt.Equal(img.LastModified, time.Unix(99, 0))
+		tt.Equal(img.Description, "expected-ocr-results")
+
+		progress := indexer.Progress()
+		tt.Equal(progress.Indexed, 1)
+		tt.Equal(progress.CurrentFile, "")
+		tt.True(progress.FilesPerMinute > 0)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		tt := is.New(t)
+
+		storage := &FileStorageMock{
+			StatFunc: func(ctx context.Context, key string) (domain.File, error) {
+				return domain.File{}, domain.ErrFileNotFound
+			},
+		}
+		indexer := NewIndexer(repo, storage, ocr, slog.Default(), monitoring.NewTracker())
+
+		_, err := indexer.IndexKey(ctx, "missing-key")
+
+		tt.True(errors.Is(err, domain.ErrFileNotFound))
+		tt.Equal(len(storage.DownloadCalls()), 0)
+	})
+}
+
+func TestIndexer_QueueRetry(t *testing.T) {
+	tt := is.New(t)
+
+	ctx := context.Background()
+	storage := &FileStorageMock{StatFunc: func(ctx context.Context, key string) (domain.File, error) {
+		return domain.File{Key: key, LastModified: time.Unix(99, 0)}, nil
+	}}
+	queue := &QueueMock{
+		EnqueueFunc: func(ctx context.Context, files []domain.File) (int, error) { return len(files), nil },
+		FailFileFunc: func(ctx context.Context, fileID, reason string, retryAfter time.Duration, maxAttempts int) error {
+			return nil
+		},
+	}
+	indexer := NewIndexer(&ImageRepoMock{}, storage, &OCRMock{}, slog.Default(), monitoring.NewTracker())
+
+	err := indexer.QueueRetry(ctx, "uploads/a.png", errors.New("expected error"))
+	tt.True(errors.Is(err, ErrNoQueue))
+
+	indexer.SetQueue(queue)
+	err = indexer.QueueRetry(ctx, "uploads/a.png", errors.New("expected error"))
+
+	tt.NoErr(err)
+	tt.Equal(queue.EnqueueCalls()[0].Files, []domain.File{{Key: "uploads/a.png", LastModified: time.Unix(99, 0)}})
+	tt.Equal(queue.FailFileCalls()[0].FileID, "uploads/a.png")
+	tt.Equal(queue.FailFileCalls()[0].Reason, "expected error")
+}
+
+func TestIndexer_Pause(t *testing.T) {
+	tt := is.New(t)
+
+	repo := &ImageRepoMock{
+		GetLastModifiedFunc: func(_ context.Context) (time.Time, error) { return time.Unix(99, 0), nil },
+		GetFunc: func(_ context.Context, fileID string) (domain.Image, error) {
+			return domain.Image{}, db.ErrRecordNotFound
+		},
+	}
+	storage := &FileStorageMock{
+		ListFilesFunc: func(_ time.Time, _ string) ([]domain.File, error) {
+			return []domain.File{{Key: "new"}}, nil
+		},
+	}
+	indexer := NewIndexer(repo, storage, &OCRMock{}, slog.Default(), monitoring.NewTracker())
+
+	indexer.Pause()
+	tt.True(indexer.Paused())
+
+	err := indexer.IndexNewList(context.Background(), "expected-pattern")
+	tt.NoErr(err)
+	tt.Equal(len(storage.DownloadCalls()), 0) // paused indexer starts no new work
+
+	indexer.Resume()
+	tt.True(!indexer.Paused())
 }
@@ -0,0 +1,92 @@
+// Package match ranks indexed images by how closely they match an example image.
+package match
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/phash"
+)
+
+// visualWeight is the share of the visual similarity in the final score, the rest comes from the text.
+const visualWeight = 0.6
+
+const hashBits = 64
+
+// Tokens splits the text into unique lowercase words consisting of letters and digits.
+// Single characters are skipped, as OCR often produces them from noise.
+func Tokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if len([]rune(w)) < 2 {
+			continue
+		}
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		tokens = append(tokens, w)
+	}
+
+	return tokens
+}
+
+// Jaccard returns the share of tokens present in both sets.
+func Jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]struct{}, len(a))
+	for _, t := range a {
+		set[t] = struct{}{}
+	}
+
+	common := 0
+	union := len(set)
+	for _, t := range b {
+		if _, ok := set[t]; ok {
+			common++
+			continue
+		}
+		union++
+	}
+
+	return float64(common) / float64(union)
+}
+
+// Rank scores candidates against the hash and the text of the example image,
+// best matches first. A zero hash means that the visual similarity is unknown.
+func Rank(hash uint64, text string, candidates []domain.Image) []domain.ImageMatch {
+	tokens := Tokens(text)
+
+	matches := make([]domain.ImageMatch, 0, len(candidates))
+	for _, c := range candidates {
+		m := domain.ImageMatch{Image: c, Distance: hashBits}
+		if hash != 0 && c.PHash != 0 {
+			m.Distance = phash.Distance(hash, uint64(c.PHash))
+		}
+		m.TextSimilarity = round(Jaccard(tokens, Tokens(c.Text())))
+		m.Score = round(visualWeight*(1-float64(m.Distance)/hashBits) + (1-visualWeight)*m.TextSimilarity)
+
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,61 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestTokens(t *testing.T) {
+	tt := is.New(t)
+
+	tokens := Tokens("Grafana: CPU usage 95% | cpu, x")
+
+	tt.Equal(tokens, []string{"grafana", "cpu", "usage", "95"}) // lowercase, unique, no single characters
+}
+
+func TestJaccard(t *testing.T) {
+	tt := is.New(t)
+
+	tt.Equal(Jaccard([]string{"a1", "b2"}, []string{"a1", "b2"}), 1.0)
+	tt.Equal(Jaccard([]string{"a1", "b2"}, []string{"b2", "c3"}), 1.0/3)
+	tt.Equal(Jaccard([]string{"a1"}, nil), 0.0)
+}
+
+func TestRank(t *testing.T) {
+	tt := is.New(t)
+
+	candidates := []domain.Image{
+		{FileID: "text-only", Description: "grafana cpu usage"},
+		{FileID: "unrelated", Description: "hello world", PHash: -1},
+		{FileID: "identical", Description: "grafana cpu usage", PHash: 0b1010},
+	}
+
+	matches := Rank(0b1010, "Grafana CPU usage", candidates)
+
+	tt.Equal(len(matches), 3)
+	tt.Equal(matches[0].FileID, "identical")
+	tt.Equal(matches[0].Distance, 0)
+	tt.Equal(matches[0].Score, 1.0)
+	tt.Equal(matches[1].FileID, "text-only")
+	tt.Equal(matches[1].Distance, 64) // unknown hash is treated as the maximum distance
+	tt.Equal(matches[1].TextSimilarity, 1.0)
+	tt.Equal(matches[2].FileID, "unrelated")
+}
+
+func TestRank_CorrectedDescription(t *testing.T) {
+	tt := is.New(t)
+
+	candidates := []domain.Image{
+		{FileID: "ocr-only", Description: "grafana cpu usage", CorrectedDescription: "grafana memory"},
+		{FileID: "corrected", Description: "grafnaa cpu usge", CorrectedDescription: "grafana cpu usage"},
+	}
+
+	matches := Rank(0, "Grafana CPU usage", candidates)
+
+	tt.Equal(matches[0].FileID, "corrected") // the corrected text is preferred to the ocr output
+	tt.Equal(matches[0].TextSimilarity, 1.0)
+	tt.Equal(matches[1].FileID, "ocr-only")
+	tt.Equal(matches[1].TextSimilarity, 0.25)
+}
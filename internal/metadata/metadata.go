@@ -0,0 +1,128 @@
+// Package metadata reads what uploaders know about a screenshot besides its pixels,
+// e.g. the window title, the app, the hostname and who took it.
+//
+// Values come from the user metadata of the stored file and from an optional sidecar,
+// a json object stored next to the file under the key of the file with .json appended, e.g.
+//
+//	{"app": "Slack", "window_title": "#incidents", "host": "laptop-42"}
+//
+// Keys are lower case, sidecar values override the metadata of the file.
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// SidecarExt is appended to the key of a file to get the key of its sidecar.
+const SidecarExt = ".json"
+
+// maxSidecarSize keeps a wrong sidecar from being read into memory, metadata is a few short values.
+const maxSidecarSize = 64 << 10
+
+//go:generate moq -out metadata_moq_test.go . source
+type source interface {
+	Metadata(ctx context.Context, key string) (map[string]string, error)
+	Read(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Reader is an indexing stage that sets the metadata of every image.
+type Reader struct {
+	source source
+	log    *slog.Logger
+}
+
+func NewReader(source source, log *slog.Logger) *Reader {
+	return &Reader{source: source, log: log.WithGroup("METADATA")}
+}
+
+// Process sets the metadata of the image. A sidecar that can not be parsed is skipped,
+// so that a broken uploader does not stop screenshots from being indexed.
+func (r *Reader) Process(ctx context.Context, _ string, img *domain.Image) error {
+	fromFile, err := r.source.Metadata(ctx, img.FileID)
+	if err != nil {
+		return fmt.Errorf("reading metadata of %s, %w", img.FileID, err)
+	}
+
+	fromSidecar, err := r.sidecar(ctx, img.FileID)
+	if err != nil {
+		return err
+	}
+
+	meta := domain.Metadata{}
+	for _, m := range []map[string]string{fromFile, fromSidecar} {
+		for k, v := range m {
+			meta[strings.ToLower(k)] = v
+		}
+	}
+	if len(meta) > 0 {
+		img.Metadata = meta
+	}
+
+	return nil
+}
+
+// sidecar returns the values of the sidecar of the file, nil if there is none or it is invalid.
+func (r *Reader) sidecar(ctx context.Context, fileID string) (map[string]string, error) {
+	key := fileID + SidecarExt
+	rc, err := r.source.Read(ctx, key)
+	if errors.Is(err, domain.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sidecar %s, %w", key, err)
+	}
+	defer rc.Close()
+
+	body, err := io.ReadAll(io.LimitReader(rc, maxSidecarSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading sidecar %s, %w", key, err)
+	}
+	if len(body) > maxSidecarSize {
+		r.log.Warn("skipping sidecar", slog.String("key", key), slog.String("err", "sidecar is too large"))
+		return nil, nil
+	}
+
+	values, err := Parse(body)
+	if err != nil {
+		r.log.Warn("skipping sidecar", slog.String("key", key), slog.String("err", err.Error()))
+		return nil, nil
+	}
+
+	return values, nil
+}
+
+// Parse reads a sidecar. Strings are kept as they are, other values are stored as json, e.g. 42 or ["a","b"].
+func Parse(body []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(body, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sidecar, %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if string(v) == "null" {
+			continue
+		}
+
+		var s string
+		if json.Unmarshal(v, &s) != nil {
+			compact := &bytes.Buffer{}
+			// the value was parsed already, compacting it can not fail
+			_ = json.Compact(compact, v)
+			s = compact.String()
+		}
+		values[k] = s
+	}
+
+	return values, nil
+}
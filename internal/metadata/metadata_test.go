@@ -0,0 +1,90 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestReader_Process(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	withSidecar := func(sidecar string) *sourceMock {
+		return &sourceMock{
+			MetadataFunc: func(ctx context.Context, key string) (map[string]string, error) {
+				return map[string]string{"app": "Terminal", "Author": "alex"}, nil
+			},
+			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				if sidecar == "" {
+					return nil, fmt.Errorf("reading %s, %w", key, domain.ErrFileNotFound)
+				}
+				return io.NopCloser(strings.NewReader(sidecar)), nil
+			},
+		}
+	}
+
+	t.Run("sidecar values override the metadata of the file", func(t *testing.T) {
+		src := withSidecar(`{"APP": "Slack", "window_title": "#incidents", "pid": 42, "tags": ["a", "b"], "empty": null}`)
+		img := &domain.Image{FileID: "expected-key.jpg"}
+
+		err := NewReader(src, slog.Default()).Process(ctx, "", img)
+		tt.NoErr(err)
+		tt.Equal(img.Metadata, domain.Metadata{
+			"app":          "Slack",
+			"author":       "alex",
+			"window_title": "#incidents",
+			"pid":          "42",
+			"tags":         `["a","b"]`,
+		})
+		tt.Equal(src.ReadCalls()[0].Key, "expected-key.jpg.json")
+	})
+
+	t.Run("missing sidecar", func(t *testing.T) {
+		img := &domain.Image{FileID: "expected-key.jpg"}
+
+		err := NewReader(withSidecar(""), slog.Default()).Process(ctx, "", img)
+		tt.NoErr(err)
+		tt.Equal(img.Metadata, domain.Metadata{"app": "Terminal", "author": "alex"})
+	})
+
+	t.Run("invalid sidecar is skipped", func(t *testing.T) {
+		img := &domain.Image{FileID: "expected-key.jpg"}
+
+		err := NewReader(withSidecar(`["not an object"]`), slog.Default()).Process(ctx, "", img)
+		tt.NoErr(err)
+		tt.Equal(img.Metadata, domain.Metadata{"app": "Terminal", "author": "alex"})
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		src := &sourceMock{
+			MetadataFunc: func(ctx context.Context, key string) (map[string]string, error) { return nil, nil },
+			ReadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return nil, domain.ErrFileNotFound
+			},
+		}
+		img := &domain.Image{FileID: "expected-key.jpg"}
+
+		err := NewReader(src, slog.Default()).Process(ctx, "", img)
+		tt.NoErr(err)
+		tt.True(img.Metadata == nil) // empty metadata is omitted from responses
+	})
+
+	t.Run("storage errors fail indexing", func(t *testing.T) {
+		src := &sourceMock{
+			MetadataFunc: func(ctx context.Context, key string) (map[string]string, error) {
+				return nil, errors.New("expected error")
+			},
+		}
+
+		err := NewReader(src, slog.Default()).Process(ctx, "", &domain.Image{FileID: "expected-key.jpg"})
+		tt.True(err != nil)
+	})
+}
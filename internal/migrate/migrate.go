@@ -0,0 +1,323 @@
+// Package migrate applies the embedded SQL migrations to Postgres and SQLite.
+// The version is kept in the schema_migrations table of golang-migrate, so databases migrated by the migrate tool keep working.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lockID is the key of the advisory lock that keeps replicas from migrating at the same time.
+const lockID = 7_046_712_019
+
+// sqliteDriver is the name of the SQLite driver, SQLite has neither advisory locks nor to_regclass.
+const sqliteDriver = "sqlite"
+
+var (
+	ErrDirty             = errors.New("database is dirty")
+	ErrUnexpectedVersion = errors.New("unexpected database version")
+	ErrUnknownVersion    = errors.New("unknown migration version")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+}
+
+// Load reads the migrations from the root of fsys, sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations, %w", err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing version of %s, %w", e.Name(), err)
+		}
+		if v == 0 {
+			return nil, fmt.Errorf("migration %s, versions start with 1", e.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading %s, %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[uint(v)]
+		if !ok {
+			mig = &Migration{Version: uint(v), Name: m[2]}
+			byVersion[uint(v)] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", v, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func New(db *sqlx.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrations returns the known migrations, sorted by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest is the version of the newest migration, 0 without migrations.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status returns the version of the database, a database that was never migrated has version 0.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	s := Status{Latest: m.Latest()}
+
+	query := `SELECT CAST(to_regclass('schema_migrations') AS text)`
+	if m.db.DriverName() == sqliteDriver {
+		query = `SELECT (SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`
+	}
+
+	var table sql.NullString
+	err := m.db.GetContext(ctx, &table, query)
+	if err != nil {
+		return s, fmt.Errorf("checking schema_migrations, %w", err)
+	}
+	if !table.Valid {
+		return s, nil
+	}
+
+	row := struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}{}
+	err = m.db.GetContext(ctx, &row, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("reading schema version, %w", err)
+	}
+	s.Version = uint(row.Version)
+	s.Dirty = row.Dirty
+
+	return s, nil
+}
+
+// Check returns an error unless the database is clean and at the latest version.
+func (m *Migrator) Check(ctx context.Context) error {
+	s, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if s.Dirty {
+		return fmt.Errorf("%w at version %d, fix the schema and run migrate force", ErrDirty, s.Version)
+	}
+	if s.Version != s.Latest {
+		return fmt.Errorf("%w %d, expected %d, run migrate up", ErrUnexpectedVersion, s.Version, s.Latest)
+	}
+
+	return nil
+}
+
+// Up applies all pending migrations and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.Steps(ctx, len(m.migrations))
+}
+
+// Down reverts the last n migrations and returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	return m.Steps(ctx, -n)
+}
+
+// Steps applies n pending migrations, or reverts -n applied ones if n is negative.
+// Every migration runs in a transaction together with the version update.
+func (m *Migrator) Steps(ctx context.Context, n int) (int, error) {
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	// the status is read after locking, another replica might have migrated in the meantime
+	s, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if s.Dirty {
+		return 0, fmt.Errorf("%w at version %d, fix the schema and run migrate force", ErrDirty, s.Version)
+	}
+
+	current := -1
+	if s.Version != 0 {
+		current = m.index(s.Version)
+		if current < 0 {
+			return 0, fmt.Errorf("%w %d", ErrUnknownVersion, s.Version)
+		}
+	}
+
+	done := 0
+	for ; n > 0 && current+1 < len(m.migrations); n-- {
+		mig := m.migrations[current+1]
+		err := m.apply(ctx, conn, mig.Up, mig.Version)
+		if err != nil {
+			return done, fmt.Errorf("applying %d_%s, %w", mig.Version, mig.Name, err)
+		}
+		current++
+		done++
+	}
+	for ; n < 0 && current >= 0; n++ {
+		mig := m.migrations[current]
+		if mig.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+		}
+		var prev uint
+		if current > 0 {
+			prev = m.migrations[current-1].Version
+		}
+		err := m.apply(ctx, conn, mig.Down, prev)
+		if err != nil {
+			return done, fmt.Errorf("reverting %d_%s, %w", mig.Version, mig.Name, err)
+		}
+		current--
+		done++
+	}
+
+	return done, nil
+}
+
+// Force sets the version and clears the dirty flag without running migrations.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return m.apply(ctx, conn, "", version)
+}
+
+func (m *Migrator) index(version uint) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, query string, version uint) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction, %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if query != "" {
+		_, err = tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("clearing schema version, %w", err)
+	}
+	// like golang-migrate, a database without applied migrations has no version row
+	if version != 0 {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(version))
+		if err != nil {
+			return fmt.Errorf("setting schema version %d, %w", version, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// lock takes the migration lock on a dedicated connection and creates schema_migrations if needed.
+// A SQLite database belongs to a single process, so it is not locked.
+func (m *Migrator) lock(ctx context.Context) (*sqlx.Conn, func(), error) {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting connection, %w", err)
+	}
+
+	unlock := func() { _ = conn.Close() }
+	if m.db.DriverName() != sqliteDriver {
+		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, int64(lockID))
+		if err != nil {
+			_ = conn.Close()
+			return nil, nil, fmt.Errorf("locking migrations, %w", err)
+		}
+		unlock = func() {
+			_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, int64(lockID))
+			_ = conn.Close()
+		}
+	}
+
+	_, err = conn.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`,
+	)
+	if err != nil {
+		unlock()
+		return nil, nil, fmt.Errorf("creating schema_migrations, %w", err)
+	}
+
+	return conn, unlock, nil
+}
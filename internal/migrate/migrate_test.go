@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/elnoro/foxyshot-indexer/migrations"
+	"github.com/matryer/is"
+)
+
+func TestLoad(t *testing.T) {
+	tt := is.New(t)
+
+	fsys := fstest.MapFS{
+		"000010_add_column.up.sql":     {Data: []byte("ALTER TABLE t ADD c int;")},
+		"000010_add_column.down.sql":   {Data: []byte("ALTER TABLE t DROP c;")},
+		"000002_create_table.up.sql":   {Data: []byte("CREATE TABLE t ();")},
+		"000002_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"README.md":                    {Data: []byte("not a migration")},
+		"migrations.go":                {Data: []byte("package migrations")},
+	}
+
+	got, err := Load(fsys)
+	tt.NoErr(err)
+	tt.Equal(got, []Migration{
+		{Version: 2, Name: "create_table", Up: "CREATE TABLE t ();", Down: "DROP TABLE t;"},
+		{Version: 10, Name: "add_column", Up: "ALTER TABLE t ADD c int;", Down: "ALTER TABLE t DROP c;"},
+	})
+	tt.Equal(New(nil, got).Latest(), uint(10))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no up file", fstest.MapFS{"000001_a.down.sql": {Data: []byte("DROP TABLE t;")}}},
+		{"different names", fstest.MapFS{
+			"000001_a.up.sql":   {Data: []byte("CREATE TABLE t ();")},
+			"000001_b.down.sql": {Data: []byte("DROP TABLE t;")},
+		}},
+		{"zero version", fstest.MapFS{"000000_a.up.sql": {Data: []byte("CREATE TABLE t ();")}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			_, err := Load(tc.fsys)
+			tt.True(err != nil)
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	tt := is.New(t)
+
+	got, err := Load(migrations.FS)
+	tt.NoErr(err)
+	tt.True(len(got) > 0)
+
+	for i, m := range got {
+		tt.Equal(m.Version, uint(i+1)) // embedded migrations are numbered without gaps
+		tt.True(m.Down != "")
+	}
+}
@@ -2,13 +2,21 @@ package monitoring
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Tracker struct {
-	searchCounter prometheus.Counter
-	indexCounter  prometheus.Counter
+	searchCounter  prometheus.Counter
+	indexCounter   prometheus.Counter
+	findingCounter *prometheus.CounterVec
+	alertCounter   *prometheus.CounterVec
+
+	runCounter       *prometheus.CounterVec
+	runFailureGauge  prometheus.Gauge
+	nextRunGauge     prometheus.Gauge
+	lastSuccessGauge prometheus.Gauge
 }
 
 func NewTracker() *Tracker {
@@ -25,6 +33,45 @@ func NewTracker() *Tracker {
 				Help: "No of images indexed",
 			},
 		),
+		findingCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "secret_finding_count",
+				Help: "No of new secrets found in indexed images, reindexing does not count them again",
+			},
+			[]string{"rule"},
+		),
+		alertCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "saved_search_match_count",
+				Help: "No of indexed images that matched saved searches",
+			},
+			[]string{"search"},
+		),
+		runCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "index_run_count",
+				Help: "No of index runs by result",
+			},
+			[]string{"result"},
+		),
+		runFailureGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "index_run_consecutive_failures",
+				Help: "No of index runs that failed since the last successful one",
+			},
+		),
+		nextRunGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "index_next_run_timestamp_seconds",
+				Help: "Unix time of the next index run",
+			},
+		),
+		lastSuccessGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "index_last_success_timestamp_seconds",
+				Help: "Unix time of the last successful index run",
+			},
+		),
 	}
 }
 
@@ -39,6 +86,23 @@ func (t *Tracker) Register() error {
 		return fmt.Errorf("registering index counter, %w", err)
 	}
 
+	err = prometheus.Register(t.findingCounter)
+	if err != nil {
+		return fmt.Errorf("registering finding counter, %w", err)
+	}
+
+	err = prometheus.Register(t.alertCounter)
+	if err != nil {
+		return fmt.Errorf("registering alert counter, %w", err)
+	}
+
+	for _, c := range []prometheus.Collector{t.runCounter, t.runFailureGauge, t.nextRunGauge, t.lastSuccessGauge} {
+		err = prometheus.Register(c)
+		if err != nil {
+			return fmt.Errorf("registering index run metrics, %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -49,3 +113,27 @@ func (t *Tracker) OnIndex() {
 func (t *Tracker) OnSearch() {
 	t.searchCounter.Inc()
 }
+
+func (t *Tracker) OnFinding(rule string) {
+	t.findingCounter.WithLabelValues(rule).Inc()
+}
+
+func (t *Tracker) OnAlert(search string) {
+	t.alertCounter.WithLabelValues(search).Inc()
+}
+
+// OnIndexRun records the result of an index run and the number of consecutive failures.
+func (t *Tracker) OnIndexRun(err error, failures int, at time.Time) {
+	t.runFailureGauge.Set(float64(failures))
+	if err != nil {
+		t.runCounter.WithLabelValues("error").Inc()
+		return
+	}
+
+	t.runCounter.WithLabelValues("success").Inc()
+	t.lastSuccessGauge.Set(float64(at.Unix()))
+}
+
+func (t *Tracker) OnIndexScheduled(next time.Time) {
+	t.nextRunGauge.Set(float64(next.Unix()))
+}
@@ -0,0 +1,100 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// tsvWordLevel is the level of rows with single words in tesseract tsv output.
+const tsvWordLevel = "5"
+
+// Word is a recognised word with its location on the image.
+type Word struct {
+	Text string
+	Box  image.Rectangle
+	// Line identifies the line of text the word belongs to, words of a line share the same value.
+	Line string
+}
+
+// Words runs tesseract in tsv mode and returns recognised words in the reading order.
+func (t *Tesseract) Words(file string) ([]Word, error) {
+	cmd := exec.Command(t.command, file, "stdout", "quiet", "tsv")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running tesseract in tsv mode, %s, %w", stderr.String(), err)
+	}
+
+	words, err := parseTSV(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("parsing tesseract tsv output, %w", err)
+	}
+
+	return words, nil
+}
+
+// parseTSV reads word rows of tesseract tsv output. Fields are not quoted, so csv parsing is not used.
+func parseTSV(r io.Reader) ([]Word, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading header, %w", err)
+		}
+		return nil, errors.New("header is missing")
+	}
+
+	header := strings.Split(scanner.Text(), "\t")
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"level", "block_num", "par_num", "line_num", "left", "top", "width", "height", "text"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is missing", name)
+		}
+	}
+
+	var words []Word
+	for scanner.Scan() {
+		record := strings.Split(scanner.Text(), "\t")
+		if len(record) != len(header) || record[columns["level"]] != tsvWordLevel {
+			continue
+		}
+
+		text := strings.TrimSpace(record[columns["text"]])
+		if text == "" {
+			continue
+		}
+
+		var box [4]int
+		for i, name := range []string{"left", "top", "width", "height"} {
+			var err error
+			box[i], err = strconv.Atoi(record[columns[name]])
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s of word %s, %w", name, text, err)
+			}
+		}
+
+		words = append(words, Word{
+			Text: text,
+			Box:  image.Rect(box[0], box[1], box[0]+box[2], box[1]+box[3]),
+			Line: strings.Join([]string{
+				record[columns["block_num"]], record[columns["par_num"]], record[columns["line_num"]],
+			}, "."),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading records, %w", err)
+	}
+
+	return words, nil
+}
@@ -0,0 +1,54 @@
+package ocr
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseTSV(t *testing.T) {
+	tt := is.New(t)
+
+	const tsv = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t640\t480\t-1\t\n" +
+		"4\t1\t1\t1\t1\t0\t10\t10\t200\t20\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t10\t10\t80\t20\t96.5\tpassword:\n" +
+		"5\t1\t1\t1\t1\t2\t95\t10\t60\t20\t91.2\thunter2\n" +
+		"5\t1\t1\t1\t1\t3\t160\t10\t5\t20\t-1\t \n" +
+		"5\t1\t2\t1\t1\t1\t10\t50\t40\t20\t90\t\"quoted\n"
+
+	words, err := parseTSV(strings.NewReader(tsv))
+	tt.NoErr(err)
+
+	tt.Equal(words, []Word{
+		{Text: "password:", Box: image.Rect(10, 10, 90, 30), Line: "1.1.1"},
+		{Text: "hunter2", Box: image.Rect(95, 10, 155, 30), Line: "1.1.1"},
+		{Text: `"quoted`, Box: image.Rect(10, 50, 50, 70), Line: "2.1.1"},
+	})
+}
+
+func TestParseTSV_InvalidInput(t *testing.T) {
+	tt := is.New(t)
+
+	_, err := parseTSV(strings.NewReader("level\ttext\n5\tword\n"))
+	tt.True(err != nil) // must fail when coordinates are missing
+
+	_, err = parseTSV(strings.NewReader(""))
+	tt.True(err != nil) // must fail without a header
+}
+
+func TestTesseract_Words(t *testing.T) {
+	t.Parallel()
+	tt := is.New(t)
+
+	ocr, err := Default()
+	tt.NoErr(err)
+
+	words, err := ocr.Words("./testdata/expected-text.jpg")
+	tt.NoErr(err)
+	tt.Equal(len(words), 2)
+	tt.Equal(words[0].Text, "expected")
+	tt.Equal(words[1].Text, "text")
+}
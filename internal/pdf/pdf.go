@@ -0,0 +1,304 @@
+// Package pdf writes searchable PDF documents of screenshots.
+//
+// Every page shows an image under a caption. The recognized text is laid over the image as invisible text,
+// line by line from the top, so that viewers can search and copy it. Pages are written as they are added,
+// a document of many screenshots is never kept in memory.
+//
+// Text uses the standard Helvetica font, characters that Windows-1252 can not encode are replaced with "?".
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // decoding of embedded images
+	_ "image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// maxWidth scales large screenshots down to the width of a landscape A4 page, in points.
+	maxWidth = 842.0
+	// minWidth keeps captions of narrow images readable.
+	minWidth      = 300.0
+	captionHeight = 28.0
+	captionSize   = 10.0
+	maxTextSize   = 14.0
+)
+
+// Page is a screenshot with its caption and recognized text.
+type Page struct {
+	// Image is the path to a JPEG or PNG file, JPEG files are embedded as they are
+	Image   string
+	Caption string
+	Text    string
+}
+
+// Writer writes the document to w, Close must be called after the last page.
+type Writer struct {
+	w *countingWriter
+	// offsets of the objects, the object number is the index plus one
+	offsets []int64
+	pages   []int
+	started bool
+	closed  bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: &countingWriter{w: w}}
+}
+
+const (
+	catalogObj = 1
+	pagesObj   = 2
+	fontObj    = 3
+)
+
+func (pw *Writer) start() {
+	pw.started = true
+	// the comment with binary characters tells tools that the file is binary
+	pw.w.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// the objects are numbered in the order of the constants, the page tree is written by Close
+	pw.newObject()
+	pw.newObject()
+	pw.newObject()
+	pw.object(catalogObj, "<< /Type /Catalog /Pages %d 0 R >>", pagesObj)
+	pw.object(fontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+}
+
+// AddPage writes the page. Images that can not be decoded return an error before anything is written.
+func (pw *Writer) AddPage(p Page) error {
+	if pw.closed {
+		return errors.New("pdf writer is closed")
+	}
+
+	img, err := loadImage(p.Image)
+	if err != nil {
+		return err
+	}
+
+	if !pw.started {
+		pw.start()
+	}
+
+	scale := math.Min(1, maxWidth/float64(img.width))
+	imgW, imgH := float64(img.width)*scale, float64(img.height)*scale
+	pageW, pageH := math.Max(imgW, minWidth), imgH+captionHeight
+	left := (pageW - imgW) / 2
+
+	imageObj := pw.newObject()
+	pw.stream(imageObj, img.data,
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s",
+		img.width, img.height, img.colorSpace, img.filter)
+
+	content := &bytes.Buffer{}
+	fmt.Fprintf(content, "q %s 0 0 %s %s 0 cm /Im0 Do Q\n", num(imgW), num(imgH), num(left))
+	fmt.Fprintf(content, "BT /F1 %s Tf 8 %s Td (%s) Tj ET\n",
+		num(captionSize), num(imgH+(captionHeight-captionSize)/2), escape(p.Caption))
+	writeTextLayer(content, p.Text, left, imgW, imgH)
+
+	contentObj := pw.newObject()
+	pw.stream(contentObj, content.Bytes(), "")
+
+	pageObj := pw.newObject()
+	pw.object(pageObj,
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R "+
+			"/Resources << /Font << /F1 %d 0 R >> /XObject << /Im0 %d 0 R >> >> >>",
+		pagesObj, num(pageW), num(pageH), contentObj, fontObj, imageObj)
+	pw.pages = append(pw.pages, pageObj)
+
+	return pw.w.err
+}
+
+// Close writes the page tree and the cross-reference table, it does not close the underlying writer.
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	if !pw.started {
+		pw.start()
+	}
+
+	kids := make([]string, len(pw.pages))
+	for i, p := range pw.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", p)
+	}
+	pw.object(pagesObj, "<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pw.pages))
+
+	xref := pw.w.n
+	pw.w.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.w.printf("%010d 00000 n \n", offset)
+	}
+	pw.w.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, catalogObj, xref)
+
+	return pw.w.err
+}
+
+func (pw *Writer) newObject() int {
+	pw.offsets = append(pw.offsets, 0)
+
+	return len(pw.offsets)
+}
+
+func (pw *Writer) object(n int, format string, args ...any) {
+	pw.offsets[n-1] = pw.w.n
+	pw.w.printf("%d 0 obj\n", n)
+	pw.w.printf(format, args...)
+	pw.w.printf("\nendobj\n")
+}
+
+func (pw *Writer) stream(n int, data []byte, format string, args ...any) {
+	pw.offsets[n-1] = pw.w.n
+	pw.w.printf("%d 0 obj\n<< ", n)
+	if format != "" {
+		pw.w.printf(format+" ", args...)
+	}
+	pw.w.printf("/Length %d >>\nstream\n", len(data))
+	pw.w.write(data)
+	pw.w.printf("\nendstream\nendobj\n")
+}
+
+// writeTextLayer spreads the lines of the text over the height of the image, render mode 3 makes them invisible.
+func writeTextLayer(content *bytes.Buffer, text string, left, width, height float64) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	lineHeight := height / float64(len(lines))
+	size := math.Max(1, math.Min(lineHeight*0.8, maxTextSize))
+
+	fmt.Fprintf(content, "BT 3 Tr /F1 %s Tf\n", num(size))
+	for i, line := range lines {
+		y := height - float64(i+1)*lineHeight + (lineHeight-size)/2
+		// Helvetica is about half as wide as it is high, the line is stretched to the width of the image
+		stretch := math.Max(1, 100*width/(0.5*size*float64(len([]rune(line)))))
+		fmt.Fprintf(content, "%s Tz 1 0 0 1 %s %s Tm (%s) Tj\n", num(math.Min(stretch, 1000)), num(left), num(y), escape(line))
+	}
+	content.WriteString("ET\n")
+}
+
+type pdfImage struct {
+	width, height int
+	colorSpace    string
+	filter        string
+	data          []byte
+}
+
+// loadImage embeds JPEG files as they are, other images are stored as compressed RGB composed over white.
+func loadImage(name string) (pdfImage, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return pdfImage{}, fmt.Errorf("reading image %s, %w", name, err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return pdfImage{}, fmt.Errorf("decoding image %s, %w", name, err)
+	}
+	if format == "jpeg" {
+		switch cfg.ColorModel {
+		case color.YCbCrModel:
+			return pdfImage{cfg.Width, cfg.Height, "DeviceRGB", "DCTDecode", data}, nil
+		case color.GrayModel:
+			return pdfImage{cfg.Width, cfg.Height, "DeviceGray", "DCTDecode", data}, nil
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return pdfImage{}, fmt.Errorf("decoding image %s, %w", name, err)
+	}
+
+	b := img.Bounds()
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+		_, _ = zw.Write(row)
+	}
+	err = zw.Close()
+	if err != nil {
+		return pdfImage{}, fmt.Errorf("compressing image %s, %w", name, err)
+	}
+
+	return pdfImage{b.Dx(), b.Dy(), "DeviceRGB", "FlateDecode", compressed.Bytes()}, nil
+}
+
+// encodingSub replaces characters that the encoding does not support.
+const encodingSub = 0x1a
+
+// escape encodes the text as a literal string of the font encoding.
+func escape(s string) string {
+	// unsupported characters are replaced, so encoding does not fail
+	encoded, _ := encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).String(s)
+
+	var b strings.Builder
+	for _, c := range []byte(encoded) {
+		switch {
+		case c == encodingSub:
+			b.WriteByte('?')
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// num formats a coordinate with two decimals, without trailing zeros.
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// countingWriter tracks the offset of the next byte, the first error stops writing.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) write(p []byte) {
+	if cw.err != nil {
+		return
+	}
+
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) printf(format string, args ...any) {
+	cw.write([]byte(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWriter(t *testing.T) {
+	tt := is.New(t)
+
+	dir := t.TempDir()
+	jpegFile := writeImage(t, filepath.Join(dir, "shot.jpg"), func(f *os.File, img image.Image) error {
+		return jpeg.Encode(f, img, nil)
+	})
+	pngFile := writeImage(t, filepath.Join(dir, "shot.png"), func(f *os.File, img image.Image) error {
+		return png.Encode(f, img)
+	})
+
+	out := &bytes.Buffer{}
+	w := NewWriter(out)
+	err := w.AddPage(Page{Image: jpegFile, Caption: "shots/a.jpg (1)", Text: "kubectl get pods\n\nconnection refused"})
+	tt.NoErr(err)
+	err = w.AddPage(Page{Image: pngFile, Caption: "shots/b.png", Text: "naïve café ✓"})
+	tt.NoErr(err)
+	err = w.Close()
+	tt.NoErr(err)
+
+	doc := out.String()
+	tt.True(strings.HasPrefix(doc, "%PDF-1.4\n"))
+	tt.True(strings.HasSuffix(doc, "%%EOF\n"))
+	tt.True(strings.Contains(doc, "/Count 2"))
+	tt.True(strings.Contains(doc, "/Filter /DCTDecode"))   // jpeg is embedded as it is
+	tt.True(strings.Contains(doc, "/Filter /FlateDecode")) // png is converted
+	tt.True(strings.Contains(doc, `(shots/a.jpg \(1\)) Tj`))
+	tt.True(strings.Contains(doc, "(kubectl get pods) Tj"))
+	tt.True(strings.Contains(doc, "(connection refused) Tj"))
+	tt.True(strings.Contains(doc, "(na\xefve caf\xe9 ?) Tj")) // text is encoded as windows-1252
+	tt.True(strings.Contains(doc, "BT 3 Tr"))                 // text over images is invisible
+
+	// every object must start at the offset listed in the cross-reference table
+	xref, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(doc)[1])
+	tt.NoErr(err)
+	tt.True(strings.HasPrefix(doc[xref:], "xref\n"))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(doc[xref:], -1)
+	tt.Equal(len(entries), 9) // catalog, pages, font and image, content and page of both pages
+	for i, e := range entries {
+		offset, err := strconv.Atoi(e[1])
+		tt.NoErr(err)
+		tt.True(strings.HasPrefix(doc[offset:], fmt.Sprintf("%d 0 obj\n", i+1)))
+	}
+}
+
+func TestWriter_InvalidImage(t *testing.T) {
+	tt := is.New(t)
+
+	name := filepath.Join(t.TempDir(), "shot.jpg")
+	err := os.WriteFile(name, []byte("not an image"), 0o600)
+	tt.NoErr(err)
+
+	out := &bytes.Buffer{}
+	err = NewWriter(out).AddPage(Page{Image: name})
+	tt.True(err != nil)
+	tt.Equal(out.Len(), 0) // nothing is written for a page that fails
+}
+
+func writeImage(t *testing.T, name string, encode func(f *os.File, img image.Image) error) string {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	img.Set(0, 0, color.NRGBA{A: 0})
+
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	err = encode(f, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
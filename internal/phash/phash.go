@@ -0,0 +1,90 @@
+// Package phash calculates perceptual hashes of images.
+//
+// The hash is a difference hash (dHash): the image is reduced to a 9x8 grayscale
+// grid and every bit records whether a cell is brighter than its right neighbour.
+// Visually similar images produce hashes with a small Hamming distance.
+package phash
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif" // registering decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"os"
+)
+
+const (
+	gridWidth  = 9
+	gridHeight = 8
+)
+
+// FromFile decodes the image stored in the file and returns its hash.
+func FromFile(name string) (uint64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, fmt.Errorf("opening image %s, %w", name, err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return 0, fmt.Errorf("decoding image %s, %w", name, err)
+	}
+
+	return Difference(img), nil
+}
+
+// Difference returns the dHash of the image.
+func Difference(img image.Image) uint64 {
+	grid := shrink(img)
+
+	var hash uint64
+	for y := 0; y < gridHeight; y++ {
+		for x := 0; x < gridWidth-1; x++ {
+			hash <<= 1
+			if grid[y][x] > grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// Distance returns the number of bits that differ between two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// shrink averages the luminance of the image over a gridWidth x gridHeight grid.
+func shrink(img image.Image) [gridHeight][gridWidth]float64 {
+	var grid [gridHeight][gridWidth]float64
+	var counts [gridHeight][gridWidth]int
+
+	b := img.Bounds()
+	if b.Empty() {
+		return grid
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		gy := (y - b.Min.Y) * gridHeight / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			gx := (x - b.Min.X) * gridWidth / b.Dx()
+			r, g, bl, _ := img.At(x, y).RGBA()
+			grid[gy][gx] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+			counts[gy][gx]++
+		}
+	}
+
+	for y := range grid {
+		for x := range grid[y] {
+			if counts[y][x] > 0 {
+				grid[y][x] /= float64(counts[y][x])
+			}
+		}
+	}
+
+	return grid
+}
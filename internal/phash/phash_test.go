@@ -0,0 +1,74 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func gradient(w, h int, shift uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 100 + 80*math.Sin(9*float64(x)/float64(w)+4*float64(y)/float64(h))
+			img.SetGray(x, y, color.Gray{Y: uint8(v) + shift})
+		}
+	}
+
+	return img
+}
+
+func checkerboard(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x/10+y/10)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	return img
+}
+
+func TestDifference(t *testing.T) {
+	t.Run("same image has zero distance", func(t *testing.T) {
+		tt := is.New(t)
+
+		a := Difference(gradient(320, 200, 0))
+		b := Difference(gradient(320, 200, 0))
+
+		tt.Equal(Distance(a, b), 0)
+	})
+
+	t.Run("brightened and resized image stays close", func(t *testing.T) {
+		tt := is.New(t)
+
+		a := Difference(gradient(320, 200, 0))
+		b := Difference(gradient(640, 400, 20))
+
+		tt.True(Distance(a, b) <= 10) // near-duplicates must be within a small distance
+	})
+
+	t.Run("different images are far apart", func(t *testing.T) {
+		tt := is.New(t)
+
+		a := Difference(gradient(320, 200, 0))
+		b := Difference(checkerboard(320, 200))
+
+		tt.True(Distance(a, b) > 10)
+	})
+}
+
+func TestFromFile(t *testing.T) {
+	tt := is.New(t)
+
+	_, err := FromFile("../ocr/testdata/expected-text.jpg")
+	tt.NoErr(err)
+
+	_, err = FromFile("./testdata/does-not-exist.jpg")
+	tt.True(err != nil) // must return an error for missing files
+}
@@ -0,0 +1,166 @@
+// Package redact produces copies of images with sensitive text blacked out.
+//
+// Words are located with tesseract, every line of text is checked against
+// the rules and the boxes of the words that overlap a match are filled black.
+package redact
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/ocr"
+	"github.com/elnoro/foxyshot-indexer/internal/secrets"
+)
+
+// padding extends word boxes, so that glyph edges outside of the box are covered too.
+const padding = 2
+
+// DefaultRules are the secret detection rules extended with email addresses.
+var DefaultRules = append(append([]secrets.Rule(nil), secrets.Rules...), secrets.Rule{
+	Name:    "email",
+	Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`),
+})
+
+//go:generate moq -out redact_moq_test.go . wordLocator uploader
+type wordLocator interface {
+	Words(file string) ([]ocr.Word, error)
+}
+
+type uploader interface {
+	Upload(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+}
+
+// Redactor is an indexing stage that stores a redacted copy of every image under the prefix.
+type Redactor struct {
+	words   wordLocator
+	storage uploader
+	rules   []secrets.Rule
+	prefix  string
+}
+
+func NewRedactor(words wordLocator, storage uploader, rules []secrets.Rule, prefix string) *Redactor {
+	return &Redactor{words: words, storage: storage, rules: rules, prefix: prefix}
+}
+
+// Key returns the key of the redacted copy of the file.
+func Key(prefix, fileID string) string {
+	return prefix + fileID
+}
+
+func (r *Redactor) Process(ctx context.Context, file string, img *domain.Image) error {
+	words, err := r.words.Words(file)
+	if err != nil {
+		return fmt.Errorf("locating words, %w", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("opening image %s, %w", file, err)
+	}
+	defer f.Close()
+
+	src, format, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("decoding image %s, %w", file, err)
+	}
+
+	var buf bytes.Buffer
+	contentType, err := encode(&buf, Redact(src, Sensitive(words, r.rules)), format)
+	if err != nil {
+		return fmt.Errorf("encoding redacted image, %w", err)
+	}
+
+	err = r.storage.Upload(ctx, Key(r.prefix, img.FileID), bytes.NewReader(buf.Bytes()), contentType)
+	if err != nil {
+		return fmt.Errorf("storing redacted image, %w", err)
+	}
+
+	return nil
+}
+
+// Sensitive returns the boxes of the words that overlap a match of any rule.
+// Rules are applied to whole lines, so that a value can be recognised by its label, e.g. "password: ...".
+func Sensitive(words []ocr.Word, rules []secrets.Rule) []image.Rectangle {
+	var boxes []image.Rectangle
+
+	for start := 0; start < len(words); {
+		end := start + 1
+		for end < len(words) && words[end].Line == words[start].Line {
+			end++
+		}
+		boxes = append(boxes, sensitiveInLine(words[start:end], rules)...)
+		start = end
+	}
+
+	return boxes
+}
+
+func sensitiveInLine(words []ocr.Word, rules []secrets.Rule) []image.Rectangle {
+	var sb strings.Builder
+	offsets := make([][2]int, len(words))
+	for i, w := range words {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		offsets[i] = [2]int{sb.Len(), sb.Len() + len(w.Text)}
+		sb.WriteString(w.Text)
+	}
+	line := sb.String()
+
+	marked := make([]bool, len(words))
+	for _, rule := range rules {
+		for _, loc := range rule.FindAll(line) {
+			for i, o := range offsets {
+				if loc[0] < o[1] && o[0] < loc[1] {
+					marked[i] = true
+				}
+			}
+		}
+	}
+
+	var boxes []image.Rectangle
+	for i, w := range words {
+		if marked[i] {
+			boxes = append(boxes, w.Box)
+		}
+	}
+
+	return boxes
+}
+
+// Redact returns a copy of the image with the boxes filled black.
+func Redact(src image.Image, boxes []image.Rectangle) image.Image {
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	black := image.NewUniform(color.Black)
+	for _, box := range boxes {
+		draw.Draw(dst, box.Inset(-padding).Intersect(dst.Bounds()), black, image.Point{}, draw.Src)
+	}
+
+	return dst
+}
+
+// encode writes the image in the format of the original when possible and returns its content type.
+func encode(w io.Writer, img image.Image, format string) (string, error) {
+	switch format {
+	case "jpeg":
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	case "gif":
+		return "image/gif", gif.Encode(w, img, nil)
+	default:
+		return "image/png", png.Encode(w, img)
+	}
+}
@@ -0,0 +1,113 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/ocr"
+	"github.com/matryer/is"
+)
+
+func TestSensitive(t *testing.T) {
+	tt := is.New(t)
+
+	words := []ocr.Word{
+		{Text: "login:", Box: image.Rect(0, 0, 10, 10), Line: "1.1.1"},
+		{Text: "admin@example.com", Box: image.Rect(12, 0, 40, 10), Line: "1.1.1"},
+		{Text: "password:", Box: image.Rect(0, 20, 10, 30), Line: "1.1.2"},
+		{Text: "hunter2hunter2", Box: image.Rect(12, 20, 40, 30), Line: "1.1.2"},
+		{Text: "nothing", Box: image.Rect(0, 40, 10, 50), Line: "1.1.3"},
+		{Text: "to", Box: image.Rect(12, 40, 20, 50), Line: "1.1.3"},
+		{Text: "hide", Box: image.Rect(22, 40, 30, 50), Line: "1.1.3"},
+	}
+
+	tt.Equal(Sensitive(words, DefaultRules), []image.Rectangle{
+		image.Rect(12, 0, 40, 10),
+		image.Rect(12, 20, 40, 30), // only the value is hidden, the label stays
+	})
+}
+
+func TestRedact(t *testing.T) {
+	tt := is.New(t)
+
+	src := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			src.Set(x, y, color.White)
+		}
+	}
+
+	res := Redact(src, []image.Rectangle{image.Rect(5, 5, 8, 8), image.Rect(18, 18, 30, 30)})
+
+	black := color.RGBAModel.Convert(color.Black)
+	white := color.RGBAModel.Convert(color.White)
+	tt.Equal(color.RGBAModel.Convert(res.At(6, 6)), black)   // inside the box
+	tt.Equal(color.RGBAModel.Convert(res.At(3, 3)), black)   // inside the padding
+	tt.Equal(color.RGBAModel.Convert(res.At(19, 19)), black) // box is clipped, not dropped
+	tt.Equal(color.RGBAModel.Convert(res.At(12, 12)), white)
+	tt.Equal(color.RGBAModel.Convert(src.At(6, 6)), white) // source must not be modified
+}
+
+func TestRedactor_Process(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "image")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := &wordLocatorMock{
+		WordsFunc: func(file string) ([]ocr.Word, error) {
+			return []ocr.Word{{Text: "admin@example.com", Box: image.Rect(1, 1, 10, 10), Line: "1"}}, nil
+		},
+	}
+
+	t.Run("uploads redacted copy", func(t *testing.T) {
+		tt := is.New(t)
+
+		var uploaded image.Image
+		storage := &uploaderMock{
+			UploadFunc: func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+				var err error
+				uploaded, err = png.Decode(body)
+				return err
+			},
+		}
+
+		err := NewRedactor(words, storage, DefaultRules, "redacted/").
+			Process(context.Background(), file, &domain.Image{FileID: "expected-id.png"})
+		tt.NoErr(err)
+
+		tt.Equal(words.WordsCalls()[0].File, file)
+		tt.Equal(storage.UploadCalls()[0].Key, "redacted/expected-id.png")
+		tt.Equal(storage.UploadCalls()[0].ContentType, "image/png")
+		tt.Equal(color.RGBAModel.Convert(uploaded.At(5, 5)), color.RGBAModel.Convert(color.Black))
+	})
+
+	t.Run("upload error", func(t *testing.T) {
+		tt := is.New(t)
+
+		expectedErr := errors.New("expected-err")
+		storage := &uploaderMock{
+			UploadFunc: func(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+				return expectedErr
+			},
+		}
+
+		err := NewRedactor(words, storage, DefaultRules, "redacted/").
+			Process(context.Background(), file, &domain.Image{FileID: "expected-id.png"})
+		tt.True(errors.Is(err, expectedErr))
+	})
+}
@@ -0,0 +1,370 @@
+// Package repotest is the test suite shared by the image repositories, every backend must pass it.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+const testFileID = "expected-file-id"
+
+// Repo is the part of the image repository that every backend implements.
+type Repo interface {
+	Upsert(ctx context.Context, image domain.Image) error
+	Get(ctx context.Context, fileID string) (domain.Image, error)
+	GetLastModified(ctx context.Context) (time.Time, error)
+	FindByDescription(ctx context.Context, searchString string, page, perPage int) ([]domain.Image, error)
+	Delete(ctx context.Context, fileID string) error
+	ImageFindings(ctx context.Context, fileID string) ([]domain.Finding, error)
+}
+
+// Run runs the suite, newRepo must return an empty repository for every test.
+func Run(t *testing.T, newRepo func(t *testing.T) Repo) {
+	ctx := context.Background()
+
+	t.Run("Upsert replaces description on when the same file id is used", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{
+			FileID:      testFileID,
+			Description: "original-description",
+		})
+		tt.NoErr(err)
+
+		gotOrig, err := repo.Get(ctx, testFileID)
+		tt.NoErr(err)
+		tt.Equal(gotOrig.Description, "original-description")
+
+		err = repo.Upsert(context.Background(), domain.Image{
+			FileID:      testFileID,
+			Description: "updated-description",
+		})
+		tt.NoErr(err)
+		gotUpdated, err := repo.Get(ctx, testFileID)
+		tt.NoErr(err)
+		tt.Equal(gotUpdated.Description, "updated-description")
+	})
+
+	t.Run("Upsert adds file id to an error in case of query failure", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		cancelledContext, cancel := context.WithCancel(ctx)
+		cancel() // cancelling immediately to induce error
+
+		err := repo.Upsert(cancelledContext, domain.Image{
+			FileID: testFileID,
+		})
+
+		tt.True(strings.Contains(err.Error(), testFileID))
+		tt.True(errors.Is(err, context.Canceled))
+	})
+
+	t.Run("Upsert stores the perceptual hash", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{FileID: testFileID, Description: "hashed", PHash: -1})
+		tt.NoErr(err)
+
+		got, err := repo.Get(ctx, testFileID)
+		tt.NoErr(err)
+		tt.Equal(got.PHash, int64(-1))
+
+		err = repo.Upsert(ctx, domain.Image{FileID: testFileID, Description: "not hashed"})
+		tt.NoErr(err)
+
+		got, err = repo.Get(ctx, testFileID)
+		tt.NoErr(err)
+		tt.Equal(got.PHash, int64(0)) // zero hash means that the hash could not be calculated
+	})
+
+	t.Run("GetLastModified returns the latest last modified from all images", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		want := time.Unix(32530952912, 0).UTC()
+		img := &domain.Image{FileID: "file-id", LastModified: want}
+		err := repo.Upsert(ctx, *img)
+		tt.NoErr(err)
+		err = repo.Upsert(ctx, domain.Image{FileID: "older-file-id", LastModified: want.Add(-time.Hour)})
+		tt.NoErr(err)
+
+		got, err := repo.GetLastModified(ctx)
+		tt.NoErr(err)
+		tt.Equal(want, got)
+
+		want = want.Add(1 * time.Second)
+		img.LastModified = want
+		err = repo.Upsert(ctx, *img)
+		tt.NoErr(err)
+
+		got, err = repo.GetLastModified(ctx)
+		tt.NoErr(err)
+		tt.Equal(want, got)
+	})
+
+	t.Run("GetLastModified returns wrapped error if there is an error in the query", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel() // inducing error
+
+		_, err := repo.GetLastModified(ctx)
+
+		tt.True(errors.Is(err, context.Canceled))
+	})
+
+	t.Run("GetLastModified returns start of Unix time if there is nothing to find", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		want := time.Unix(0, 0).UTC()
+		got, err := repo.GetLastModified(ctx)
+
+		tt.NoErr(err)
+		tt.Equal(want, got)
+	})
+
+	t.Run("FindByDescription returns images with descriptions matching search string", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{
+			FileID:      "expected-found-id",
+			Description: "find me",
+		})
+		tt.NoErr(err)
+
+		err = repo.Upsert(ctx, domain.Image{
+			FileID:      "expected-not-found-id",
+			Description: "skip me",
+		})
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, "find me", 1, 100)
+		tt.NoErr(err)
+
+		tt.Equal(1, len(images))
+		tt.Equal("expected-found-id", images[0].FileID)
+	})
+
+	t.Run("FindByDescription returns the latest images first by pages", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		for i, id := range []string{"page-old", "page-middle", "page-new"} {
+			err := repo.Upsert(ctx, domain.Image{
+				FileID:       id,
+				Description:  "Connection timeout",
+				LastModified: time.Unix(int64(i+1)*100, 0),
+			})
+			tt.NoErr(err)
+		}
+
+		images, err := repo.FindByDescription(ctx, "connection TIMEOUT", 1, 2)
+		tt.NoErr(err)
+		tt.Equal(2, len(images))
+		tt.Equal("page-new", images[0].FileID)
+		tt.Equal("page-middle", images[1].FileID)
+
+		images, err = repo.FindByDescription(ctx, "connection TIMEOUT", 2, 2)
+		tt.NoErr(err)
+		tt.Equal(1, len(images))
+		tt.Equal("page-old", images[0].FileID)
+	})
+
+	t.Run("FindByDescription returns error if there is an error in the query", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		images, err := repo.FindByDescription(ctx, "find me", 1, 100)
+		tt.Equal(0, len(images))
+		tt.True(errors.Is(err, context.Canceled))
+	})
+
+	t.Run("FindByDescription uses pattern matching if full text fails", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{
+			FileID:      "expected-found-id",
+			Description: "complicatedstring",
+		})
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, "catedstring", 1, 100)
+		tt.NoErr(err)
+
+		tt.Equal(1, len(images))
+		tt.Equal("expected-found-id", images[0].FileID)
+	})
+
+	t.Run("FindByDescription does not interpret the syntax of the search engine", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{
+			FileID:      "expected-found-id",
+			Description: `error: "quoted" (code 42) OR NOT*`,
+		})
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, `"quoted" (code OR NOT*`, 1, 100)
+		tt.NoErr(err)
+
+		tt.Equal(1, len(images))
+		tt.Equal("expected-found-id", images[0].FileID)
+	})
+
+	t.Run("FindByDescription returns empty slice if there is nothing to be found", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{FileID: "expected-not-found-id", Description: "skip me"})
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, "skipme", 1, 100)
+		tt.Equal([]domain.Image{}, images)
+		tt.NoErr(err)
+	})
+
+	t.Run("FindByDescription filters images by entities", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{
+			FileID:      "entity-ip",
+			Description: "connection to 10.0.0.1 refused",
+			Entities:    []domain.Entity{{Kind: domain.EntityIP, Value: "10.0.0.1"}},
+		})
+		tt.NoErr(err)
+		err = repo.Upsert(ctx, domain.Image{
+			FileID:      "entity-url",
+			Description: "connection to http://localhost refused",
+			Entities:    []domain.Entity{{Kind: domain.EntityURL, Value: "http://localhost"}},
+		})
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, "connection has:url", 1, 100)
+		tt.NoErr(err)
+		tt.Equal(1, len(images))
+		tt.Equal("entity-url", images[0].FileID)
+
+		images, err = repo.FindByDescription(ctx, "entity:ip=10.0.0.1", 1, 100)
+		tt.NoErr(err)
+		tt.Equal(1, len(images))
+		tt.Equal("entity-ip", images[0].FileID)
+
+		err = repo.Upsert(ctx, domain.Image{FileID: "entity-ip", Description: "connection refused again"})
+		tt.NoErr(err)
+
+		images, err = repo.FindByDescription(ctx, "again has:ip", 1, 100)
+		tt.NoErr(err)
+		tt.Equal(1, len(images)) // entities are kept if they were not extracted
+	})
+
+	t.Run("FindByDescription filters images by tags", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{FileID: "tagged", Description: "grafana", Tags: []string{"monitoring"}})
+		tt.NoErr(err)
+		err = repo.Upsert(ctx, domain.Image{FileID: "untagged", Description: "grafana"})
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, "tag:monitoring", 1, 100)
+		tt.NoErr(err)
+		tt.Equal(1, len(images))
+		tt.Equal("tagged", images[0].FileID)
+	})
+
+	t.Run("ImageFindings returns findings in the order they were found", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		findings := []domain.Finding{
+			{Rule: "github_token", Preview: "ghp_****"},
+			{Rule: "aws_access_key_id", Preview: "AKIA****"},
+		}
+		err := repo.Upsert(ctx, domain.Image{FileID: testFileID, Findings: findings})
+		tt.NoErr(err)
+		// findings are kept when the image is stored without them
+		err = repo.Upsert(ctx, domain.Image{FileID: testFileID})
+		tt.NoErr(err)
+
+		got, err := repo.ImageFindings(ctx, testFileID)
+		tt.NoErr(err)
+		tt.Equal(got, findings)
+
+		got, err = repo.ImageFindings(ctx, "does-not-exist")
+		tt.NoErr(err)
+		tt.Equal(len(got), 0)
+	})
+
+	t.Run("Delete removes the image from search results", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Upsert(ctx, domain.Image{FileID: "delete-me", Description: "deleted"})
+		tt.NoErr(err)
+		err = repo.Delete(ctx, "delete-me")
+		tt.NoErr(err)
+
+		images, err := repo.FindByDescription(ctx, "deleted", 1, 100)
+		tt.NoErr(err)
+		tt.Equal(0, len(images))
+	})
+
+	t.Run("Delete returns nil if the image does not exist", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		err := repo.Delete(ctx, "does-not-exist")
+		tt.NoErr(err)
+	})
+
+	t.Run("Delete returns error if removal has failed", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel() // inducing error
+		err := repo.Delete(ctx, "delete-me")
+
+		tt.True(errors.Is(err, context.Canceled))
+	})
+
+	t.Run("Get returns wrapped error if there is an error in the query", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel() // inducing error
+
+		_, err := repo.Get(ctx, testFileID)
+
+		tt.True(errors.Is(err, context.Canceled))
+	})
+
+	t.Run("Get returns not found error if fileID does not exist", func(t *testing.T) {
+		tt := is.New(t)
+		repo := newRepo(t)
+
+		_, err := repo.Get(ctx, "does-not-exist")
+
+		tt.True(errors.Is(err, dbadapter.ErrRecordNotFound))
+	})
+}
@@ -6,16 +6,19 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/imageformat"
 )
 
 const tempFilePrefix = "foxyshot_indexer_"
@@ -29,6 +32,9 @@ type client interface {
 	HeadBucket(*s3.HeadBucketInput) (*s3.HeadBucketOutput, error)
 	ListObjectsV2(*s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
 	DeleteObject(object *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+	PutObject(object *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	GetObject(object *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	HeadObject(object *s3.HeadObjectInput) (*s3.HeadObjectOutput, error)
 }
 
 type BucketClient struct {
@@ -38,6 +44,8 @@ type BucketClient struct {
 
 	bucket         string
 	tempFilePrefix string
+	// excludedPrefixes hide files that are produced by the indexer itself
+	excludedPrefixes []string
 }
 
 var ErrNoAttempts = errors.New("invalid number of attempts, must be > 0")
@@ -74,6 +82,11 @@ func NewFromSecrets(
 	return NewClient(s3Client, s3Downloader, logger, bucket, tempFilePrefix), nil
 }
 
+// Exclude hides files with the given key prefix from ListFiles.
+func (c *BucketClient) Exclude(prefix string) {
+	c.excludedPrefixes = append(c.excludedPrefixes, prefix)
+}
+
 func (c *BucketClient) CheckConnectivity(attempts int, dur time.Duration) error {
 	if attempts < 1 {
 		return fmt.Errorf("checking connectivity, passed %d, %w", attempts, ErrNoAttempts)
@@ -104,7 +117,10 @@ func (c *BucketClient) ListFiles(start time.Time, ext string) ([]domain.File, er
 		if object.LastModified.Before(start) {
 			continue
 		}
-		if !strings.HasSuffix(*object.Key, ext) {
+		if !imageformat.Match(*object.Key, ext) {
+			continue
+		}
+		if c.excluded(*object.Key) {
 			continue
 		}
 
@@ -116,6 +132,16 @@ func (c *BucketClient) ListFiles(start time.Time, ext string) ([]domain.File, er
 	return files, nil
 }
 
+func (c *BucketClient) excluded(key string) bool {
+	for _, prefix := range c.excludedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *BucketClient) Download(key string) (*os.File, error) {
 	f, err := os.CreateTemp("", c.tempFilePrefix)
 	if err != nil {
@@ -133,6 +159,48 @@ func (c *BucketClient) Download(key string) (*os.File, error) {
 	return f, nil
 }
 
+// Stat returns the file with the key without downloading it.
+func (c *BucketClient) Stat(_ context.Context, key string) (domain.File, error) {
+	out, err := c.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		// HeadObject has no body, so a missing key is reported with the status code only
+		var rerr awserr.RequestFailure
+		if errors.As(err, &rerr) && rerr.StatusCode() == http.StatusNotFound {
+			return domain.File{}, fmt.Errorf("getting file %s from s3, %w", key, domain.ErrFileNotFound)
+		}
+
+		return domain.File{}, fmt.Errorf("getting file %s from s3, %w", key, err)
+	}
+
+	return domain.File{Key: key, LastModified: aws.TimeValue(out.LastModified)}, nil
+}
+
+// Metadata returns the user metadata of the file, the x-amz-meta-* headers, with lower case keys.
+func (c *BucketClient) Metadata(_ context.Context, key string) (map[string]string, error) {
+	out, err := c.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var rerr awserr.RequestFailure
+		if errors.As(err, &rerr) && rerr.StatusCode() == http.StatusNotFound {
+			return nil, fmt.Errorf("getting metadata of %s from s3, %w", key, domain.ErrFileNotFound)
+		}
+
+		return nil, fmt.Errorf("getting metadata of %s from s3, %w", key, err)
+	}
+
+	meta := make(map[string]string, len(out.Metadata))
+	for k, v := range out.Metadata {
+		meta[strings.ToLower(k)] = aws.StringValue(v)
+	}
+
+	return meta, nil
+}
+
 func (c *BucketClient) DeleteFile(_ context.Context, key string) error {
 	_, err := c.client.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: aws.String(c.bucket),
@@ -144,3 +212,35 @@ func (c *BucketClient) DeleteFile(_ context.Context, key string) error {
 
 	return nil
 }
+
+func (c *BucketClient) Upload(_ context.Context, key string, body io.ReadSeeker, contentType string) error {
+	_, err := c.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("uploading file %s to s3, %w", key, err)
+	}
+
+	return nil
+}
+
+// Read streams the file from s3, the caller must close the reader.
+func (c *BucketClient) Read(_ context.Context, key string) (io.ReadCloser, error) {
+	out, err := c.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, fmt.Errorf("reading file %s from s3, %w", key, domain.ErrFileNotFound)
+		}
+
+		return nil, fmt.Errorf("reading file %s from s3, %w", key, err)
+	}
+
+	return out.Body, nil
+}
@@ -101,12 +101,21 @@ var _ client = &clientMock{}
 //			DeleteObjectFunc: func(object *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
 //				panic("mock out the DeleteObject method")
 //			},
+//			GetObjectFunc: func(object *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+//				panic("mock out the GetObject method")
+//			},
 //			HeadBucketFunc: func(headBucketInput *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
 //				panic("mock out the HeadBucket method")
 //			},
+//			HeadObjectFunc: func(object *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+//				panic("mock out the HeadObject method")
+//			},
 //			ListObjectsV2Func: func(listObjectsV2Input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
 //				panic("mock out the ListObjectsV2 method")
 //			},
+//			PutObjectFunc: func(object *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+//				panic("mock out the PutObject method")
+//			},
 //		}
 //
 //		// use mockedclient in code that requires client
@@ -117,12 +126,21 @@ type clientMock struct {
 	// DeleteObjectFunc mocks the DeleteObject method.
 	DeleteObjectFunc func(object *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
 
+	// GetObjectFunc mocks the GetObject method.
+	GetObjectFunc func(object *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+
 	// HeadBucketFunc mocks the HeadBucket method.
 	HeadBucketFunc func(headBucketInput *s3.HeadBucketInput) (*s3.HeadBucketOutput, error)
 
+	// HeadObjectFunc mocks the HeadObject method.
+	HeadObjectFunc func(object *s3.HeadObjectInput) (*s3.HeadObjectOutput, error)
+
 	// ListObjectsV2Func mocks the ListObjectsV2 method.
 	ListObjectsV2Func func(listObjectsV2Input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
 
+	// PutObjectFunc mocks the PutObject method.
+	PutObjectFunc func(object *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// DeleteObject holds details about calls to the DeleteObject method.
@@ -130,20 +148,38 @@ type clientMock struct {
 			// Object is the object argument value.
 			Object *s3.DeleteObjectInput
 		}
+		// GetObject holds details about calls to the GetObject method.
+		GetObject []struct {
+			// Object is the object argument value.
+			Object *s3.GetObjectInput
+		}
 		// HeadBucket holds details about calls to the HeadBucket method.
 		HeadBucket []struct {
 			// HeadBucketInput is the headBucketInput argument value.
 			HeadBucketInput *s3.HeadBucketInput
 		}
+		// HeadObject holds details about calls to the HeadObject method.
+		HeadObject []struct {
+			// Object is the object argument value.
+			Object *s3.HeadObjectInput
+		}
 		// ListObjectsV2 holds details about calls to the ListObjectsV2 method.
 		ListObjectsV2 []struct {
 			// ListObjectsV2Input is the listObjectsV2Input argument value.
 			ListObjectsV2Input *s3.ListObjectsV2Input
 		}
+		// PutObject holds details about calls to the PutObject method.
+		PutObject []struct {
+			// Object is the object argument value.
+			Object *s3.PutObjectInput
+		}
 	}
 	lockDeleteObject  sync.RWMutex
+	lockGetObject     sync.RWMutex
 	lockHeadBucket    sync.RWMutex
+	lockHeadObject    sync.RWMutex
 	lockListObjectsV2 sync.RWMutex
+	lockPutObject     sync.RWMutex
 }
 
 // DeleteObject calls DeleteObjectFunc.
@@ -178,6 +214,38 @@ func (mock *clientMock) DeleteObjectCalls() []struct {
 	return calls
 }
 
+// GetObject calls GetObjectFunc.
+func (mock *clientMock) GetObject(object *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	if mock.GetObjectFunc == nil {
+		panic("clientMock.GetObjectFunc: method is nil but client.GetObject was just called")
+	}
+	callInfo := struct {
+		Object *s3.GetObjectInput
+	}{
+		Object: object,
+	}
+	mock.lockGetObject.Lock()
+	mock.calls.GetObject = append(mock.calls.GetObject, callInfo)
+	mock.lockGetObject.Unlock()
+	return mock.GetObjectFunc(object)
+}
+
+// GetObjectCalls gets all the calls that were made to GetObject.
+// Check the length with:
+//
+//	len(mockedclient.GetObjectCalls())
+func (mock *clientMock) GetObjectCalls() []struct {
+	Object *s3.GetObjectInput
+} {
+	var calls []struct {
+		Object *s3.GetObjectInput
+	}
+	mock.lockGetObject.RLock()
+	calls = mock.calls.GetObject
+	mock.lockGetObject.RUnlock()
+	return calls
+}
+
 // HeadBucket calls HeadBucketFunc.
 func (mock *clientMock) HeadBucket(headBucketInput *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
 	if mock.HeadBucketFunc == nil {
@@ -210,6 +278,38 @@ func (mock *clientMock) HeadBucketCalls() []struct {
 	return calls
 }
 
+// HeadObject calls HeadObjectFunc.
+func (mock *clientMock) HeadObject(object *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	if mock.HeadObjectFunc == nil {
+		panic("clientMock.HeadObjectFunc: method is nil but client.HeadObject was just called")
+	}
+	callInfo := struct {
+		Object *s3.HeadObjectInput
+	}{
+		Object: object,
+	}
+	mock.lockHeadObject.Lock()
+	mock.calls.HeadObject = append(mock.calls.HeadObject, callInfo)
+	mock.lockHeadObject.Unlock()
+	return mock.HeadObjectFunc(object)
+}
+
+// HeadObjectCalls gets all the calls that were made to HeadObject.
+// Check the length with:
+//
+//	len(mockedclient.HeadObjectCalls())
+func (mock *clientMock) HeadObjectCalls() []struct {
+	Object *s3.HeadObjectInput
+} {
+	var calls []struct {
+		Object *s3.HeadObjectInput
+	}
+	mock.lockHeadObject.RLock()
+	calls = mock.calls.HeadObject
+	mock.lockHeadObject.RUnlock()
+	return calls
+}
+
 // ListObjectsV2 calls ListObjectsV2Func.
 func (mock *clientMock) ListObjectsV2(listObjectsV2Input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
 	if mock.ListObjectsV2Func == nil {
@@ -241,3 +341,35 @@ func (mock *clientMock) ListObjectsV2Calls() []struct {
 	mock.lockListObjectsV2.RUnlock()
 	return calls
 }
+
+// PutObject calls PutObjectFunc.
+func (mock *clientMock) PutObject(object *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	if mock.PutObjectFunc == nil {
+		panic("clientMock.PutObjectFunc: method is nil but client.PutObject was just called")
+	}
+	callInfo := struct {
+		Object *s3.PutObjectInput
+	}{
+		Object: object,
+	}
+	mock.lockPutObject.Lock()
+	mock.calls.PutObject = append(mock.calls.PutObject, callInfo)
+	mock.lockPutObject.Unlock()
+	return mock.PutObjectFunc(object)
+}
+
+// PutObjectCalls gets all the calls that were made to PutObject.
+// Check the length with:
+//
+//	len(mockedclient.PutObjectCalls())
+func (mock *clientMock) PutObjectCalls() []struct {
+	Object *s3.PutObjectInput
+} {
+	var calls []struct {
+		Object *s3.PutObjectInput
+	}
+	mock.lockPutObject.RLock()
+	calls = mock.calls.PutObject
+	mock.lockPutObject.RUnlock()
+	return calls
+}
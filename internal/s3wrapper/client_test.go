@@ -1,14 +1,18 @@
 package s3wrapper
 
 import (
+	"context"
 	"errors"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/elnoro/foxyshot-indexer/internal/domain"
@@ -95,6 +99,19 @@ func TestBucketClient_ListFiles(t *testing.T) {
 		tt.Equal(domain.File{Key: "second-expected-key", LastModified: time.Unix(200, 0)}, files[1])
 	})
 
+	t.Run("filters out excluded prefixes", func(t *testing.T) {
+		tt := is.New(t)
+
+		bc := NewClient(c, d, l, "expected-bucket", "expected-prefix")
+		bc.Exclude("second-")
+
+		files, err := bc.ListFiles(time.Unix(99, 0), "-key")
+		tt.NoErr(err)
+
+		tt.Equal(1, len(files)) // files with excluded prefix must be skipped
+		tt.Equal("first-expected-key", files[0].Key)
+	})
+
 	t.Run("s3 error", func(t *testing.T) {
 		tt := is.New(t)
 
@@ -112,3 +129,134 @@ func TestBucketClient_ListFiles(t *testing.T) {
 		tt.True(errors.Is(err, expectedErr))
 	})
 }
+
+func TestBucketClient_Upload(t *testing.T) {
+	tt := is.New(t)
+
+	c := &clientMock{
+		PutObjectFunc: func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+			return &s3.PutObjectOutput{}, nil
+		},
+	}
+	bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+	body := strings.NewReader("expected-content")
+	err := bc.Upload(context.Background(), "redacted/expected-key", body, "image/png")
+	tt.NoErr(err)
+
+	tt.Equal(&s3.PutObjectInput{
+		Bucket:      aws.String("expected-bucket"),
+		Key:         aws.String("redacted/expected-key"),
+		Body:        body,
+		ContentType: aws.String("image/png"),
+	}, c.PutObjectCalls()[0].Object)
+}
+
+func TestBucketClient_Read(t *testing.T) {
+	t.Run("returns file content", func(t *testing.T) {
+		tt := is.New(t)
+
+		c := &clientMock{
+			GetObjectFunc: func(_ *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+				return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("expected-content"))}, nil
+			},
+		}
+		bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+		r, err := bc.Read(context.Background(), "expected-key")
+		tt.NoErr(err)
+		defer r.Close()
+
+		content, err := io.ReadAll(r)
+		tt.NoErr(err)
+		tt.Equal("expected-content", string(content))
+		tt.Equal(&s3.GetObjectInput{
+			Bucket: aws.String("expected-bucket"),
+			Key:    aws.String("expected-key"),
+		}, c.GetObjectCalls()[0].Object)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		tt := is.New(t)
+
+		c := &clientMock{
+			GetObjectFunc: func(_ *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+				return nil, awserr.New(s3.ErrCodeNoSuchKey, "expected-message", nil)
+			},
+		}
+		bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+		_, err := bc.Read(context.Background(), "expected-key")
+		tt.True(errors.Is(err, domain.ErrFileNotFound)) // missing keys must be reported as not found
+	})
+}
+
+func TestBucketClient_Stat(t *testing.T) {
+	t.Run("returns the file", func(t *testing.T) {
+		tt := is.New(t)
+
+		modified := time.Unix(100, 0)
+		c := &clientMock{
+			HeadObjectFunc: func(_ *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return &s3.HeadObjectOutput{LastModified: &modified}, nil
+			},
+		}
+		bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+		file, err := bc.Stat(context.Background(), "expected-key")
+		tt.NoErr(err)
+		tt.Equal(file, domain.File{Key: "expected-key", LastModified: modified})
+		tt.Equal(&s3.HeadObjectInput{
+			Bucket: aws.String("expected-bucket"),
+			Key:    aws.String("expected-key"),
+		}, c.HeadObjectCalls()[0].Object)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		tt := is.New(t)
+
+		c := &clientMock{
+			HeadObjectFunc: func(_ *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "")
+			},
+		}
+		bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+		_, err := bc.Stat(context.Background(), "expected-key")
+		tt.True(errors.Is(err, domain.ErrFileNotFound))
+	})
+}
+
+func TestBucketClient_Metadata(t *testing.T) {
+	t.Run("returns user metadata with lower case keys", func(t *testing.T) {
+		tt := is.New(t)
+
+		c := &clientMock{
+			HeadObjectFunc: func(_ *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return &s3.HeadObjectOutput{Metadata: map[string]*string{
+					"App":          aws.String("Slack"),
+					"Window-Title": aws.String("#incidents"),
+				}}, nil
+			},
+		}
+		bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+		meta, err := bc.Metadata(context.Background(), "expected-key")
+		tt.NoErr(err)
+		tt.Equal(meta, map[string]string{"app": "Slack", "window-title": "#incidents"})
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		tt := is.New(t)
+
+		c := &clientMock{
+			HeadObjectFunc: func(_ *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+				return nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "")
+			},
+		}
+		bc := NewClient(c, &downloaderMock{}, slog.Default(), "expected-bucket", "expected-prefix")
+
+		_, err := bc.Metadata(context.Background(), "expected-key")
+		tt.True(errors.Is(err, domain.ErrFileNotFound))
+	})
+}
@@ -0,0 +1,278 @@
+// Package schedule decides when the indexer runs: at intervals, on cron expressions and within allowed time windows.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the time of the next run after the given time.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Interval runs at a fixed interval after the previous run.
+type Interval time.Duration
+
+func (i Interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// Cron runs at the times matching a standard 5-field cron expression in local time:
+// minute, hour, day of month, month and day of week.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// like in cron, when both days are restricted a day matching either of them is a match
+	domAny, dowAny bool
+}
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// ParseCron parses expressions like "*/15 1-5 * * 1-5", fields support lists, ranges and steps.
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w %q, expected 5 fields", ErrInvalidCron, expr)
+	}
+
+	bits := make([]uint64, len(parts))
+	for i, p := range parts {
+		b, err := parseCronField(p, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q, %s", ErrInvalidCron, expr, err)
+		}
+		bits[i] = b
+	}
+
+	// 7 is sunday as well as 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	c := &Cron{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w %q, it never matches", ErrInvalidCron, expr)
+	}
+
+	return c, nil
+}
+
+func parseCronField(s string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		from, to := f.min, f.max
+		if rng != "*" {
+			lo, hi, isRange := strings.Cut(rng, "-")
+			n, err := strconv.Atoi(lo)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", lo)
+			}
+			from, to = n, n
+			if isRange {
+				to, err = strconv.Atoi(hi)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value %q", hi)
+				}
+			} else if hasStep {
+				to = f.max
+			}
+		}
+		if from < f.min || to > f.max || from > to {
+			return 0, fmt.Errorf("%q is out of range %d-%d", item, f.min, f.max)
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first matching minute after the given time, zero time if nothing matches within 5 years.
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<t.Weekday()) != 0
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Window is a daily time range in local time, a window that ends before it starts spans midnight.
+type Window struct {
+	// From and To are minutes since midnight
+	From, To int
+}
+
+var ErrInvalidWindow = errors.New("invalid time window")
+
+// ParseWindow parses windows like "22:00-06:00".
+func ParseWindow(s string) (Window, error) {
+	fromStr, toStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("%w %q, expected HH:MM-HH:MM", ErrInvalidWindow, s)
+	}
+
+	from, err := time.Parse("15:04", strings.TrimSpace(fromStr))
+	if err != nil {
+		return Window{}, fmt.Errorf("%w %q, %s", ErrInvalidWindow, s, err)
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(toStr))
+	if err != nil {
+		return Window{}, fmt.Errorf("%w %q, %s", ErrInvalidWindow, s, err)
+	}
+
+	w := Window{From: from.Hour()*60 + from.Minute(), To: to.Hour()*60 + to.Minute()}
+	if w.From == w.To {
+		return Window{}, fmt.Errorf("%w %q, the window is empty", ErrInvalidWindow, s)
+	}
+
+	return w, nil
+}
+
+func (w Window) contains(minute int) bool {
+	if w.From < w.To {
+		return minute >= w.From && minute < w.To
+	}
+
+	return minute >= w.From || minute < w.To
+}
+
+// Windows are the times when runs are allowed, no windows allow any time.
+type Windows []Window
+
+// Open returns t if runs are allowed at t, otherwise the start of the next window.
+func (ws Windows) Open(t time.Time) time.Time {
+	if len(ws) == 0 {
+		return t
+	}
+
+	if _, ok := ws.containing(t); ok {
+		return t
+	}
+
+	var next time.Time
+	for _, w := range ws {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, w.From, 0, 0, t.Location())
+		if !start.After(t) {
+			start = time.Date(t.Year(), t.Month(), t.Day()+1, 0, w.From, 0, 0, t.Location())
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+
+	return next
+}
+
+// Close returns the end of the window that contains t, windows that overlap are joined.
+// It returns the zero time if there are no windows or t is outside of them.
+func (ws Windows) Close(t time.Time) time.Time {
+	var end time.Time
+	for range ws {
+		w, ok := ws.containing(t)
+		if !ok {
+			break
+		}
+
+		minute := t.Hour()*60 + t.Minute()
+		day := t.Day()
+		if w.From > w.To && minute >= w.From {
+			day++ // the window spans midnight
+		}
+		end = time.Date(t.Year(), t.Month(), day, 0, w.To, 0, 0, t.Location())
+		t = end
+	}
+
+	return end
+}
+
+func (ws Windows) containing(t time.Time) (Window, bool) {
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range ws {
+		if w.contains(minute) {
+			return w, true
+		}
+	}
+
+	return Window{}, false
+}
+
+// Backoff returns the delay before the retry after the given number of consecutive failures.
+// The delay doubles from base up to maxDelay and is randomized between its half and itself,
+// so that replicas failing at the same time do not retry at the same time.
+func Backoff(failures int, base, maxDelay time.Duration) time.Duration {
+	d := base
+	for i := 1; i < failures && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
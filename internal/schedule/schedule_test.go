@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestCron_Next(t *testing.T) {
+	// 2024-01-10 is a wednesday
+	from := time.Date(2024, 1, 10, 10, 7, 30, 0, time.UTC)
+
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 10, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 10, 10, 15, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 1, 11, 2, 0, 0, 0, time.UTC)},
+		{"30 9,18 * * *", time.Date(2024, 1, 10, 18, 30, 0, 0, time.UTC)},
+		{"0 1-5/2 * * *", time.Date(2024, 1, 11, 1, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 5", time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)}, // either the day of month or the day of week
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			tt := is.New(t)
+
+			c, err := ParseCron(tc.expr)
+			tt.NoErr(err)
+			tt.Equal(c.Next(from), tc.expected)
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "0 0 30 2 *"} {
+		t.Run(expr, func(t *testing.T) {
+			tt := is.New(t)
+
+			_, err := ParseCron(expr)
+			tt.True(errors.Is(err, ErrInvalidCron))
+		})
+	}
+}
+
+func TestWindows_Open(t *testing.T) {
+	tt := is.New(t)
+
+	night, err := ParseWindow("22:00-06:00")
+	tt.NoErr(err)
+	lunch, err := ParseWindow("12:00-13:30")
+	tt.NoErr(err)
+	ws := Windows{night, lunch}
+
+	day := func(h, m int) time.Time { return time.Date(2024, 1, 10, h, m, 0, 0, time.UTC) }
+
+	tt.Equal(ws.Open(day(23, 0)), day(23, 0))                              // inside the night window
+	tt.Equal(ws.Open(day(5, 59)), day(5, 59))                              // the night window spans midnight
+	tt.Equal(ws.Open(day(6, 0)), day(12, 0))                               // the end is exclusive
+	tt.Equal(ws.Open(day(13, 30)), day(22, 0))                             // the next window of the day
+	tt.Equal(ws.Open(day(9, 0)), day(12, 0))                               // the closest window
+	tt.Equal(Windows{lunch}.Open(day(14, 0)), day(12, 0).AddDate(0, 0, 1)) // the window of the next day
+	tt.Equal(Windows(nil).Open(day(14, 0)), day(14, 0))                    // no windows allow any time
+
+	tt.Equal(ws.Close(day(23, 0)), day(6, 0).AddDate(0, 0, 1))                              // the night window ends the next day
+	tt.Equal(ws.Close(day(5, 59)), day(6, 0))                                               // after midnight it ends the same day
+	tt.Equal(ws.Close(day(12, 0)), day(13, 30))                                             // the end of the lunch window
+	tt.Equal(ws.Close(day(9, 0)), time.Time{})                                              // outside of the windows
+	tt.Equal(Windows(nil).Close(day(9, 0)), time.Time{})                                    // no windows never close
+	tt.Equal(Windows{lunch, {From: 13*60 + 30, To: 14 * 60}}.Close(day(12, 0)), day(14, 0)) // adjacent windows are joined
+
+	for _, s := range []string{"", "22:00", "25:00-06:00", "10:00-10:00"} {
+		_, err := ParseWindow(s)
+		tt.True(errors.Is(err, ErrInvalidWindow))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tt := is.New(t)
+
+	for i := 0; i < 100; i++ {
+		d := Backoff(1, 10*time.Second, time.Minute)
+		tt.True(d >= 5*time.Second && d <= 10*time.Second)
+
+		d = Backoff(3, 10*time.Second, time.Minute)
+		tt.True(d >= 20*time.Second && d <= 40*time.Second)
+
+		d = Backoff(30, 10*time.Second, time.Minute) // capped
+		tt.True(d >= 30*time.Second && d <= time.Minute)
+	}
+}
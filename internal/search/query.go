@@ -0,0 +1,71 @@
+// Package search parses search strings with filters,
+// e.g. "timeout has:url entity:ip=10.0.0.1 tag:monitoring meta.app:Slack".
+package search
+
+import (
+	"strings"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// Query is a parsed search string.
+type Query struct {
+	// Text is the part of the search string that is matched against descriptions.
+	Text string
+	// Has lists entity kinds that an image must contain.
+	Has []string
+	// Entities lists exact entities that an image must contain.
+	Entities []domain.Entity
+	// Tags lists tags that an image must have.
+	Tags []string
+	// Meta lists metadata values that an image must have.
+	Meta []MetaFilter
+}
+
+// MetaFilter matches images whose metadata has the key with the value, ignoring case of the value.
+type MetaFilter struct {
+	Key   string
+	Value string
+}
+
+// metaPrefix starts the names of metadata filters, e.g. meta.app:Slack.
+const metaPrefix = "meta."
+
+// Parse extracts known filters from the search string, everything else is treated as text.
+func Parse(s string) Query {
+	var q Query
+	var text []string
+
+	for _, word := range strings.Fields(s) {
+		name, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			text = append(text, word)
+			continue
+		}
+
+		name = strings.ToLower(name)
+		if key, ok := strings.CutPrefix(name, metaPrefix); ok && key != "" {
+			q.Meta = append(q.Meta, MetaFilter{Key: key, Value: value})
+			continue
+		}
+
+		switch name {
+		case "has":
+			q.Has = append(q.Has, strings.ToLower(value))
+		case "entity":
+			kind, v, ok := strings.Cut(value, "=")
+			if !ok || v == "" {
+				text = append(text, word)
+				continue
+			}
+			q.Entities = append(q.Entities, domain.Entity{Kind: strings.ToLower(kind), Value: v})
+		case "tag":
+			q.Tags = append(q.Tags, strings.ToLower(value))
+		default:
+			text = append(text, word)
+		}
+	}
+	q.Text = strings.Join(text, " ")
+
+	return q
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		want Query
+	}{
+		{
+			name: "text only",
+			in:   "connection  refused",
+			want: Query{Text: "connection refused"},
+		},
+		{
+			name: "entity filters",
+			in:   "timeout has:URL entity:ip=10.0.0.1 HAS:email",
+			want: Query{
+				Text:     "timeout",
+				Has:      []string{"url", "email"},
+				Entities: []domain.Entity{{Kind: domain.EntityIP, Value: "10.0.0.1"}},
+			},
+		},
+		{
+			name: "tag filters",
+			in:   "dashboard tag:Monitoring tag:prod",
+			want: Query{Text: "dashboard", Tags: []string{"monitoring", "prod"}},
+		},
+		{
+			name: "metadata filters",
+			in:   "deploy meta.App:Slack meta.window_title:#incidents",
+			want: Query{Text: "deploy", Meta: []MetaFilter{
+				{Key: "app", Value: "Slack"},
+				{Key: "window_title", Value: "#incidents"},
+			}},
+		},
+		{
+			name: "unknown and incomplete filters are text",
+			in:   "panic: has: entity:ip tag: http://localhost meta.:value meta.app:",
+			want: Query{Text: "panic: has: entity:ip tag: http://localhost meta.:value meta.app:"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			tt.Equal(Parse(tc.in), tc.want)
+		})
+	}
+}
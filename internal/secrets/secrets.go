@@ -0,0 +1,223 @@
+// Package secrets detects credentials and tokens in OCR text.
+//
+// Detection is based on a ruleset of known token formats. Long random-looking
+// strings that match no known format are caught by an entropy check.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// MinEntropy is the minimum Shannon entropy of the secret in bits per character, 0 disables the check
+	MinEntropy float64
+}
+
+// Rules are checked in order, a match of an earlier rule hides overlapping matches of later rules.
+// If a pattern has a capturing group, only the group is treated as the secret.
+var Rules = []Rule{
+	{Name: "private_key", Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{Name: "aws_access_key_id", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{
+		Name:    "aws_secret_access_key",
+		Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+	},
+	{Name: "github_token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{Name: "slack_token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{Name: "google_api_key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{Name: "stripe_key", Pattern: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{24,}\b`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`)},
+	{
+		Name:    "password",
+		Pattern: regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api[_\-]?key)\s*[:=]\s*["']?([^\s"']{6,})`),
+	},
+	{Name: "high_entropy_string", Pattern: regexp.MustCompile(`[A-Za-z0-9+/_\-]{20,}={0,2}`), MinEntropy: 3.8},
+}
+
+type match struct {
+	rule       string
+	start, end int
+}
+
+type tracker interface {
+	OnFinding(rule string)
+}
+
+type repository interface {
+	ImageFindings(ctx context.Context, fileID string) ([]domain.Finding, error)
+}
+
+// Detector is an indexing stage that stores findings of the image
+// and optionally masks the secrets in its description.
+type Detector struct {
+	mask    bool
+	repo    repository
+	tracker tracker
+}
+
+func NewDetector(mask bool, repo repository, tracker tracker) *Detector {
+	return &Detector{mask: mask, repo: repo, tracker: tracker}
+}
+
+// Process tracks only findings that the image did not have yet, so that reindexing does not count them again.
+func (d *Detector) Process(ctx context.Context, _ string, img *domain.Image) error {
+	stored, err := d.repo.ImageFindings(ctx, img.FileID)
+	if err != nil {
+		return fmt.Errorf("reading findings of %s, %w", img.FileID, err)
+	}
+	known := make(map[domain.Finding]int, len(stored))
+	for _, f := range stored {
+		known[f]++
+	}
+
+	matches := scan(img.Description)
+
+	img.Findings = make([]domain.Finding, 0, len(matches))
+	for _, m := range matches {
+		f := domain.Finding{Rule: m.rule, Preview: preview(img.Description[m.start:m.end])}
+		img.Findings = append(img.Findings, f)
+
+		if known[f] > 0 {
+			known[f]--
+			continue
+		}
+		d.tracker.OnFinding(m.rule)
+	}
+
+	if d.mask {
+		img.Description = mask(img.Description, matches)
+	}
+
+	return nil
+}
+
+// Scan returns findings in the text in the order they appear.
+func Scan(text string) []domain.Finding {
+	matches := scan(text)
+
+	findings := make([]domain.Finding, 0, len(matches))
+	for _, m := range matches {
+		findings = append(findings, domain.Finding{Rule: m.rule, Preview: preview(text[m.start:m.end])})
+	}
+
+	return findings
+}
+
+// FindAll returns start and end offsets of the secrets matched by the rule in the text.
+func (r Rule) FindAll(text string) [][2]int {
+	var found [][2]int
+
+	for _, loc := range r.Pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+
+		if r.MinEntropy > 0 && !looksRandom(text[start:end], r.MinEntropy) {
+			continue
+		}
+
+		found = append(found, [2]int{start, end})
+	}
+
+	return found
+}
+
+func scan(text string) []match {
+	var matches []match
+
+	for _, rule := range Rules {
+		for _, loc := range rule.FindAll(text) {
+			m := match{rule: rule.Name, start: loc[0], end: loc[1]}
+			if overlaps(matches, m) {
+				continue
+			}
+
+			matches = append(matches, m)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	return matches
+}
+
+// mask replaces secrets in the text with a placeholder that names the rule.
+func mask(text string, matches []match) string {
+	var sb strings.Builder
+
+	pos := 0
+	for _, m := range matches {
+		sb.WriteString(text[pos:m.start])
+		sb.WriteString("[REDACTED:" + m.rule + "]")
+		pos = m.end
+	}
+	sb.WriteString(text[pos:])
+
+	return sb.String()
+}
+
+func overlaps(matches []match, m match) bool {
+	for _, other := range matches {
+		if m.start < other.end && other.start < m.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// preview keeps a few leading characters of long secrets, so that they can be recognised.
+func preview(secret string) string {
+	const shown = 4
+	if len(secret) < 16 {
+		return strings.Repeat("*", len(secret))
+	}
+
+	return secret[:shown] + strings.Repeat("*", len(secret)-shown)
+}
+
+// looksRandom checks that the string mixes letters and digits and its entropy is high enough.
+func looksRandom(s string, minEntropy float64) bool {
+	var hasLetter, hasDigit bool
+	for _, r := range s {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+	}
+
+	return hasLetter && hasDigit && Entropy(s) >= minEntropy
+}
+
+// Entropy returns the Shannon entropy of the string in bits per character.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type feedTokenRow struct {
+	ID         int        `db:"id"`
+	Name       string     `db:"name"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+func (r feedTokenRow) toDomain() domain.FeedToken {
+	return domain.FeedToken{ID: r.ID, Name: r.Name, CreatedAt: r.CreatedAt, LastUsedAt: r.LastUsedAt}
+}
+
+func (i *ImageRepo) ListFeedTokens(ctx context.Context) ([]domain.FeedToken, error) {
+	var rows []feedTokenRow
+	err := i.db.SelectContext(ctx, &rows, `SELECT id, name, created_at, last_used_at FROM feed_tokens ORDER BY id`)
+	if err != nil {
+		return []domain.FeedToken{}, fmt.Errorf("listing feed tokens, %w", err)
+	}
+
+	tokens := make([]domain.FeedToken, 0, len(rows))
+	for _, r := range rows {
+		tokens = append(tokens, r.toDomain())
+	}
+
+	return tokens, nil
+}
+
+// CreateFeedToken stores the hash of a new token, the returned token has no value.
+func (i *ImageRepo) CreateFeedToken(ctx context.Context, name, hash string) (domain.FeedToken, error) {
+	row := feedTokenRow{Name: name, CreatedAt: time.Now().UTC()}
+	res, err := i.db.ExecContext(ctx,
+		`INSERT INTO feed_tokens (name, token_hash, created_at) VALUES (?, ?, ?)`,
+		row.Name, hash, row.CreatedAt,
+	)
+	if err != nil {
+		return domain.FeedToken{}, fmt.Errorf("creating feed token for %s, %w", name, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return domain.FeedToken{}, fmt.Errorf("creating feed token for %s, %w", name, err)
+	}
+	row.ID = int(id)
+
+	return row.toDomain(), nil
+}
+
+func (i *ImageRepo) DeleteFeedToken(ctx context.Context, id int) error {
+	res, err := i.db.ExecContext(ctx, `DELETE FROM feed_tokens WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting feed token %d, %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting feed token %d, %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("feed token %d not found, %w", id, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// UseFeedToken records that the token with the hash was used, ErrRecordNotFound means it is not valid.
+func (i *ImageRepo) UseFeedToken(ctx context.Context, hash string) error {
+	res, err := i.db.ExecContext(ctx,
+		`UPDATE feed_tokens SET last_used_at = ? WHERE token_hash = ?`, time.Now().UTC(), hash,
+	)
+	if err != nil {
+		return fmt.Errorf("using feed token, %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("using feed token, %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("feed token not found, %w", ErrRecordNotFound)
+	}
+
+	return nil
+}
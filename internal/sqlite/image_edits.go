@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// Patch updates user editable fields of the image and records changed values in its edit history.
+func (i *ImageRepo) Patch(ctx context.Context, fileID string, patch domain.ImagePatch) (domain.Image, error) {
+	// transactions take the write lock when they begin, there is no need for FOR UPDATE
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return domain.Image{}, fmt.Errorf("starting transaction for editing image id=%s, %w", fileID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	img := domain.Image{}
+	err = tx.GetContext(ctx, &img, `SELECT `+imageColumns+` FROM image_descriptions WHERE file_id = ?`, fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return domain.Image{}, fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+		default:
+			return domain.Image{}, fmt.Errorf("loading image id=%s for editing, %w", fileID, err)
+		}
+	}
+
+	changes := []struct {
+		field    string
+		value    *string
+		oldValue *string
+	}{
+		{domain.FieldAnnotations, patch.Annotations, &img.Annotations},
+		{domain.FieldCorrectedDescription, patch.CorrectedDescription, &img.CorrectedDescription},
+	}
+	for _, c := range changes {
+		if c.value == nil || *c.value == *c.oldValue {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO image_edits (file_id, field, old_value, new_value, edited_at) VALUES (?, ?, ?, ?, ?)`,
+			fileID, c.field, *c.oldValue, *c.value, time.Now().UTC(),
+		)
+		if err != nil {
+			return domain.Image{}, fmt.Errorf("recording edit of %s of image id=%s, %w", c.field, fileID, err)
+		}
+		*c.oldValue = *c.value
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE image_descriptions SET annotations = ?, corrected_description = ? WHERE file_id = ?`,
+		img.Annotations, img.CorrectedDescription, fileID,
+	)
+	if err != nil {
+		return domain.Image{}, fmt.Errorf("editing image id=%s, %w", fileID, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain.Image{}, fmt.Errorf("committing edit of image id=%s, %w", fileID, err)
+	}
+
+	return img, nil
+}
+
+// ImageEdits returns the edit history of the image, the latest edit first.
+func (i *ImageRepo) ImageEdits(ctx context.Context, fileID string) ([]domain.ImageEdit, error) {
+	edits := make([]domain.ImageEdit, 0)
+
+	query := `SELECT field, old_value, new_value, edited_at
+		FROM image_edits WHERE file_id = ?
+		ORDER BY edited_at desc, id desc`
+	err := i.db.SelectContext(ctx, &edits, query, fileID)
+	if err != nil {
+		return edits, fmt.Errorf("listing edits of image id=%s, %w", fileID, err)
+	}
+
+	return edits, nil
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/search"
+	"github.com/jmoiron/sqlx"
+)
+
+// ListEntities returns distinct entities of the kind found across all images, the most frequent first.
+// Empty kind means all kinds.
+func (i *ImageRepo) ListEntities(ctx context.Context, kind string, limit int) ([]domain.EntityCount, error) {
+	entities := make([]domain.EntityCount, 0)
+
+	query := `SELECT kind, value, count(*) AS count
+		FROM image_entities
+		WHERE (kind = ? OR ? = '')
+		GROUP BY kind, value
+		ORDER BY count desc, kind, value LIMIT ?`
+	err := i.db.SelectContext(ctx, &entities, query, kind, kind, limit)
+	if err != nil {
+		return entities, fmt.Errorf("listing entities of kind %s, %w", kind, err)
+	}
+
+	return entities, nil
+}
+
+func replaceEntities(ctx context.Context, tx *sqlx.Tx, fileID string, entities []domain.Entity) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM image_entities WHERE file_id = ?`, fileID)
+	if err != nil {
+		return fmt.Errorf("deleting old entities, %w", err)
+	}
+
+	for _, e := range entities {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO image_entities (file_id, kind, value) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+			fileID, e.Kind, e.Value,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting entity %s=%s, %w", e.Kind, e.Value, err)
+		}
+	}
+
+	return nil
+}
+
+// queryFilters returns conditions for the filters of the query to be appended to a WHERE clause
+// of a query on image_descriptions. Values of the filters are appended to args.
+func queryFilters(q search.Query, args []any) (string, []any) {
+	var sb strings.Builder
+
+	for _, kind := range q.Has {
+		args = append(args, kind)
+		sb.WriteString(` AND EXISTS (SELECT 1 FROM image_entities e
+			WHERE e.file_id = image_descriptions.file_id AND e.kind = ?)`)
+	}
+
+	for _, e := range q.Entities {
+		args = append(args, e.Kind, e.Value)
+		sb.WriteString(` AND EXISTS (SELECT 1 FROM image_entities e
+			WHERE e.file_id = image_descriptions.file_id AND e.kind = ? AND lower(e.value) = lower(?))`)
+	}
+
+	for _, tag := range q.Tags {
+		args = append(args, tag)
+		sb.WriteString(` AND EXISTS (SELECT 1 FROM image_tags it JOIN tags t ON t.id = it.tag_id
+			WHERE it.file_id = image_descriptions.file_id AND t.name = ?)`)
+	}
+
+	for _, m := range q.Meta {
+		args = append(args, m.Key, m.Value)
+		sb.WriteString(` AND EXISTS (SELECT 1 FROM json_each(image_descriptions.metadata) m
+			WHERE m.key = ? AND lower(m.value) = lower(?))`)
+	}
+
+	return sb.String(), args
+}
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type eventRow struct {
+	Seq     int64  `db:"seq"`
+	Payload string `db:"payload"`
+}
+
+// AppendEvent stores the event in the event log and wakes up Wait.
+func (i *ImageRepo) AppendEvent(ctx context.Context, e domain.Event) (int64, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("encoding event, %w", err)
+	}
+
+	res, err := i.db.ExecContext(ctx,
+		`INSERT INTO image_events (type, file_id, payload, created_at) VALUES (?, ?, ?, ?)`,
+		e.Type, e.FileID, string(payload), time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("inserting event %s, %w", e.Type, err)
+	}
+
+	seq, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("inserting event %s, %w", e.Type, err)
+	}
+
+	// a pending notification is enough, the waiter reads all events after the last one it has seen
+	select {
+	case i.notify <- struct{}{}:
+	default:
+	}
+
+	return seq, nil
+}
+
+// EventsAfter returns up to limit events with sequence numbers greater than after, in order.
+func (i *ImageRepo) EventsAfter(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+	var rows []eventRow
+	err := i.db.SelectContext(ctx, &rows,
+		`SELECT seq, payload FROM image_events WHERE seq > ? ORDER BY seq LIMIT ?`,
+		after, limit,
+	)
+	if err != nil {
+		return []domain.StreamEvent{}, fmt.Errorf("listing events after %d, %w", after, err)
+	}
+
+	events := make([]domain.StreamEvent, 0, len(rows))
+	for _, r := range rows {
+		se := domain.StreamEvent{Seq: r.Seq}
+		err = json.Unmarshal([]byte(r.Payload), &se.Event)
+		if err != nil {
+			return []domain.StreamEvent{}, fmt.Errorf("decoding event %d, %w", r.Seq, err)
+		}
+		events = append(events, se)
+	}
+
+	return events, nil
+}
+
+// LastEventSeq returns the sequence number of the latest event, 0 if there are none.
+// The sequence is read from sqlite_sequence, so that it keeps growing after old events are deleted.
+func (i *ImageRepo) LastEventSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := i.db.GetContext(ctx, &seq,
+		`SELECT coalesce((SELECT seq FROM sqlite_sequence WHERE name = 'image_events'), 0)`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("getting last event, %w", err)
+	}
+
+	return seq, nil
+}
+
+// DeleteEventsBefore removes events that are too old to be resumed from.
+func (i *ImageRepo) DeleteEventsBefore(ctx context.Context, before time.Time) error {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM image_events WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return fmt.Errorf("deleting events before %s, %w", before, err)
+	}
+
+	return nil
+}
+
+// Wait blocks until an event is appended. Events are appended by the same process, there is no need to listen
+// on the database. Wait has a single caller, the event broker.
+func (i *ImageRepo) Wait(ctx context.Context) error {
+	select {
+	case <-i.notify:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// ListFindings returns images with findings of the rule, the latest first. Empty rule means any rule.
+func (i *ImageRepo) ListFindings(ctx context.Context, rule string, page, perPage int) ([]domain.FlaggedImage, error) {
+	limit := perPage
+	offset := (page - 1) * perPage
+
+	var rows []struct {
+		domain.Finding
+		FileID       string    `db:"file_id"`
+		LastModified time.Time `db:"last_modified"`
+	}
+
+	query := `WITH flagged AS (
+			SELECT d.file_id, d.last_modified
+			FROM image_descriptions d
+			WHERE EXISTS (SELECT 1 FROM image_findings f WHERE f.file_id = d.file_id AND (f.rule = ? OR ? = ''))
+			ORDER BY d.last_modified desc, d.file_id LIMIT ? OFFSET ?
+		)
+		SELECT fl.file_id, d.last_modified, f.rule, f.preview
+		FROM flagged fl
+		    JOIN image_descriptions d ON d.file_id = fl.file_id
+		    JOIN image_findings f ON f.file_id = fl.file_id
+		ORDER BY d.last_modified desc, fl.file_id, f.id`
+	err := i.db.SelectContext(ctx, &rows, query, rule, rule, limit, offset)
+	if err != nil {
+		return []domain.FlaggedImage{}, fmt.Errorf("listing findings of rule %s, %w", rule, err)
+	}
+
+	images := make([]domain.FlaggedImage, 0)
+	for _, row := range rows {
+		if len(images) == 0 || images[len(images)-1].FileID != row.FileID {
+			images = append(images, domain.FlaggedImage{FileID: row.FileID, LastModified: row.LastModified})
+		}
+		last := &images[len(images)-1]
+		last.Findings = append(last.Findings, row.Finding)
+	}
+
+	return images, nil
+}
+
+// ImageFindings returns findings of the image in the order they were found.
+func (i *ImageRepo) ImageFindings(ctx context.Context, fileID string) ([]domain.Finding, error) {
+	findings := make([]domain.Finding, 0)
+
+	query := `SELECT rule, preview FROM image_findings WHERE file_id = ? ORDER BY id`
+	err := i.db.SelectContext(ctx, &findings, query, fileID)
+	if err != nil {
+		return findings, fmt.Errorf("listing findings of image id=%s, %w", fileID, err)
+	}
+
+	return findings, nil
+}
+
+func replaceFindings(ctx context.Context, tx *sqlx.Tx, fileID string, findings []domain.Finding) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM image_findings WHERE file_id = ?`, fileID)
+	if err != nil {
+		return fmt.Errorf("deleting old findings, %w", err)
+	}
+
+	for _, f := range findings {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO image_findings (file_id, rule, preview) VALUES (?, ?, ?)`,
+			fileID, f.Rule, f.Preview,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting finding of rule %s, %w", f.Rule, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,374 @@
+// Package sqlite stores images in a single SQLite file, for deployments that do not need Postgres.
+// Search uses an FTS5 index, it behaves like the full text search of the Postgres repository.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"time"
+
+	dbadapter "github.com/elnoro/foxyshot-indexer/internal/db"
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/search"
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/jmoiron/sqlx"
+)
+
+// DriverName is the name of the database/sql driver.
+const DriverName = "sqlite"
+
+// ErrRecordNotFound is shared with the Postgres repository, so that callers do not depend on the backend.
+var ErrRecordNotFound = dbadapter.ErrRecordNotFound
+
+func init() {
+	sqlx.BindDriver(DriverName, sqlx.QUESTION)
+	gosqlite.MustRegisterDeterministicScalarFunction("hamming_distance", 2, hammingDistance)
+}
+
+// imageColumns are the columns of image_descriptions that are selected into domain.Image.
+const imageColumns = `file_id, description, last_modified, coalesce(phash, 0) AS phash,
+	annotations, corrected_description, source_url, metadata`
+
+type ImageRepo struct {
+	db *sqlx.DB
+	// notify wakes up Wait, all writers of a SQLite database are in the same process
+	notify chan struct{}
+}
+
+// Open opens the database file and creates it if needed. Foreign keys are enforced
+// and transactions take the write lock when they begin, so that concurrent writers wait for each other.
+func Open(path string) (*sqlx.DB, error) {
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sqlx.Connect(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite database %s, %w", path, err)
+	}
+
+	return db, nil
+}
+
+func NewImageRepo(db *sqlx.DB) *ImageRepo {
+	return &ImageRepo{db: db, notify: make(chan struct{}, 1)}
+}
+
+func (i *ImageRepo) Upsert(ctx context.Context, image domain.Image) error {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction for image id=%s, %w", image.FileID, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// times are stored as text in UTC, so that they are ordered correctly
+	stored := image
+	stored.LastModified = image.LastModified.UTC()
+	// zero hash means that the hash could not be calculated
+	query := `INSERT INTO image_descriptions (file_id, description, last_modified, phash, metadata)
+			VALUES (:file_id, :description, :last_modified, nullif(:phash, 0), :metadata)
+			ON CONFLICT (file_id) DO UPDATE SET description = excluded.description,
+			    last_modified = excluded.last_modified, phash = excluded.phash, metadata = excluded.metadata`
+	_, err = tx.NamedExecContext(ctx, query, stored)
+	if err != nil {
+		return fmt.Errorf("inserting image id=%s, %w", image.FileID, err)
+	}
+
+	if image.Entities != nil {
+		err = replaceEntities(ctx, tx, image.FileID, image.Entities)
+		if err != nil {
+			return fmt.Errorf("storing entities of image id=%s, %w", image.FileID, err)
+		}
+	}
+
+	if image.Findings != nil {
+		err = replaceFindings(ctx, tx, image.FileID, image.Findings)
+		if err != nil {
+			return fmt.Errorf("storing findings of image id=%s, %w", image.FileID, err)
+		}
+	}
+
+	if image.Tags != nil {
+		err = replaceRuleTags(ctx, tx, image.FileID, image.Tags)
+		if err != nil {
+			return fmt.Errorf("storing tags of image id=%s, %w", image.FileID, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing image id=%s, %w", image.FileID, err)
+	}
+
+	return nil
+}
+
+func (i *ImageRepo) FindByDescription(
+	ctx context.Context,
+	searchString string,
+	page,
+	perPage int,
+) ([]domain.Image, error) {
+	if perPage <= 0 {
+		return []domain.Image{}, nil
+	}
+
+	q := search.Parse(searchString)
+
+	images, err := i.fullTextSearch(ctx, q, page, perPage)
+	if err != nil {
+		return []domain.Image{}, fmt.Errorf("full text search err, %w", err)
+	}
+
+	if len(images) > 0 {
+		return images, nil
+	}
+
+	images, err = i.patternMatching(ctx, q, page, perPage)
+	if err != nil {
+		return []domain.Image{}, fmt.Errorf("pattern matching err, %w", err)
+	}
+
+	return images, nil
+}
+
+func (i *ImageRepo) fullTextSearch(
+	ctx context.Context,
+	q search.Query,
+	page,
+	perPage int,
+) ([]domain.Image, error) {
+	where, args := "true", []any{}
+	if q.Text != "" {
+		where, args = `id IN (SELECT rowid FROM image_search WHERE image_search MATCH ?)`, []any{matchAll(q.Text)}
+	}
+
+	return i.searchImages(ctx, where, args, q, page, perPage)
+}
+
+func (i *ImageRepo) patternMatching(
+	ctx context.Context,
+	q search.Query,
+	page,
+	perPage int,
+) ([]domain.Image, error) {
+	pattern := "%" + q.Text + "%"
+	where := `(description LIKE ? OR corrected_description LIKE ? OR annotations LIKE ? OR source_url LIKE ?)`
+
+	return i.searchImages(ctx, where, []any{pattern, pattern, pattern, pattern}, q, page, perPage)
+}
+
+// searchImages returns a page of images matching the condition and the filters of the query, the latest first.
+func (i *ImageRepo) searchImages(
+	ctx context.Context,
+	where string,
+	args []any,
+	q search.Query,
+	page,
+	perPage int,
+) ([]domain.Image, error) {
+	limit := perPage
+	offset := (page - 1) * perPage
+
+	images := make([]domain.Image, 0)
+
+	filters, args := queryFilters(q, args)
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`SELECT %s
+		FROM image_descriptions
+		WHERE %s%s
+		ORDER BY last_modified desc LIMIT ? OFFSET ?`, imageColumns, where, filters)
+	err := i.db.SelectContext(ctx, &images, query, args...)
+	if err != nil {
+		return images, fmt.Errorf("searching for images with query %s, %w", query, err)
+	}
+
+	return images, nil
+}
+
+// SetSourceURL records the url the image was fetched from, indexing the image again keeps it.
+func (i *ImageRepo) SetSourceURL(ctx context.Context, fileID, sourceURL string) error {
+	res, err := i.db.ExecContext(ctx, `UPDATE image_descriptions SET source_url = ? WHERE file_id = ?`, sourceURL, fileID)
+	if err != nil {
+		return fmt.Errorf("setting source url of image id=%s, %w", fileID, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking source url of image id=%s, %w", fileID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+func (i *ImageRepo) Delete(ctx context.Context, fileID string) error {
+	query := `DELETE FROM image_descriptions where file_id = ?`
+	_, err := i.db.ExecContext(ctx, query, fileID)
+	if err != nil {
+		return fmt.Errorf("deleting image id=%s, %w", fileID, err)
+	}
+
+	return nil
+}
+
+// FindSimilar returns images whose perceptual hash is within maxDistance bits of the hash of the given image.
+func (i *ImageRepo) FindSimilar(
+	ctx context.Context,
+	fileID string,
+	maxDistance,
+	limit int,
+) ([]domain.SimilarImage, error) {
+	images := make([]domain.SimilarImage, 0)
+
+	query := `SELECT d.file_id, d.description, d.last_modified, d.phash, d.annotations, d.corrected_description,
+       		d.source_url, d.metadata,
+       		hamming_distance(d.phash, s.phash) AS distance
+		FROM image_descriptions d
+		    JOIN image_descriptions s ON s.file_id = ? AND s.file_id <> d.file_id
+		WHERE d.phash IS NOT NULL AND s.phash IS NOT NULL AND hamming_distance(d.phash, s.phash) <= ?
+		ORDER BY distance, d.last_modified desc LIMIT ?`
+	err := i.db.SelectContext(ctx, &images, query, fileID, maxDistance, limit)
+	if err != nil {
+		return images, fmt.Errorf("searching for images similar to %s, %w", fileID, err)
+	}
+
+	return images, nil
+}
+
+// FindCandidates returns images that are visually close to the hash or whose text contains any of the terms,
+// the text is the corrected description if it is set.
+// Candidates are ordered by visual distance, so that the closest images are not cut off by the limit.
+func (i *ImageRepo) FindCandidates(
+	ctx context.Context,
+	hash int64,
+	terms []string,
+	maxDistance,
+	limit int,
+) ([]domain.Image, error) {
+	images := make([]domain.Image, 0)
+
+	where, args := `false`, []any{hash, hash, maxDistance}
+	if len(terms) > 0 {
+		where = `(corrected_description <> '' AND id IN (SELECT rowid FROM image_search WHERE image_search MATCH ?))
+			OR (corrected_description = '' AND id IN (SELECT rowid FROM image_search WHERE image_search MATCH ?))`
+		args = append(args, "corrected_description : ("+matchAny(terms)+")", "description : ("+matchAny(terms)+")")
+	}
+	args = append(args, hash, limit)
+
+	query := `SELECT ` + imageColumns + `
+		FROM image_descriptions
+		WHERE (? <> 0 AND phash IS NOT NULL AND hamming_distance(phash, ?) <= ?)
+			OR ` + where + `
+		ORDER BY coalesce(hamming_distance(phash, ?), 64), last_modified desc LIMIT ?`
+	err := i.db.SelectContext(ctx, &images, query, args...)
+	if err != nil {
+		return images, fmt.Errorf("searching for candidates with query %s, %w", query, err)
+	}
+
+	return images, nil
+}
+
+// ListImages returns images ordered by file id, starting after the given id, for iterating over all images.
+func (i *ImageRepo) ListImages(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+	images := make([]domain.Image, 0)
+
+	query := `SELECT ` + imageColumns + `
+		FROM image_descriptions
+		WHERE file_id > ?
+		ORDER BY file_id LIMIT ?`
+	err := i.db.SelectContext(ctx, &images, query, after, limit)
+	if err != nil {
+		return images, fmt.Errorf("listing images after %s, %w", after, err)
+	}
+
+	return images, nil
+}
+
+// RecentImages returns the latest images by modification time.
+func (i *ImageRepo) RecentImages(ctx context.Context, limit int) ([]domain.Image, error) {
+	images := make([]domain.Image, 0)
+
+	query := `SELECT ` + imageColumns + ` FROM image_descriptions ORDER BY last_modified DESC, file_id LIMIT ?`
+	err := i.db.SelectContext(ctx, &images, query, limit)
+	if err != nil {
+		return images, fmt.Errorf("listing recent images, %w", err)
+	}
+
+	return images, nil
+}
+
+func (i *ImageRepo) Get(ctx context.Context, fileID string) (domain.Image, error) {
+	query := `SELECT ` + imageColumns + ` FROM image_descriptions where file_id = ?`
+	img := &domain.Image{}
+	err := i.db.GetContext(ctx, img, query, fileID)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return domain.Image{}, fmt.Errorf("image with file id %s not found, %w", fileID, ErrRecordNotFound)
+		default:
+			return domain.Image{}, fmt.Errorf("looking for image id=%s, %w", fileID, err)
+		}
+	}
+
+	return *img, nil
+}
+
+func (i *ImageRepo) GetLastModified(ctx context.Context) (time.Time, error) {
+	query := `SELECT file_id, description, last_modified FROM image_descriptions ORDER BY last_modified DESC LIMIT 1`
+	lastImg := &domain.Image{}
+	err := i.db.GetContext(ctx, lastImg, query)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return time.Unix(0, 0).UTC(), nil
+		default:
+			return time.Time{}, fmt.Errorf("looking for last modified, %w", err)
+		}
+	}
+
+	return lastImg.LastModified, nil
+}
+
+// IndexQueueDepth is always zero, a SQLite database is used by a single worker that does not need the queue.
+func (i *ImageRepo) IndexQueueDepth(context.Context) (pending, failed int, err error) {
+	return 0, 0, nil
+}
+
+// matchAll returns an FTS5 query that matches documents with all words of the text.
+// Words are quoted, so that the syntax of FTS5 queries is not interpreted.
+func matchAll(text string) string {
+	return strings.Join(quoteWords(strings.Fields(text)), " ")
+}
+
+// matchAny returns an FTS5 query that matches documents with any of the terms.
+func matchAny(terms []string) string {
+	return strings.Join(quoteWords(terms), " OR ")
+}
+
+func quoteWords(words []string) []string {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		quoted = append(quoted, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
+	}
+
+	return quoted
+}
+
+// hammingDistance is the number of different bits of two hashes, SQLite has no bit_count.
+func hammingDistance(_ *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	a, okA := args[0].(int64)
+	b, okB := args[1].(int64)
+	if !okA || !okB {
+		return nil, nil
+	}
+
+	return int64(bits.OnesCount64(uint64(a ^ b))), nil
+}
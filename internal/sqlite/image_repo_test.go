@@ -0,0 +1,323 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/elnoro/foxyshot-indexer/internal/migrate"
+	"github.com/elnoro/foxyshot-indexer/internal/repotest"
+	sqlitemigrations "github.com/elnoro/foxyshot-indexer/migrations/sqlite"
+	"github.com/jmoiron/sqlx"
+	"github.com/matryer/is"
+)
+
+func TestImageRepo_Suite(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repo {
+		return NewImageRepo(newTestDB(t))
+	})
+}
+
+func TestImageRepo_FindSimilar(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+	images := []domain.Image{
+		{FileID: "similar-source", PHash: 0b1111},
+		{FileID: "similar-close", PHash: 0b0111},
+		{FileID: "similar-far", PHash: -1},
+		{FileID: "similar-no-hash"},
+	}
+	for _, img := range images {
+		tt.NoErr(repo.Upsert(ctx, img))
+	}
+
+	similar, err := repo.FindSimilar(ctx, "similar-source", 5, 10)
+	tt.NoErr(err)
+	tt.Equal(1, len(similar))
+	tt.Equal("similar-close", similar[0].FileID)
+	tt.Equal(1, similar[0].Distance)
+
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "candidate-text", Description: "kubernetes OOMKilled"}))
+
+	candidates, err := repo.FindCandidates(ctx, 0b1110, []string{"oomkilled", "pods"}, 2, 10)
+	tt.NoErr(err)
+	tt.Equal(3, len(candidates))
+	tt.Equal("similar-source", candidates[0].FileID) // visually close images go first
+	tt.Equal("similar-close", candidates[1].FileID)
+	tt.Equal("candidate-text", candidates[2].FileID)
+
+	corrected := "kubernetes pending"
+	_, err = repo.Patch(ctx, "candidate-text", domain.ImagePatch{CorrectedDescription: &corrected})
+	tt.NoErr(err)
+
+	// the corrected text replaces the ocr output
+	candidates, err = repo.FindCandidates(ctx, 0, []string{"oomkilled"}, 2, 10)
+	tt.NoErr(err)
+	tt.Equal(0, len(candidates))
+	candidates, err = repo.FindCandidates(ctx, 0, []string{"pending"}, 2, 10)
+	tt.NoErr(err)
+	tt.Equal(1, len(candidates))
+	tt.Equal("candidate-text", candidates[0].FileID)
+}
+
+func TestImageRepo_Edits(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "edited", Description: "conection refused", Tags: []string{"errors"}}))
+	tt.NoErr(repo.TagImage(ctx, "edited", "favourite"))
+
+	annotations, corrected := "prod incident 2024-05", "connection refused"
+	_, err := repo.Patch(ctx, "edited", domain.ImagePatch{Annotations: &annotations, CorrectedDescription: &corrected})
+	tt.NoErr(err)
+
+	// indexing again keeps user edits and the tags that were set by hand
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "edited", Description: "conection refused again", Tags: []string{}}))
+
+	img, err := repo.Get(ctx, "edited")
+	tt.NoErr(err)
+	tt.Equal(img.Annotations, annotations)
+	tt.Equal(img.CorrectedDescription, corrected)
+
+	tags, err := repo.ImageTags(ctx, "edited")
+	tt.NoErr(err)
+	tt.Equal(tags, []domain.ImageTag{{Name: "favourite", Source: domain.TagSourceManual}})
+
+	tagsOf, err := repo.TagsOfImages(ctx, []string{"edited", "missing"})
+	tt.NoErr(err)
+	tt.Equal(tagsOf, map[string][]domain.ImageTag{"edited": tags})
+
+	edits, err := repo.ImageEdits(ctx, "edited")
+	tt.NoErr(err)
+	tt.Equal(2, len(edits))
+
+	// annotations are searchable
+	images, err := repo.FindByDescription(ctx, "incident tag:favourite", 1, 10)
+	tt.NoErr(err)
+	tt.Equal(1, len(images))
+
+	ok, err := repo.Matches(ctx, "edited", "prod incident")
+	tt.NoErr(err)
+	tt.True(ok)
+	ok, err = repo.Matches(ctx, "edited", "panic:")
+	tt.NoErr(err)
+	tt.True(!ok)
+
+	_, err = repo.Patch(ctx, "does-not-exist", domain.ImagePatch{Annotations: &annotations})
+	tt.True(errors.Is(err, ErrRecordNotFound))
+
+	// deleting the image removes its tags and edits
+	tt.NoErr(repo.Delete(ctx, "edited"))
+	edits, err = repo.ImageEdits(ctx, "edited")
+	tt.NoErr(err)
+	tt.Equal(0, len(edits))
+}
+
+func TestImageRepo_SourceURL(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "ingested", Description: "stack trace"}))
+	tt.NoErr(repo.SetSourceURL(ctx, "ingested", "https://chat.example.com/files/trace.png"))
+
+	// indexing again keeps the source url
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "ingested", Description: "stack trace again"}))
+
+	img, err := repo.Get(ctx, "ingested")
+	tt.NoErr(err)
+	tt.Equal(img.SourceURL, "https://chat.example.com/files/trace.png")
+
+	images, err := repo.FindByDescription(ctx, "chat.example.com", 1, 10)
+	tt.NoErr(err)
+	tt.Equal(len(images), 1)
+
+	err = repo.SetSourceURL(ctx, "does-not-exist", "https://example.com")
+	tt.True(errors.Is(err, ErrRecordNotFound))
+}
+
+func TestImageRepo_Metadata(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+	meta := domain.Metadata{"app": "Slack", "window_title": "#incidents"}
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "with-meta", Description: "deploy failed", Metadata: meta}))
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "without-meta", Description: "deploy failed"}))
+
+	img, err := repo.Get(ctx, "with-meta")
+	tt.NoErr(err)
+	tt.Equal(img.Metadata, meta)
+
+	img, err = repo.Get(ctx, "without-meta")
+	tt.NoErr(err)
+	tt.True(img.Metadata == nil)
+
+	images, err := repo.FindByDescription(ctx, "deploy meta.app:slack", 1, 10)
+	tt.NoErr(err)
+	tt.Equal(len(images), 1)
+	tt.Equal(images[0].FileID, "with-meta")
+
+	images, err = repo.FindByDescription(ctx, "meta.app:Terminal", 1, 10)
+	tt.NoErr(err)
+	tt.Equal(len(images), 0)
+}
+
+func TestImageRepo_Deliveries(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+	all, err := repo.CreateWebhook(ctx, "http://localhost/all", nil, "all-secret")
+	tt.NoErr(err)
+	_, err = repo.CreateWebhook(ctx, "http://localhost/deleted", []string{domain.EventImageDeleted}, "secret")
+	tt.NoErr(err)
+
+	tt.NoErr(repo.EnqueueDeliveries(ctx, domain.EventImageIndexed, []byte(`{"FileID":"expected-id"}`)))
+
+	claimed, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	tt.NoErr(err)
+	tt.Equal(1, len(claimed)) // only the webhook subscribed to all events gets the delivery
+	tt.Equal(claimed[0].URL, "http://localhost/all")
+	tt.Equal(claimed[0].Secret, "all-secret")
+	tt.Equal(claimed[0].Attempts, 1)
+	tt.Equal(string(claimed[0].Payload), `{"FileID":"expected-id"}`)
+
+	again, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	tt.NoErr(err)
+	tt.Equal(0, len(again)) // claimed deliveries are leased
+
+	now := time.Now()
+	d := claimed[0].WebhookDelivery
+	d.Status, d.ResponseCode, d.DeliveredAt = domain.DeliveryDelivered, 204, &now
+	tt.NoErr(repo.UpdateDelivery(ctx, d))
+
+	deliveries, err := repo.ListDeliveries(ctx, all.ID, domain.DeliveryDelivered, 1, 10)
+	tt.NoErr(err)
+	tt.Equal(1, len(deliveries))
+	tt.Equal(deliveries[0].ResponseCode, 204)
+
+	tt.NoErr(repo.DeleteWebhook(ctx, all.ID))
+	_, err = repo.GetWebhook(ctx, all.ID)
+	tt.True(errors.Is(err, ErrRecordNotFound))
+}
+
+func TestImageRepo_Events(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+
+	// appends before the wait are not lost
+	seq, err := repo.AppendEvent(ctx, domain.Event{Type: domain.EventImageIndexed, FileID: "streamed-id"})
+	tt.NoErr(err)
+	tt.Equal(seq, int64(1))
+	tt.NoErr(repo.Wait(ctx))
+
+	events, err := repo.EventsAfter(ctx, 0, 10)
+	tt.NoErr(err)
+	tt.Equal(len(events), 1)
+	tt.Equal(events[0].FileID, "streamed-id")
+
+	// sequence numbers keep growing after old events are removed
+	tt.NoErr(repo.DeleteEventsBefore(ctx, time.Now().Add(time.Hour)))
+	last, err := repo.LastEventSeq(ctx)
+	tt.NoErr(err)
+	tt.Equal(last, int64(1))
+	seq, err = repo.AppendEvent(ctx, domain.Event{Type: domain.EventImageDeleted, FileID: "streamed-id"})
+	tt.NoErr(err)
+	tt.Equal(seq, int64(2))
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	tt.NoErr(repo.Wait(waitCtx))
+	tt.True(errors.Is(repo.Wait(waitCtx), context.DeadlineExceeded))
+}
+
+func TestImageRepo_FeedTokens(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	repo := NewImageRepo(newTestDB(t))
+
+	created, err := repo.CreateFeedToken(ctx, "alex", "expected-hash")
+	tt.NoErr(err)
+	tt.True(created.LastUsedAt == nil)
+
+	tt.NoErr(repo.UseFeedToken(ctx, "expected-hash"))
+	err = repo.UseFeedToken(ctx, "unknown-hash")
+	tt.True(errors.Is(err, ErrRecordNotFound))
+
+	tokens, err := repo.ListFeedTokens(ctx)
+	tt.NoErr(err)
+	tt.Equal(len(tokens), 1)
+	tt.True(tokens[0].LastUsedAt != nil)
+
+	saved, err := repo.CreateSavedSearch(ctx, "oom", "oomkilled")
+	tt.NoErr(err)
+	saved, err = repo.UpdateSavedSearch(ctx, saved.ID, "oom kills", "OOMKilled")
+	tt.NoErr(err)
+	tt.Equal(saved.Name, "oom kills")
+
+	tt.NoErr(repo.Upsert(ctx, domain.Image{FileID: "alerted", Description: "OOMKilled", LastModified: time.Now()}))
+	for _, tc := range []struct{ matches, added bool }{{true, true}, {true, false}, {false, false}, {true, true}} {
+		added, err := repo.SetSavedSearchMatch(ctx, saved.ID, "alerted", tc.matches)
+		tt.NoErr(err)
+		tt.Equal(added, tc.added) // only an image that starts to match is added
+	}
+	tt.NoErr(repo.DeleteSavedSearch(ctx, saved.ID))
+	_, err = repo.UpdateSavedSearch(ctx, saved.ID, "oom kills", "OOMKilled")
+	tt.True(errors.Is(err, ErrRecordNotFound))
+}
+
+func TestMigrations(t *testing.T) {
+	tt := is.New(t)
+	ctx := context.Background()
+
+	db := newTestDB(t)
+	migrations, err := migrate.Load(sqlitemigrations.FS)
+	tt.NoErr(err)
+	m := migrate.New(db, migrations)
+
+	n, err := m.Down(ctx, len(migrations))
+	tt.NoErr(err)
+	tt.Equal(n, len(migrations))
+
+	var tables int
+	query := `SELECT count(*) FROM sqlite_master
+		WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')`
+	tt.NoErr(db.Get(&tables, query))
+	tt.Equal(tables, 0)
+
+	_, err = m.Up(ctx)
+	tt.NoErr(err)
+	tt.NoErr(m.Check(ctx))
+}
+
+// newTestDB returns a migrated database in a temporary file.
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrations, err := migrate.Load(sqlitemigrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrate.New(db, migrations).Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,235 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+type webhookRow struct {
+	ID        int       `db:"id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Events    string    `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (r webhookRow) toDomain() domain.Webhook {
+	events := make([]string, 0)
+	if r.Events != "" {
+		events = strings.Split(r.Events, ",")
+	}
+
+	return domain.Webhook{ID: r.ID, URL: r.URL, Events: events, Secret: r.Secret, CreatedAt: r.CreatedAt}
+}
+
+type deliveryRow struct {
+	ID            int64      `db:"id"`
+	WebhookID     int        `db:"webhook_id"`
+	Event         string     `db:"event"`
+	Payload       string     `db:"payload"`
+	Status        string     `db:"status"`
+	Attempts      int        `db:"attempts"`
+	ResponseCode  int        `db:"response_code"`
+	LastError     string     `db:"last_error"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+	URL           string     `db:"url"`
+	Secret        string     `db:"secret"`
+}
+
+func (r deliveryRow) toDomain() domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:            r.ID,
+		WebhookID:     r.WebhookID,
+		Event:         r.Event,
+		Payload:       json.RawMessage(r.Payload),
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		ResponseCode:  r.ResponseCode,
+		LastError:     r.LastError,
+		NextAttemptAt: r.NextAttemptAt,
+		CreatedAt:     r.CreatedAt,
+		DeliveredAt:   r.DeliveredAt,
+	}
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts,
+	d.response_code, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+// ListWebhooks returns registered webhooks without their secrets.
+func (i *ImageRepo) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	var rows []webhookRow
+	err := i.db.SelectContext(ctx, &rows, `SELECT id, url, '' AS secret, events, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		return []domain.Webhook{}, fmt.Errorf("listing webhooks, %w", err)
+	}
+
+	webhooks := make([]domain.Webhook, 0, len(rows))
+	for _, r := range rows {
+		webhooks = append(webhooks, r.toDomain())
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns the webhook without its secret.
+func (i *ImageRepo) GetWebhook(ctx context.Context, id int) (domain.Webhook, error) {
+	var row webhookRow
+	err := i.db.GetContext(ctx, &row, `SELECT id, url, '' AS secret, events, created_at FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return domain.Webhook{}, fmt.Errorf("webhook %d not found, %w", id, ErrRecordNotFound)
+		default:
+			return domain.Webhook{}, fmt.Errorf("getting webhook %d, %w", id, err)
+		}
+	}
+
+	return row.toDomain(), nil
+}
+
+func (i *ImageRepo) CreateWebhook(ctx context.Context, url string, events []string, secret string) (domain.Webhook, error) {
+	row := webhookRow{URL: url, Secret: secret, Events: strings.Join(events, ","), CreatedAt: time.Now().UTC()}
+	res, err := i.db.ExecContext(ctx,
+		`INSERT INTO webhooks (url, secret, events, created_at) VALUES (?, ?, ?, ?)`,
+		row.URL, row.Secret, row.Events, row.CreatedAt,
+	)
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("creating webhook for %s, %w", url, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("creating webhook for %s, %w", url, err)
+	}
+	row.ID = int(id)
+
+	return row.toDomain(), nil
+}
+
+func (i *ImageRepo) DeleteWebhook(ctx context.Context, id int) error {
+	res, err := i.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting webhook %d, %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting webhook %d, %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("webhook %d not found, %w", id, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// EnqueueDeliveries adds a pending delivery of the event for every webhook subscribed to it.
+func (i *ImageRepo) EnqueueDeliveries(ctx context.Context, event string, payload []byte) error {
+	now := time.Now().UTC()
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at, created_at)
+		SELECT id, ?, ?, ?, ? FROM webhooks
+		WHERE events = '' OR ',' || events || ',' LIKE '%,' || ? || ',%'`
+	_, err := i.db.ExecContext(ctx, query, event, string(payload), now, now, event)
+	if err != nil {
+		return fmt.Errorf("enqueueing deliveries of %s, %w", event, err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries leases due pending deliveries, so that they are not claimed again until the lease expires.
+// Transactions take the write lock when they begin, so concurrent claims wait for each other.
+func (i *ImageRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.ClaimedDelivery, error) {
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("starting transaction for claiming deliveries, %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+	var rows []deliveryRow
+	query := `SELECT ` + deliveryColumns + `, w.url, w.secret
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at, d.id LIMIT ?`
+	err = tx.SelectContext(ctx, &rows, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claiming deliveries, %w", err)
+	}
+
+	claimed := make([]domain.ClaimedDelivery, 0, len(rows))
+	for _, r := range rows {
+		r.Attempts++
+		r.NextAttemptAt = now.Add(lease)
+		_, err = tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET attempts = ?, next_attempt_at = ? WHERE id = ?`,
+			r.Attempts, r.NextAttemptAt, r.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("leasing delivery %d, %w", r.ID, err)
+		}
+		claimed = append(claimed, domain.ClaimedDelivery{WebhookDelivery: r.toDomain(), URL: r.URL, Secret: r.Secret})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("committing claimed deliveries, %w", err)
+	}
+
+	return claimed, nil
+}
+
+// UpdateDelivery stores the result of a delivery attempt.
+func (i *ImageRepo) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	var deliveredAt *time.Time
+	if d.DeliveredAt != nil {
+		t := d.DeliveredAt.UTC()
+		deliveredAt = &t
+	}
+
+	query := `UPDATE webhook_deliveries
+		SET status = ?, response_code = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?`
+	_, err := i.db.ExecContext(ctx, query,
+		d.Status, d.ResponseCode, d.LastError, d.NextAttemptAt.UTC(), deliveredAt, d.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating delivery %d, %w", d.ID, err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns deliveries of the webhook, the latest first. Empty status means any status.
+func (i *ImageRepo) ListDeliveries(
+	ctx context.Context,
+	webhookID int,
+	status string,
+	page,
+	perPage int,
+) ([]domain.WebhookDelivery, error) {
+	var rows []deliveryRow
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d
+		WHERE d.webhook_id = ? AND (d.status = ? OR ? = '')
+		ORDER BY d.id desc LIMIT ? OFFSET ?`
+	err := i.db.SelectContext(ctx, &rows, query, webhookID, status, status, perPage, (page-1)*perPage)
+	if err != nil {
+		return []domain.WebhookDelivery{}, fmt.Errorf("listing deliveries of webhook %d, %w", webhookID, err)
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(rows))
+	for _, r := range rows {
+		deliveries = append(deliveries, r.toDomain())
+	}
+
+	return deliveries, nil
+}
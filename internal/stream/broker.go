@@ -0,0 +1,161 @@
+// Package stream fans out stored image events to live subscribers.
+//
+// Events are appended to a log in the database, the database notifies every replica
+// and each replica reads new events from the log. This way subscribers of any replica
+// receive events of all indexers, and clients can resume from the last event they saw.
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+const (
+	fetchLimit = 100
+	// bufferSize is how many events a subscriber may lag behind before it is dropped
+	bufferSize  = 64
+	retryDelay  = 5 * time.Second
+	retention   = 7 * 24 * time.Hour
+	prunePeriod = time.Hour
+)
+
+//go:generate moq -out stream_moq_test.go . eventLog notifier
+type eventLog interface {
+	AppendEvent(ctx context.Context, e domain.Event) (int64, error)
+	EventsAfter(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error)
+	LastEventSeq(ctx context.Context) (int64, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) error
+}
+
+type notifier interface {
+	Wait(ctx context.Context) error
+}
+
+type Broker struct {
+	events  eventLog
+	logger  *slog.Logger
+	mu      sync.Mutex
+	subs    map[chan domain.StreamEvent]struct{}
+	lastSeq int64
+}
+
+func NewBroker(events eventLog, logger *slog.Logger) *Broker {
+	return &Broker{
+		events: events,
+		logger: logger.WithGroup("STREAM"),
+		subs:   make(map[chan domain.StreamEvent]struct{}),
+	}
+}
+
+// Handle appends indexed and deleted images to the event log.
+func (b *Broker) Handle(ctx context.Context, e domain.Event) {
+	if e.Type != domain.EventImageIndexed && e.Type != domain.EventImageDeleted {
+		return
+	}
+
+	_, err := b.events.AppendEvent(ctx, e)
+	if err != nil {
+		b.logger.Error("appending event", slog.String("event", e.Type), slog.String("err", err.Error()))
+	}
+}
+
+// Subscribe returns a channel of new events and a function that cancels the subscription.
+// The channel is closed if the subscriber falls behind, it should resume with Replay.
+func (b *Broker) Subscribe() (<-chan domain.StreamEvent, func()) {
+	ch := make(chan domain.StreamEvent, bufferSize)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Replay returns stored events after the sequence number.
+func (b *Broker) Replay(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+	return b.events.EventsAfter(ctx, after, limit)
+}
+
+// Run reads new events from the log whenever the notifier wakes up and sends them to subscribers.
+func (b *Broker) Run(ctx context.Context, n notifier) error {
+	var err error
+	b.lastSeq, err = b.events.LastEventSeq(ctx)
+	if err != nil {
+		return err
+	}
+
+	prune := time.NewTicker(prunePeriod)
+	defer prune.Stop()
+
+	for {
+		err = n.Wait(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// events appended in the meantime are read after the delay
+			b.logger.Warn("waiting for events", slog.String("err", err.Error()))
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		b.fetch(ctx)
+
+		select {
+		case <-prune.C:
+			err = b.events.DeleteEventsBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				b.logger.Error("pruning events", slog.String("err", err.Error()))
+			}
+		default:
+		}
+	}
+}
+
+func (b *Broker) fetch(ctx context.Context) {
+	for {
+		events, err := b.events.EventsAfter(ctx, b.lastSeq, fetchLimit)
+		if err != nil {
+			b.logger.Error("reading events", slog.String("err", err.Error()))
+			return
+		}
+
+		for _, e := range events {
+			b.broadcast(e)
+			b.lastSeq = e.Seq
+		}
+
+		if len(events) < fetchLimit {
+			return
+		}
+	}
+}
+
+func (b *Broker) broadcast(e domain.StreamEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+func TestBroker_Handle(t *testing.T) {
+	tt := is.New(t)
+
+	events := &eventLogMock{
+		AppendEventFunc: func(ctx context.Context, e domain.Event) (int64, error) { return 1, nil },
+	}
+	b := NewBroker(events, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	b.Handle(context.Background(), domain.Event{Type: domain.EventImageIndexed, FileID: "indexed.jpg"})
+	b.Handle(context.Background(), domain.Event{Type: domain.EventImageFailed, FileID: "failed.jpg"})
+	b.Handle(context.Background(), domain.Event{Type: domain.EventImageDeleted, FileID: "deleted.jpg"})
+
+	tt.Equal(len(events.AppendEventCalls()), 2) // failures are not streamed
+	tt.Equal(events.AppendEventCalls()[0].E.FileID, "indexed.jpg")
+	tt.Equal(events.AppendEventCalls()[1].E.FileID, "deleted.jpg")
+}
+
+func TestBroker_Run(t *testing.T) {
+	tt := is.New(t)
+
+	stored := []domain.StreamEvent{
+		{Seq: 5, Event: domain.Event{Type: domain.EventImageIndexed, FileID: "old.jpg"}},
+		{Seq: 6, Event: domain.Event{Type: domain.EventImageIndexed, FileID: "first.jpg"}},
+		{Seq: 7, Event: domain.Event{Type: domain.EventImageDeleted, FileID: "second.jpg"}},
+	}
+	events := &eventLogMock{
+		LastEventSeqFunc: func(ctx context.Context) (int64, error) { return 5, nil },
+		EventsAfterFunc: func(ctx context.Context, after int64, limit int) ([]domain.StreamEvent, error) {
+			var res []domain.StreamEvent
+			for _, e := range stored {
+				if e.Seq > after {
+					res = append(res, e)
+				}
+			}
+			return res, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notified := make(chan struct{})
+	n := &notifierMock{WaitFunc: func(ctx context.Context) error {
+		select {
+		case <-notified:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+
+	b := NewBroker(events, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	done := make(chan error)
+	go func() { done <- b.Run(ctx, n) }()
+
+	notified <- struct{}{}
+
+	// events stored before the broker started are only available through Replay
+	tt.Equal((<-ch).Seq, int64(6))
+	tt.Equal((<-ch).Seq, int64(7))
+
+	cancel()
+	tt.True(errors.Is(<-done, context.Canceled))
+}
+
+func TestBroker_broadcast(t *testing.T) {
+	tt := is.New(t)
+
+	b := NewBroker(&eventLogMock{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	slow, _ := b.Subscribe()
+	fast, unsubscribe := b.Subscribe()
+
+	for i := 1; i <= bufferSize+1; i++ {
+		b.broadcast(domain.StreamEvent{Seq: int64(i)})
+		if i <= bufferSize {
+			<-fast
+		}
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	tt.Equal(received, bufferSize) // a subscriber that falls behind is dropped, it must resume from the log
+
+	tt.Equal((<-fast).Seq, int64(bufferSize+1))
+
+	unsubscribe()
+	_, ok := <-fast
+	tt.True(!ok)
+	unsubscribe() // must not panic when called twice
+}
+
+func TestBroker_Run_retriesAfterNotifierError(t *testing.T) {
+	tt := is.New(t)
+
+	events := &eventLogMock{
+		LastEventSeqFunc: func(ctx context.Context) (int64, error) { return 0, nil },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	n := &notifierMock{WaitFunc: func(ctx context.Context) error { return errors.New("connection lost") }}
+
+	b := NewBroker(events, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	err := b.Run(ctx, n)
+
+	tt.True(errors.Is(err, context.DeadlineExceeded))
+	tt.Equal(len(n.WaitCalls()), 1) // waits before reconnecting instead of spinning
+}
@@ -0,0 +1,199 @@
+// Package tagging assigns tags to images with rules.
+//
+// Rules are read from a JSON file, e.g.
+//
+//	[{"tag": "monitoring", "text": "(?i)grafana|prometheus"}, {"tag": "work", "prefix": "work/"}]
+//
+// A rule matches when the description matches its text pattern and the file id starts with its prefix,
+// empty conditions are ignored.
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+// reapplyBatchSize is the number of images loaded at once when rules are re-applied.
+const reapplyBatchSize = 100
+
+var (
+	ErrInvalidTag  = errors.New("tag must be 1-64 lowercase letters, digits, dots, dashes or underscores")
+	ErrInvalidRule = errors.New("invalid rule")
+
+	validTag = regexp.MustCompile(`^[a-z0-9][a-z0-9._\-]{0,63}$`)
+)
+
+// NormalizeTag lowercases the tag name and checks that it is valid.
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !validTag.MatchString(name) {
+		return "", fmt.Errorf("checking tag %q, %w", name, ErrInvalidTag)
+	}
+
+	return name, nil
+}
+
+type Rule struct {
+	Tag    string `json:"tag"`
+	Text   string `json:"text"`
+	Prefix string `json:"prefix"`
+
+	text *regexp.Regexp
+}
+
+// Matches checks the rule against the image.
+func (r Rule) Matches(img domain.Image) bool {
+	if r.Prefix != "" && !strings.HasPrefix(img.FileID, r.Prefix) {
+		return false
+	}
+	if r.text != nil && !r.text.MatchString(img.Description) {
+		return false
+	}
+
+	return true
+}
+
+// ParseRules reads and validates rules in JSON.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	err := json.NewDecoder(r).Decode(&rules)
+	if err != nil {
+		return nil, fmt.Errorf("decoding rules, %w", err)
+	}
+
+	for i := range rules {
+		rules[i].Tag, err = NormalizeTag(rules[i].Tag)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d, %w", i, err)
+		}
+		if rules[i].Text == "" && rules[i].Prefix == "" {
+			return nil, fmt.Errorf("rule %d has neither text nor prefix, %w", i, ErrInvalidRule)
+		}
+		if rules[i].Text != "" {
+			rules[i].text, err = regexp.Compile(rules[i].Text)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d has invalid text pattern, %w", i, err)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// Apply returns sorted unique tags of the rules that match the image. The result is never nil.
+func Apply(rules []Rule, img domain.Image) []string {
+	seen := make(map[string]struct{})
+	tags := make([]string, 0)
+
+	for _, r := range rules {
+		if _, ok := seen[r.Tag]; ok || !r.Matches(img) {
+			continue
+		}
+		seen[r.Tag] = struct{}{}
+		tags = append(tags, r.Tag)
+	}
+	sort.Strings(tags)
+
+	return tags
+}
+
+//go:generate moq -out tagging_moq_test.go . imageRepo
+type imageRepo interface {
+	ListImages(ctx context.Context, after string, limit int) ([]domain.Image, error)
+	SetRuleTags(ctx context.Context, fileID string, tags []string) error
+}
+
+// Tagger is an indexing stage that tags images with rules from a file.
+// Rules can be reloaded, so that changes are applied without a restart.
+type Tagger struct {
+	path string
+
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// NewTagger loads rules from the file, empty path means no rules.
+func NewTagger(path string) (*Tagger, error) {
+	t := &Tagger{path: path}
+
+	err := t.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Reload reads the rules file again. Current rules are kept if the file is invalid.
+func (t *Tagger) Reload() error {
+	if t.path == "" {
+		return nil
+	}
+
+	f, err := os.Open(t.path)
+	if err != nil {
+		return fmt.Errorf("opening tagging rules %s, %w", t.path, err)
+	}
+	defer f.Close()
+
+	rules, err := ParseRules(f)
+	if err != nil {
+		return fmt.Errorf("parsing tagging rules %s, %w", t.path, err)
+	}
+
+	t.mu.Lock()
+	t.rules = rules
+	t.mu.Unlock()
+
+	return nil
+}
+
+func (t *Tagger) Rules() []Rule {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.rules
+}
+
+func (t *Tagger) Process(_ context.Context, _ string, img *domain.Image) error {
+	img.Tags = Apply(t.Rules(), *img)
+
+	return nil
+}
+
+// Reapply replaces rule tags of all stored images using the current rules and returns the number of images.
+func (t *Tagger) Reapply(ctx context.Context, repo imageRepo) (int, error) {
+	rules := t.Rules()
+	total := 0
+	after := ""
+
+	for {
+		images, err := repo.ListImages(ctx, after, reapplyBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("listing images, %w", err)
+		}
+
+		for _, img := range images {
+			err = repo.SetRuleTags(ctx, img.FileID, Apply(rules, img))
+			if err != nil {
+				return total, fmt.Errorf("tagging image %s, %w", img.FileID, err)
+			}
+			total++
+		}
+
+		if len(images) < reapplyBatchSize {
+			return total, nil
+		}
+		after = images[len(images)-1].FileID
+	}
+}
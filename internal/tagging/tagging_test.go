@@ -0,0 +1,164 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+	"github.com/matryer/is"
+)
+
+const testRules = `[
+	{"tag": "Monitoring", "text": "(?i)grafana|prometheus"},
+	{"tag": "work", "prefix": "work/"},
+	{"tag": "work-alerts", "prefix": "work/", "text": "(?i)alert"},
+	{"tag": "monitoring", "text": "(?i)datadog"}
+]`
+
+func TestApply(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		img  domain.Image
+		want []string
+	}{
+		{
+			name: "text match",
+			img:  domain.Image{FileID: "a.jpg", Description: "Grafana dashboard"},
+			want: []string{"monitoring"},
+		},
+		{
+			name: "prefix and text match",
+			img:  domain.Image{FileID: "work/a.jpg", Description: "Prometheus alert firing"},
+			want: []string{"monitoring", "work", "work-alerts"},
+		},
+		{
+			name: "all conditions must match",
+			img:  domain.Image{FileID: "home/a.jpg", Description: "alert"},
+			want: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			tt.Equal(Apply(rules, tc.img), tc.want)
+		})
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	for _, in := range []string{
+		`{"tag": "not-a-list"}`,
+		`[{"tag": "no conditions"}]`,
+		`[{"tag": "", "text": "x"}]`,
+		`[{"tag": "bad", "text": "("}]`,
+	} {
+		t.Run(in, func(t *testing.T) {
+			tt := is.New(t)
+
+			_, err := ParseRules(strings.NewReader(in))
+			tt.True(err != nil)
+		})
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tt := is.New(t)
+
+	name, err := NormalizeTag(" Prod-EU ")
+	tt.NoErr(err)
+	tt.Equal(name, "prod-eu")
+
+	_, err = NormalizeTag("two words")
+	tt.True(errors.Is(err, ErrInvalidTag))
+}
+
+func TestTagger(t *testing.T) {
+	tt := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	err := os.WriteFile(path, []byte(`[{"tag": "monitoring", "text": "grafana"}]`), 0o600)
+	tt.NoErr(err)
+
+	tagger, err := NewTagger(path)
+	tt.NoErr(err)
+
+	img := &domain.Image{Description: "grafana"}
+	err = tagger.Process(context.Background(), "", img)
+	tt.NoErr(err)
+	tt.Equal(img.Tags, []string{"monitoring"})
+
+	err = os.WriteFile(path, []byte(`[{"tag": "dashboards", "text": "grafana"}]`), 0o600)
+	tt.NoErr(err)
+	err = tagger.Reload()
+	tt.NoErr(err)
+
+	err = os.WriteFile(path, []byte(`invalid`), 0o600)
+	tt.NoErr(err)
+	err = tagger.Reload()
+	tt.True(err != nil) // invalid rules must be reported
+
+	err = tagger.Process(context.Background(), "", img)
+	tt.NoErr(err)
+	tt.Equal(img.Tags, []string{"dashboards"}) // previous valid rules must be kept
+}
+
+func TestTagger_Reapply(t *testing.T) {
+	tagger := &Tagger{}
+	tagger.rules, _ = ParseRules(strings.NewReader(testRules))
+
+	t.Run("iterates over all images", func(t *testing.T) {
+		tt := is.New(t)
+
+		var all []domain.Image
+		for i := 0; i < reapplyBatchSize+1; i++ {
+			all = append(all, domain.Image{FileID: fmt.Sprintf("%03d", i), Description: "grafana"})
+		}
+		repo := &imageRepoMock{
+			ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+				var page []domain.Image
+				for _, img := range all {
+					if img.FileID > after && len(page) < limit {
+						page = append(page, img)
+					}
+				}
+				return page, nil
+			},
+			SetRuleTagsFunc: func(ctx context.Context, fileID string, tags []string) error {
+				return nil
+			},
+		}
+
+		n, err := tagger.Reapply(context.Background(), repo)
+		tt.NoErr(err)
+		tt.Equal(n, reapplyBatchSize+1)
+		tt.Equal(len(repo.ListImagesCalls()), 2)
+		tt.Equal(repo.ListImagesCalls()[1].After, fmt.Sprintf("%03d", reapplyBatchSize-1))
+		tt.Equal(repo.SetRuleTagsCalls()[0].Tags, []string{"monitoring"})
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		tt := is.New(t)
+
+		expectedErr := errors.New("expected-err")
+		repo := &imageRepoMock{
+			ListImagesFunc: func(ctx context.Context, after string, limit int) ([]domain.Image, error) {
+				return nil, expectedErr
+			},
+		}
+
+		_, err := tagger.Reapply(context.Background(), repo)
+		tt.True(errors.Is(err, expectedErr))
+	})
+}
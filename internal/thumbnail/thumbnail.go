@@ -0,0 +1,69 @@
+// Package thumbnail scales screenshots down for previews.
+package thumbnail
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+const quality = 80
+
+// Write decodes the image, scales it down to the width and writes it as jpeg.
+func Write(w io.Writer, r io.Reader, width int) error {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("decoding image, %w", err)
+	}
+
+	err = jpeg.Encode(w, Resize(src, width), &jpeg.Options{Quality: quality})
+	if err != nil {
+		return fmt.Errorf("encoding thumbnail, %w", err)
+	}
+
+	return nil
+}
+
+// Resize scales the image down to the width keeping the aspect ratio, narrower images are returned as is.
+// Every pixel of the thumbnail is the average of the pixels it covers, so that small text turns grey
+// instead of into noise.
+func Resize(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if width <= 0 || b.Dx() <= width {
+		return src
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/height)
+
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/width)
+
+			var sr, sg, sb, sa, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, a := src.At(sx, sy).RGBA()
+					sr, sg, sb, sa = sr+uint64(r), sg+uint64(g), sb+uint64(b), sa+uint64(a)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(sr / n),
+				G: uint16(sg / n),
+				B: uint16(sb / n),
+				A: uint16(sa / n),
+			})
+		}
+	}
+
+	return dst
+}
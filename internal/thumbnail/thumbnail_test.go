@@ -0,0 +1,53 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestResize(t *testing.T) {
+	tt := is.New(t)
+
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	thumb := Resize(src, 10)
+	tt.Equal(thumb.Bounds(), image.Rect(0, 0, 10, 5)) // aspect ratio is kept
+
+	r, g, b, a := thumb.At(3, 2).RGBA()
+	tt.Equal([]uint32{r, g, b, a}, []uint32{0x7fff, 0x7fff, 0x7fff, 0xffff}) // stripes are averaged to grey
+
+	tt.Equal(Resize(src, 100), image.Image(src)) // narrow images are not scaled up
+}
+
+func TestWrite(t *testing.T) {
+	tt := is.New(t)
+
+	var src bytes.Buffer
+	tt.NoErr(png.Encode(&src, image.NewRGBA(image.Rect(0, 0, 640, 480))))
+
+	var thumb bytes.Buffer
+	tt.NoErr(Write(&thumb, &src, 320))
+
+	cfg, err := jpeg.DecodeConfig(&thumb)
+	tt.NoErr(err)
+	tt.Equal(cfg.Width, 320)
+	tt.Equal(cfg.Height, 240)
+
+	err = Write(&thumb, bytes.NewBufferString("not an image"), 320)
+	tt.True(err != nil)
+}
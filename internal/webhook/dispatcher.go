@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	batchSize    = 20
+	// lease must be longer than the client timeout, otherwise a delivery may be sent twice at the same time
+	lease       = time.Minute
+	maxAttempts = 10
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// lifecycleEvents are the events that are sent to registered webhooks.
+var lifecycleEvents = map[string]struct{}{
+	domain.EventImageIndexed: {},
+	domain.EventImageFailed:  {},
+	domain.EventImageDeleted: {},
+}
+
+//go:generate moq -out webhook_moq_test.go . outbox
+type outbox interface {
+	EnqueueDeliveries(ctx context.Context, event string, payload []byte) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.ClaimedDelivery, error)
+	UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error
+}
+
+// Dispatcher stores lifecycle events in the outbox and delivers them to registered webhooks.
+// Storing events first means that they survive restarts and failures of receivers.
+type Dispatcher struct {
+	outbox outbox
+	client *Client
+	log    *slog.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(outbox outbox, client *Client, log *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		outbox: outbox,
+		client: client,
+		log:    log.WithGroup("WEBHOOKS"),
+		now:    time.Now,
+	}
+}
+
+func (d *Dispatcher) Handle(ctx context.Context, e domain.Event) {
+	if _, ok := lifecycleEvents[e.Type]; !ok {
+		return
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		d.log.Error("encoding event", slog.String("event", e.Type), slog.String("err", err.Error()))
+		return
+	}
+
+	err = d.outbox.EnqueueDeliveries(ctx, e.Type, payload)
+	if err != nil {
+		d.log.Error("enqueueing event", slog.String("event", e.Type), slog.String("err", err.Error()))
+	}
+}
+
+// Run delivers due events until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for {
+				n, err := d.deliverDue(ctx)
+				if err != nil {
+					d.log.Error("delivering webhooks", slog.String("err", err.Error()))
+				}
+				if err != nil || n < batchSize {
+					break
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) (int, error) {
+	claimed, err := d.outbox.ClaimDeliveries(ctx, batchSize, lease)
+	if err != nil {
+		return 0, fmt.Errorf("claiming deliveries, %w", err)
+	}
+
+	for _, c := range claimed {
+		err = d.outbox.UpdateDelivery(ctx, d.attempt(ctx, c))
+		if err != nil {
+			return 0, fmt.Errorf("storing delivery result, %w", err)
+		}
+	}
+
+	return len(claimed), nil
+}
+
+// attempt sends the delivery and returns it with the updated state.
+func (d *Dispatcher) attempt(ctx context.Context, c domain.ClaimedDelivery) domain.WebhookDelivery {
+	res := c.WebhookDelivery
+
+	code, err := d.client.Post(ctx, c.URL, c.Secret, c.Event, c.Payload)
+	res.ResponseCode = code
+	if err == nil {
+		now := d.now()
+		res.Status, res.LastError, res.DeliveredAt = domain.DeliveryDelivered, "", &now
+
+		return res
+	}
+
+	res.LastError = err.Error()
+	if !Retryable(err) || res.Attempts >= maxAttempts {
+		res.Status = domain.DeliveryFailed
+		d.log.Warn("webhook delivery failed", slog.Int64("delivery", res.ID), slog.String("err", err.Error()))
+
+		return res
+	}
+
+	res.Status = domain.DeliveryPending
+	res.NextAttemptAt = d.now().Add(Backoff(res.Attempts))
+
+	return res
+}
+
+// Backoff returns the delay before the next attempt, it doubles with every attempt up to an hour.
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxBackoff)
+}
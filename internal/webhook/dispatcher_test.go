@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/elnoro/foxyshot-indexer/internal/domain"
+)
+
+func TestDispatcher_Handle(t *testing.T) {
+	tt := is.New(t)
+
+	outbox := &outboxMock{
+		EnqueueDeliveriesFunc: func(ctx context.Context, event string, payload []byte) error {
+			return nil
+		},
+	}
+	d := NewDispatcher(outbox, NewClient(time.Second, true), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	d.Handle(context.Background(), domain.Event{Type: "saved_search.matched"})
+	tt.Equal(len(outbox.EnqueueDeliveriesCalls()), 0) // only lifecycle events are sent to webhooks
+
+	d.Handle(context.Background(), domain.Event{Type: domain.EventImageFailed, FileID: "expected.jpg", Error: "expected error"})
+	tt.Equal(len(outbox.EnqueueDeliveriesCalls()), 1)
+
+	call := outbox.EnqueueDeliveriesCalls()[0]
+	tt.Equal(call.Event, domain.EventImageFailed)
+
+	var payload domain.Event
+	tt.NoErr(json.Unmarshal(call.Payload, &payload))
+	tt.Equal(payload.FileID, "expected.jpg")
+	tt.Equal(payload.Error, "expected error")
+}
+
+func TestDispatcher_deliverDue(t *testing.T) {
+	statuses := map[string]int{"/ok": http.StatusNoContent, "/down": http.StatusBadGateway, "/gone": http.StatusGone}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		path     string
+		attempts int
+
+		expectedStatus      string
+		expectedCode        int
+		expectedNextAttempt time.Time
+	}{
+		{name: "delivered", path: "/ok", attempts: 1, expectedStatus: domain.DeliveryDelivered, expectedCode: http.StatusNoContent},
+		{
+			name: "retried with backoff", path: "/down", attempts: 3,
+			expectedStatus: domain.DeliveryPending, expectedCode: http.StatusBadGateway, expectedNextAttempt: now.Add(2 * time.Minute),
+		},
+		{name: "out of attempts", path: "/down", attempts: maxAttempts, expectedStatus: domain.DeliveryFailed, expectedCode: http.StatusBadGateway},
+		{name: "not retryable", path: "/gone", attempts: 1, expectedStatus: domain.DeliveryFailed, expectedCode: http.StatusGone},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := is.New(t)
+
+			outbox := &outboxMock{
+				ClaimDeliveriesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]domain.ClaimedDelivery, error) {
+					return []domain.ClaimedDelivery{{
+						WebhookDelivery: domain.WebhookDelivery{ID: 1, Event: domain.EventImageIndexed, Payload: []byte(`{}`), Attempts: tc.attempts},
+						URL:             srv.URL + tc.path,
+						Secret:          "expected-secret",
+					}}, nil
+				},
+				UpdateDeliveryFunc: func(ctx context.Context, d domain.WebhookDelivery) error {
+					return nil
+				},
+			}
+			d := NewDispatcher(outbox, NewClient(time.Second, true), slog.New(slog.NewTextHandler(io.Discard, nil)))
+			d.now = func() time.Time { return now }
+
+			n, err := d.deliverDue(context.Background())
+			tt.NoErr(err)
+			tt.Equal(n, 1)
+
+			updated := outbox.UpdateDeliveryCalls()[0].D
+			tt.Equal(updated.Status, tc.expectedStatus)
+			tt.Equal(updated.ResponseCode, tc.expectedCode)
+			if tc.expectedStatus == domain.DeliveryPending {
+				tt.Equal(updated.NextAttemptAt, tc.expectedNextAttempt)
+			}
+			if tc.expectedStatus == domain.DeliveryDelivered {
+				tt.Equal(*updated.DeliveredAt, now)
+				tt.Equal(updated.LastError, "")
+			} else {
+				tt.True(updated.LastError != "")
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tt := is.New(t)
+
+	tt.Equal(Backoff(1), 30*time.Second)
+	tt.Equal(Backoff(2), time.Minute)
+	tt.Equal(Backoff(5), 8*time.Minute)
+	tt.Equal(Backoff(20), time.Hour)
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	queueSize       = 100
+	defaultAttempts = 5
+	defaultBackoff  = time.Second
+)
+
+type message struct {
+	event string
+	body  []byte
+}
+
+// Sender delivers payloads to a single url in the background, retrying with exponential backoff.
+// Payloads are dropped if the queue is full, so that callers are never blocked.
+type Sender struct {
+	client *Client
+	url    string
+	secret string
+	log    *slog.Logger
+
+	attempts int
+	backoff  time.Duration
+	queue    chan message
+}
+
+func NewSender(client *Client, url, secret string, log *slog.Logger) *Sender {
+	return &Sender{
+		client:   client,
+		url:      url,
+		secret:   secret,
+		log:      log.WithGroup("WEBHOOK"),
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+		queue:    make(chan message, queueSize),
+	}
+}
+
+// Enqueue schedules the payload for delivery.
+func (s *Sender) Enqueue(event string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.log.Error("encoding webhook payload", slog.String("event", event), slog.String("err", err.Error()))
+		return
+	}
+
+	select {
+	case s.queue <- message{event: event, body: body}:
+	default:
+		s.log.Error("webhook queue is full, dropping payload", slog.String("event", event))
+	}
+}
+
+// Run delivers queued payloads until the context is cancelled.
+func (s *Sender) Run(ctx context.Context) error {
+	for {
+		select {
+		case msg := <-s.queue:
+			err := s.deliver(ctx, msg)
+			if err != nil {
+				s.log.Error("delivering webhook", slog.String("event", msg.event), slog.String("err", err.Error()))
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Sender) deliver(ctx context.Context, msg message) error {
+	var err error
+	backoff := s.backoff
+
+	for attempt := 1; attempt <= s.attempts; attempt++ {
+		_, err = s.client.Post(ctx, s.url, s.secret, msg.event, msg.body)
+		if err == nil || !Retryable(err) {
+			break
+		}
+		if attempt == s.attempts {
+			break
+		}
+
+		s.log.Warn("webhook delivery failed, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("err", err.Error()),
+		)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("delivering %s after retries, %w", msg.event, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package webhook sends signed JSON payloads over HTTP.
+//
+// Every request has a unix timestamp in the X-Foxyshot-Timestamp header and a signature in the
+// X-Foxyshot-Signature header: "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot
+// and the body, keyed with the shared secret. Receivers should reject requests with old timestamps.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/fetch"
+)
+
+const (
+	SignatureHeader = "X-Foxyshot-Signature"
+	TimestampHeader = "X-Foxyshot-Timestamp"
+	EventHeader     = "X-Foxyshot-Event"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("timestamp is too old")
+)
+
+// StatusError is returned when the receiver responds with a non-2xx status.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %d", e.Code)
+}
+
+// Retryable reports whether the request may succeed if it is sent again.
+func Retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= http.StatusInternalServerError || statusErr.Code == http.StatusTooManyRequests
+	}
+
+	// a forbidden address stays forbidden
+	return !errors.Is(err, fetch.ErrForbiddenAddr)
+}
+
+// Sign returns the signature of the body sent at the timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and that the timestamp is not older than the tolerance.
+func Verify(secret, signature, timestamp string, body []byte, now time.Time, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing timestamp %q, %w", timestamp, ErrInvalidSignature)
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age > tolerance || age < -tolerance {
+		return fmt.Errorf("checking timestamp %d, %w", ts, ErrExpired)
+	}
+
+	return nil
+}
+
+// GenerateSecret returns a random secret for a new webhook.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("generating webhook secret, %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Client sends signed requests.
+type Client struct {
+	http *http.Client
+	now  func() time.Time
+}
+
+// NewClient returns a client that refuses private, loopback and link-local addresses unless allowPrivate is set,
+// webhook urls are given by users of the API.
+func NewClient(timeout time.Duration, allowPrivate bool) *Client {
+	return &Client{
+		http: &http.Client{Timeout: timeout, Transport: fetch.NewTransport(timeout, allowPrivate)},
+		now:  time.Now,
+	}
+}
+
+// Post sends the body signed with the secret once and returns the response status code,
+// the caller is responsible for retries. The code is 0 if no response was received.
+func (c *Client) Post(ctx context.Context, url, secret, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("creating webhook request, %w", err)
+	}
+
+	ts := c.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, ts, body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("sending webhook to %s, %w", url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("sending webhook to %s, %w", url, &StatusError{Code: resp.StatusCode})
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elnoro/foxyshot-indexer/internal/fetch"
+	"github.com/matryer/is"
+)
+
+func TestVerify(t *testing.T) {
+	tt := is.New(t)
+
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"expected":"payload"}`)
+	signature := Sign("expected-secret", now.Unix(), body)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	tt.NoErr(Verify("expected-secret", signature, ts, body, now.Add(time.Minute), 5*time.Minute))
+
+	err := Verify("wrong-secret", signature, ts, body, now, 5*time.Minute)
+	tt.True(errors.Is(err, ErrInvalidSignature))
+
+	err = Verify("expected-secret", signature, ts, []byte(`{"tampered":"payload"}`), now, 5*time.Minute)
+	tt.True(errors.Is(err, ErrInvalidSignature))
+
+	err = Verify("expected-secret", signature, ts, body, now.Add(time.Hour), 5*time.Minute)
+	tt.True(errors.Is(err, ErrExpired))
+}
+
+func TestClient_Post(t *testing.T) {
+	tt := is.New(t)
+
+	var received *http.Request
+	var receivedBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := NewClient(time.Second, true)
+	code, err := c.Post(context.Background(), srv.URL, "expected-secret", "expected.event", []byte(`{}`))
+	tt.Equal(code, http.StatusBadRequest)
+
+	var statusErr *StatusError
+	tt.True(errors.As(err, &statusErr))
+	tt.Equal(statusErr.Code, http.StatusBadRequest)
+	tt.True(!Retryable(err)) // client errors must not be retried
+
+	tt.Equal(received.Header.Get(EventHeader), "expected.event")
+	tt.NoErr(Verify(
+		"expected-secret",
+		received.Header.Get(SignatureHeader),
+		received.Header.Get(TimestampHeader),
+		receivedBody,
+		time.Now(),
+		time.Minute,
+	))
+}
+
+func TestClient_Post_PrivateAddress(t *testing.T) {
+	tt := is.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request to a loopback address was sent")
+	}))
+	defer srv.Close()
+
+	code, err := NewClient(time.Second, false).Post(context.Background(), srv.URL, "expected-secret", "expected.event", []byte(`{}`))
+
+	tt.Equal(code, 0)
+	tt.True(errors.Is(err, fetch.ErrForbiddenAddr))
+	tt.True(!Retryable(err))
+}
+
+func TestSender_deliver(t *testing.T) {
+	t.Run("retries server errors", func(t *testing.T) {
+		tt := is.New(t)
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		s := NewSender(NewClient(time.Second, true), srv.URL, "expected-secret", slog.Default())
+		s.backoff = time.Millisecond
+
+		err := s.deliver(context.Background(), message{event: "expected.event", body: []byte(`{}`)})
+		tt.NoErr(err)
+		tt.Equal(calls.Load(), int32(3))
+	})
+
+	t.Run("gives up after all attempts", func(t *testing.T) {
+		tt := is.New(t)
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		s := NewSender(NewClient(time.Second, true), srv.URL, "expected-secret", slog.Default())
+		s.backoff = time.Millisecond
+
+		err := s.deliver(context.Background(), message{event: "expected.event", body: []byte(`{}`)})
+		tt.True(err != nil)
+		tt.Equal(calls.Load(), int32(defaultAttempts))
+	})
+}
+
+func TestSender_Run(t *testing.T) {
+	tt := is.New(t)
+
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewSender(NewClient(time.Second, true), srv.URL, "expected-secret", slog.Default())
+	go func() { _ = s.Run(ctx) }()
+
+	s.Enqueue("expected.event", map[string]string{"expected": "payload"})
+
+	select {
+	case body := <-received:
+		tt.Equal(body, `{"expected":"payload"}`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("payload was not delivered")
+	}
+}
@@ -0,0 +1 @@
+alter table image_descriptions drop phash;
@@ -0,0 +1,2 @@
+alter table image_descriptions
+    add phash bigint;
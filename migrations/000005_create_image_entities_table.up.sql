@@ -0,0 +1,13 @@
+create table image_entities
+(
+    file_id text not null
+        constraint image_entities_image_descriptions_fk
+            references image_descriptions
+            on delete cascade,
+    kind    text not null,
+    value   text not null,
+    constraint image_entities_pk
+        primary key (file_id, kind, value)
+);
+
+create index image_entities_kind_value_idx on image_entities (kind, value);
@@ -0,0 +1,14 @@
+create table image_findings
+(
+    id      serial
+        constraint image_findings_pk
+            primary key,
+    file_id text not null
+        constraint image_findings_image_descriptions_fk
+            references image_descriptions
+            on delete cascade,
+    rule    text not null,
+    preview text not null
+);
+
+create index image_findings_file_id_idx on image_findings (file_id);
@@ -0,0 +1,2 @@
+drop table image_tags;
+drop table tags;
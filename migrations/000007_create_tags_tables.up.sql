@@ -0,0 +1,28 @@
+create table tags
+(
+    id   serial
+        constraint tags_pk
+            primary key,
+    name text not null
+        constraint tags_name_key
+            unique
+);
+
+create table image_tags
+(
+    file_id text    not null
+        constraint image_tags_image_descriptions_fk
+            references image_descriptions
+            on delete cascade,
+    tag_id  integer not null
+        constraint image_tags_tags_fk
+            references tags
+            on delete cascade,
+    source  text    not null
+        constraint image_tags_source_check
+            check (source in ('manual', 'rule')),
+    constraint image_tags_pk
+        primary key (file_id, tag_id)
+);
+
+create index image_tags_tag_id_idx on image_tags (tag_id);
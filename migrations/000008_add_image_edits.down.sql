@@ -0,0 +1,6 @@
+drop table image_edits;
+
+alter table image_descriptions
+    drop column document,
+    drop column corrected_description,
+    drop column annotations;
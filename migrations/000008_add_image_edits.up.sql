@@ -0,0 +1,25 @@
+alter table image_descriptions
+    add annotations text default '' not null,
+    add corrected_description text default '' not null,
+    add document tsvector generated always as (
+        to_tsvector('simple', description || ' ' || corrected_description || ' ' || annotations)
+    ) stored;
+
+create index image_descriptions_document_idx on image_descriptions using gin (document);
+
+create table image_edits
+(
+    id        serial
+        constraint image_edits_pk
+            primary key,
+    file_id   text                      not null
+        constraint image_edits_image_descriptions_fk
+            references image_descriptions
+            on delete cascade,
+    field     text                      not null,
+    old_value text                      not null,
+    new_value text                      not null,
+    edited_at timestamptz default now() not null
+);
+
+create index image_edits_file_id_idx on image_edits (file_id);
@@ -0,0 +1,9 @@
+create table saved_searches
+(
+    id         serial
+        constraint saved_searches_pk
+            primary key,
+    name       text                      not null,
+    query      text                      not null,
+    created_at timestamptz default now() not null
+);
@@ -0,0 +1,36 @@
+create table webhooks
+(
+    id         serial
+        constraint webhooks_pk
+            primary key,
+    url        text                      not null,
+    secret     text                      not null,
+    -- comma separated event types, empty means all events
+    events     text        default ''    not null,
+    created_at timestamptz default now() not null
+);
+
+create table webhook_deliveries
+(
+    id              bigserial
+        constraint webhook_deliveries_pk
+            primary key,
+    webhook_id      integer                       not null
+        constraint webhook_deliveries_webhooks_fk
+            references webhooks
+            on delete cascade,
+    event           text                          not null,
+    payload         jsonb                         not null,
+    status          text        default 'pending' not null
+        constraint webhook_deliveries_status_check
+            check (status in ('pending', 'delivered', 'failed')),
+    attempts        integer     default 0         not null,
+    response_code   integer     default 0         not null,
+    last_error      text        default ''        not null,
+    next_attempt_at timestamptz default now()     not null,
+    created_at      timestamptz default now()     not null,
+    delivered_at    timestamptz
+);
+
+create index webhook_deliveries_pending_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index webhook_deliveries_webhook_id_idx on webhook_deliveries (webhook_id, id);
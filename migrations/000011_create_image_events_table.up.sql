@@ -0,0 +1,12 @@
+create table image_events
+(
+    seq        bigserial
+        constraint image_events_pk
+            primary key,
+    type       text                      not null,
+    file_id    text                      not null,
+    payload    jsonb                     not null,
+    created_at timestamptz default now() not null
+);
+
+create index image_events_created_at_idx on image_events (created_at);
@@ -0,0 +1,14 @@
+create table feed_tokens
+(
+    id           serial
+        constraint feed_tokens_pk
+            primary key,
+    -- who the token was issued to
+    name         text                      not null,
+    -- only the sha256 of the token is stored, the token itself is shown once
+    token_hash   text                      not null
+        constraint feed_tokens_token_hash_uindex
+            unique,
+    created_at   timestamptz default now() not null,
+    last_used_at timestamptz
+);
@@ -0,0 +1 @@
+drop table index_queue;
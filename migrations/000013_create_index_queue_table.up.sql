@@ -0,0 +1,17 @@
+create table index_queue
+(
+    file_id         text                          not null
+        constraint index_queue_pk
+            primary key,
+    last_modified   timestamp                     not null,
+    status          text        default 'pending' not null
+        constraint index_queue_status_check
+            check (status in ('pending', 'failed')),
+    attempts        integer     default 0         not null,
+    last_error      text        default ''        not null,
+    -- a claimed file becomes available again when its lease expires
+    next_attempt_at timestamptz default now()     not null,
+    created_at      timestamptz default now()     not null
+);
+
+create index index_queue_pending_idx on index_queue (next_attempt_at) where status = 'pending';
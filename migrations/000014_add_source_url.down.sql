@@ -0,0 +1,10 @@
+alter table image_descriptions
+    drop column document;
+
+alter table image_descriptions
+    drop column source_url,
+    add document tsvector generated always as (
+        to_tsvector('simple', description || ' ' || corrected_description || ' ' || annotations)
+    ) stored;
+
+create index image_descriptions_document_idx on image_descriptions using gin (document);
@@ -0,0 +1,13 @@
+alter table image_descriptions
+    add source_url text default '' not null;
+
+-- a generated column can not be altered, the document is recreated to make source urls searchable
+alter table image_descriptions
+    drop column document;
+
+alter table image_descriptions
+    add document tsvector generated always as (
+        to_tsvector('simple', description || ' ' || corrected_description || ' ' || annotations || ' ' || source_url)
+    ) stored;
+
+create index image_descriptions_document_idx on image_descriptions using gin (document);
@@ -0,0 +1 @@
+drop table saved_search_matches;
@@ -0,0 +1,14 @@
+-- images that match a saved search, an alert is sent when an image starts to match
+create table saved_search_matches
+(
+    saved_search_id integer not null
+        constraint saved_search_matches_saved_searches_fk
+            references saved_searches
+            on delete cascade,
+    file_id         text    not null
+        constraint saved_search_matches_image_descriptions_fk
+            references image_descriptions
+            on delete cascade,
+    constraint saved_search_matches_pk
+        primary key (saved_search_id, file_id)
+);
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations of the database, so that the binary can apply them itself.
+package migrations
+
+import "embed"
+
+// FS holds the migrations named NNNNNN_name.up.sql and NNNNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
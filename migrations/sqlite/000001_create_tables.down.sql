@@ -0,0 +1,12 @@
+drop table feed_tokens;
+drop table image_events;
+drop table webhook_deliveries;
+drop table webhooks;
+drop table saved_searches;
+drop table image_edits;
+drop table image_tags;
+drop table tags;
+drop table image_findings;
+drop table image_entities;
+drop table image_search;
+drop table image_descriptions;
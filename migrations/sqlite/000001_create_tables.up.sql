@@ -0,0 +1,180 @@
+create table image_descriptions
+(
+    -- fts rows are linked by id, rowids of tables without an integer primary key can change on vacuum
+    id                    integer primary key,
+    file_id               text              not null
+        constraint image_descriptions_file_id_key
+            unique,
+    description           text              not null,
+    last_modified         timestamp         not null,
+    phash                 integer,
+    annotations           text default ''   not null,
+    corrected_description text default ''   not null
+);
+
+create index image_descriptions_last_modified_idx on image_descriptions (last_modified);
+
+create virtual table image_search using fts5
+(
+    description,
+    corrected_description,
+    annotations,
+    content = 'image_descriptions',
+    content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+create trigger image_descriptions_ai after insert on image_descriptions
+begin
+    insert into image_search (rowid, description, corrected_description, annotations)
+    values (new.id, new.description, new.corrected_description, new.annotations);
+end;
+
+create trigger image_descriptions_ad after delete on image_descriptions
+begin
+    insert into image_search (image_search, rowid, description, corrected_description, annotations)
+    values ('delete', old.id, old.description, old.corrected_description, old.annotations);
+end;
+
+create trigger image_descriptions_au after update on image_descriptions
+begin
+    insert into image_search (image_search, rowid, description, corrected_description, annotations)
+    values ('delete', old.id, old.description, old.corrected_description, old.annotations);
+    insert into image_search (rowid, description, corrected_description, annotations)
+    values (new.id, new.description, new.corrected_description, new.annotations);
+end;
+
+create table image_entities
+(
+    file_id text not null
+        constraint image_entities_image_descriptions_fk
+            references image_descriptions (file_id)
+            on delete cascade,
+    kind    text not null,
+    value   text not null,
+    constraint image_entities_pk
+        primary key (file_id, kind, value)
+);
+
+create index image_entities_kind_value_idx on image_entities (kind, value);
+
+create table image_findings
+(
+    id      integer primary key,
+    file_id text not null
+        constraint image_findings_image_descriptions_fk
+            references image_descriptions (file_id)
+            on delete cascade,
+    rule    text not null,
+    preview text not null
+);
+
+create index image_findings_file_id_idx on image_findings (file_id);
+
+create table tags
+(
+    id   integer primary key,
+    name text not null
+        constraint tags_name_key
+            unique
+);
+
+create table image_tags
+(
+    file_id text    not null
+        constraint image_tags_image_descriptions_fk
+            references image_descriptions (file_id)
+            on delete cascade,
+    tag_id  integer not null
+        constraint image_tags_tags_fk
+            references tags
+            on delete cascade,
+    source  text    not null
+        constraint image_tags_source_check
+            check (source in ('manual', 'rule')),
+    constraint image_tags_pk
+        primary key (file_id, tag_id)
+);
+
+create index image_tags_tag_id_idx on image_tags (tag_id);
+
+create table image_edits
+(
+    id        integer primary key,
+    file_id   text      not null
+        constraint image_edits_image_descriptions_fk
+            references image_descriptions (file_id)
+            on delete cascade,
+    field     text      not null,
+    old_value text      not null,
+    new_value text      not null,
+    edited_at timestamp not null
+);
+
+create index image_edits_file_id_idx on image_edits (file_id);
+
+create table saved_searches
+(
+    id         integer primary key,
+    name       text      not null,
+    query      text      not null,
+    created_at timestamp not null
+);
+
+create table webhooks
+(
+    id         integer primary key,
+    url        text            not null,
+    secret     text            not null,
+    -- comma separated event types, empty means all events
+    events     text default '' not null,
+    created_at timestamp       not null
+);
+
+create table webhook_deliveries
+(
+    id              integer primary key,
+    webhook_id      integer                 not null
+        constraint webhook_deliveries_webhooks_fk
+            references webhooks
+            on delete cascade,
+    event           text                    not null,
+    payload         text                    not null,
+    status          text default 'pending'  not null
+        constraint webhook_deliveries_status_check
+            check (status in ('pending', 'delivered', 'failed')),
+    attempts        integer default 0       not null,
+    response_code   integer default 0       not null,
+    last_error      text default ''         not null,
+    next_attempt_at timestamp               not null,
+    created_at      timestamp               not null,
+    delivered_at    timestamp
+);
+
+create index webhook_deliveries_pending_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index webhook_deliveries_webhook_id_idx on webhook_deliveries (webhook_id, id);
+
+create table image_events
+(
+    -- autoincrement never reuses sequence numbers of deleted events, clients resume streams by them
+    seq        integer primary key autoincrement,
+    type       text      not null,
+    file_id    text      not null,
+    payload    text      not null,
+    created_at timestamp not null
+);
+
+create index image_events_created_at_idx on image_events (created_at);
+
+create table feed_tokens
+(
+    id           integer primary key,
+    -- who the token was issued to
+    name         text      not null,
+    -- only the sha256 of the token is stored, the token itself is shown once
+    token_hash   text      not null
+        constraint feed_tokens_token_hash_key
+            unique,
+    created_at   timestamp not null,
+    last_used_at timestamp
+);
@@ -0,0 +1,39 @@
+drop trigger image_descriptions_ai;
+drop trigger image_descriptions_ad;
+drop trigger image_descriptions_au;
+drop table image_search;
+
+alter table image_descriptions
+    drop column source_url;
+
+create virtual table image_search using fts5
+(
+    description,
+    corrected_description,
+    annotations,
+    content = 'image_descriptions',
+    content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+insert into image_search (image_search) values ('rebuild');
+
+create trigger image_descriptions_ai after insert on image_descriptions
+begin
+    insert into image_search (rowid, description, corrected_description, annotations)
+    values (new.id, new.description, new.corrected_description, new.annotations);
+end;
+
+create trigger image_descriptions_ad after delete on image_descriptions
+begin
+    insert into image_search (image_search, rowid, description, corrected_description, annotations)
+    values ('delete', old.id, old.description, old.corrected_description, old.annotations);
+end;
+
+create trigger image_descriptions_au after update on image_descriptions
+begin
+    insert into image_search (image_search, rowid, description, corrected_description, annotations)
+    values ('delete', old.id, old.description, old.corrected_description, old.annotations);
+    insert into image_search (rowid, description, corrected_description, annotations)
+    values (new.id, new.description, new.corrected_description, new.annotations);
+end;
@@ -0,0 +1,41 @@
+alter table image_descriptions
+    add source_url text default '' not null;
+
+-- columns can not be added to an fts5 table, the index is recreated with the source url
+drop trigger image_descriptions_ai;
+drop trigger image_descriptions_ad;
+drop trigger image_descriptions_au;
+drop table image_search;
+
+create virtual table image_search using fts5
+(
+    description,
+    corrected_description,
+    annotations,
+    source_url,
+    content = 'image_descriptions',
+    content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+insert into image_search (image_search) values ('rebuild');
+
+create trigger image_descriptions_ai after insert on image_descriptions
+begin
+    insert into image_search (rowid, description, corrected_description, annotations, source_url)
+    values (new.id, new.description, new.corrected_description, new.annotations, new.source_url);
+end;
+
+create trigger image_descriptions_ad after delete on image_descriptions
+begin
+    insert into image_search (image_search, rowid, description, corrected_description, annotations, source_url)
+    values ('delete', old.id, old.description, old.corrected_description, old.annotations, old.source_url);
+end;
+
+create trigger image_descriptions_au after update on image_descriptions
+begin
+    insert into image_search (image_search, rowid, description, corrected_description, annotations, source_url)
+    values ('delete', old.id, old.description, old.corrected_description, old.annotations, old.source_url);
+    insert into image_search (rowid, description, corrected_description, annotations, source_url)
+    values (new.id, new.description, new.corrected_description, new.annotations, new.source_url);
+end;
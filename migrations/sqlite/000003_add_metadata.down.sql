@@ -0,0 +1,2 @@
+alter table image_descriptions
+    drop column metadata;
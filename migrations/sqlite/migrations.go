@@ -0,0 +1,9 @@
+// Package sqlite embeds the SQL migrations of the SQLite database, they are numbered separately from Postgres ones.
+package sqlite
+
+import "embed"
+
+// FS holds the migrations named NNNNNN_name.up.sql and NNNNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS